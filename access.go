@@ -0,0 +1,25 @@
+package fsx
+
+// Access modes checked by canAccess, matching R_OK, W_OK and X_OK
+const (
+	accessRead    uint32 = 4
+	accessWrite   uint32 = 2
+	accessExecute uint32 = 1
+)
+
+// IsReadable reports whether the current process can read path, taking ACLs,
+// root privileges and read-only mounts into account where the platform can
+func IsReadable(path string) bool {
+	return canAccess(path, accessRead)
+}
+
+// IsWritable reports whether the current process can write path
+func IsWritable(path string) bool {
+	return canAccess(path, accessWrite)
+}
+
+// IsExecutable reports whether the current process can execute the file path,
+// or search the directory path. On Windows files are executable by extension (PATHEXT)
+func IsExecutable(path string) bool {
+	return canAccess(path, accessExecute)
+}
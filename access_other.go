@@ -0,0 +1,77 @@
+//go:build !linux && !darwin && !freebsd
+
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// canAccess probes access by opening path, platforms without faccessat
+// don't answer for the effective ids of the process
+func canAccess(path string, mode uint32) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	switch mode {
+	case accessRead:
+		file, err := os.Open(path)
+		if err != nil {
+			return false
+		}
+		file.Close()
+		return true
+	case accessWrite:
+		if info.IsDir() {
+			probe, err := os.CreateTemp(path, ".fsx-access-*")
+			if err != nil {
+				return false
+			}
+			probe.Close()
+			os.Remove(probe.Name())
+			return true
+		}
+
+		file, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return false
+		}
+		file.Close()
+		return true
+	case accessExecute:
+		if info.IsDir() {
+			return canAccess(path, accessRead)
+		}
+		if runtime.GOOS == "windows" {
+			return hasExecutableExtension(path)
+		}
+		return info.Mode().Perm()&0111 != 0
+	default:
+		return false
+	}
+}
+
+// hasExecutableExtension reports whether the extension of path is listed in PATHEXT
+func hasExecutableExtension(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return false
+	}
+
+	pathExt := os.Getenv("PATHEXT")
+	if pathExt == "" {
+		pathExt = ".com;.exe;.bat;.cmd"
+	}
+
+	for _, executable := range filepath.SplitList(strings.ToLower(pathExt)) {
+		if executable == ext {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,10 @@
+//go:build linux || darwin || freebsd
+
+package fsx
+
+import "golang.org/x/sys/unix"
+
+// canAccess checks access with the effective ids of the process like access(2)
+func canAccess(path string, mode uint32) bool {
+	return unix.Faccessat(unix.AT_FDCWD, path, mode, unix.AT_EACCESS) == nil
+}
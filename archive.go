@@ -0,0 +1,727 @@
+package fsx
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ListZipArchive returns entries of a zip archive without extracting it
+func ListZipArchive(zipPath string) ([]ArchiveEntry, error) {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, ErrListArchive.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  zipPath,
+				Error: err,
+			})
+	}
+	defer reader.Close()
+
+	entries := make([]ArchiveEntry, 0, len(reader.File))
+	for _, file := range reader.File {
+		entries = append(entries, ArchiveEntry{
+			Name:           file.Name,
+			Size:           int64(file.UncompressedSize64),
+			CompressedSize: int64(file.CompressedSize64),
+			Mode:           file.Mode(),
+			ModTime:        file.Modified,
+			CRC32:          file.CRC32,
+			IsDir:          file.FileInfo().IsDir(),
+		})
+	}
+
+	return entries, nil
+}
+
+// ExtractZipFiles extracts only entries whose names match any of the given patterns
+func ExtractZipFiles(zipPath, destDir string, patterns ...string) error {
+	return ExtractZipArchive(zipPath, destDir, WithExtractPatterns(patterns...))
+}
+
+// matchZipEntry checks if a zip entry name matches any of the patterns
+func matchZipEntry(name string, patterns []string) (bool, error) {
+	for _, pattern := range patterns {
+		matched, err := path.Match(pattern, name)
+		if err != nil {
+			return false, ErrInvalidPattern.
+				SetError(err).
+				SetData(struct {
+					Pattern string `json:"pattern"`
+					Error   error  `json:"error"`
+				}{
+					Pattern: pattern,
+					Error:   err,
+				})
+		}
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// WriteZipArchive streams the contents of root as a zip archive to w
+func WriteZipArchive(w io.Writer, root string, options ...ArchiveOption) error {
+	opts := defaultArchiveOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	zipWriter := zip.NewWriter(w)
+
+	err := walkArchiveSource(root, opts, func(path, name string, info os.FileInfo) error {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		} else {
+			header.Method = zip.Deflate
+		}
+
+		writer, err := zipWriter.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		return writeArchiveEntryContent(writer, path, info)
+	})
+	if err != nil {
+		return ErrCompress.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return ErrCompress.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+
+	return nil
+}
+
+// WriteTarArchive streams the contents of root as a tar archive to w
+func WriteTarArchive(w io.Writer, root string, options ...ArchiveOption) error {
+	opts := defaultArchiveOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	tarWriter := tar.NewWriter(w)
+
+	err := walkArchiveSource(root, opts, func(path, name string, info os.FileInfo) error {
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			link = target
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		return writeArchiveEntryContent(tarWriter, path, info)
+	})
+	if err != nil {
+		return ErrCompress.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return ErrCompress.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+
+	return nil
+}
+
+// walkArchiveSource walks root and calls fn with slash-separated entry names
+func walkArchiveSource(root string, opts *archiveOptions, fn func(path, name string, info os.FileInfo) error) error {
+	return walkTree(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path == root {
+			return nil
+		}
+
+		if opts.filter != nil && !opts.filter(path, info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		return fn(path, filepath.ToSlash(relPath), info)
+	})
+}
+
+// writeArchiveEntryContent copies file content (or symlink target) into an archive entry
+func writeArchiveEntryContent(w io.Writer, path string, info os.FileInfo) error {
+	if info.IsDir() {
+		return nil
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, target)
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// ExtractTarStream extracts a tar stream into destDir without buffering it on disk.
+// Entries and links that would resolve outside destDir are rejected
+func ExtractTarStream(r io.Reader, destDir string, options ...ExtractOption) error {
+	opts := defaultExtractOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	tarReader := tar.NewReader(r)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return ErrDecompress.
+				SetError(err).
+				SetData(pathErrorContext{
+					Path:  destDir,
+					Error: err,
+				})
+		}
+
+		if len(opts.patterns) > 0 {
+			matched, err := matchZipEntry(header.Name, opts.patterns)
+			if err != nil {
+				return err
+			}
+			if !matched {
+				continue
+			}
+		}
+
+		if err := extractTarEntry(tarReader, header, destDir, opts); err != nil {
+			return err
+		}
+	}
+}
+
+// extractTarEntry writes a single tar entry below destDir
+func extractTarEntry(r io.Reader, header *tar.Header, destDir string, opts *extractOptions) error {
+	destPath, err := SafeJoin(destDir, header.Name)
+	if err != nil {
+		return err
+	}
+
+	switch header.Typeflag {
+	case tar.TypeDir:
+		err = os.MkdirAll(destPath, header.FileInfo().Mode().Perm()|0700)
+	case tar.TypeReg:
+		err = extractTarFile(r, header, destPath, opts)
+	case tar.TypeSymlink:
+		err = extractTarSymlink(header, destDir, destPath)
+	case tar.TypeLink:
+		err = extractTarHardlink(header, destDir, destPath)
+	default:
+		// Devices, FIFOs and other special entries are skipped
+		return nil
+	}
+
+	if err != nil {
+		return ErrDecompress.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  destPath,
+				Error: err,
+			})
+	}
+
+	return nil
+}
+
+// extractTarFile writes a regular file entry
+func extractTarFile(r io.Reader, header *tar.Header, destPath string, opts *extractOptions) error {
+	skip, err := checkExtractTarget(destPath, opts)
+	if err != nil || skip {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return err
+	}
+
+	// Replace a symlink instead of writing through it
+	if info, err := os.Lstat(destPath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(destPath); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, header.FileInfo().Mode().Perm())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, r); err != nil {
+		return err
+	}
+
+	return os.Chtimes(destPath, header.ModTime, header.ModTime)
+}
+
+// extractTarSymlink creates a symlink entry whose target stays inside destDir
+func extractTarSymlink(header *tar.Header, destDir, destPath string) error {
+	target := filepath.FromSlash(header.Linkname)
+	if filepath.IsAbs(target) || filepath.VolumeName(target) != "" {
+		return ErrUnsafeArchivePath.
+			SetData(pathErrorContext{
+				Path:  header.Linkname,
+				Error: nil,
+			})
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return err
+	}
+
+	within, err := linkTargetWithin(destDir, filepath.Dir(destPath), target)
+	if err != nil {
+		return err
+	}
+	if !within {
+		return ErrUnsafeArchivePath.
+			SetData(pathErrorContext{
+				Path:  header.Linkname,
+				Error: nil,
+			})
+	}
+
+	return os.Symlink(header.Linkname, destPath)
+}
+
+// linkTargetWithin reports whether a relative symlink target, created in dir, resolves
+// inside base. The target is resolved on disk one name at a time, following the links
+// extracted so far; ".." after a name that doesn't exist yet is rejected, since a later
+// entry could make that name a link
+func linkTargetWithin(base, dir, target string) (bool, error) {
+	base, err := filepath.EvalSymlinks(base)
+	if err != nil {
+		return false, err
+	}
+	current, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false, err
+	}
+
+	missing := false
+	for _, name := range strings.Split(target, string(filepath.Separator)) {
+		switch {
+		case name == "" || name == ".":
+			continue
+		case name == "..":
+			if missing {
+				return false, nil
+			}
+			current = filepath.Dir(current)
+		default:
+			current = filepath.Join(current, name)
+			if missing {
+				continue
+			}
+			// Dangling links count as missing: their target may still be extracted
+			if resolved, err := filepath.EvalSymlinks(current); err == nil {
+				current = resolved
+			} else {
+				missing = true
+			}
+		}
+
+		if !isWithin(base, current) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// extractTarHardlink creates a hardlink to a previously extracted entry
+func extractTarHardlink(header *tar.Header, destDir, destPath string) error {
+	target, err := SafeJoin(destDir, header.Linkname)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return err
+	}
+
+	return os.Link(target, destPath)
+}
+
+// CompressDirectory archives src into dst. With ArchiveAuto the format is
+// detected from the dst extension (.zip, .tar, .tar.gz, .tgz)
+func CompressDirectory(src, dst string, format ArchiveFormat, options ...ArchiveOption) error {
+	if format == ArchiveAuto {
+		format = DetectArchiveFormat(dst)
+	}
+
+	if !DirectoryExist(src) {
+		return ErrSourceNotDirectory.
+			SetData(moveErrorContext{
+				Source:      src,
+				Destination: dst,
+				Error:       nil,
+			})
+	}
+
+	opts := defaultArchiveOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if opts.requireSpace {
+		if err := CheckSpaceFor(src, filepath.Dir(dst)); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(dst)
+	if err != nil {
+		return ErrCompress.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  dst,
+				Error: err,
+			})
+	}
+
+	var writer io.Writer = file
+	var encrypter io.WriteCloser
+	if opts.cipher != nil {
+		if encrypter, err = opts.cipher.EncryptWriter(file); err != nil {
+			file.Close()
+			os.Remove(dst)
+			return ErrCompress.
+				SetError(err).
+				SetData(pathErrorContext{
+					Path:  dst,
+					Error: err,
+				})
+		}
+		writer = encrypter
+	}
+
+	err = writeArchive(writer, src, format, options)
+	if encrypter != nil && err == nil {
+		if closeErr := encrypter.Close(); closeErr != nil {
+			err = ErrCompress.
+				SetError(closeErr).
+				SetData(pathErrorContext{
+					Path:  dst,
+					Error: closeErr,
+				})
+		}
+	}
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = ErrCompress.
+			SetError(closeErr).
+			SetData(pathErrorContext{
+				Path:  dst,
+				Error: closeErr,
+			})
+	}
+
+	if err != nil {
+		os.Remove(dst)
+		return err
+	}
+
+	return nil
+}
+
+// CreateZipFromDirectory creates a zip archive from a whole directory
+func CreateZipFromDirectory(src, zipPath string, options ...ArchiveOption) error {
+	return CompressDirectory(src, zipPath, ArchiveZip, options...)
+}
+
+// writeArchive writes root to w in the given format
+func writeArchive(w io.Writer, root string, format ArchiveFormat, options []ArchiveOption) error {
+	switch format {
+	case ArchiveZip:
+		return WriteZipArchive(w, root, options...)
+	case ArchiveTar:
+		return WriteTarArchive(w, root, options...)
+	case ArchiveTarGz:
+		gzWriter := gzip.NewWriter(w)
+		if err := WriteTarArchive(gzWriter, root, options...); err != nil {
+			gzWriter.Close()
+			return err
+		}
+		if err := gzWriter.Close(); err != nil {
+			return ErrCompress.
+				SetError(err).
+				SetData(pathErrorContext{
+					Path:  root,
+					Error: err,
+				})
+		}
+		return nil
+	default:
+		return newInvalidArchiveFormatError(root, format)
+	}
+}
+
+// DecompressToDirectory extracts an archive into dstDir, detecting the format by extension
+func DecompressToDirectory(src, dstDir string, options ...ExtractOption) error {
+	opts := defaultExtractOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	format := DetectArchiveFormat(src)
+	if format == ArchiveZip && opts.cipher == nil {
+		return ExtractZipArchive(src, dstDir, options...)
+	}
+
+	if format == ArchiveAuto {
+		return newInvalidArchiveFormatError(src, format)
+	}
+
+	file, err := os.Open(src)
+	if err != nil {
+		return ErrDecompress.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  src,
+				Error: err,
+			})
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if opts.cipher != nil {
+		if reader, err = opts.cipher.DecryptReader(file); err != nil {
+			return ErrDecompress.
+				SetError(err).
+				SetData(pathErrorContext{
+					Path:  src,
+					Error: err,
+				})
+		}
+	}
+
+	switch format {
+	case ArchiveZip:
+		return extractZipStream(reader, src, dstDir, options)
+	case ArchiveTar:
+		return ExtractTarStream(reader, dstDir, options...)
+	}
+
+	gzReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return ErrDecompress.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  src,
+				Error: err,
+			})
+	}
+	defer gzReader.Close()
+
+	return ExtractTarStream(gzReader, dstDir, options...)
+}
+
+// extractZipStream extracts a zip read from r. Zip needs random access, so the
+// archive is spooled to a private temp file first
+func extractZipStream(r io.Reader, src, dstDir string, options []ExtractOption) error {
+	temp, err := os.CreateTemp("", "fsx-zip-*")
+	if err != nil {
+		return ErrDecompress.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  src,
+				Error: err,
+			})
+	}
+	defer os.Remove(temp.Name())
+
+	_, err = io.Copy(temp, r)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return ErrDecompress.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  src,
+				Error: err,
+			})
+	}
+
+	return ExtractZipArchive(temp.Name(), dstDir, options...)
+}
+
+func newInvalidArchiveFormatError(path string, format ArchiveFormat) error {
+	return ErrInvalidArchive.
+		SetData(struct {
+			Path   string        `json:"path"`
+			Format ArchiveFormat `json:"format"`
+		}{
+			Path:   path,
+			Format: format,
+		})
+}
+
+// VerifyZipArchive reads every entry of a zip archive and validates its CRC32
+// without extracting anything to disk
+func VerifyZipArchive(zipPath string) error {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return newArchiveCorruptedError(zipPath, "", err)
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		if err := verifyZipEntry(file); err != nil {
+			return newArchiveCorruptedError(zipPath, file.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// verifyZipEntry reads a zip entry fully; archive/zip validates the CRC32 at EOF
+func verifyZipEntry(file *zip.File) error {
+	entryReader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer entryReader.Close()
+
+	_, err = io.Copy(io.Discard, entryReader)
+	return err
+}
+
+// VerifyTarArchive reads every entry of a tar (or .tar.gz/.tgz) archive and
+// validates headers, entry sizes and gzip checksums without extracting
+func VerifyTarArchive(tarPath string) error {
+	file, err := os.Open(tarPath)
+	if err != nil {
+		return newArchiveCorruptedError(tarPath, "", err)
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if DetectArchiveFormat(tarPath) == ArchiveTarGz {
+		gzReader, err := gzip.NewReader(file)
+		if err != nil {
+			return newArchiveCorruptedError(tarPath, "", err)
+		}
+		defer gzReader.Close()
+		reader = gzReader
+	}
+
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return newArchiveCorruptedError(tarPath, "", err)
+		}
+
+		n, err := io.Copy(io.Discard, tarReader)
+		if err != nil {
+			return newArchiveCorruptedError(tarPath, header.Name, err)
+		}
+		if header.Typeflag == tar.TypeReg && n != header.Size {
+			return newArchiveCorruptedError(tarPath, header.Name, io.ErrUnexpectedEOF)
+		}
+	}
+
+	// Drain the rest so trailing gzip data is checked as well
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return newArchiveCorruptedError(tarPath, "", err)
+	}
+
+	return nil
+}
+
+func newArchiveCorruptedError(path, entry string, err error) error {
+	return ErrArchiveCorrupted.
+		SetError(err).
+		SetData(struct {
+			Path  string `json:"path"`
+			Entry string `json:"entry"`
+			Error error  `json:"error"`
+		}{
+			Path:  path,
+			Entry: entry,
+			Error: err,
+		})
+}
@@ -0,0 +1,30 @@
+package fsx
+
+import "strings"
+
+// ArchiveFormat represents the archive container and codec
+type ArchiveFormat string
+
+const (
+	ArchiveAuto  ArchiveFormat = ""
+	ArchiveZip   ArchiveFormat = "zip"
+	ArchiveTar   ArchiveFormat = "tar"
+	ArchiveTarGz ArchiveFormat = "tar.gz"
+)
+
+// DetectArchiveFormat detects archive format by file extension.
+// The .enc suffix of encrypted archives (backup.tar.gz.enc) is ignored
+func DetectArchiveFormat(path string) ArchiveFormat {
+	lower := strings.TrimSuffix(strings.ToLower(path), ".enc")
+
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return ArchiveZip
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return ArchiveTarGz
+	case strings.HasSuffix(lower, ".tar"):
+		return ArchiveTar
+	default:
+		return ArchiveAuto
+	}
+}
@@ -0,0 +1,469 @@
+package fsx
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchiveOperations(t *testing.T) {
+	// Create a temporary directory for tests
+	tmpDir, err := os.MkdirTemp("", "fsx_archive_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	t.Run("ListZipArchive", func(t *testing.T) {
+		file1 := filepath.Join(tmpDir, "list1.txt")
+		file2 := filepath.Join(tmpDir, "list2.txt")
+		if err := WriteFileString(file1, "first file"); err != nil {
+			t.Fatalf("Failed to create file1: %v", err)
+		}
+		if err := WriteFileString(file2, "second"); err != nil {
+			t.Fatalf("Failed to create file2: %v", err)
+		}
+
+		zipPath := filepath.Join(tmpDir, "list.zip")
+		if err := CreateZipArchive(zipPath, []string{file1, file2}); err != nil {
+			t.Fatalf("Failed to create zip archive: %v", err)
+		}
+
+		entries, err := ListZipArchive(zipPath)
+		if err != nil {
+			t.Fatalf("Failed to list zip archive: %v", err)
+		}
+
+		if len(entries) != 2 {
+			t.Fatalf("Expected 2 entries, got %d", len(entries))
+		}
+
+		if entries[0].Name != "list1.txt" || entries[0].Size != int64(len("first file")) {
+			t.Errorf("Unexpected first entry: %+v", entries[0])
+		}
+
+		if entries[0].CRC32 != crc32.ChecksumIEEE([]byte("first file")) {
+			t.Error("CRC32 mismatch for first entry")
+		}
+
+		if entries[1].IsDir {
+			t.Error("Second entry should not be a directory")
+		}
+	})
+
+	t.Run("ListZipArchiveInvalid", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "not_a_zip.zip")
+		if err := WriteFileString(path, "plain text"); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		if _, err := ListZipArchive(path); err == nil {
+			t.Error("Expected error for invalid archive")
+		}
+	})
+
+	t.Run("ExtractZipFiles", func(t *testing.T) {
+		srcDir := filepath.Join(tmpDir, "selective_src")
+		if err := CreateFile(filepath.Join(srcDir, "app.yaml"), []byte("app: true"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create app.yaml: %v", err)
+		}
+		if err := CreateFile(filepath.Join(srcDir, "data.bin"), []byte("binary"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create data.bin: %v", err)
+		}
+
+		zipPath := filepath.Join(tmpDir, "selective.zip")
+		files := []string{filepath.Join(srcDir, "app.yaml"), filepath.Join(srcDir, "data.bin")}
+		if err := CreateZipArchive(zipPath, files); err != nil {
+			t.Fatalf("Failed to create zip archive: %v", err)
+		}
+
+		destDir := filepath.Join(tmpDir, "selective_dst")
+		if err := ExtractZipFiles(zipPath, destDir, "*.yaml"); err != nil {
+			t.Fatalf("Failed to extract zip files: %v", err)
+		}
+
+		if !FileExist(filepath.Join(destDir, "app.yaml")) {
+			t.Error("app.yaml should be extracted")
+		}
+		if FileExist(filepath.Join(destDir, "data.bin")) {
+			t.Error("data.bin should not be extracted")
+		}
+
+		if err := ExtractZipFiles(zipPath, destDir, "[invalid"); err == nil {
+			t.Error("Expected error for invalid pattern")
+		}
+	})
+
+	t.Run("ExtractZipArchiveRejectsTraversal", func(t *testing.T) {
+		zipPath := filepath.Join(tmpDir, "evil.zip")
+		writeTestZip(t, zipPath, map[string]string{"../escaped.txt": "evil"})
+
+		destDir := filepath.Join(tmpDir, "evil_dst")
+		err := ExtractZipArchive(zipPath, destDir)
+		if !errors.Is(err, ErrUnsafeArchivePath) {
+			t.Fatalf("Expected ErrUnsafeArchivePath, got %v", err)
+		}
+
+		if FileExist(filepath.Join(tmpDir, "escaped.txt")) {
+			t.Error("Entry must not be extracted outside destination")
+		}
+	})
+
+	t.Run("ExtractZipArchiveOverwritePolicy", func(t *testing.T) {
+		zipPath := filepath.Join(tmpDir, "policy.zip")
+		writeTestZip(t, zipPath, map[string]string{"file.txt": "from archive"})
+
+		destDir := filepath.Join(tmpDir, "policy_dst")
+		existing := filepath.Join(destDir, "file.txt")
+		if err := CreateFile(existing, []byte("local"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create existing file: %v", err)
+		}
+
+		// Skip keeps local content
+		if err := ExtractZipArchive(zipPath, destDir, WithOverwritePolicy(SkipExisting)); err != nil {
+			t.Fatalf("Failed to extract with skip policy: %v", err)
+		}
+		if content, _ := ReadFileString(existing); content != "local" {
+			t.Errorf("Expected local content, got %s", content)
+		}
+
+		// Fail returns typed error
+		err := ExtractZipArchive(zipPath, destDir, WithOverwritePolicy(FailOnExisting))
+		if !errors.Is(err, ErrArchiveFileExists) {
+			t.Errorf("Expected ErrArchiveFileExists, got %v", err)
+		}
+
+		// Default overwrites
+		if err := ExtractZipArchive(zipPath, destDir); err != nil {
+			t.Fatalf("Failed to extract with default policy: %v", err)
+		}
+		if content, _ := ReadFileString(existing); content != "from archive" {
+			t.Errorf("Expected archive content, got %s", content)
+		}
+	})
+
+	t.Run("WriteZipArchiveToWriter", func(t *testing.T) {
+		srcDir := filepath.Join(tmpDir, "stream_zip_src")
+		createArchiveTestTree(t, srcDir)
+
+		var buf bytes.Buffer
+		if err := WriteZipArchive(&buf, srcDir); err != nil {
+			t.Fatalf("Failed to write zip archive: %v", err)
+		}
+
+		reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatalf("Failed to read zip archive: %v", err)
+		}
+
+		names := make(map[string]bool)
+		for _, file := range reader.File {
+			names[file.Name] = true
+		}
+
+		for _, expected := range []string{"root.txt", "sub/", "sub/nested.txt"} {
+			if !names[expected] {
+				t.Errorf("Expected entry %s in zip", expected)
+			}
+		}
+	})
+
+	t.Run("WriteTarArchiveToWriter", func(t *testing.T) {
+		srcDir := filepath.Join(tmpDir, "stream_tar_src")
+		createArchiveTestTree(t, srcDir)
+
+		var buf bytes.Buffer
+		filter := func(path string, info os.FileInfo) bool {
+			return info.IsDir() || filepath.Ext(path) == ".txt"
+		}
+		if err := WriteTarArchive(&buf, srcDir, WithArchiveFilter(filter)); err != nil {
+			t.Fatalf("Failed to write tar archive: %v", err)
+		}
+
+		reader := tar.NewReader(&buf)
+		contents := make(map[string]string)
+		for {
+			header, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Failed to read tar entry: %v", err)
+			}
+
+			data, _ := io.ReadAll(reader)
+			contents[header.Name] = string(data)
+		}
+
+		if contents["sub/nested.txt"] != "nested" {
+			t.Errorf("Unexpected nested content: %q", contents["sub/nested.txt"])
+		}
+		if _, exists := contents["skip.log"]; exists {
+			t.Error("Filtered file should not be archived")
+		}
+	})
+
+	t.Run("ExtractTarStream", func(t *testing.T) {
+		srcDir := filepath.Join(tmpDir, "tar_stream_src")
+		createArchiveTestTree(t, srcDir)
+
+		reader, writer := io.Pipe()
+		go func() {
+			writer.CloseWithError(WriteTarArchive(writer, srcDir))
+		}()
+
+		destDir := filepath.Join(tmpDir, "tar_stream_dst")
+		if err := ExtractTarStream(reader, destDir); err != nil {
+			t.Fatalf("Failed to extract tar stream: %v", err)
+		}
+
+		content, err := ReadFileString(filepath.Join(destDir, "sub", "nested.txt"))
+		if err != nil {
+			t.Fatalf("Failed to read extracted file: %v", err)
+		}
+		if content != "nested" {
+			t.Errorf("Content mismatch: got %s", content)
+		}
+	})
+
+	t.Run("ExtractTarStreamRejectsEscapingSymlink", func(t *testing.T) {
+		var buf bytes.Buffer
+		writer := tar.NewWriter(&buf)
+		header := &tar.Header{
+			Name:     "link",
+			Typeflag: tar.TypeSymlink,
+			Linkname: "../../outside",
+		}
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		writer.Close()
+
+		err := ExtractTarStream(&buf, filepath.Join(tmpDir, "tar_evil_dst"))
+		if !errors.Is(err, ErrUnsafeArchivePath) {
+			t.Errorf("Expected ErrUnsafeArchivePath, got %v", err)
+		}
+	})
+
+	t.Run("ExtractTarStreamSymlinkChains", func(t *testing.T) {
+		type entry struct {
+			header  *tar.Header
+			content string
+		}
+		tarOf := func(entries ...entry) *bytes.Buffer {
+			var buf bytes.Buffer
+			writer := tar.NewWriter(&buf)
+			for _, e := range entries {
+				e.header.Size = int64(len(e.content))
+				if err := writer.WriteHeader(e.header); err != nil {
+					t.Fatalf("Failed to write tar header: %v", err)
+				}
+				writer.Write([]byte(e.content))
+			}
+			writer.Close()
+			return &buf
+		}
+		link := func(name, target string) entry {
+			return entry{header: &tar.Header{Name: name, Typeflag: tar.TypeSymlink, Linkname: target}}
+		}
+		file := func(name, content string) entry {
+			return entry{header: &tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644}, content: content}
+		}
+
+		// Each target is inside lexically, but resolves outside through an earlier link
+		escaping := map[string]*bytes.Buffer{
+			"through_link": tarOf(link("a", "."), link("a/b", "..")),
+			"via_link_dir": tarOf(link("a", "."), link("c", "a/..")),
+			"missing_name": tarOf(link("c", "later/.."), link("later", ".")),
+		}
+		for name, archive := range escaping {
+			err := ExtractTarStream(archive, filepath.Join(tmpDir, "tar_chain_"+name))
+			if !errors.Is(err, ErrUnsafeArchivePath) {
+				t.Errorf("%s: expected ErrUnsafeArchivePath, got %v", name, err)
+			}
+		}
+
+		destDir := filepath.Join(tmpDir, "tar_chain_ok")
+		err := ExtractTarStream(tarOf(
+			link("lib.so", "lib.so.1"),
+			file("lib.so.1", "lib"),
+			link("sub/up", ".."),
+			file("target.txt", "target"),
+			link("replaced", "target.txt"),
+			file("replaced", "regular"),
+		), destDir)
+		if err != nil {
+			t.Fatalf("Failed to extract links that stay inside: %v", err)
+		}
+
+		if content, _ := ReadFileString(filepath.Join(destDir, "lib.so")); content != "lib" {
+			t.Errorf("Expected lib.so to resolve to lib.so.1, got %q", content)
+		}
+		if content, _ := ReadFileString(filepath.Join(destDir, "target.txt")); content != "target" {
+			t.Errorf("Expected the file entry to replace the link, not write through it; target is %q", content)
+		}
+		if IsSymlink(filepath.Join(destDir, "replaced")) {
+			t.Error("Expected replaced to be a regular file")
+		}
+	})
+
+	t.Run("CompressDirectoryRoundTrip", func(t *testing.T) {
+		srcDir := filepath.Join(tmpDir, "roundtrip_src")
+		createArchiveTestTree(t, srcDir)
+
+		for _, name := range []string{"bundle.zip", "bundle.tar", "bundle.tar.gz"} {
+			archivePath := filepath.Join(tmpDir, name)
+			if err := CompressDirectory(srcDir, archivePath, ArchiveAuto); err != nil {
+				t.Fatalf("Failed to compress directory to %s: %v", name, err)
+			}
+
+			destDir := filepath.Join(tmpDir, "roundtrip_"+name)
+			if err := DecompressToDirectory(archivePath, destDir); err != nil {
+				t.Fatalf("Failed to decompress %s: %v", name, err)
+			}
+
+			content, err := ReadFileString(filepath.Join(destDir, "sub", "nested.txt"))
+			if err != nil || content != "nested" {
+				t.Errorf("Unexpected content from %s: %q, %v", name, content, err)
+			}
+		}
+	})
+
+	t.Run("EncryptedArchiveRoundTrip", func(t *testing.T) {
+		srcDir := filepath.Join(tmpDir, "encrypted_src")
+		createArchiveTestTree(t, srcDir)
+		cipher := NewAESCipher(WithPassphrase("secret"), WithArgon2Params(1, 1024, 1))
+
+		for _, name := range []string{"secret.zip.enc", "secret.tar.enc", "secret.tar.gz.enc"} {
+			archivePath := filepath.Join(tmpDir, name)
+			if err := CompressDirectory(srcDir, archivePath, ArchiveAuto, WithArchiveCipher(cipher)); err != nil {
+				t.Fatalf("Failed to compress directory to %s: %v", name, err)
+			}
+
+			if err := DecompressToDirectory(archivePath, filepath.Join(tmpDir, "plain_"+name)); err == nil {
+				t.Errorf("Expected %s to fail without cipher", name)
+			}
+
+			destDir := filepath.Join(tmpDir, "decrypted_"+name)
+			if err := DecompressToDirectory(archivePath, destDir, WithExtractCipher(cipher)); err != nil {
+				t.Fatalf("Failed to decompress %s: %v", name, err)
+			}
+
+			content, err := ReadFileString(filepath.Join(destDir, "sub", "nested.txt"))
+			if err != nil || content != "nested" {
+				t.Errorf("Unexpected content from %s: %q, %v", name, content, err)
+			}
+		}
+	})
+
+	t.Run("CompressDirectoryUnknownFormat", func(t *testing.T) {
+		srcDir := filepath.Join(tmpDir, "unknown_src")
+		createArchiveTestTree(t, srcDir)
+
+		archivePath := filepath.Join(tmpDir, "bundle.rar")
+		err := CompressDirectory(srcDir, archivePath, ArchiveAuto)
+		if !errors.Is(err, ErrInvalidArchive) {
+			t.Errorf("Expected ErrInvalidArchive, got %v", err)
+		}
+
+		if FileExist(archivePath) {
+			t.Error("Partial archive should be removed")
+		}
+	})
+
+	t.Run("VerifyArchives", func(t *testing.T) {
+		srcDir := filepath.Join(tmpDir, "verify_src")
+		createArchiveTestTree(t, srcDir)
+
+		zipPath := filepath.Join(tmpDir, "verify.zip")
+		tarPath := filepath.Join(tmpDir, "verify.tar.gz")
+		if err := CompressDirectory(srcDir, zipPath, ArchiveAuto); err != nil {
+			t.Fatalf("Failed to create zip: %v", err)
+		}
+		if err := CompressDirectory(srcDir, tarPath, ArchiveAuto); err != nil {
+			t.Fatalf("Failed to create tar.gz: %v", err)
+		}
+
+		if err := VerifyZipArchive(zipPath); err != nil {
+			t.Errorf("Valid zip should verify: %v", err)
+		}
+		if err := VerifyTarArchive(tarPath); err != nil {
+			t.Errorf("Valid tar.gz should verify: %v", err)
+		}
+
+		// Truncate the tar.gz to simulate corruption
+		data, _ := ReadFile(tarPath)
+		if err := WriteFile(tarPath, data[:len(data)/2]); err != nil {
+			t.Fatalf("Failed to truncate archive: %v", err)
+		}
+		if err := VerifyTarArchive(tarPath); !errors.Is(err, ErrArchiveCorrupted) {
+			t.Errorf("Expected ErrArchiveCorrupted, got %v", err)
+		}
+	})
+
+	t.Run("VerifyZipArchiveBadCRC", func(t *testing.T) {
+		zipPath := filepath.Join(tmpDir, "badcrc.zip")
+		writeTestZip(t, zipPath, map[string]string{"file.txt": "some stored content"})
+
+		// Flip a byte inside the compressed payload
+		data, _ := ReadFile(zipPath)
+		index := bytes.Index(data, []byte("file.txt")) + len("file.txt") + 2
+		data[index] ^= 0xFF
+		if err := WriteFile(zipPath, data); err != nil {
+			t.Fatalf("Failed to corrupt archive: %v", err)
+		}
+
+		if err := VerifyZipArchive(zipPath); !errors.Is(err, ErrArchiveCorrupted) {
+			t.Errorf("Expected ErrArchiveCorrupted, got %v", err)
+		}
+	})
+}
+
+// createArchiveTestTree creates a small directory tree for archive tests
+func createArchiveTestTree(t *testing.T, root string) {
+	t.Helper()
+
+	files := map[string]string{
+		"root.txt":       "root",
+		"skip.log":       "log",
+		"sub/nested.txt": "nested",
+	}
+
+	for name, content := range files {
+		if err := CreateFile(filepath.Join(root, name), []byte(content), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+}
+
+// writeTestZip writes a zip archive with the given name -> content entries
+func writeTestZip(t *testing.T, zipPath string, entries map[string]string) {
+	t.Helper()
+
+	file, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("Failed to create zip: %v", err)
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	for name, content := range entries {
+		w, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create zip entry: %v", err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write zip entry: %v", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close zip writer: %v", err)
+	}
+}
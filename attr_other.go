@@ -0,0 +1,25 @@
+//go:build !windows
+
+package fsx
+
+import "os"
+
+// hiddenAttribute is always false on platforms without file attributes
+func hiddenAttribute(info os.FileInfo) bool {
+	return false
+}
+
+// platformAttributes has no attributes to add on platforms without file attributes
+func platformAttributes(info os.FileInfo) FileAttributes {
+	return FileAttributes{}
+}
+
+// setHiddenAttribute does nothing on platforms without file attributes
+func setHiddenAttribute(path string, hidden bool) error {
+	return nil
+}
+
+// isJunction is always false on platforms without junctions
+func isJunction(info os.FileInfo) bool {
+	return false
+}
@@ -0,0 +1,64 @@
+//go:build windows
+
+package fsx
+
+import (
+	"os"
+	"syscall"
+)
+
+// hiddenAttribute reports whether a file has the hidden or system attribute
+func hiddenAttribute(info os.FileInfo) bool {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return false
+	}
+
+	return data.FileAttributes&(syscall.FILE_ATTRIBUTE_HIDDEN|syscall.FILE_ATTRIBUTE_SYSTEM) != 0
+}
+
+// platformAttributes returns the system and archive attributes of a file
+func platformAttributes(info os.FileInfo) FileAttributes {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return FileAttributes{}
+	}
+
+	return FileAttributes{
+		System:  data.FileAttributes&syscall.FILE_ATTRIBUTE_SYSTEM != 0,
+		Archive: data.FileAttributes&syscall.FILE_ATTRIBUTE_ARCHIVE != 0,
+	}
+}
+
+// setHiddenAttribute sets or clears the hidden attribute of path
+func setHiddenAttribute(path string, hidden bool) error {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+
+	attrs, err := syscall.GetFileAttributes(name)
+	if err != nil {
+		return err
+	}
+
+	if hidden {
+		attrs |= syscall.FILE_ATTRIBUTE_HIDDEN
+	} else {
+		attrs &^= syscall.FILE_ATTRIBUTE_HIDDEN
+	}
+
+	return syscall.SetFileAttributes(name, attrs)
+}
+
+// isJunction reports whether a directory is a junction or another reparse point
+// that is not a symbolic link, which os.Lstat reports as a plain directory
+func isJunction(info os.FileInfo) bool {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return false
+	}
+
+	return info.IsDir() && info.Mode()&os.ModeSymlink == 0 &&
+		data.FileAttributes&syscall.FILE_ATTRIBUTE_REPARSE_POINT != 0
+}
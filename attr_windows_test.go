@@ -0,0 +1,116 @@
+//go:build windows
+
+package fsx
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJunctions(t *testing.T) {
+	// Create a temporary directory for tests
+	tmpDir, err := os.MkdirTemp("", "fsx_junction_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	target := filepath.Join(tmpDir, "target")
+	if err := CreateFile(filepath.Join(target, "inner.txt"), []byte("inner"), WithCreateDirs()); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	srcDir := filepath.Join(tmpDir, "src")
+	if err := CreateFile(filepath.Join(srcDir, "file.txt"), []byte("file"), WithCreateDirs()); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	junction := filepath.Join(srcDir, "junction")
+	if out, err := exec.Command("cmd", "/c", "mklink", "/J", junction, target).CombinedOutput(); err != nil {
+		t.Skipf("Junctions not supported: %v (%s)", err, out)
+	}
+
+	t.Run("Detection", func(t *testing.T) {
+		info, err := os.Lstat(junction)
+		if err != nil {
+			t.Fatalf("Failed to stat junction: %v", err)
+		}
+		if !isJunction(info) {
+			t.Errorf("Expected a junction, got mode %v", info.Mode())
+		}
+		if !IsSymlink(junction) {
+			t.Error("Junctions should be reported as links")
+		}
+		if stored, err := ReadSymlink(junction); err != nil || !strings.EqualFold(filepath.Clean(stored), target) {
+			t.Errorf("Expected the junction target %s, got %s (%v)", target, stored, err)
+		}
+	})
+
+	t.Run("WalkDoesNotDescend", func(t *testing.T) {
+		var visited []string
+		err := WalkDirectory(srcDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			visited = append(visited, path)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to walk directory: %v", err)
+		}
+		for _, path := range visited {
+			if path == filepath.Join(junction, "inner.txt") {
+				t.Error("Walk should not descend into junctions")
+			}
+		}
+	})
+
+	t.Run("SkippedByDefault", func(t *testing.T) {
+		dstDir := filepath.Join(tmpDir, "plain")
+		if err := CopyDirectory(srcDir, dstDir); err != nil {
+			t.Fatalf("Failed to copy directory: %v", err)
+		}
+
+		if _, err := os.Lstat(filepath.Join(dstDir, "file.txt")); err != nil {
+			t.Errorf("Regular file should be copied: %v", err)
+		}
+		if _, err := os.Lstat(filepath.Join(dstDir, "junction")); !os.IsNotExist(err) {
+			t.Errorf("Junction should be skipped, got %v", err)
+		}
+	})
+
+	// Junctions are copied as symbolic links, which need the privilege to create them
+	probe := filepath.Join(tmpDir, "probe")
+	if err := os.Symlink(target, probe); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	t.Run("WithCopyJunctions", func(t *testing.T) {
+		dstDir := filepath.Join(tmpDir, "links")
+		if err := CopyDirectory(srcDir, dstDir, WithCopyJunctions()); err != nil {
+			t.Fatalf("Failed to copy directory: %v", err)
+		}
+
+		info, err := os.Lstat(filepath.Join(dstDir, "junction"))
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			t.Fatalf("Expected the junction to be copied as a symlink, got %v (%v)", info, err)
+		}
+		content, err := os.ReadFile(filepath.Join(dstDir, "junction", "inner.txt"))
+		if err != nil || string(content) != "inner" {
+			t.Errorf("Expected the copied link to reach the target, got %q (%v)", content, err)
+		}
+	})
+
+	t.Run("CopySymlink", func(t *testing.T) {
+		copied := filepath.Join(tmpDir, "copied")
+		if err := CopySymlink(junction, copied); err != nil {
+			t.Fatalf("Failed to copy junction: %v", err)
+		}
+
+		info, err := os.Lstat(copied)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			t.Errorf("Expected a symlink, got %v (%v)", info, err)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package fsx
+
+import "os"
+
+// GetAttributes returns the attributes of path. Hidden reports a dot prefix or the
+// Windows hidden or system attribute, ReadOnly a missing owner write permission
+func GetAttributes(path string) (FileAttributes, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return FileAttributes{}, newStatFile(path, err)
+	}
+
+	attrs := platformAttributes(info)
+	attrs.Hidden = isHidden(info)
+	attrs.ReadOnly = info.Mode().Perm()&0200 == 0
+
+	return attrs, nil
+}
+
+// SetHidden sets or clears the Windows hidden attribute. Elsewhere only dot names
+// are hidden and SetHidden does nothing
+func SetHidden(path string, hidden bool) error {
+	if err := setHiddenAttribute(path, hidden); err != nil {
+		return newFileAttributesError(path, err)
+	}
+
+	return nil
+}
+
+// SetReadOnly makes path read-only by removing all write permissions, which sets the
+// read-only attribute on Windows, or writable again by granting the owner write permission
+func SetReadOnly(path string, readOnly bool) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return newStatFile(path, err)
+	}
+
+	mode := info.Mode().Perm() | 0200
+	if readOnly {
+		mode = info.Mode().Perm() &^ 0222
+	}
+
+	if err = os.Chmod(path, mode); err != nil {
+		return newFileAttributesError(path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,431 @@
+package fsx
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Backup repository layout:
+//
+//	catalog.json             sets of the repository
+//	index.json               checksum index of the source used for incremental sets
+//	sets/<id>/manifest.json  full file list of the set and where each file is stored
+//	sets/<id>/files/...      files stored by the set
+const (
+	backupCatalogName  = "catalog.json"
+	backupIndexName    = "index.json"
+	backupSetsDir      = "sets"
+	backupFilesDir     = "files"
+	backupManifestName = "manifest.json"
+	backupIDLayout     = "20060102T150405.000000000Z"
+)
+
+// BackupType is the kind of a backup set
+type BackupType string
+
+const (
+	BackupFull        BackupType = "full"
+	BackupIncremental BackupType = "incremental"
+)
+
+// BackupSet describes a backup stored in a repository
+type BackupSet struct {
+	ID          string     `json:"id"`
+	Type        BackupType `json:"type"`
+	Parent      string     `json:"parent,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Files       int        `json:"files"`
+	StoredFiles int        `json:"stored_files"`
+	StoredBytes int64      `json:"stored_bytes"`
+	Encrypted   bool       `json:"encrypted,omitempty"`
+}
+
+// BackupCatalog lists backup sets of a repository, oldest first
+type BackupCatalog struct {
+	Source   string      `json:"source"`
+	HashType HashType    `json:"hash_type"`
+	Sets     []BackupSet `json:"sets"`
+}
+
+// backupEntry is a file of a set and the set that stores its content
+type backupEntry struct {
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"`
+	Set      string `json:"set"`
+}
+
+// BackupDirectory stores regular files of src as a new set in repo. The first set is full;
+// later sets are incremental and only store files changed since the previous set
+func BackupDirectory(src, repo string, options ...BackupOption) (*BackupSet, error) {
+	opts := defaultBackupOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	absSrc, err := filepath.Abs(src)
+	if err != nil {
+		return nil, newBackupError(src, repo, err)
+	}
+
+	catalog, err := readBackupCatalog(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	if catalog.Source != "" && catalog.Source != absSrc {
+		return nil, newBackupError(src, repo, fmt.Errorf("repository belongs to %s", catalog.Source))
+	}
+	catalog.Source = absSrc
+
+	// Never back up the repository itself
+	excludes := opts.excludePatterns
+	if absRepo, err := filepath.Abs(repo); err == nil {
+		if relRepo, err := filepath.Rel(absSrc, absRepo); err == nil && !strings.HasPrefix(relRepo, "..") {
+			excludes = append(excludes, filepath.ToSlash(relRepo))
+		}
+	}
+
+	index, err := OpenIndex(filepath.Join(repo, backupIndexName), src,
+		WithIndexHashType(catalog.HashType),
+		WithIndexExclude(excludes...))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := index.Refresh(); err != nil {
+		return nil, err
+	}
+
+	set := &BackupSet{
+		ID:        newBackupSetID(repo),
+		Type:      BackupFull,
+		CreatedAt: time.Now(),
+		Encrypted: opts.cipher != nil,
+	}
+
+	previous := make(map[string]backupEntry)
+	if len(catalog.Sets) > 0 && !opts.full {
+		parent := catalog.Sets[len(catalog.Sets)-1]
+		entries, err := readBackupSetEntries(repo, parent.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			previous[entry.Path] = entry
+		}
+		set.Type = BackupIncremental
+		set.Parent = parent.ID
+	}
+
+	setDir := filepath.Join(repo, backupSetsDir, set.ID)
+	indexed := index.Entries()
+	entries := make([]backupEntry, 0, len(indexed))
+
+	for i, indexEntry := range indexed {
+		entry, unchanged := previous[indexEntry.Path]
+		if !unchanged || entry.Checksum != indexEntry.Checksum || entry.Size != indexEntry.Size {
+			srcPath := filepath.Join(src, filepath.FromSlash(indexEntry.Path))
+			dstPath := filepath.Join(setDir, backupFilesDir, filepath.FromSlash(indexEntry.Path))
+
+			size, checksum, err := copyFileWithChecksum(srcPath, dstPath, catalog.HashType, opts.cipher, nil)
+			if err != nil {
+				os.RemoveAll(setDir)
+				return nil, newBackupError(srcPath, repo, err)
+			}
+
+			entry = backupEntry{
+				Path:     indexEntry.Path,
+				Size:     size,
+				Checksum: checksum,
+				Set:      set.ID,
+			}
+			set.StoredFiles++
+			set.StoredBytes += size
+		}
+
+		entries = append(entries, entry)
+
+		if opts.progressHandler != nil {
+			opts.progressHandler(int64(i+1), int64(len(indexed)), indexEntry.Path)
+		}
+	}
+	set.Files = len(entries)
+
+	if err := writeBackupJSON(filepath.Join(setDir, backupManifestName), entries); err != nil {
+		os.RemoveAll(setDir)
+		return nil, err
+	}
+
+	catalog.Sets = append(catalog.Sets, *set)
+	if err := writeBackupJSON(filepath.Join(repo, backupCatalogName), catalog); err != nil {
+		os.RemoveAll(setDir)
+		return nil, err
+	}
+
+	if err := index.Save(); err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
+
+// ListBackups returns backup sets of a repository, oldest first
+func ListBackups(repo string) ([]BackupSet, error) {
+	catalog, err := readBackupCatalog(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return catalog.Sets, nil
+}
+
+// RestoreBackup restores the state captured by a set into dst, verifying every file.
+// An empty setID restores the latest set
+func RestoreBackup(repo, setID, dst string, options ...BackupOption) error {
+	opts := defaultBackupOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	catalog, err := readBackupCatalog(repo)
+	if err != nil {
+		return err
+	}
+
+	if setID == "" && len(catalog.Sets) > 0 {
+		setID = catalog.Sets[len(catalog.Sets)-1].ID
+	}
+
+	// Unchanged files of incremental sets are stored by older sets,
+	// which may differ in encryption
+	encrypted := make(map[string]bool, len(catalog.Sets))
+	found := false
+	for _, set := range catalog.Sets {
+		encrypted[set.ID] = set.Encrypted
+		if set.ID == setID {
+			found = true
+		}
+	}
+	if !found {
+		return ErrBackupSetNotFound.
+			SetData(struct {
+				Repository string `json:"repository"`
+				Set        string `json:"set"`
+			}{
+				Repository: repo,
+				Set:        setID,
+			})
+	}
+
+	entries, err := readBackupSetEntries(repo, setID)
+	if err != nil {
+		return err
+	}
+
+	for i, entry := range entries {
+		srcPath, err := SafeJoin(filepath.Join(repo, backupSetsDir, entry.Set, backupFilesDir), entry.Path)
+		if err != nil {
+			return newRestoreBackupError(entry.Path, dst, err)
+		}
+
+		dstPath, err := SafeJoin(dst, entry.Path)
+		if err != nil {
+			return newRestoreBackupError(entry.Path, dst, err)
+		}
+
+		var decrypt Cipher
+		if encrypted[entry.Set] {
+			if opts.cipher == nil {
+				return newRestoreBackupError(srcPath, dstPath, fmt.Errorf("set %s is encrypted", entry.Set))
+			}
+			decrypt = opts.cipher
+		}
+
+		_, checksum, err := copyFileWithChecksum(srcPath, dstPath, catalog.HashType, nil, decrypt)
+		if err != nil {
+			return newRestoreBackupError(srcPath, dstPath, err)
+		}
+
+		if checksum != entry.Checksum {
+			return newRestoreBackupError(srcPath, dstPath, fmt.Errorf("checksum mismatch: expected %s, got %s", entry.Checksum, checksum))
+		}
+
+		if opts.progressHandler != nil {
+			opts.progressHandler(int64(i+1), int64(len(entries)), entry.Path)
+		}
+	}
+
+	return nil
+}
+
+// readBackupCatalog loads the repository catalog; a missing catalog is an empty repository
+func readBackupCatalog(repo string) (*BackupCatalog, error) {
+	catalogPath := filepath.Join(repo, backupCatalogName)
+
+	catalog := &BackupCatalog{
+		HashType: HashSHA256,
+		Sets:     []BackupSet{},
+	}
+	if !FileExist(catalogPath) {
+		return catalog, nil
+	}
+
+	data, err := os.ReadFile(catalogPath)
+	if err == nil {
+		err = json.Unmarshal(data, catalog)
+	}
+	if err != nil {
+		return nil, ErrBackupCatalog.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  catalogPath,
+				Error: err,
+			})
+	}
+
+	return catalog, nil
+}
+
+// readBackupSetEntries loads the file list of a set
+func readBackupSetEntries(repo, setID string) ([]backupEntry, error) {
+	manifestPath := filepath.Join(repo, backupSetsDir, setID, backupManifestName)
+
+	var entries []backupEntry
+	data, err := os.ReadFile(manifestPath)
+	if err == nil {
+		err = json.Unmarshal(data, &entries)
+	}
+	if err != nil {
+		return nil, ErrBackupCatalog.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  manifestPath,
+				Error: err,
+			})
+	}
+
+	return entries, nil
+}
+
+// writeBackupJSON writes repository metadata atomically
+func writeBackupJSON(path string, value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err == nil {
+		err = CreateDirectories(filepath.Dir(path))
+	}
+	if err == nil {
+		err = AtomicWriteFile(path, data, 0644)
+	}
+	if err != nil {
+		return ErrBackupCatalog.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  path,
+				Error: err,
+			})
+	}
+
+	return nil
+}
+
+// newBackupSetID returns a time based set ID not used in repo yet
+func newBackupSetID(repo string) string {
+	for {
+		id := time.Now().UTC().Format(backupIDLayout)
+		if !FileExist(filepath.Join(repo, backupSetsDir, id)) {
+			return id
+		}
+		time.Sleep(time.Microsecond)
+	}
+}
+
+// copyFileWithChecksum copies src to dst preserving mode and mtime and returns
+// the size and checksum of the plain content. A non-nil encrypt encrypts dst,
+// a non-nil decrypt decrypts src
+func copyFileWithChecksum(src, dst string, hashType HashType, encrypt, decrypt Cipher) (int64, string, error) {
+	hasher, err := newHasher(hashType)
+	if err != nil {
+		return 0, "", err
+	}
+
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return 0, "", err
+	}
+	defer srcFile.Close()
+
+	srcInfo, err := srcFile.Stat()
+	if err != nil {
+		return 0, "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return 0, "", err
+	}
+
+	var reader io.Reader = srcFile
+	if decrypt != nil {
+		if reader, err = decrypt.DecryptReader(srcFile); err != nil {
+			return 0, "", err
+		}
+	}
+
+	dstFile, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, srcInfo.Mode().Perm())
+	if err != nil {
+		return 0, "", err
+	}
+
+	var writer io.Writer = dstFile
+	var encrypter io.WriteCloser
+	if encrypt != nil {
+		if encrypter, err = encrypt.EncryptWriter(dstFile); err != nil {
+			dstFile.Close()
+			return 0, "", err
+		}
+		writer = encrypter
+	}
+
+	size, err := io.Copy(io.MultiWriter(writer, hasher), reader)
+	if encrypter != nil && err == nil {
+		err = encrypter.Close()
+	}
+	if closeErr := dstFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, "", err
+	}
+
+	os.Chmod(dst, srcInfo.Mode().Perm())
+	os.Chtimes(dst, srcInfo.ModTime(), srcInfo.ModTime())
+
+	return size, hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+func newBackupError(src, repo string, err error) error {
+	return ErrBackup.
+		SetError(err).
+		SetData(moveErrorContext{
+			Source:      src,
+			Destination: repo,
+			Error:       err,
+		})
+}
+
+func newRestoreBackupError(src, dst string, err error) error {
+	return ErrRestoreBackup.
+		SetError(err).
+		SetData(moveErrorContext{
+			Source:      src,
+			Destination: dst,
+			Error:       err,
+		})
+}
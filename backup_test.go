@@ -0,0 +1,149 @@
+package fsx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackupOperations(t *testing.T) {
+	// Create a temporary directory for tests
+	tmpDir, err := os.MkdirTemp("", "fsx_backup_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "data")
+	// Repository inside the source must not back itself up
+	repo := filepath.Join(src, ".backups")
+
+	files := map[string]string{
+		"a.txt":     "alpha",
+		"b.txt":     "beta",
+		"sub/c.txt": "gamma",
+	}
+	for name, content := range files {
+		if err := CreateFile(filepath.Join(src, name), []byte(content), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	var fullID string
+
+	t.Run("FullBackup", func(t *testing.T) {
+		set, err := BackupDirectory(src, repo)
+		if err != nil {
+			t.Fatalf("Failed to back up: %v", err)
+		}
+		if set.Type != BackupFull || set.Files != 3 || set.StoredFiles != 3 {
+			t.Errorf("Unexpected full set: %+v", set)
+		}
+		fullID = set.ID
+	})
+
+	t.Run("IncrementalBackup", func(t *testing.T) {
+		if err := WriteFileString(filepath.Join(src, "b.txt"), "beta v2"); err != nil {
+			t.Fatalf("Failed to modify file: %v", err)
+		}
+		os.Remove(filepath.Join(src, "sub/c.txt"))
+		CreateFile(filepath.Join(src, "d.txt"), []byte("delta"))
+
+		set, err := BackupDirectory(src, repo)
+		if err != nil {
+			t.Fatalf("Failed to back up: %v", err)
+		}
+		if set.Type != BackupIncremental || set.Parent != fullID {
+			t.Errorf("Expected incremental set on top of %s, got %+v", fullID, set)
+		}
+		if set.Files != 3 || set.StoredFiles != 2 {
+			t.Errorf("Expected 3 files with 2 stored, got %+v", set)
+		}
+
+		sets, err := ListBackups(repo)
+		if err != nil {
+			t.Fatalf("Failed to list backups: %v", err)
+		}
+		if len(sets) != 2 {
+			t.Errorf("Expected 2 sets, got %d", len(sets))
+		}
+	})
+
+	t.Run("RestoreBackup", func(t *testing.T) {
+		latest := filepath.Join(tmpDir, "restore_latest")
+		if err := RestoreBackup(repo, "", latest); err != nil {
+			t.Fatalf("Failed to restore latest set: %v", err)
+		}
+
+		expected := map[string]string{"a.txt": "alpha", "b.txt": "beta v2", "d.txt": "delta"}
+		for name, content := range expected {
+			if got, _ := ReadFileString(filepath.Join(latest, name)); got != content {
+				t.Errorf("%s: expected %q, got %q", name, content, got)
+			}
+		}
+		if FileExist(filepath.Join(latest, "sub/c.txt")) {
+			t.Error("Removed file must not be restored from the latest set")
+		}
+
+		initial := filepath.Join(tmpDir, "restore_full")
+		if err := RestoreBackup(repo, fullID, initial); err != nil {
+			t.Fatalf("Failed to restore full set: %v", err)
+		}
+		for name, content := range files {
+			if got, _ := ReadFileString(filepath.Join(initial, name)); got != content {
+				t.Errorf("%s: expected %q, got %q", name, content, got)
+			}
+		}
+	})
+
+	t.Run("RestoreCorruptedBackup", func(t *testing.T) {
+		stored := filepath.Join(repo, backupSetsDir, fullID, backupFilesDir, "a.txt")
+		if err := WriteFileString(stored, "tampered"); err != nil {
+			t.Fatalf("Failed to tamper stored file: %v", err)
+		}
+
+		err := RestoreBackup(repo, fullID, filepath.Join(tmpDir, "restore_corrupted"))
+		if !errors.Is(err, ErrRestoreBackup) {
+			t.Errorf("Expected ErrRestoreBackup, got %v", err)
+		}
+	})
+
+	t.Run("EncryptedBackup", func(t *testing.T) {
+		encryptedRepo := filepath.Join(tmpDir, "encrypted_repo")
+		cipher := NewAESCipher(WithPassphrase("secret"), WithArgon2Params(1, 1024, 1))
+
+		set, err := BackupDirectory(src, encryptedRepo, WithBackupCipher(cipher))
+		if err != nil {
+			t.Fatalf("Failed to back up: %v", err)
+		}
+		if !set.Encrypted {
+			t.Error("Expected set to be marked encrypted")
+		}
+
+		stored, _ := ReadFileString(filepath.Join(encryptedRepo, backupSetsDir, set.ID, backupFilesDir, "b.txt"))
+		if stored == "beta v2" {
+			t.Error("Stored file is not encrypted")
+		}
+
+		err = RestoreBackup(encryptedRepo, "", filepath.Join(tmpDir, "restore_no_cipher"))
+		if !errors.Is(err, ErrRestoreBackup) {
+			t.Errorf("Expected ErrRestoreBackup without cipher, got %v", err)
+		}
+
+		restored := filepath.Join(tmpDir, "restore_encrypted")
+		if err := RestoreBackup(encryptedRepo, "", restored, WithBackupCipher(cipher)); err != nil {
+			t.Fatalf("Failed to restore encrypted set: %v", err)
+		}
+		if got, _ := ReadFileString(filepath.Join(restored, "b.txt")); got != "beta v2" {
+			t.Errorf("Expected %q, got %q", "beta v2", got)
+		}
+	})
+
+	t.Run("UnknownSet", func(t *testing.T) {
+		err := RestoreBackup(repo, "missing", filepath.Join(tmpDir, "restore_missing"))
+		if !errors.Is(err, ErrBackupSetNotFound) {
+			t.Errorf("Expected ErrBackupSetNotFound, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,269 @@
+package fsx
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// renameToken matches {name}, {ext}, {seq[:width]}, {mtime[:layout]} and {date[:layout]}
+var renameToken = regexp.MustCompile(`\{(name|ext|seq|mtime|date)(?::([^}]*))?\}`)
+
+// defaultRenameDateLayout formats {mtime} and {date} without a layout
+const defaultRenameDateLayout = "2006-01-02"
+
+// renameCandidate is a file matched by BatchRename
+type renameCandidate struct {
+	path   string
+	name   string
+	target string
+	info   os.FileInfo
+}
+
+// BatchRename renames the files in root whose name matches pattern (filepath.Match, or a
+// regular expression with WithBatchRenameRegex) to the name built from replacement, which
+// may contain tokens:
+//
+//	{name}          name without extension
+//	{ext}           extension including the dot
+//	{seq} {seq:3}   sequence number in natural name order, optionally zero padded
+//	{mtime:layout}  modification time formatted with a time layout (2006-01-02 by default)
+//	{date:layout}   current time formatted with a time layout
+//
+// Taken names are handled by the collision strategy (CollisionOverwrite never replaces
+// directories) and the renames are applied with RenameAll, so they are validated first
+// and rolled back on failure. The applied (or, with WithBatchRenameDryRun, planned)
+// renames are returned
+func BatchRename(root, pattern, replacement string, options ...BatchRenameOption) ([]RenamePair, error) {
+	opts := defaultBatchRenameOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	match := func(name string) bool {
+		matched, _ := filepath.Match(pattern, name)
+		return matched
+	}
+	_, err := filepath.Match(pattern, "")
+
+	var re *regexp.Regexp
+	if opts.regex {
+		if re, err = regexp.Compile(pattern); err == nil {
+			match = re.MatchString
+		}
+	}
+	if err != nil {
+		return nil, ErrInvalidRename.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  pattern,
+				Error: err,
+			})
+	}
+
+	candidates, err := findRenameCandidates(root, match, opts.recursive)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i, candidate := range candidates {
+		name := replacement
+		if re != nil {
+			name = re.ReplaceAllString(candidate.name, replacement)
+		}
+
+		name = expandRenameTokens(name, candidate, opts.start+i, now)
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return nil, newInvalidRenameError(RenamePair{From: candidate.path, To: name}, errors.New("invalid new name"))
+		}
+		candidate.target = filepath.Join(filepath.Dir(candidate.path), name)
+	}
+
+	pairs, err := resolveRenameCollisions(candidates, opts.collision, newRenameKeys())
+	if err != nil {
+		return nil, err
+	}
+
+	renameOptions := []RenameOption{}
+	if opts.collision == CollisionOverwrite {
+		renameOptions = append(renameOptions, WithRenameOverwrite())
+	}
+	if opts.dryRun {
+		renameOptions = append(renameOptions, WithRenameDryRun())
+	}
+
+	if err := RenameAll(pairs, renameOptions...); err != nil {
+		return nil, err
+	}
+
+	return pairs, nil
+}
+
+// findRenameCandidates returns the matching regular files of root in natural order
+func findRenameCandidates(root string, match func(name string) bool, recursive bool) ([]*renameCandidate, error) {
+	var candidates []*renameCandidate
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if path != root && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !entry.Type().IsRegular() || !match(entry.Name()) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		candidates = append(candidates, &renameCandidate{
+			path: path,
+			name: entry.Name(),
+			info: info,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, ErrReadDirectory.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return naturalLess(candidates[i].path, candidates[j].path)
+	})
+
+	return candidates, nil
+}
+
+// expandRenameTokens replaces the tokens of name for candidate
+func expandRenameTokens(name string, candidate *renameCandidate, seq int, now time.Time) string {
+	ext := filepath.Ext(candidate.name)
+
+	return renameToken.ReplaceAllStringFunc(name, func(token string) string {
+		groups := renameToken.FindStringSubmatch(token)
+		argument := groups[2]
+
+		switch groups[1] {
+		case "name":
+			return strings.TrimSuffix(candidate.name, ext)
+		case "ext":
+			return ext
+		case "seq":
+			width, _ := strconv.Atoi(argument)
+			return fmt.Sprintf("%0*d", width, seq)
+		case "mtime":
+			return candidate.info.ModTime().Format(renameDateLayout(argument))
+		default:
+			return now.Format(renameDateLayout(argument))
+		}
+	})
+}
+
+func renameDateLayout(layout string) string {
+	if layout == "" {
+		return defaultRenameDateLayout
+	}
+
+	return layout
+}
+
+// resolveRenameCollisions returns the pairs to rename after applying strategy to new
+// names taken by existing files or by other candidates. Skipped files keep their name,
+// which may make other new names collide, so it repeats until nothing else is skipped
+func resolveRenameCollisions(candidates []*renameCandidate, strategy CollisionStrategy, keys *renameKeys) ([]RenamePair, error) {
+	skipped := make(map[int]bool)
+
+	for {
+		freed := make(map[string]bool, len(candidates))
+		for i, candidate := range candidates {
+			if !skipped[i] && candidate.target != candidate.path {
+				freed[keys.key(candidate.path)] = true
+			}
+		}
+
+		taken := make(map[string]bool, len(candidates))
+		occupied := func(path string) bool {
+			key := keys.key(path)
+			if taken[key] {
+				return true
+			}
+			_, err := os.Lstat(path)
+			return err == nil && !freed[key]
+		}
+
+		var pairs []RenamePair
+		changed := false
+
+		for i, candidate := range candidates {
+			if skipped[i] {
+				continue
+			}
+
+			target := candidate.target
+			if keys.key(target) != keys.key(candidate.path) && occupied(target) {
+				pair := RenamePair{From: candidate.path, To: target}
+
+				switch strategy {
+				case CollisionSkip:
+					skipped[i] = true
+					changed = true
+					continue
+				case CollisionOverwrite:
+					// Existing files are replaced; two files of the batch can't share a name
+					if taken[keys.key(target)] {
+						return nil, newRenameConflictError(pair, errors.New("duplicate destination"))
+					}
+					if info, err := os.Lstat(target); err == nil && info.IsDir() {
+						return nil, newRenameConflictError(pair, errors.New("destination is a directory"))
+					}
+				case CollisionUniquify:
+					target = uniqueRenameTarget(target, occupied)
+				default:
+					return nil, newRenameConflictError(pair, os.ErrExist)
+				}
+			}
+
+			taken[keys.key(target)] = true
+			if target != candidate.path {
+				pairs = append(pairs, RenamePair{From: candidate.path, To: target})
+			}
+		}
+
+		if !changed {
+			return pairs, nil
+		}
+	}
+}
+
+// uniqueRenameTarget returns target with the first number making it free (name.2.ext, ...)
+func uniqueRenameTarget(target string, occupied func(path string) bool) string {
+	ext := filepath.Ext(target)
+	stem := strings.TrimSuffix(target, ext)
+
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s.%d%s", stem, i, ext)
+		if !occupied(candidate) {
+			return candidate
+		}
+	}
+}
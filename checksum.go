@@ -0,0 +1,31 @@
+package fsx
+
+import (
+	"encoding/hex"
+	"io"
+)
+
+// ChecksumReader calculates checksum of everything read from r
+func ChecksumReader(r io.Reader, hashType HashType) (string, error) {
+	h, err := newHasher(hashType)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := io.Copy(h, r); err != nil {
+		return "", ErrChecksum.SetError(err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ChecksumBytes calculates checksum of an in-memory payload
+func ChecksumBytes(data []byte, hashType HashType) (string, error) {
+	h, err := newHasher(hashType)
+	if err != nil {
+		return "", err
+	}
+
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,156 @@
+package fsx
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ChecksumCache stores file checksums keyed by path and invalidated by size and mtime.
+// The cache can be persisted as a JSON index between runs
+type ChecksumCache struct {
+	path    string
+	mu      sync.RWMutex
+	entries map[string]*checksumCacheEntry
+}
+
+type checksumCacheEntry struct {
+	Size      int64               `json:"size"`
+	ModTime   int64               `json:"mod_time"`
+	Checksums map[HashType]string `json:"checksums"`
+}
+
+// NewChecksumCache creates a checksum cache persisted at path.
+// Existing index is loaded; an empty path creates an in-memory cache
+func NewChecksumCache(path string) (*ChecksumCache, error) {
+	cache := &ChecksumCache{
+		path:    path,
+		entries: make(map[string]*checksumCacheEntry),
+	}
+
+	if path == "" || !FileExist(path) {
+		return cache, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, ErrChecksumCache.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  path,
+				Error: err,
+			})
+	}
+
+	if err := json.Unmarshal(data, &cache.entries); err != nil {
+		return nil, ErrChecksumCache.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  path,
+				Error: err,
+			})
+	}
+
+	return cache, nil
+}
+
+// Checksum returns cached checksum of a file or calculates and caches it
+func (c *ChecksumCache) Checksum(path string, hashType HashType) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", ErrChecksum.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  path,
+				Error: err,
+			})
+	}
+
+	key := checksumCacheKey(path)
+
+	c.mu.RLock()
+	entry, exists := c.entries[key]
+	if exists && entry.Size == info.Size() && entry.ModTime == info.ModTime().UnixNano() {
+		if checksum, ok := entry.Checksums[hashType]; ok {
+			c.mu.RUnlock()
+			return checksum, nil
+		}
+	}
+	c.mu.RUnlock()
+
+	checksum, err := calculateFileChecksum(path, hashType)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, exists = c.entries[key]
+	if !exists || entry.Size != info.Size() || entry.ModTime != info.ModTime().UnixNano() {
+		entry = &checksumCacheEntry{
+			Size:      info.Size(),
+			ModTime:   info.ModTime().UnixNano(),
+			Checksums: make(map[HashType]string),
+		}
+		c.entries[key] = entry
+	}
+	entry.Checksums[hashType] = checksum
+
+	return checksum, nil
+}
+
+// Prune removes entries of files that no longer exist
+func (c *ChecksumCache) Prune() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for path := range c.entries {
+		if !FileExist(path) {
+			delete(c.entries, path)
+		}
+	}
+}
+
+// Len returns number of cached files
+func (c *ChecksumCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.entries)
+}
+
+// Save persists the cache index atomically. In-memory caches are not saved
+func (c *ChecksumCache) Save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	c.mu.RLock()
+	data, err := json.Marshal(c.entries)
+	c.mu.RUnlock()
+	if err != nil {
+		return ErrChecksumCache.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  c.path,
+				Error: err,
+			})
+	}
+
+	if err := CreateDirectories(filepath.Dir(c.path)); err != nil {
+		return err
+	}
+
+	return AtomicWriteFile(c.path, data, 0644)
+}
+
+// checksumCacheKey normalizes path to an absolute cache key
+func checksumCacheKey(path string) string {
+	if absPath, err := filepath.Abs(path); err == nil {
+		return absPath
+	}
+
+	return path
+}
@@ -0,0 +1,276 @@
+package fsx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFormat represents the encoding of a config file
+type ConfigFormat string
+
+const (
+	ConfigAuto ConfigFormat = ""
+	ConfigJSON ConfigFormat = "json"
+	ConfigYAML ConfigFormat = "yaml"
+)
+
+// configPlaceholder matches ${NAME} and ${NAME:-default}
+var configPlaceholder = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+var lookupEnvironment = os.LookupEnv
+
+// DetectConfigFormat detects config format by file extension (.json, .yaml, .yml)
+func DetectConfigFormat(path string) ConfigFormat {
+	lower := strings.ToLower(path)
+
+	switch {
+	case strings.HasSuffix(lower, ".json"):
+		return ConfigJSON
+	case strings.HasSuffix(lower, ".yaml"), strings.HasSuffix(lower, ".yml"):
+		return ConfigYAML
+	default:
+		return ConfigAuto
+	}
+}
+
+// LoadConfig decodes the JSON or YAML config at path into v, a pointer. ${NAME} and
+// ${NAME:-default} placeholders are replaced by environment variables inside scalar
+// values only, so a variable can't add keys or change the document structure. With
+// WithConfigWatch the file keeps being watched after loading
+func LoadConfig(path string, v any, options ...ConfigOption) error {
+	opts := defaultConfigOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return ErrLoadConfig.
+			SetData(struct {
+				Path string `json:"path"`
+				Type string `json:"type"`
+			}{
+				Path: path,
+				Type: fmt.Sprintf("%T", v),
+			})
+	}
+
+	format := opts.format
+	if format == ConfigAuto {
+		format = DetectConfigFormat(path)
+	}
+	if format != ConfigJSON && format != ConfigYAML {
+		return ErrUnsupportedConfigFormat.
+			SetData(struct {
+				Path   string       `json:"path"`
+				Format ConfigFormat `json:"format"`
+			}{
+				Path:   path,
+				Format: format,
+			})
+	}
+
+	if opts.onChange != nil && opts.watchInterval <= 0 {
+		return ErrLoadConfig.
+			SetData(struct {
+				Path     string        `json:"path"`
+				Interval time.Duration `json:"interval"`
+			}{
+				Path:     path,
+				Interval: opts.watchInterval,
+			})
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return newReadFileError(path, err)
+	}
+
+	if err := decodeConfig(data, v, format, opts); err != nil {
+		return newLoadConfigError(path, err)
+	}
+
+	if opts.onChange != nil {
+		go watchConfig(path, xxhash.Sum64(data), target.Type().Elem(), format, opts)
+	}
+
+	return nil
+}
+
+// decodeConfig expands placeholders in data and decodes it into v
+func decodeConfig(data []byte, v any, format ConfigFormat, opts *configOptions) error {
+	expander := &configExpander{opts: opts}
+
+	if format == ConfigYAML {
+		var root yaml.Node
+		if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&root); err != nil {
+			return err
+		}
+		expander.expandYAML(&root)
+		if err := expander.err(); err != nil {
+			return err
+		}
+
+		expanded, err := yaml.Marshal(&root)
+		if err != nil {
+			return err
+		}
+
+		decoder := yaml.NewDecoder(bytes.NewReader(expanded))
+		decoder.KnownFields(opts.strict)
+		return decoder.Decode(v)
+	}
+
+	data = expander.expandJSON(data)
+	if err := expander.err(); err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if opts.strict {
+		decoder.DisallowUnknownFields()
+	}
+	return decoder.Decode(v)
+}
+
+// configExpander replaces ${NAME} and ${NAME:-default} placeholders and collects the
+// variables it couldn't resolve
+type configExpander struct {
+	opts    *configOptions
+	missing []string
+}
+
+// value returns the replacement of a single placeholder
+func (e *configExpander) value(match string) string {
+	groups := configPlaceholder.FindStringSubmatch(match)
+	if value, ok := e.opts.lookupEnv(groups[1]); ok {
+		return value
+	}
+	if groups[2] != "" {
+		return groups[3]
+	}
+	if e.opts.requireEnv {
+		e.missing = append(e.missing, groups[1])
+	}
+	return ""
+}
+
+// expandYAML expands placeholders in every scalar of node. Plain scalars have their
+// tag resolved again, so ${PORT} still decodes into a number
+func (e *configExpander) expandYAML(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && configPlaceholder.MatchString(node.Value) {
+		node.Value = configPlaceholder.ReplaceAllStringFunc(node.Value, e.value)
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+	}
+
+	for _, child := range node.Content {
+		e.expandYAML(child)
+	}
+}
+
+// expandJSON expands placeholders in JSON text. Inside strings values are escaped;
+// elsewhere they are inserted as is only when they are a number, boolean or null, and
+// as a JSON string otherwise
+func (e *configExpander) expandJSON(data []byte) []byte {
+	var expanded bytes.Buffer
+	inString, escaped, last := false, false, 0
+
+	for _, loc := range configPlaceholder.FindAllIndex(data, -1) {
+		for _, c := range data[last:loc[0]] {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\' && inString:
+				escaped = true
+			case c == '"':
+				inString = !inString
+			}
+		}
+		expanded.Write(data[last:loc[0]])
+		last = loc[1]
+
+		value := e.value(string(data[loc[0]:loc[1]]))
+		quoted, _ := json.Marshal(value)
+		switch {
+		case inString:
+			expanded.Write(quoted[1 : len(quoted)-1])
+		case jsonScalar(value):
+			expanded.WriteString(value)
+		default:
+			expanded.Write(quoted)
+		}
+	}
+
+	expanded.Write(data[last:])
+	return expanded.Bytes()
+}
+
+// err reports the variables required by WithConfigRequireEnv that were not set
+func (e *configExpander) err() error {
+	if len(e.missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("environment variables not set: %s", strings.Join(e.missing, ", "))
+}
+
+// jsonScalar reports whether value is a JSON number, boolean or null
+func jsonScalar(value string) bool {
+	if value == "" || strings.TrimSpace(value) != value || !json.Valid([]byte(value)) {
+		return false
+	}
+	return value[0] != '"' && value[0] != '{' && value[0] != '['
+}
+
+// watchConfig polls the config until the watch context is done and reloads it
+// into a new value of type typ whenever its content changes
+func watchConfig(path string, sum uint64, typ reflect.Type, format ConfigFormat, opts *configOptions) {
+	ticker := time.NewTicker(opts.watchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-opts.watchContext.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Missing files are skipped: editors and atomic writes replace them briefly
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		current := xxhash.Sum64(data)
+		if current == sum {
+			continue
+		}
+		sum = current
+
+		config := reflect.New(typ).Interface()
+		if err := decodeConfig(data, config, format, opts); err != nil {
+			opts.onChange(nil, newLoadConfigError(path, err))
+			continue
+		}
+		opts.onChange(config, nil)
+	}
+}
+
+func newLoadConfigError(path string, err error) error {
+	return ErrLoadConfig.
+		SetError(err).
+		SetData(pathErrorContext{
+			Path:  path,
+			Error: err,
+		})
+}
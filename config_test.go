@@ -0,0 +1,185 @@
+package fsx
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Name    string   `json:"name" yaml:"name"`
+	Port    int      `json:"port" yaml:"port"`
+	Servers []string `json:"servers" yaml:"servers"`
+}
+
+func TestLoadConfig(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "fsx_config_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	t.Setenv("FSX_TEST_HOST", "db.local")
+	t.Setenv("FSX_TEST_PORT", "5432")
+
+	t.Run("JSONWithEnvironment", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "app.json")
+		content := `{"name": "${FSX_TEST_NAME:-app}", "port": ${FSX_TEST_PORT}, "servers": ["${FSX_TEST_HOST}", "$HOME"]}`
+		if err := WriteFileString(path, content); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+
+		var config testConfig
+		if err := LoadConfig(path, &config); err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if config.Name != "app" || config.Port != 5432 || len(config.Servers) != 2 ||
+			config.Servers[0] != "db.local" || config.Servers[1] != "$HOME" {
+			t.Errorf("Unexpected config: %+v", config)
+		}
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "app.yml")
+		content := "name: yaml\nport: ${FSX_TEST_PORT}\nservers:\n  - ${FSX_TEST_HOST}\n"
+		if err := WriteFileString(path, content); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+
+		var config testConfig
+		if err := LoadConfig(path, &config); err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if config.Name != "yaml" || config.Port != 5432 || len(config.Servers) != 1 || config.Servers[0] != "db.local" {
+			t.Errorf("Unexpected config: %+v", config)
+		}
+	})
+
+	t.Run("Injection", func(t *testing.T) {
+		t.Setenv("FSX_TEST_NAME", `x", "port": 1, "name": "y`)
+		t.Setenv("FSX_TEST_SERVERS", "[\"a\", \"b\"]")
+
+		path := filepath.Join(tmpDir, "injection.json")
+		content := `{"name": "${FSX_TEST_NAME}", "port": ${FSX_TEST_PORT}, "servers": ${FSX_TEST_SERVERS}}`
+		if err := WriteFileString(path, content); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+
+		// A value that isn't a scalar becomes a string, which doesn't fit []string
+		var config testConfig
+		if err := LoadConfig(path, &config); !errors.Is(err, ErrLoadConfig) {
+			t.Errorf("Expected ErrLoadConfig for a spliced array, got %v (%+v)", err, config)
+		}
+
+		content = `{"name": "${FSX_TEST_NAME}", "port": ${FSX_TEST_PORT}}`
+		if err := WriteFileString(path, content); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		config = testConfig{}
+		if err := LoadConfig(path, &config, WithConfigStrict()); err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if config.Name != os.Getenv("FSX_TEST_NAME") || config.Port != 5432 {
+			t.Errorf("Expected the value to stay inside its string, got %+v", config)
+		}
+
+		t.Setenv("FSX_TEST_NAME", "x\nport: 1\nservers: [evil]")
+		path = filepath.Join(tmpDir, "injection.yaml")
+		if err := WriteFileString(path, "name: ${FSX_TEST_NAME}\nservers:\n  - '${FSX_TEST_PORT}'\n"); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		config = testConfig{}
+		if err := LoadConfig(path, &config, WithConfigStrict()); err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if config.Name != os.Getenv("FSX_TEST_NAME") || config.Port != 0 ||
+			len(config.Servers) != 1 || config.Servers[0] != "5432" {
+			t.Errorf("Expected the value to stay inside its scalar, got %+v", config)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "strict.json")
+		if err := WriteFileString(path, `{"name": "${FSX_TEST_MISSING}", "unknown": true}`); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+
+		var config testConfig
+		if err := LoadConfig(path, &config, WithConfigRequireEnv()); !errors.Is(err, ErrLoadConfig) {
+			t.Errorf("Expected ErrLoadConfig for missing variable, got %v", err)
+		}
+		if err := LoadConfig(path, &config, WithConfigStrict()); !errors.Is(err, ErrLoadConfig) {
+			t.Errorf("Expected ErrLoadConfig for unknown field, got %v", err)
+		}
+		if err := LoadConfig(path, config); !errors.Is(err, ErrLoadConfig) {
+			t.Errorf("Expected ErrLoadConfig for non-pointer target, got %v", err)
+		}
+		if err := LoadConfig(filepath.Join(tmpDir, "app.toml"), &config); !errors.Is(err, ErrUnsupportedConfigFormat) {
+			t.Errorf("Expected ErrUnsupportedConfigFormat, got %v", err)
+		}
+		if err := LoadConfig(path, &config, WithConfigFormat(ConfigYAML)); err != nil {
+			t.Errorf("JSON should load as YAML: %v", err)
+		}
+		err := LoadConfig(path, &config,
+			WithConfigWatch(context.Background(), func(any, error) {}),
+			WithConfigWatchInterval(0))
+		if !errors.Is(err, ErrLoadConfig) {
+			t.Errorf("Expected ErrLoadConfig for a zero watch interval, got %v", err)
+		}
+	})
+
+	t.Run("Watch", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "watched.json")
+		if err := WriteFileString(path, `{"name": "v1"}`); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		type reload struct {
+			config any
+			err    error
+		}
+		reloads := make(chan reload, 10)
+
+		var config testConfig
+		err := LoadConfig(path, &config,
+			WithConfigWatch(ctx, func(config any, err error) { reloads <- reload{config, err} }),
+			WithConfigWatchInterval(10*time.Millisecond))
+		if err != nil || config.Name != "v1" {
+			t.Fatalf("Failed to load config: %v, %+v", err, config)
+		}
+
+		next := func() reload {
+			t.Helper()
+			select {
+			case r := <-reloads:
+				return r
+			case <-time.After(5 * time.Second):
+				t.Fatal("Timed out waiting for reload")
+				return reload{}
+			}
+		}
+
+		if err := AtomicWriteFile(path, []byte(`{"name": "v2"}`), 0644); err != nil {
+			t.Fatalf("Failed to update config: %v", err)
+		}
+		if r := next(); r.err != nil || r.config.(*testConfig).Name != "v2" {
+			t.Errorf("Unexpected reload: %+v", r)
+		}
+
+		if err := WriteFileString(path, `{"name": `); err != nil {
+			t.Fatalf("Failed to update config: %v", err)
+		}
+		if r := next(); !errors.Is(r.err, ErrLoadConfig) {
+			t.Errorf("Expected ErrLoadConfig on invalid reload, got %+v", r)
+		}
+		if config.Name != "v1" {
+			t.Error("Reloads must not modify the original value")
+		}
+	})
+}
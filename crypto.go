@@ -0,0 +1,442 @@
+package fsx
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Encrypted files start with a header that is authenticated with every chunk:
+//
+//	magic "FSXE" | version | kdf | chunk size (uint32) | nonce prefix (7 bytes)
+//	[kdf argon2id: salt (16 bytes) | time (uint32) | memory (uint32) | threads]
+//
+// followed by chunks of AES-256-GCM ciphertext. The nonce of a chunk is the prefix,
+// the chunk counter (uint32) and a flag marking the last chunk, so reordered,
+// dropped or truncated chunks fail authentication
+const (
+	cryptoMagic        = "FSXE"
+	cryptoVersion      = 1
+	cryptoKDFNone      = 0
+	cryptoKDFArgon2id  = 1
+	cryptoHeaderSize   = 4 + 1 + 1 + 4 + cryptoPrefixSize
+	cryptoArgonSize    = cryptoSaltSize + 4 + 4 + 1
+	cryptoPrefixSize   = 7
+	cryptoSaltSize     = 16
+	cryptoKeySize      = 32
+	maxCryptoChunkSize = 16 * 1024 * 1024
+
+	// Bounds of the Argon2id parameters; headers are untrusted, so they must not
+	// make key derivation panic or allocate unbounded memory
+	maxArgonTime   = 64
+	maxArgonMemory = 4 * 1024 * 1024 // 4GB in KiB
+)
+
+// Cipher encrypts and decrypts streams. fsx uses it to encrypt copies, archives and
+// backups at rest, so any provider (age, a KMS, ...) can be plugged in. NewAESCipher
+// returns the built-in AES-256-GCM implementation
+type Cipher interface {
+	// EncryptWriter returns a writer encrypting into w. Closing it finishes the
+	// stream without closing w
+	EncryptWriter(w io.Writer) (io.WriteCloser, error)
+	// DecryptReader returns a reader decrypting r. Reads fail when the stream
+	// was modified or truncated
+	DecryptReader(r io.Reader) (io.Reader, error)
+}
+
+// aesCipher is the Cipher of EncryptFile and DecryptFile
+type aesCipher struct {
+	options []CryptoOption
+}
+
+// NewAESCipher returns a Cipher using the chunked AES-256-GCM format of EncryptFile
+func NewAESCipher(options ...CryptoOption) Cipher {
+	return &aesCipher{options: options}
+}
+
+func (c *aesCipher) EncryptWriter(w io.Writer) (io.WriteCloser, error) {
+	return NewEncryptWriter(w, c.options...)
+}
+
+func (c *aesCipher) DecryptReader(r io.Reader) (io.Reader, error) {
+	return NewDecryptReader(r, c.options...)
+}
+
+var (
+	errNoEncryptionKey = errors.New("no encryption key or passphrase given")
+	errNotEncrypted    = errors.New("not an fsx encrypted stream")
+	errTooManyChunks   = errors.New("too many chunks for one stream")
+	errArgon2Params    = errors.New("argon2 parameters out of range")
+)
+
+// EncryptFile encrypts src into dst with AES-256-GCM in authenticated chunks, using the
+// key of WithEncryptionKey or a key derived from WithPassphrase. dst is replaced only once
+// the whole file is written, so src and dst may be the same file
+func EncryptFile(src, dst string, options ...CryptoOption) error {
+	err := transformFile(src, dst, func(r io.Reader, w io.Writer) error {
+		writer, err := NewEncryptWriter(w, options...)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(writer, r); err != nil {
+			return err
+		}
+		return writer.Close()
+	})
+
+	if err != nil {
+		return ErrEncrypt.
+			SetError(err).
+			SetData(moveErrorContext{
+				Source:      src,
+				Destination: dst,
+				Error:       err,
+			})
+	}
+
+	return nil
+}
+
+// DecryptFile decrypts src, written by EncryptFile, into dst. A wrong key or passphrase
+// and modified or truncated files fail with ErrDecrypt; dst is left untouched then
+func DecryptFile(src, dst string, options ...CryptoOption) error {
+	err := transformFile(src, dst, func(r io.Reader, w io.Writer) error {
+		reader, err := NewDecryptReader(r, options...)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, reader)
+		return err
+	})
+
+	if err != nil {
+		return ErrDecrypt.
+			SetError(err).
+			SetData(moveErrorContext{
+				Source:      src,
+				Destination: dst,
+				Error:       err,
+			})
+	}
+
+	return nil
+}
+
+// transformFile writes src through transform into a replacement of dst, renamed over
+// dst with the mode of src only once transform succeeded. A failure leaves dst as it
+// was, and src may be dst itself
+func transformFile(src, dst string, transform func(r io.Reader, w io.Writer) error) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	info, err := srcFile.Stat()
+	if err != nil {
+		return err
+	}
+
+	dstFile, err := createReplacement(dst)
+	if err != nil {
+		return err
+	}
+
+	if err := transform(srcFile, dstFile); err != nil {
+		dstFile.Close()
+		os.Remove(dstFile.Name())
+		return err
+	}
+
+	return replaceFile(dstFile, dst, info.Mode().Perm(), defaultFileOptions())
+}
+
+// NewEncryptWriter returns a writer encrypting everything written to it into w, in the
+// format of EncryptFile. Close writes the final chunk; it doesn't close w
+func NewEncryptWriter(w io.Writer, options ...CryptoOption) (io.WriteCloser, error) {
+	opts := defaultCryptoOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if opts.chunkSize <= 0 || opts.chunkSize > maxCryptoChunkSize {
+		return nil, fmt.Errorf("chunk size %d out of range", opts.chunkSize)
+	}
+
+	header := make([]byte, cryptoHeaderSize, cryptoHeaderSize+cryptoArgonSize)
+	copy(header, cryptoMagic)
+	header[4] = cryptoVersion
+	binary.BigEndian.PutUint32(header[6:], uint32(opts.chunkSize))
+	if _, err := rand.Read(header[10:cryptoHeaderSize]); err != nil {
+		return nil, err
+	}
+
+	key := opts.key
+	switch {
+	case opts.hasPassphrase:
+		if !validArgon2Params(opts.argonTime, opts.argonMemory, opts.argonThreads) {
+			return nil, errArgon2Params
+		}
+		header[5] = cryptoKDFArgon2id
+		salt := make([]byte, cryptoSaltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		header = append(header, salt...)
+		header = binary.BigEndian.AppendUint32(header, opts.argonTime)
+		header = binary.BigEndian.AppendUint32(header, opts.argonMemory)
+		header = append(header, opts.argonThreads)
+		key = argon2.IDKey([]byte(opts.passphrase), salt, opts.argonTime, opts.argonMemory, opts.argonThreads, cryptoKeySize)
+	case key == nil:
+		return nil, errNoEncryptionKey
+	default:
+		header[5] = cryptoKDFNone
+	}
+
+	aead, err := newCryptoAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &encryptWriter{
+		stream: newCryptoStream(aead, header),
+		w:      w,
+		buf:    make([]byte, 0, opts.chunkSize),
+	}, nil
+}
+
+// NewDecryptReader returns a reader decrypting the stream r written by NewEncryptWriter
+// or EncryptFile. Reads fail when a chunk doesn't authenticate or the stream is truncated
+func NewDecryptReader(r io.Reader, options ...CryptoOption) (io.Reader, error) {
+	opts := defaultCryptoOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	header := make([]byte, cryptoHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, errNotEncrypted
+	}
+	if string(header[:4]) != cryptoMagic || header[4] != cryptoVersion {
+		return nil, errNotEncrypted
+	}
+
+	chunkSize := binary.BigEndian.Uint32(header[6:])
+	if chunkSize == 0 || chunkSize > maxCryptoChunkSize {
+		return nil, errNotEncrypted
+	}
+
+	key := opts.key
+	switch header[5] {
+	case cryptoKDFArgon2id:
+		params := make([]byte, cryptoArgonSize)
+		if _, err := io.ReadFull(r, params); err != nil {
+			return nil, errNotEncrypted
+		}
+		header = append(header, params...)
+		if !opts.hasPassphrase {
+			return nil, errNoEncryptionKey
+		}
+		salt := params[:cryptoSaltSize]
+		time := binary.BigEndian.Uint32(params[cryptoSaltSize:])
+		memory := binary.BigEndian.Uint32(params[cryptoSaltSize+4:])
+		threads := params[cryptoSaltSize+8]
+		if !validArgon2Params(time, memory, threads) {
+			return nil, errNotEncrypted
+		}
+		key = argon2.IDKey([]byte(opts.passphrase), salt, time, memory, threads, cryptoKeySize)
+	case cryptoKDFNone:
+		if key == nil {
+			return nil, errNoEncryptionKey
+		}
+	default:
+		return nil, errNotEncrypted
+	}
+
+	aead, err := newCryptoAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decryptReader{
+		stream: newCryptoStream(aead, header),
+		r:      bufio.NewReader(r),
+		chunk:  make([]byte, int(chunkSize)+aead.Overhead()),
+	}, nil
+}
+
+// validArgon2Params reports whether Argon2id can derive a key with the parameters
+// within the bounds accepted by fsx
+func validArgon2Params(time, memory uint32, threads uint8) bool {
+	return time >= 1 && time <= maxArgonTime &&
+		threads >= 1 &&
+		memory >= 8*uint32(threads) && memory <= maxArgonMemory
+}
+
+func newCryptoAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != cryptoKeySize {
+		return nil, fmt.Errorf("key of %d bytes, AES-256 needs %d", len(key), cryptoKeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// cryptoStream seals and opens the chunks of one stream in order
+type cryptoStream struct {
+	aead    cipher.AEAD
+	header  []byte
+	nonce   []byte
+	counter uint64
+}
+
+func newCryptoStream(aead cipher.AEAD, header []byte) *cryptoStream {
+	nonce := make([]byte, aead.NonceSize())
+	copy(nonce, header[10:cryptoHeaderSize])
+
+	return &cryptoStream{
+		aead:   aead,
+		header: header,
+		nonce:  nonce,
+	}
+}
+
+// next sets the nonce for the next chunk
+func (s *cryptoStream) next(last bool) error {
+	if s.counter > math.MaxUint32 {
+		return errTooManyChunks
+	}
+
+	binary.BigEndian.PutUint32(s.nonce[cryptoPrefixSize:], uint32(s.counter))
+	s.nonce[len(s.nonce)-1] = 0
+	if last {
+		s.nonce[len(s.nonce)-1] = 1
+	}
+	s.counter++
+
+	return nil
+}
+
+type encryptWriter struct {
+	stream *cryptoStream
+	w      io.Writer
+	buf    []byte
+	out    []byte
+	closed bool
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, os.ErrClosed
+	}
+
+	written := 0
+	for len(p) > 0 {
+		// A full chunk is sealed once more data shows it is not the last one
+		if len(e.buf) == cap(e.buf) {
+			if err := e.seal(false); err != nil {
+				return written, err
+			}
+		}
+
+		n := min(len(p), cap(e.buf)-len(e.buf))
+		e.buf = append(e.buf, p[:n]...)
+		p = p[n:]
+		written += n
+	}
+
+	return written, nil
+}
+
+func (e *encryptWriter) seal(last bool) error {
+	if err := e.stream.next(last); err != nil {
+		return err
+	}
+
+	e.out = e.stream.aead.Seal(e.out[:0], e.stream.nonce, e.buf, e.stream.header)
+	e.buf = e.buf[:0]
+
+	_, err := e.w.Write(e.out)
+	return err
+}
+
+// Close seals the last chunk
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+
+	return e.seal(true)
+}
+
+type decryptReader struct {
+	stream *cryptoStream
+	r      *bufio.Reader
+	chunk  []byte
+	plain  []byte
+	done   bool
+	err    error
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.done {
+			return 0, io.EOF
+		}
+		d.err = d.open()
+	}
+
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// open reads and authenticates the next chunk
+func (d *decryptReader) open() error {
+	n, err := io.ReadFull(d.r, d.chunk)
+	switch {
+	case err == io.EOF:
+		return io.ErrUnexpectedEOF // the last chunk is missing
+	case err == io.ErrUnexpectedEOF:
+		d.done = true
+	case err != nil:
+		return err
+	default:
+		if _, err := d.r.Peek(1); err == io.EOF {
+			d.done = true
+		}
+	}
+
+	if err := d.stream.next(d.done); err != nil {
+		return err
+	}
+
+	plain, err := d.stream.aead.Open(d.chunk[:0], d.stream.nonce, d.chunk[:n], d.stream.header)
+	if err != nil {
+		return ErrDecryptAuthentication.SetError(err)
+	}
+	d.plain = plain
+
+	return nil
+}
@@ -0,0 +1,327 @@
+package fsx
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "fsx_crypto_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	key := make([]byte, 32)
+	rand.Read(key)
+
+	// Small chunks and cheap Argon2 parameters keep the tests fast
+	small := WithEncryptionChunkSize(1024)
+	cheap := WithArgon2Params(1, 1024, 1)
+
+	content := make([]byte, 10*1024+17)
+	rand.Read(content)
+	original := filepath.Join(tmpDir, "original.bin")
+	if err := os.WriteFile(original, content, 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	roundTrip := func(t *testing.T, data []byte, encrypt, decrypt []CryptoOption) {
+		t.Helper()
+		src := filepath.Join(tmpDir, "plain")
+		enc := filepath.Join(tmpDir, "plain.enc")
+		dec := filepath.Join(tmpDir, "plain.dec")
+		if err := os.WriteFile(src, data, 0600); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		if err := EncryptFile(src, enc, encrypt...); err != nil {
+			t.Fatalf("Failed to encrypt: %v", err)
+		}
+		if err := DecryptFile(enc, dec, decrypt...); err != nil {
+			t.Fatalf("Failed to decrypt: %v", err)
+		}
+		got, _ := os.ReadFile(dec)
+		if !bytes.Equal(got, data) {
+			t.Fatalf("Decrypted %d bytes, want %d", len(got), len(data))
+		}
+	}
+
+	t.Run("Key", func(t *testing.T) {
+		// Empty, partial, exact and multiple chunks
+		for _, size := range []int{0, 1, 1023, 1024, 2048, 5000} {
+			roundTrip(t, content[:size], []CryptoOption{WithEncryptionKey(key), small}, []CryptoOption{WithEncryptionKey(key)})
+		}
+
+		enc := filepath.Join(tmpDir, "original.enc")
+		if err := EncryptFile(original, enc, WithEncryptionKey(key)); err != nil {
+			t.Fatalf("Failed to encrypt: %v", err)
+		}
+		data, _ := os.ReadFile(enc)
+		if bytes.Contains(data, content[:64]) {
+			t.Error("Encrypted file contains plaintext")
+		}
+	})
+
+	t.Run("Passphrase", func(t *testing.T) {
+		roundTrip(t, content, []CryptoOption{WithPassphrase("secret"), cheap, small}, []CryptoOption{WithPassphrase("secret")})
+
+		enc := filepath.Join(tmpDir, "passphrase.enc")
+		if err := EncryptFile(original, enc, WithPassphrase("secret"), cheap); err != nil {
+			t.Fatalf("Failed to encrypt: %v", err)
+		}
+		dec := filepath.Join(tmpDir, "passphrase.dec")
+		err := DecryptFile(enc, dec, WithPassphrase("wrong"))
+		if !errors.Is(err, ErrDecryptAuthentication) {
+			t.Fatalf("Expected authentication error, got %v", err)
+		}
+		if FileExist(dec) {
+			t.Error("Failed decryption left the destination")
+		}
+
+		// Headers are untrusted: out of range parameters fail instead of panicking
+		data, _ := os.ReadFile(enc)
+		params := cryptoHeaderSize + cryptoSaltSize
+		for name, patch := range map[string]func(b []byte){
+			"Time":    func(b []byte) { copy(b[params:], []byte{0, 0, 0, 0}) },
+			"Memory":  func(b []byte) { copy(b[params+4:], []byte{0xff, 0xff, 0xff, 0xff}) },
+			"Threads": func(b []byte) { b[params+8] = 0 },
+		} {
+			hostile := append([]byte{}, data...)
+			patch(hostile)
+			path := filepath.Join(tmpDir, "hostile.enc")
+			if err := os.WriteFile(path, hostile, 0600); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+			if err := DecryptFile(path, dec, WithPassphrase("secret")); !errors.Is(err, ErrDecrypt) {
+				t.Errorf("%s: expected decrypt error, got %v", name, err)
+			}
+		}
+
+		if err := EncryptFile(original, enc, WithPassphrase("secret"), WithArgon2Params(1, 1024, 0)); !errors.Is(err, ErrEncrypt) {
+			t.Errorf("Expected encrypt error for zero threads, got %v", err)
+		}
+	})
+
+	t.Run("WrongKey", func(t *testing.T) {
+		enc := filepath.Join(tmpDir, "wrong.enc")
+		if err := EncryptFile(original, enc, WithEncryptionKey(key)); err != nil {
+			t.Fatalf("Failed to encrypt: %v", err)
+		}
+
+		other := make([]byte, 32)
+		rand.Read(other)
+		err := DecryptFile(enc, filepath.Join(tmpDir, "wrong.dec"), WithEncryptionKey(other))
+		if !errors.Is(err, ErrDecryptAuthentication) {
+			t.Fatalf("Expected authentication error, got %v", err)
+		}
+
+		if err := DecryptFile(enc, filepath.Join(tmpDir, "wrong.dec")); !errors.Is(err, ErrDecrypt) {
+			t.Fatalf("Expected decrypt error without key, got %v", err)
+		}
+		if err := EncryptFile(original, enc, WithEncryptionKey(key[:16])); !errors.Is(err, ErrEncrypt) {
+			t.Fatalf("Expected encrypt error for short key, got %v", err)
+		}
+		if err := DecryptFile(original, filepath.Join(tmpDir, "wrong.dec"), WithEncryptionKey(key)); !errors.Is(err, ErrDecrypt) {
+			t.Fatalf("Expected decrypt error for plain file, got %v", err)
+		}
+	})
+
+	t.Run("Tampering", func(t *testing.T) {
+		enc := filepath.Join(tmpDir, "tamper.enc")
+		if err := EncryptFile(original, enc, WithEncryptionKey(key), small); err != nil {
+			t.Fatalf("Failed to encrypt: %v", err)
+		}
+		data, _ := os.ReadFile(enc)
+		chunk := 1024 + 16
+
+		cases := map[string][]byte{
+			"FlippedBit":     append([]byte{}, data...),
+			"TruncatedChunk": data[:len(data)-5],
+			"DroppedChunk":   data[:len(data)-(len(data)-cryptoHeaderSize)%chunk],
+			"SwappedChunks": append(append(append([]byte{}, data[:cryptoHeaderSize]...),
+				data[cryptoHeaderSize+chunk:cryptoHeaderSize+2*chunk]...),
+				append(append([]byte{}, data[cryptoHeaderSize:cryptoHeaderSize+chunk]...), data[cryptoHeaderSize+2*chunk:]...)...),
+			"ModifiedHeader": append([]byte{}, data...),
+		}
+		cases["FlippedBit"][cryptoHeaderSize+100] ^= 1
+		cases["ModifiedHeader"][10] ^= 1
+
+		for name, tampered := range cases {
+			path := filepath.Join(tmpDir, "tampered.enc")
+			if err := os.WriteFile(path, tampered, 0600); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+			err := DecryptFile(path, filepath.Join(tmpDir, "tampered.dec"), WithEncryptionKey(key))
+			if !errors.Is(err, ErrDecrypt) {
+				t.Errorf("%s: expected decrypt error, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("CustomCipher", func(t *testing.T) {
+		enc := filepath.Join(tmpDir, "custom.enc")
+		if err := CopyFile(original, enc, WithEncryptionCipher(xorCipher{0x5a})); err != nil {
+			t.Fatalf("Failed to copy: %v", err)
+		}
+		if got, _ := os.ReadFile(enc); bytes.Equal(got, content) {
+			t.Error("Custom cipher was not applied")
+		}
+
+		dec := filepath.Join(tmpDir, "custom.dec")
+		if err := CopyFile(enc, dec, WithDecryptionCipher(xorCipher{0x5a})); err != nil {
+			t.Fatalf("Failed to copy: %v", err)
+		}
+		if got, _ := os.ReadFile(dec); !bytes.Equal(got, content) {
+			t.Error("Content differs after custom cipher round trip")
+		}
+	})
+
+	t.Run("CopyAndCompress", func(t *testing.T) {
+		enc := filepath.Join(tmpDir, "copy.enc")
+		if err := CopyFile(original, enc, WithEncryption(WithEncryptionKey(key))); err != nil {
+			t.Fatalf("Failed to copy: %v", err)
+		}
+		dec := filepath.Join(tmpDir, "copy.dec")
+		if err := CopyFile(enc, dec, WithDecryption(WithEncryptionKey(key))); err != nil {
+			t.Fatalf("Failed to copy: %v", err)
+		}
+		if got, _ := os.ReadFile(dec); !bytes.Equal(got, content) {
+			t.Error("Copied content differs after encryption round trip")
+		}
+
+		gz := filepath.Join(tmpDir, "compressed.gz.enc")
+		if err := CompressFile(original, gz, WithEncryption(WithPassphrase("secret"), cheap)); err != nil {
+			t.Fatalf("Failed to compress: %v", err)
+		}
+		if err := DecompressFile(gz, filepath.Join(tmpDir, "plain.gz")); !errors.Is(err, ErrDecompress) {
+			t.Fatalf("Expected decompress error without passphrase, got %v", err)
+		}
+		out := filepath.Join(tmpDir, "decompressed.bin")
+		if err := DecompressFile(gz, out, WithDecryption(WithPassphrase("secret"))); err != nil {
+			t.Fatalf("Failed to decompress: %v", err)
+		}
+		if got, _ := os.ReadFile(out); !bytes.Equal(got, content) {
+			t.Error("Decompressed content differs after encryption round trip")
+		}
+	})
+
+	t.Run("FailedDecryptionKeepsDestination", func(t *testing.T) {
+		dir := filepath.Join(tmpDir, "keep")
+		os.MkdirAll(dir, 0755)
+		existing := filepath.Join(dir, "existing.bin")
+
+		enc := filepath.Join(dir, "tampered.enc")
+		if err := CopyFile(original, enc, WithEncryption(WithEncryptionKey(key), small)); err != nil {
+			t.Fatalf("Failed to copy: %v", err)
+		}
+		data, _ := os.ReadFile(enc)
+		data[len(data)-20] ^= 1 // the last chunk fails after earlier ones decrypted
+		os.WriteFile(enc, data, 0600)
+
+		gz := filepath.Join(dir, "tampered.gz.enc")
+		if err := CompressFile(original, gz, WithEncryption(WithEncryptionKey(key), small)); err != nil {
+			t.Fatalf("Failed to compress: %v", err)
+		}
+		data, _ = os.ReadFile(gz)
+		data[len(data)-20] ^= 1
+		os.WriteFile(gz, data, 0600)
+
+		for name, decrypt := range map[string]func() error{
+			"CopyFile":       func() error { return CopyFile(enc, existing, WithDecryption(WithEncryptionKey(key)), WithBackup()) },
+			"DecompressFile": func() error { return DecompressFile(gz, existing, WithDecryption(WithEncryptionKey(key))) },
+		} {
+			os.WriteFile(existing, []byte("keep me"), 0600)
+			if err := decrypt(); err == nil {
+				t.Fatalf("%s: expected tampering to fail", name)
+			}
+			if got, _ := os.ReadFile(existing); string(got) != "keep me" {
+				t.Errorf("%s: destination replaced by unauthenticated data", name)
+			}
+			if leftovers, _ := filepath.Glob(filepath.Join(dir, ".tmp-*")); len(leftovers) != 0 {
+				t.Errorf("%s: temp files left: %v", name, leftovers)
+			}
+		}
+		if FileExist(existing + ".backup") {
+			t.Error("No backup should be taken when decryption fails")
+		}
+
+		// DecryptFile itself only replaces dst once the whole stream authenticated
+		for _, wrong := range [][]CryptoOption{{WithEncryptionKey(key)}, {WithEncryptionKey(make([]byte, 32))}} {
+			os.WriteFile(existing, []byte("keep me"), 0600)
+			if err := DecryptFile(enc, existing, wrong...); !errors.Is(err, ErrDecrypt) {
+				t.Fatalf("Expected ErrDecrypt, got %v", err)
+			}
+			if got, _ := os.ReadFile(existing); string(got) != "keep me" {
+				t.Errorf("DecryptFile replaced the destination after failing: %q", got)
+			}
+		}
+		if leftovers, _ := filepath.Glob(filepath.Join(dir, ".tmp-*")); len(leftovers) != 0 {
+			t.Errorf("DecryptFile: temp files left: %v", leftovers)
+		}
+	})
+
+	t.Run("InPlace", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "in_place.txt")
+		if err := os.WriteFile(path, []byte("hello world"), 0600); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+
+		if err := EncryptFile(path, path, WithEncryptionKey(key)); err != nil {
+			t.Fatalf("Failed to encrypt in place: %v", err)
+		}
+		if err := DecryptFile(path, path, WithEncryptionKey(key)); err != nil {
+			t.Fatalf("Failed to decrypt in place: %v", err)
+		}
+		if got, _ := os.ReadFile(path); string(got) != "hello world" {
+			t.Errorf("Expected the content to survive an in-place round trip, got %q", got)
+		}
+	})
+}
+
+// xorCipher is a toy Cipher showing that providers can be plugged in
+type xorCipher struct {
+	key byte
+}
+
+func (c xorCipher) EncryptWriter(w io.Writer) (io.WriteCloser, error) {
+	return xorWriter{w: w, key: c.key}, nil
+}
+
+func (c xorCipher) DecryptReader(r io.Reader) (io.Reader, error) {
+	return xorReader{r: r, key: c.key}, nil
+}
+
+type xorWriter struct {
+	w   io.Writer
+	key byte
+}
+
+func (x xorWriter) Write(p []byte) (int, error) {
+	out := make([]byte, len(p))
+	for i, b := range p {
+		out[i] = b ^ x.key
+	}
+	return x.w.Write(out)
+}
+
+func (x xorWriter) Close() error {
+	return nil
+}
+
+type xorReader struct {
+	r   io.Reader
+	key byte
+}
+
+func (x xorReader) Read(p []byte) (int, error) {
+	n, err := x.r.Read(p)
+	for i := range p[:n] {
+		p[i] ^= x.key
+	}
+	return n, err
+}
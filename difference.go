@@ -1,6 +1,12 @@
 package fsx
 
-import "os"
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
 
 // DifferenceType represents the type of difference between files/directories
 type DifferenceType string
@@ -19,3 +25,101 @@ type Difference struct {
 	LeftInfo  os.FileInfo
 	RightInfo os.FileInfo
 }
+
+// DiffFileInfo is the serializable form of os.FileInfo used in diff reports
+type DiffFileInfo struct {
+	Size    int64     `json:"size"`
+	Mode    string    `json:"mode"`
+	ModTime time.Time `json:"mod_time"`
+	IsDir   bool      `json:"is_dir"`
+}
+
+type differenceJSON struct {
+	Path  string         `json:"path"`
+	Type  DifferenceType `json:"type"`
+	Left  *DiffFileInfo  `json:"left,omitempty"`
+	Right *DiffFileInfo  `json:"right,omitempty"`
+}
+
+// MarshalJSON encodes the difference with file infos reduced to plain fields
+func (d Difference) MarshalJSON() ([]byte, error) {
+	return json.Marshal(differenceJSON{
+		Path:  d.Path,
+		Type:  d.Type,
+		Left:  newDiffFileInfo(d.LeftInfo),
+		Right: newDiffFileInfo(d.RightInfo),
+	})
+}
+
+func newDiffFileInfo(info os.FileInfo) *DiffFileInfo {
+	if info == nil {
+		return nil
+	}
+
+	return &DiffFileInfo{
+		Size:    info.Size(),
+		Mode:    info.Mode().String(),
+		ModTime: info.ModTime(),
+		IsDir:   info.IsDir(),
+	}
+}
+
+// DiffReport is a list of differences that can be rendered for humans and machines
+type DiffReport []Difference
+
+// JSON renders the report as a JSON array
+func (r DiffReport) JSON() ([]byte, error) {
+	return json.Marshal([]Difference(r))
+}
+
+// Counts returns number of differences per type
+func (r DiffReport) Counts() map[DifferenceType]int {
+	counts := make(map[DifferenceType]int)
+	for _, diff := range r {
+		counts[diff.Type]++
+	}
+
+	return counts
+}
+
+// Summary renders a human-readable summary followed by every change
+// ("+" added, "-" removed, "~" modified); unchanged entries are omitted
+func (r DiffReport) Summary() string {
+	counts := r.Counts()
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%d added, %d removed, %d modified, %d unchanged\n",
+		counts[DiffAdded], counts[DiffRemoved], counts[DiffModified], counts[DiffSame])
+
+	for _, diff := range r {
+		switch diff.Type {
+		case DiffAdded:
+			fmt.Fprintf(&builder, "+ %s\n", diff.Path)
+		case DiffRemoved:
+			fmt.Fprintf(&builder, "- %s\n", diff.Path)
+		case DiffModified:
+			fmt.Fprintf(&builder, "~ %s\n", diff.Path)
+		}
+	}
+
+	return builder.String()
+}
+
+// Actions renders the steps turning the left directory into the right one,
+// one "<action> <path>" per line with actions create, update and delete
+func (r DiffReport) Actions() string {
+	var builder strings.Builder
+
+	for _, diff := range r {
+		switch diff.Type {
+		case DiffAdded:
+			fmt.Fprintf(&builder, "create %s\n", diff.Path)
+		case DiffRemoved:
+			fmt.Fprintf(&builder, "delete %s\n", diff.Path)
+		case DiffModified:
+			fmt.Fprintf(&builder, "update %s\n", diff.Path)
+		}
+	}
+
+	return builder.String()
+}
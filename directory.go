@@ -1,13 +1,20 @@
 package fsx
 
 import (
-	"crypto/md5"
+	"encoding/binary"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // FilterFunc is used to filter files/directories during operations
@@ -16,9 +23,13 @@ type FilterFunc func(path string, info os.FileInfo) bool
 // ProgressFunc is called to report progress during operations
 type ProgressFunc func(current, total int64, currentFile string)
 
+// ErrorFunc is called for each path skipped because of an error
+type ErrorFunc func(path string, err error)
+
 // WalkFunc is called for each file/directory during tree walk
 type WalkFunc func(path string, info os.FileInfo, err error) error
 
+// DirectoryExist reports whether path is a directory, symlinks and junctions are followed
 func DirectoryExist(path string) bool {
 	stat, _ := os.Stat(path)
 	if stat == nil {
@@ -47,6 +58,17 @@ func CreateDirectory(path string, options ...DirectoryOption) error {
 			})
 	}
 
+	if opts.exactPerm {
+		if err := os.Chmod(path, opts.perm); err != nil {
+			return ErrCreateDirectory.
+				SetError(err).
+				SetData(pathErrorContext{
+					Path:  path,
+					Error: err,
+				})
+		}
+	}
+
 	return nil
 }
 
@@ -57,7 +79,19 @@ func CreateDirectories(path string, options ...DirectoryOption) error {
 		opt(opts)
 	}
 
-	if err := os.MkdirAll(path, opts.perm); err != nil {
+	var created []string
+	if opts.exactPerm {
+		created = missingDirectories(path)
+	}
+
+	err := os.MkdirAll(path, opts.perm)
+
+	// Deepest first, a restrictive mode on a parent could deny access to its children
+	for i := 0; i < len(created) && err == nil; i++ {
+		err = os.Chmod(created[i], opts.perm)
+	}
+
+	if err != nil {
 		return ErrCreateDirectories.
 			SetError(err).
 			SetData(pathErrorContext{
@@ -69,6 +103,23 @@ func CreateDirectories(path string, options ...DirectoryOption) error {
 	return nil
 }
 
+// missingDirectories returns path and its ancestors that don't exist yet, deepest first
+func missingDirectories(path string) []string {
+	var missing []string
+	for dir := filepath.Clean(path); ; dir = filepath.Dir(dir) {
+		if _, err := os.Lstat(dir); err == nil {
+			break
+		}
+		missing = append(missing, dir)
+
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	return missing
+}
+
 // DeleteDirectory removes a directory
 func DeleteDirectory(path string, options ...DirectoryOption) error {
 	opts := defaultDirectoryOptions()
@@ -154,6 +205,159 @@ func RenameDirectory(oldPath, newPath string, options ...DirectoryOption) error
 	return nil
 }
 
+// destinationInside reports whether dst is src or lies below it, with symlinks in the
+// existing part of both paths resolved
+func destinationInside(src, dst string) (bool, error) {
+	canonicalSrc, err := CanonicalPath(src)
+	if err != nil {
+		return false, err
+	}
+
+	absDst, err := filepath.Abs(dst)
+	if err != nil {
+		return false, err
+	}
+
+	existing := existingAncestor(absDst)
+	canonical, err := CanonicalPath(existing)
+	if err != nil {
+		return false, err
+	}
+	rel, err := filepath.Rel(existing, absDst)
+	if err != nil {
+		return false, err
+	}
+
+	return isWithin(canonicalSrc, filepath.Join(canonical, rel)), nil
+}
+
+// MoveDirectory moves a directory tree. It tries os.Rename first and falls back to
+// CopyDirectory, a checksum verification and removal of src when src and dst are on
+// different filesystems. Copy options apply to the fallback; filters are ignored since
+// everything is moved. dst may not be src or lie inside it
+func MoveDirectory(src, dst string, options ...CopyOption) error {
+	opts := defaultCopyOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return ErrMoveDirectory.
+			SetError(err).
+			SetData(moveErrorContext{
+				Source:      src,
+				Destination: dst,
+				Error:       err,
+			})
+	}
+
+	if !srcInfo.IsDir() {
+		return ErrSourceNotDirectory.
+			SetData(moveErrorContext{
+				Source:      src,
+				Destination: dst,
+				Error:       nil,
+			})
+	}
+
+	if inside, err := destinationInside(src, dst); err != nil || inside {
+		if err == nil {
+			err = errors.New("destination is inside the source")
+		}
+		return ErrMoveDirectory.
+			SetError(err).
+			SetData(moveErrorContext{
+				Source:      src,
+				Destination: dst,
+				Error:       err,
+			})
+	}
+
+	dstExists := DirectoryExist(dst)
+	if dstExists && !opts.overwrite {
+		return ErrDestinationExists.
+			SetData(moveErrorContext{
+				Source:      src,
+				Destination: dst,
+				Error:       nil,
+			})
+	}
+
+	if err := CreateDirectories(filepath.Dir(dst)); err != nil {
+		return err
+	}
+
+	if !dstExists {
+		err := os.Rename(src, dst)
+		if err == nil {
+			return nil
+		}
+		if !isCrossDevice(err) {
+			return ErrMoveDirectory.
+				SetError(err).
+				SetData(moveErrorContext{
+					Source:      src,
+					Destination: dst,
+					Error:       err,
+				})
+		}
+	}
+
+	copyOptions := append(options[:len(options):len(options)], func(opts *copyOptions) {
+		opts.filter = nil
+		opts.includePatterns = nil
+		opts.excludePatterns = nil
+	})
+	if err := CopyDirectory(src, dst, copyOptions...); err != nil {
+		return ErrMoveDirectory.
+			SetError(err).
+			SetData(moveErrorContext{
+				Source:      src,
+				Destination: dst,
+				Error:       err,
+			})
+	}
+
+	// Only remove the source once every file is confirmed at the destination
+	report, err := VerifyDirectoryCopy(src, dst, HashXXH64)
+	if err != nil {
+		return ErrMoveDirectory.
+			SetError(err).
+			SetData(moveErrorContext{
+				Source:      src,
+				Destination: dst,
+				Error:       err,
+			})
+	}
+	if len(report.Missing) > 0 || len(report.Corrupted) > 0 {
+		return ErrMoveDirectory.
+			SetData(struct {
+				Source      string   `json:"source"`
+				Destination string   `json:"destination"`
+				Missing     []string `json:"missing"`
+				Corrupted   []string `json:"corrupted"`
+			}{
+				Source:      src,
+				Destination: dst,
+				Missing:     report.Missing,
+				Corrupted:   report.Corrupted,
+			})
+	}
+
+	if err := os.RemoveAll(src); err != nil {
+		return ErrMoveDirectory.
+			SetError(err).
+			SetData(moveErrorContext{
+				Source:      src,
+				Destination: dst,
+				Error:       err,
+			})
+	}
+
+	return nil
+}
+
 // ListDirectory returns entries in a directory
 func ListDirectory(path string, options ...DirectoryOption) ([]DirectoryEntry, error) {
 	opts := defaultDirectoryOptions()
@@ -179,6 +383,17 @@ func ListDirectory(path string, options ...DirectoryOption) ([]DirectoryEntry, e
 			})
 	}
 
+	result := listDirectoryEntries(path, entries, opts)
+	if opts.sortBy != SortNone {
+		sortListedEntries(result, opts.sortBy, opts.sortAscending)
+	}
+
+	return result, nil
+}
+
+// listDirectoryEntries applies listing filters to entries of path and lists
+// subdirectories when listing recursively
+func listDirectoryEntries(path string, entries []os.DirEntry, opts *directoryOptions) []DirectoryEntry {
 	var result []DirectoryEntry
 	for _, entry := range entries {
 		info, err := entry.Info()
@@ -186,28 +401,64 @@ func ListDirectory(path string, options ...DirectoryOption) ([]DirectoryEntry, e
 			continue
 		}
 
-		dirEntry := DirectoryEntry{
-			Name:    entry.Name(),
-			Path:    filepath.Join(path, entry.Name()),
-			Size:    info.Size(),
-			Mode:    info.Mode(),
-			ModTime: info.ModTime().Format("2006-01-02 15:04:05"),
-			IsDir:   entry.IsDir(),
+		entryPath := filepath.Join(path, entry.Name())
+		if opts.listFilter != nil && !opts.listFilter(entryPath, info) {
+			continue
 		}
 
-		result = append(result, dirEntry)
+		if (entry.IsDir() && !opts.filesOnly) || (!entry.IsDir() && !opts.dirsOnly) {
+			listed := DirectoryEntry{
+				Name:       entry.Name(),
+				Path:       entryPath,
+				Size:       info.Size(),
+				Mode:       info.Mode(),
+				ModTime:    info.ModTime().Format("2006-01-02 15:04:05"),
+				IsDir:      entry.IsDir(),
+				ModifiedAt: info.ModTime(),
+			}
+			if !entry.IsDir() {
+				listed.Extension = strings.ToLower(filepath.Ext(entry.Name()))
+			}
+			if accessed, ok := fileAccessTime(info); ok {
+				listed.AccessedAt = accessed
+			}
+			if opts.contentType && info.Mode().IsRegular() {
+				listed.DetectContentType()
+			}
+			result = append(result, listed)
+		}
 
 		// If recursive and it's a directory, list its contents
 		if opts.recursive && entry.IsDir() {
-			subPath := filepath.Join(path, entry.Name())
-			subEntries, err := ListDirectory(subPath, options...)
+			subEntries, err := os.ReadDir(entryPath)
 			if err == nil {
-				result = append(result, subEntries...)
+				result = append(result, listDirectoryEntries(entryPath, subEntries, opts)...)
 			}
 		}
 	}
 
-	return result, nil
+	return result
+}
+
+// sortListedEntries sorts entries by key; ties keep the listing order
+func sortListedEntries(entries []DirectoryEntry, key SortKey, ascending bool) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if !ascending {
+			a, b = b, a
+		}
+
+		switch key {
+		case SortBySize:
+			return a.Size < b.Size
+		case SortByModTime:
+			return a.ModifiedAt.Before(b.ModifiedAt)
+		case SortByPath:
+			return a.Path < b.Path
+		default:
+			return a.Name < b.Name
+		}
+	})
 }
 
 // GetDirectoryInfo returns detailed directory information
@@ -245,7 +496,7 @@ func GetDirectoryInfo(path string) (*DirectoryInfo, error) {
 	}
 
 	// Calculate size and count files/dirs
-	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+	err = walkTree(path, func(p string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil // Skip errors
 		}
@@ -265,6 +516,134 @@ func GetDirectoryInfo(path string) (*DirectoryInfo, error) {
 	return dirInfo, nil
 }
 
+// AnalyzeDirectory collects per-extension totals, the largest, oldest and newest files
+// and depth statistics of a directory tree in a single walk. Unreadable entries are skipped
+func AnalyzeDirectory(root string, options ...AnalyzeOption) (*DirectoryAnalysis, error) {
+	opts := defaultAnalyzeOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, ErrStatDirectory.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+
+	if !info.IsDir() {
+		return nil, ErrNotDirectory.
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: nil,
+			})
+	}
+
+	analysis := &DirectoryAnalysis{
+		Path:         root,
+		Extensions:   make(map[string]ExtensionStats),
+		FilesByDepth: make(map[int]int),
+	}
+
+	var depthSum int
+	err = walkTree(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // Skip errors
+		}
+
+		relPath, err := filepath.Rel(root, path)
+		if err != nil || relPath == "." {
+			return nil
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if matchAnyPattern(relPath, opts.excludePatterns) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		depth := strings.Count(relPath, "/") + 1
+		if depth > analysis.MaxDepth {
+			analysis.MaxDepth = depth
+		}
+
+		if info.IsDir() {
+			analysis.DirCount++
+			return nil
+		}
+
+		analysis.FileCount++
+		analysis.TotalSize += info.Size()
+		analysis.FilesByDepth[depth]++
+		depthSum += depth
+
+		ext := strings.ToLower(filepath.Ext(info.Name()))
+		stats := analysis.Extensions[ext]
+		stats.Count++
+		stats.Size += info.Size()
+		analysis.Extensions[ext] = stats
+
+		file := AnalyzedFile{
+			Path:    path,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		}
+		analysis.Largest = insertTopFile(analysis.Largest, file, opts.topN, func(a, b AnalyzedFile) bool {
+			return a.Size > b.Size
+		})
+		analysis.Oldest = insertTopFile(analysis.Oldest, file, opts.topN, func(a, b AnalyzedFile) bool {
+			return a.ModTime.Before(b.ModTime)
+		})
+		analysis.Newest = insertTopFile(analysis.Newest, file, opts.topN, func(a, b AnalyzedFile) bool {
+			return a.ModTime.After(b.ModTime)
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, ErrWalkDirectory.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+
+	if analysis.FileCount > 0 {
+		analysis.AverageFileDepth = float64(depthSum) / float64(analysis.FileCount)
+	}
+
+	return analysis, nil
+}
+
+// insertTopFile inserts file into a list of at most n files ordered by before
+func insertTopFile(files []AnalyzedFile, file AnalyzedFile, n int, before func(a, b AnalyzedFile) bool) []AnalyzedFile {
+	if n <= 0 {
+		return files
+	}
+
+	i := sort.Search(len(files), func(i int) bool {
+		return before(file, files[i])
+	})
+	if i >= n {
+		return files
+	}
+
+	files = append(files, AnalyzedFile{})
+	copy(files[i+1:], files[i:])
+	files[i] = file
+
+	if len(files) > n {
+		files = files[:n]
+	}
+	return files
+}
+
 // ChangeDirectoryPermissions changes directory permissions
 func ChangeDirectoryPermissions(path string, mode os.FileMode, options ...DirectoryOption) error {
 	opts := defaultDirectoryOptions()
@@ -282,7 +661,7 @@ func ChangeDirectoryPermissions(path string, mode os.FileMode, options ...Direct
 
 	if opts.recursive {
 		// Change permissions recursively
-		err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		err := walkTree(path, func(p string, info os.FileInfo, err error) error {
 			if err != nil {
 				return err
 			}
@@ -316,19 +695,76 @@ func ChangeDirectoryPermissions(path string, mode os.FileMode, options ...Direct
 	return nil
 }
 
-// IsEmptyDirectory checks if directory is empty
-func IsEmptyDirectory(path string) (bool, error) {
+// ApplyPermissions sets dirMode on the directory path and its subdirectories
+// and fileMode on the regular files in them, such as "dirs 0755, files 0644".
+// Without WithRecursive only path and its direct entries are changed.
+// Symlinks are left alone
+func ApplyPermissions(path string, dirMode, fileMode os.FileMode, options ...DirectoryOption) error {
+	opts := defaultDirectoryOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
 	if !DirectoryExist(path) {
-		return false, ErrDirectoryNotExist.
+		return ErrDirectoryNotExist.
 			SetData(pathErrorContext{
 				Path:  path,
 				Error: os.ErrNotExist,
 			})
 	}
 
-	entries, err := os.ReadDir(path)
+	// Directories are changed on entry when dirMode still lets them be read,
+	// so that unreadable ones are opened up before the walk reads them.
+	// Restrictive modes are applied after the walk, deepest first
+	var restricted []string
+	chmodDir := func(p string) error {
+		if dirMode&0500 == 0500 {
+			return os.Chmod(p, dirMode)
+		}
+		restricted = append(restricted, p)
+		return nil
+	}
+
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		if err := chmodDir(dir); err != nil {
+			return err
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			p := filepath.Join(dir, entry.Name())
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case info.IsDir() && opts.recursive && !isJunction(info):
+				err = walk(p)
+			case info.IsDir():
+				err = chmodDir(p)
+			case info.Mode().IsRegular():
+				err = os.Chmod(p, fileMode)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err := walk(path)
+	for i := len(restricted) - 1; i >= 0 && err == nil; i-- {
+		err = os.Chmod(restricted[i], dirMode)
+	}
+
 	if err != nil {
-		return false, ErrReadDirectory.
+		return ErrApplyPermissions.
 			SetError(err).
 			SetData(pathErrorContext{
 				Path:  path,
@@ -336,58 +772,51 @@ func IsEmptyDirectory(path string) (bool, error) {
 			})
 	}
 
-	return len(entries) == 0, nil
+	return nil
 }
 
-// ListDirectoryByName returns directory entries sorted by name
-func ListDirectoryByName(path string, ascending bool) ([]DirectoryEntry, error) {
-	entries, err := ListDirectory(path)
-	if err != nil {
-		return nil, err
-	}
+// IsEmptyDirectory checks if directory is empty
+func IsEmptyDirectory(path string) (bool, error) {
+	if !DirectoryExist(path) {
+		return false, ErrDirectoryNotExist.
+			SetData(pathErrorContext{
+				Path:  path,
+				Error: os.ErrNotExist,
+			})
+	}
 
-	sort.Slice(entries, func(i, j int) bool {
-		if ascending {
-			return entries[i].Name < entries[j].Name
-		}
-		return entries[i].Name > entries[j].Name
-	})
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return false, ErrReadDirectory.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  path,
+				Error: err,
+			})
+	}
 
-	return entries, nil
+	return len(entries) == 0, nil
+}
+
+// ListDirectoryByName returns directory entries sorted by name
+//
+// Deprecated: use ListDirectory with WithSortBy(SortByName, ascending)
+func ListDirectoryByName(path string, ascending bool) ([]DirectoryEntry, error) {
+	return ListDirectory(path, WithSortBy(SortByName, ascending))
 }
 
 // ListDirectoryBySize returns directory entries sorted by size
+//
+// Deprecated: use ListDirectory with WithSortBy(SortBySize, ascending)
 func ListDirectoryBySize(path string, ascending bool) ([]DirectoryEntry, error) {
-	entries, err := ListDirectory(path)
-	if err != nil {
-		return nil, err
-	}
-
-	sort.Slice(entries, func(i, j int) bool {
-		if ascending {
-			return entries[i].Size < entries[j].Size
-		}
-		return entries[i].Size > entries[j].Size
-	})
-
-	return entries, nil
+	return ListDirectory(path, WithSortBy(SortBySize, ascending))
 }
 
 // ListDirectoryByModTime returns directory entries sorted by modification time
+//
+// Deprecated: use ListDirectory with WithSortBy(SortByModTime, ascending)
 func ListDirectoryByModTime(path string, ascending bool) ([]DirectoryEntry, error) {
-	entries, err := ListDirectory(path)
-	if err != nil {
-		return nil, err
-	}
-
-	sort.Slice(entries, func(i, j int) bool {
-		if ascending {
-			return entries[i].ModTime < entries[j].ModTime
-		}
-		return entries[i].ModTime > entries[j].ModTime
-	})
-
-	return entries, nil
+	return ListDirectory(path, WithSortBy(SortByModTime, ascending))
 }
 
 // CopyDirectory copies entire directory tree from source to destination
@@ -428,8 +857,14 @@ func CopyDirectory(src, dst string, options ...CopyOption) error {
 			})
 	}
 
+	if opts.requireSpace {
+		if err := CheckSpaceFor(src, dst); err != nil {
+			return err
+		}
+	}
+
 	// Calculate total size for progress
-	var totalSize, copiedSize int64
+	var totalSize int64
 	if opts.progressHandler != nil {
 		totalSize, _ = CalculateDirectorySize(src)
 	}
@@ -440,19 +875,68 @@ func CopyDirectory(src, dst string, options ...CopyOption) error {
 	}
 
 	// Copy directory attributes
-	if opts.preservePerms {
-		_ = os.Chmod(dst, srcInfo.Mode())
+	if err := preserveAttributes(src, dst, srcInfo, opts); err != nil {
+		return ErrCopyDirectory.
+			SetError(err).
+			SetData(moveErrorContext{
+				Source:      src,
+				Destination: dst,
+				Error:       err,
+			})
 	}
 
 	// Walk through source directory
-	err = filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+	state := &copyTreeState{
+		src:       src,
+		totalSize: totalSize,
+	}
+	err = copyDirectoryTree(src, src, dst, opts, state, nil)
+
+	if err != nil {
+		return ErrCopyDirectory.
+			SetError(err).
+			SetData(moveErrorContext{
+				Source:      src,
+				Destination: dst,
+				Error:       err,
+			})
+	}
+
+	return nil
+}
+
+// copyTreeState is shared by the trees copied through followed directory symlinks
+type copyTreeState struct {
+	src        string // top-level source, base of relative paths
+	totalSize  int64
+	copiedSize int64
+	links      map[fileID]string // first copy of each hardlinked source file
+}
+
+// copyDirectoryTree copies the tree at root into dst. logicalRoot is where root appears
+// under the copied source (it differs from root for followed directory symlinks);
+// ancestors holds keys of directories enclosing root and is used to detect symlink loops
+func copyDirectoryTree(root, logicalRoot, dst string, opts *copyOptions, state *copyTreeState, ancestors []string) error {
+	return filepath.Walk(root, func(realPath string, info os.FileInfo, err error) error {
 		if err != nil {
-			if opts.skipErrors {
+			if opts.skipError(realPath, err) {
 				return nil
 			}
 			return err
 		}
 
+		// Calculate relative paths
+		treeRel, err := filepath.Rel(root, realPath)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(logicalRoot, treeRel)
+
+		relPath, err := filepath.Rel(state.src, path)
+		if err != nil {
+			return err
+		}
+
 		// Apply filter if provided
 		if opts.filter != nil && !opts.filter(path, info) {
 			if info.IsDir() {
@@ -461,51 +945,89 @@ func CopyDirectory(src, dst string, options ...CopyOption) error {
 			return nil
 		}
 
-		// Calculate relative path
-		relPath, err := filepath.Rel(src, path)
-		if err != nil {
-			return err
+		// Apply include/exclude patterns
+		if path != state.src && !copyPatternsAccept(filepath.ToSlash(relPath), info, opts) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
 		}
 
-		dstPath := filepath.Join(dst, relPath)
+		dstPath := filepath.Join(dst, treeRel)
+
+		// Handle symlinks and junctions
+		if isLink(info) {
+			// Walk sees junctions as directories, never descend into them
+			var done error
+			if info.IsDir() {
+				done = filepath.SkipDir
+			}
 
-		// Handle symlinks
-		if info.Mode()&os.ModeSymlink != 0 {
 			if !opts.followSymlinks {
+				// Junctions are copied as links only on request
+				if info.IsDir() && !opts.copyJunctions {
+					return done
+				}
+
 				// Copy symlink as-is
-				link, err := os.Readlink(path)
-				if err != nil {
-					if opts.skipErrors {
-						return nil
+				if err := copySymlink(realPath, dstPath, opts.overwrite); err != nil {
+					if opts.skipError(path, err) {
+						return done
 					}
 					return err
 				}
-				return os.Symlink(link, dstPath)
+				if opts.preserveOwner {
+					if err := preserveOwnership(dstPath, info); err != nil && !opts.skipError(path, err) {
+						return err
+					}
+				}
+				return done
+			}
+
+			// Following symlinks: copy the target instead
+			targetInfo, err := os.Stat(realPath)
+			if err != nil {
+				if opts.skipError(path, err) {
+					return done
+				}
+				return err
+			}
+
+			if targetInfo.IsDir() {
+				err := copyLinkedDirectory(root, realPath, path, dstPath, targetInfo, opts, state, ancestors)
+				if err != nil && !opts.skipError(path, err) {
+					return err
+				}
+				return done
 			}
-			// If following symlinks, continue to copy the target
+			info = targetInfo
 		}
 
 		// Copy based on type
 		if info.IsDir() {
 			// Create directory
 			if err := CreateDirectory(dstPath); err != nil {
-				if opts.skipErrors {
+				if opts.skipError(path, err) {
 					return nil
 				}
 				return err
 			}
 
 			// Preserve directory attributes
-			if opts.preservePerms {
-				os.Chmod(dstPath, info.Mode())
-			}
-			if opts.preserveTimes {
-				os.Chtimes(dstPath, info.ModTime(), info.ModTime())
+			if err := preserveAttributes(realPath, dstPath, info, opts); err != nil {
+				if opts.skipError(path, err) {
+					return nil
+				}
+				return err
 			}
 		} else {
-			// Copy file
-			if err := copyFileWithOptions(path, dstPath, info, opts); err != nil {
-				if opts.skipErrors {
+			// Link to an already copied file sharing the inode, or copy the file
+			linked, err := linkCopiedFile(dstPath, info, opts, state)
+			if err == nil && !linked {
+				err = copyFileWithOptions(realPath, dstPath, info, opts)
+			}
+			if err != nil {
+				if opts.skipError(path, err) {
 					return nil
 				}
 				return err
@@ -513,25 +1035,143 @@ func CopyDirectory(src, dst string, options ...CopyOption) error {
 
 			// Update progress
 			if opts.progressHandler != nil {
-				copiedSize += info.Size()
-				opts.progressHandler(copiedSize, totalSize, path)
+				state.copiedSize += info.Size()
+				opts.progressHandler(state.copiedSize, state.totalSize, path)
 			}
 		}
 
 		return nil
 	})
+}
+
+// identicalCopy reports whether dst already holds the content of src
+func identicalCopy(src, dst string, srcInfo os.FileInfo, opts *copyOptions) bool {
+	dstInfo, err := os.Stat(dst)
+	if err != nil || !dstInfo.Mode().IsRegular() || dstInfo.Size() != srcInfo.Size() {
+		return false
+	}
+
+	if opts.identicalHash == "" {
+		return dstInfo.ModTime().Equal(srcInfo.ModTime())
+	}
 
+	srcSum, err := calculateFileChecksum(src, opts.identicalHash)
 	if err != nil {
-		return ErrCopyDirectory.
-			SetError(err).
-			SetData(moveErrorContext{
-				Source:      src,
-				Destination: dst,
-				Error:       err,
-			})
+		return false
+	}
+	dstSum, err := calculateFileChecksum(dst, opts.identicalHash)
+	if err != nil {
+		return false
 	}
 
-	return nil
+	return srcSum == dstSum
+}
+
+// linkCopiedFile hardlinks dst to the copy of a file sharing the inode of info
+// when hardlinks are preserved. Otherwise it remembers dst as the copy of that inode
+func linkCopiedFile(dst string, info os.FileInfo, opts *copyOptions, state *copyTreeState) (bool, error) {
+	if !opts.preserveLinks {
+		return false, nil
+	}
+
+	if count, ok := fileLinkCount(info); !ok || count < 2 {
+		return false, nil
+	}
+
+	id, ok := fileIdentity(info)
+	if !ok {
+		return false, nil
+	}
+
+	target, exists := state.links[id]
+	if !exists {
+		if state.links == nil {
+			state.links = make(map[fileID]string)
+		}
+		state.links[id] = dst
+		return false, nil
+	}
+
+	if FileExist(dst) {
+		if !opts.overwrite {
+			return true, nil
+		}
+		if err := os.Remove(dst); err != nil {
+			return true, err
+		}
+	}
+
+	return true, os.Link(target, dst)
+}
+
+// copyLinkedDirectory copies the directory a followed symlink points to.
+// It fails with ErrSymlinkLoop when the target encloses the link itself
+func copyLinkedDirectory(root, realPath, path, dstPath string, targetInfo os.FileInfo, opts *copyOptions, state *copyTreeState, ancestors []string) error {
+	chain := append(ancestors[:len(ancestors):len(ancestors)], enclosingDirKeys(root, realPath)...)
+
+	key := dirKey(realPath, targetInfo)
+	for _, ancestor := range chain {
+		if ancestor == key {
+			return newSymlinkLoopError(path)
+		}
+	}
+
+	target, err := filepath.EvalSymlinks(realPath)
+	if err != nil {
+		return err
+	}
+
+	return copyDirectoryTree(target, path, dstPath, opts, state, chain)
+}
+
+// enclosingDirKeys returns keys of directories from the parent of path up to root
+func enclosingDirKeys(root, path string) []string {
+	var keys []string
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if info, err := os.Stat(dir); err == nil {
+			keys = append(keys, dirKey(dir, info))
+		}
+		if dir == root || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+
+	return keys
+}
+
+// dirKey identifies a directory by device and inode, or by its resolved path
+// where those are not available
+func dirKey(path string, info os.FileInfo) string {
+	if id, ok := fileIdentity(info); ok {
+		return strconv.FormatUint(id.dev, 10) + ":" + strconv.FormatUint(id.ino, 10)
+	}
+
+	if canonical, err := CanonicalPath(path); err == nil {
+		return canonical
+	}
+
+	return path
+}
+
+func newSymlinkLoopError(path string) error {
+	return ErrSymlinkLoop.
+		SetData(pathErrorContext{
+			Path:  path,
+			Error: nil,
+		})
+}
+
+// copyPatternsAccept checks a relative path against include/exclude copy patterns
+func copyPatternsAccept(relPath string, info os.FileInfo, opts *copyOptions) bool {
+	if matchAnyPattern(relPath, opts.excludePatterns) {
+		return false
+	}
+
+	if len(opts.includePatterns) > 0 && !info.IsDir() {
+		return matchAnyPattern(relPath, opts.includePatterns)
+	}
+
+	return true
 }
 
 // copyFileWithOptions is a helper to copy files with options
@@ -541,6 +1181,11 @@ func copyFileWithOptions(src, dst string, srcInfo os.FileInfo, opts *copyOptions
 		return nil
 	}
 
+	// Keep files already copied by a previous run
+	if opts.skipIdentical && identicalCopy(src, dst, srcInfo, opts) {
+		return nil
+	}
+
 	// Open source
 	srcFile, err := os.Open(src)
 	if err != nil {
@@ -560,7 +1205,32 @@ func copyFileWithOptions(src, dst string, srcInfo os.FileInfo, opts *copyOptions
 		return err
 	}
 
-	// Preserve attributes
+	return preserveAttributes(src, dst, srcInfo, opts)
+}
+
+// preserveAttributes copies the attributes requested by opts from src to dst in an order
+// that keeps each of them: the owner first, as chown clears setuid and setgid, then
+// extended attributes and ACLs while dst is still writable, then mode and times, and
+// the macOS creation time and flags last, since a locked file rejects further changes.
+// Mode and time failures are ignored
+func preserveAttributes(src, dst string, srcInfo os.FileInfo, opts *copyOptions) error {
+	if opts.preserveOwner {
+		if err := preserveOwnership(dst, srcInfo); err != nil {
+			return err
+		}
+	}
+
+	if opts.preserveXattrs || opts.preserveACLs {
+		if err := copyXattrs(src, dst, opts.preserveXattrs, opts.preserveACLs); err != nil {
+			return err
+		}
+	}
+	if opts.preserveMacMetadata {
+		if err := copyMacXattrs(src, dst); err != nil {
+			return err
+		}
+	}
+
 	if opts.preservePerms {
 		os.Chmod(dst, srcInfo.Mode())
 	}
@@ -568,14 +1238,41 @@ func copyFileWithOptions(src, dst string, srcInfo os.FileInfo, opts *copyOptions
 		os.Chtimes(dst, srcInfo.ModTime(), srcInfo.ModTime())
 	}
 
+	if opts.preserveMacMetadata {
+		return copyMacFlags(src, dst)
+	}
 	return nil
 }
 
+// preserveOwnership sets the owner of dst to the owner of the source. It must run
+// before the mode is set, as chown clears setuid and setgid. Without the privileges
+// to give files away the owner is left unchanged
+func preserveOwnership(dst string, srcInfo os.FileInfo) error {
+	uid, gid, ok := fileOwner(srcInfo)
+	if !ok {
+		return nil
+	}
+
+	err := os.Lchown(dst, uid, gid)
+	if errors.Is(err, fs.ErrPermission) && os.Geteuid() != 0 {
+		return nil
+	}
+	return err
+}
+
+// syncTrashLayout names the trash directory of each sync run with WithSyncTrash
+const syncTrashLayout = "20060102T150405.000000000"
+
 // SyncDirectories synchronizes source directory to destination
 func SyncDirectories(src, dst string, options ...CopyOption) error {
 	// Create options with overwrite enabled by default for sync
 	syncOptions := append([]CopyOption{WithOverwrite()}, options...)
 
+	opts := defaultCopyOptions()
+	for _, opt := range syncOptions {
+		opt(opts)
+	}
+
 	// First, copy all from source to destination
 	if err := CopyDirectory(src, dst, syncOptions...); err != nil {
 		return ErrSyncDirectory.
@@ -587,11 +1284,15 @@ func SyncDirectories(src, dst string, options ...CopyOption) error {
 			})
 	}
 
+	if opts.syncNoDelete {
+		return nil
+	}
+
 	// Then, remove files from destination that don't exist in source
 	srcFiles := make(map[string]bool)
 
 	// Collect all source files
-	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+	err := walkTree(src, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -615,8 +1316,14 @@ func SyncDirectories(src, dst string, options ...CopyOption) error {
 			})
 	}
 
+	// Removed files go to a per-run trash directory when enabled
+	trashDir := ""
+	if opts.syncTrash != "" {
+		trashDir = filepath.Join(opts.syncTrash, time.Now().Format(syncTrashLayout))
+	}
+
 	// Remove extra files from destination
-	err = filepath.Walk(dst, func(path string, info os.FileInfo, err error) error {
+	err = walkTree(dst, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -626,10 +1333,28 @@ func SyncDirectories(src, dst string, options ...CopyOption) error {
 			return err
 		}
 
+		// Never remove a trash directory kept inside the destination
+		if opts.syncTrash != "" && info.IsDir() && sameCleanPath(path, opts.syncTrash) {
+			return filepath.SkipDir
+		}
+
 		if !srcFiles[relPath] {
+			if trashDir != "" {
+				if err := moveEntry(path, filepath.Join(trashDir, relPath), info); err != nil {
+					return err
+				}
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
 			// File doesn't exist in source, remove it
 			if info.IsDir() {
-				return DeleteDirectory(path, WithForce())
+				if err := DeleteDirectory(path, WithForce()); err != nil {
+					return err
+				}
+				return filepath.SkipDir
 			}
 			return DeleteFile(path)
 		}
@@ -650,8 +1375,37 @@ func SyncDirectories(src, dst string, options ...CopyOption) error {
 	return nil
 }
 
+// moveEntry moves a file or directory into trashPath, creating its parent
+// directories and copying across filesystems
+func moveEntry(path, trashPath string, info os.FileInfo) error {
+	if err := os.MkdirAll(filepath.Dir(trashPath), 0755); err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return MoveDirectory(path, trashPath)
+	}
+	return MoveFile(path, trashPath)
+}
+
+// sameCleanPath reports whether two paths refer to the same location
+func sameCleanPath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+
+	return absA == absB
+}
+
 // CompareDirectories compares two directories and returns differences
-func CompareDirectories(left, right string) ([]Difference, error) {
+func CompareDirectories(left, right string, options ...CompareOption) ([]Difference, error) {
+	opts := defaultCompareOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
 	if !DirectoryExist(left) || !DirectoryExist(right) {
 		return nil, ErrCompareDirectory.
 			SetData(struct {
@@ -663,47 +1417,32 @@ func CompareDirectories(left, right string) ([]Difference, error) {
 			})
 	}
 
-	leftFiles := make(map[string]os.FileInfo)
-	rightFiles := make(map[string]os.FileInfo)
 	var differences []Difference
 
-	// Collect files from left directory
-	err := filepath.Walk(left, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		relPath, err := filepath.Rel(left, path)
-		if err != nil {
-			return err
-		}
-
-		leftFiles[relPath] = info
-		return nil
-	})
-
-	if err != nil {
-		return nil, ErrCompareDirectory.SetError(err)
+	// Collect files from both directories concurrently
+	var leftFiles, rightFiles map[string]os.FileInfo
+	var leftErr, rightErr error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		leftFiles, leftErr = collectCompareTree(left, opts)
+	}()
+	go func() {
+		defer wg.Done()
+		rightFiles, rightErr = collectCompareTree(right, opts)
+	}()
+	wg.Wait()
+
+	if leftErr != nil {
+		return nil, ErrCompareDirectory.SetError(leftErr)
+	}
+	if rightErr != nil {
+		return nil, ErrCompareDirectory.SetError(rightErr)
 	}
 
-	// Collect files from right directory
-	err = filepath.Walk(right, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		relPath, err := filepath.Rel(right, path)
-		if err != nil {
-			return err
-		}
-
-		rightFiles[relPath] = info
-		return nil
-	})
-
-	if err != nil {
-		return nil, ErrCompareDirectory.SetError(err)
-	}
+	// Indexes of same-size files whose content must be compared
+	var contentChecks []int
 
 	// Compare files
 	for path, leftInfo := range leftFiles {
@@ -711,24 +1450,22 @@ func CompareDirectories(left, right string) ([]Difference, error) {
 			// File exists in both, check if modified
 			if leftInfo.IsDir() == rightInfo.IsDir() {
 				if !leftInfo.IsDir() {
-					// Compare file content by size and modification time
-					// For more accuracy, could compare checksums
-					if leftInfo.Size() != rightInfo.Size() ||
-						leftInfo.ModTime().Unix() != rightInfo.ModTime().Unix() {
-						differences = append(differences, Difference{
-							Path:      path,
-							Type:      DiffModified,
-							LeftInfo:  leftInfo,
-							RightInfo: rightInfo,
-						})
-					} else {
-						differences = append(differences, Difference{
-							Path:      path,
-							Type:      DiffSame,
-							LeftInfo:  leftInfo,
-							RightInfo: rightInfo,
-						})
+					diffType := DiffSame
+					switch {
+					case leftInfo.Size() != rightInfo.Size():
+						diffType = DiffModified
+					case opts.compareContent:
+						contentChecks = append(contentChecks, len(differences))
+					case !modTimesEqual(leftInfo, rightInfo, opts.mtimeTolerance):
+						diffType = DiffModified
 					}
+
+					differences = append(differences, Difference{
+						Path:      path,
+						Type:      diffType,
+						LeftInfo:  leftInfo,
+						RightInfo: rightInfo,
+					})
 				}
 			} else {
 				// Type changed (file <-> directory)
@@ -760,12 +1497,220 @@ func CompareDirectories(left, right string) ([]Difference, error) {
 		}
 	}
 
+	if err := compareContentParallel(left, right, differences, contentChecks, opts.workers); err != nil {
+		return nil, ErrCompareDirectory.SetError(err)
+	}
+
+	sort.Slice(differences, func(i, j int) bool {
+		return differences[i].Path < differences[j].Path
+	})
+
 	return differences, nil
 }
 
+// compareContentParallel compares file contents of the selected differences
+// using a bounded worker pool and marks changed ones as modified
+func compareContentParallel(left, right string, differences []Difference, indexes []int, workers int) error {
+	if len(indexes) == 0 {
+		return nil
+	}
+
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	jobs := make(chan int)
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				diff := &differences[index]
+				equal, _, err := CompareFiles(filepath.Join(left, diff.Path), filepath.Join(right, diff.Path))
+				if err != nil {
+					errs <- err
+					return
+				}
+				if !equal {
+					diff.Type = DiffModified
+				}
+			}
+		}()
+	}
+
+	var firstErr error
+	for _, index := range indexes {
+		select {
+		case jobs <- index:
+		case firstErr = <-errs:
+		}
+		if firstErr != nil {
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr == nil && len(errs) > 0 {
+		firstErr = <-errs
+	}
+
+	return firstErr
+}
+
+// ApplyDifferences reconciles dst with src using differences produced by
+// CompareDirectories(dst, src): added and modified entries are copied from src
+// and removed entries are deleted from dst
+func ApplyDifferences(src, dst string, diffs []Difference, options ...CopyOption) error {
+	opts := defaultCopyOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+	opts.overwrite = true
+
+	for _, diff := range diffs {
+		if err := applyDifference(src, dst, diff, opts); err != nil {
+			if opts.skipError(diff.Path, err) {
+				continue
+			}
+			return ErrApplyDifferences.
+				SetError(err).
+				SetData(struct {
+					Source      string         `json:"source"`
+					Destination string         `json:"destination"`
+					Path        string         `json:"path"`
+					Type        DifferenceType `json:"type"`
+					Error       error          `json:"error"`
+				}{
+					Source:      src,
+					Destination: dst,
+					Path:        diff.Path,
+					Type:        diff.Type,
+					Error:       err,
+				})
+		}
+	}
+
+	return nil
+}
+
+// applyDifference applies a single difference to dst
+func applyDifference(src, dst string, diff Difference, opts *copyOptions) error {
+	srcPath, err := SafeJoin(src, diff.Path)
+	if err != nil {
+		return err
+	}
+
+	dstPath, err := SafeJoin(dst, diff.Path)
+	if err != nil {
+		return err
+	}
+
+	switch diff.Type {
+	case DiffRemoved:
+		return os.RemoveAll(dstPath)
+	case DiffAdded, DiffModified:
+		srcInfo, err := os.Stat(srcPath)
+		if err != nil {
+			return err
+		}
+
+		// Type changed (file <-> directory): drop the old entry first
+		if dstInfo, err := os.Stat(dstPath); err == nil && dstInfo.IsDir() != srcInfo.IsDir() {
+			if err := os.RemoveAll(dstPath); err != nil {
+				return err
+			}
+		}
+
+		if srcInfo.IsDir() {
+			if err := CreateDirectories(dstPath); err != nil {
+				return err
+			}
+			if opts.preserveOwner {
+				if err := preserveOwnership(dstPath, srcInfo); err != nil {
+					return err
+				}
+			}
+			if opts.preservePerms {
+				os.Chmod(dstPath, srcInfo.Mode())
+			}
+			return nil
+		}
+
+		if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+			return err
+		}
+
+		return copyFileWithOptions(srcPath, dstPath, srcInfo, opts)
+	default:
+		return nil
+	}
+}
+
+// collectCompareTree collects entries of root by relative path, honoring exclude patterns
+func collectCompareTree(root string, opts *compareOptions) (map[string]os.FileInfo, error) {
+	files := make(map[string]os.FileInfo)
+
+	err := walkTree(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		if relPath != "." && matchAnyPattern(filepath.ToSlash(relPath), opts.excludePatterns) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		files[relPath] = info
+		return nil
+	})
+
+	return files, err
+}
+
+// modTimesEqual compares modification times with the given tolerance
+// (second precision when tolerance is zero)
+func modTimesEqual(left, right os.FileInfo, tolerance time.Duration) bool {
+	if tolerance <= 0 {
+		return left.ModTime().Unix() == right.ModTime().Unix()
+	}
+
+	diff := left.ModTime().Sub(right.ModTime())
+	if diff < 0 {
+		diff = -diff
+	}
+
+	return diff <= tolerance
+}
+
+// walkTree is filepath.Walk that, like for symlinks, does not descend into Windows
+// junctions, which filepath.Walk reports as directories
+func walkTree(root string, fn filepath.WalkFunc) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err := fn(path, info, err); err != nil {
+			return err
+		}
+
+		if info != nil && path != root && isJunction(info) {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
 // WalkDirectory walks through directory tree with custom function
 func WalkDirectory(root string, walkFn WalkFunc) error {
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+	err := walkTree(root, func(path string, info os.FileInfo, err error) error {
 		return walkFn(path, info, err)
 	})
 
@@ -785,7 +1730,7 @@ func WalkDirectory(root string, walkFn WalkFunc) error {
 func CalculateDirectorySize(path string) (int64, error) {
 	var totalSize int64
 
-	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+	err := walkTree(path, func(_ string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -809,34 +1754,117 @@ func CalculateDirectorySize(path string) (int64, error) {
 	return totalSize, nil
 }
 
-// DirectoryChecksum calculates checksum of all files in directory
-func DirectoryChecksum(path string) (string, error) {
-	hash := md5.New()
+// DiskUsageTree aggregates file sizes per subdirectory like `du -d depth`. Children are
+// reported down to depth levels below root (a negative depth means no limit) and sorted
+// by size, largest first. Symlinks are not followed and unreadable directories are skipped
+func DiskUsageTree(root string, depth int) (*UsageNode, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, ErrCalculateSize.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
 
-	err := filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+	if !info.IsDir() {
+		return nil, ErrNotDirectory.
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: nil,
+			})
+	}
 
-		// Include file path in hash
-		relPath, _ := filepath.Rel(path, filePath)
-		hash.Write([]byte(relPath))
+	return diskUsageNode(root, 0, depth, make(map[fileID]bool)), nil
+}
 
-		if !info.IsDir() {
-			// Include file content in hash
-			file, err := os.Open(filePath)
-			if err != nil {
-				return err
+// diskUsageNode aggregates the directory at path, level directories below the root,
+// keeping children above maxDepth
+func diskUsageNode(path string, level, maxDepth int, seen map[fileID]bool) *UsageNode {
+	node := &UsageNode{
+		Path: path,
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return node
+	}
+
+	for _, entry := range entries {
+		entryPath := filepath.Join(path, entry.Name())
+
+		if entry.IsDir() {
+			child := diskUsageNode(entryPath, level+1, maxDepth, seen)
+			node.Size += child.Size
+			node.Files += child.Files
+			if maxDepth < 0 || level < maxDepth {
+				node.Children = append(node.Children, child)
 			}
-			defer file.Close()
+			continue
+		}
 
-			if _, err := io.Copy(hash, file); err != nil {
-				return err
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		// Count the content of hardlinked files once
+		if count, ok := fileLinkCount(info); ok && count > 1 {
+			if id, ok := fileIdentity(info); ok {
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
 			}
 		}
 
+		node.Size += info.Size()
+		node.Files++
+	}
+
+	sort.SliceStable(node.Children, func(i, j int) bool {
+		return node.Children[i].Size > node.Children[j].Size
+	})
+
+	return node
+}
+
+// DirectoryChecksum calculates checksum of all files in directory.
+// Entries are hashed in sorted order of their slash-separated relative paths,
+// so the result doesn't depend on traversal order or platform
+func DirectoryChecksum(path string, options ...ChecksumOption) (string, error) {
+	opts := defaultChecksumOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	hash, err := newHasher(opts.hashType)
+	if err != nil {
+		return "", err
+	}
+
+	entries := make(map[string]os.FileInfo)
+	err = walkTree(path, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(path, filePath)
+		if err != nil {
+			return err
+		}
+
+		entries[filepath.ToSlash(relPath)] = info
 		return nil
 	})
+	if err == nil {
+		err = hashDirectoryEntries(hash, path, entries, opts)
+	}
 
 	if err != nil {
 		return "", ErrWalkDirectory.
@@ -850,29 +1878,107 @@ func DirectoryChecksum(path string) (string, error) {
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
+// hashDirectoryEntries writes sorted entries (path, type, optional metadata, content digest)
+// to hash. Every field is prefixed with its length, so moving bytes between a name and the
+// content or between neighbouring files can't produce the same input
+func hashDirectoryEntries(hash io.Writer, root string, entries map[string]os.FileInfo, opts *checksumOptions) error {
+	paths := make([]string, 0, len(entries))
+	for relPath := range entries {
+		paths = append(paths, relPath)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return comparePathComponents(paths[i], paths[j]) < 0
+	})
+
+	content, err := newHasher(opts.hashType)
+	if err != nil {
+		return err
+	}
+
+	for _, relPath := range paths {
+		info := entries[relPath]
+
+		// Include file path and type in hash
+		writeHashField(hash, []byte(relPath))
+		if info.IsDir() {
+			writeHashField(hash, []byte("d"))
+		} else {
+			writeHashField(hash, []byte("f"))
+		}
+
+		if opts.includePerms {
+			writeHashField(hash, []byte(info.Mode().String()))
+		}
+		if opts.includeModTimes && !info.IsDir() {
+			writeHashField(hash, []byte(strconv.FormatInt(info.ModTime().UnixNano(), 10)))
+		}
+
+		if info.IsDir() {
+			continue
+		}
+
+		// Include the digest of the file content in hash
+		content.Reset()
+		if err := copyFileTo(content, filepath.Join(root, filepath.FromSlash(relPath))); err != nil {
+			return err
+		}
+		writeHashField(hash, content.Sum(nil))
+	}
+
+	return nil
+}
+
+// writeHashField writes data to hash prefixed with its length
+func writeHashField(hash io.Writer, data []byte) {
+	var prefix [8]byte
+	binary.BigEndian.PutUint64(prefix[:], uint64(len(data)))
+	hash.Write(prefix[:])
+	hash.Write(data)
+}
+
+// copyFileTo copies file content to w
+func copyFileTo(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// comparePathComponents compares slash-separated paths component by component,
+// matching the lexical depth-first order of a directory walk
+func comparePathComponents(a, b string) int {
+	aParts := strings.Split(a, "/")
+	bParts := strings.Split(b, "/")
+
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		if c := strings.Compare(aParts[i], bParts[i]); c != 0 {
+			return c
+		}
+	}
+
+	return len(aParts) - len(bParts)
+}
+
 // FindDuplicateFiles finds duplicate files in directory based on content
-func FindDuplicateFiles(root string) (map[string][]string, error) {
+func FindDuplicateFiles(root string, options ...ChecksumOption) (map[string][]string, error) {
 	fileHashes := make(map[string][]string)
 
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+	err := walkTree(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
 		if !info.IsDir() {
 			// Calculate file hash
-			file, err := os.Open(path)
+			hashStr, err := CalculateFileChecksum(path, HashMD5, options...)
 			if err != nil {
 				return err
 			}
-			defer file.Close()
-
-			hash := md5.New()
-			if _, err := io.Copy(hash, file); err != nil {
-				return err
-			}
 
-			hashStr := hex.EncodeToString(hash.Sum(nil))
 			fileHashes[hashStr] = append(fileHashes[hashStr], path)
 		}
 
@@ -899,24 +2005,244 @@ func FindDuplicateFiles(root string) (map[string][]string, error) {
 	return duplicates, nil
 }
 
-// CleanEmptyDirectories removes all empty directories recursively
-func CleanEmptyDirectories(root string) error {
-	// First pass: collect all directories
-	var dirs []string
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+// DeduplicateDirectory replaces identical regular files under root with hardlinks
+// to a single copy. Only files with equal permissions and owner on the same filesystem
+// are linked, and their bytes are compared before a file is replaced
+func DeduplicateDirectory(root string, options ...DedupOption) (*DedupReport, error) {
+	opts := defaultDedupOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	type dedupFile struct {
+		path  string
+		info  os.FileInfo
+		id    fileID
+		hasID bool
+	}
+
+	// Group by size first, only same-sized files need hashing
+	bySize := make(map[int64][]dedupFile)
+	err := walkTree(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if info.IsDir() && path != root {
-			dirs = append(dirs, path)
+		if !info.Mode().IsRegular() || info.Size() < opts.minSize {
+			return nil
+		}
+
+		file := dedupFile{
+			path: path,
+			info: info,
 		}
+		file.id, file.hasID = fileIdentity(info)
+		bySize[info.Size()] = append(bySize[info.Size()], file)
 
 		return nil
 	})
+	if err != nil {
+		return nil, ErrDeduplicate.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+
+	groups := make(map[string][]dedupFile)
+	for size, files := range bySize {
+		if len(files) < 2 {
+			continue
+		}
+
+		for _, file := range files {
+			checksum, err := CalculateFileChecksum(file.path, HashSHA256, opts.checksumOptions...)
+			if err != nil {
+				return nil, ErrDeduplicate.
+					SetError(err).
+					SetData(pathErrorContext{
+						Path:  file.path,
+						Error: err,
+					})
+			}
+
+			// Linked paths share the owner and mode of the kept copy, so only files
+			// that already agree on them are grouped
+			uid, gid, _ := fileOwner(file.info)
+			key := fmt.Sprintf("%d:%s:%s:%d:%d:%d", size, checksum, file.info.Mode(), file.id.dev, uid, gid)
+			groups[key] = append(groups[key], file)
+		}
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	report := &DedupReport{
+		Links: []DedupLink{},
+	}
+
+	for _, key := range keys {
+		files := groups[key]
+		if len(files) < 2 {
+			continue
+		}
+
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].path < files[j].path
+		})
+
+		keep := files[0]
+		reclaimed := map[fileID]bool{keep.id: true}
+		linked := false
+
+		for _, dup := range files[1:] {
+			// Already a hardlink to the kept copy
+			if os.SameFile(keep.info, dup.info) {
+				continue
+			}
+
+			// Digests may come from a stale checksum cache, only identical bytes are linked
+			equal, _, err := CompareFiles(keep.path, dup.path)
+			if err != nil {
+				return report, ErrDeduplicate.
+					SetError(err).
+					SetData(moveErrorContext{
+						Source:      keep.path,
+						Destination: dup.path,
+						Error:       err,
+					})
+			}
+			if !equal {
+				continue
+			}
+
+			if !opts.dryRun {
+				if err := replaceWithHardlink(keep.path, dup.path); err != nil {
+					return report, ErrDeduplicate.
+						SetError(err).
+						SetData(moveErrorContext{
+							Source:      keep.path,
+							Destination: dup.path,
+							Error:       err,
+						})
+				}
+			}
+
+			report.Links = append(report.Links, DedupLink{
+				Path:   dup.path,
+				Target: keep.path,
+				Size:   dup.info.Size(),
+			})
+
+			// Other links of the same inode keep its data alive, count it once
+			if !dup.hasID || !reclaimed[dup.id] {
+				report.ReclaimedBytes += dup.info.Size()
+				reclaimed[dup.id] = true
+			}
+			linked = true
+		}
+
+		if linked {
+			report.Groups++
+		}
+	}
+
+	return report, nil
+}
+
+// replaceWithHardlink atomically replaces path with a hardlink to target
+func replaceWithHardlink(target, path string) error {
+	tmpPath := filepath.Join(filepath.Dir(path),
+		fmt.Sprintf(".%s.link-%d", filepath.Base(path), time.Now().UnixNano()))
+
+	if err := os.Link(target, tmpPath); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
+
+// CleanEmptyDirectories removes all empty directories recursively
+func CleanEmptyDirectories(root string, options ...CleanOption) error {
+	_, err := RemoveEmptyDirectories(root, options...)
+	return err
+}
+
+// RemoveEmptyDirectories removes empty directories under root, including directories
+// that only contain empty directories, and returns the removed paths deepest first.
+// With WithCleanDryRun it returns the paths that would be removed
+func RemoveEmptyDirectories(root string, options ...CleanOption) ([]string, error) {
+	opts := defaultCleanOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	var removed []string
+	if _, err := removeEmptyTree(root, root, opts, &removed); err != nil {
+		return removed, ErrWalkDirectory.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+
+	return removed, nil
+}
+
+// CleanDirectory removes files under root according to policy, oldest first,
+// e.g. to keep a cache directory bounded. Use WithCleanDryRun to only report them
+func CleanDirectory(root string, policy CleanPolicy, options ...CleanOption) (*CleanReport, error) {
+	opts := defaultCleanOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	type cleanFile struct {
+		path string
+		info os.FileInfo
+	}
+
+	var files []cleanFile
+	err := walkTree(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
 
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if relPath != "." && matchAnyPattern(relPath, opts.keepPatterns) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if len(policy.Patterns) > 0 && !matchAnyPattern(relPath, policy.Patterns) {
+			return nil
+		}
+
+		files = append(files, cleanFile{path: path, info: info})
+		return nil
+	})
 	if err != nil {
-		return ErrWalkDirectory.
+		return nil, ErrCleanDirectory.
 			SetError(err).
 			SetData(pathErrorContext{
 				Path:  root,
@@ -924,26 +2250,87 @@ func CleanEmptyDirectories(root string) error {
 			})
 	}
 
-	// Sort dirs by depth (deepest first)
-	for i := 0; i < len(dirs)-1; i++ {
-		for j := i + 1; j < len(dirs); j++ {
-			if strings.Count(dirs[i], string(os.PathSeparator)) < strings.Count(dirs[j], string(os.PathSeparator)) {
-				dirs[i], dirs[j] = dirs[j], dirs[i]
+	// Oldest first
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].info.ModTime().Before(files[j].info.ModTime())
+	})
+
+	report := &CleanReport{}
+	for _, file := range files {
+		report.Kept++
+		report.KeptBytes += file.info.Size()
+	}
+
+	cutoff := time.Now().Add(-policy.MaxAge)
+	for _, file := range files {
+		expired := policy.MaxAge > 0 && file.info.ModTime().Before(cutoff)
+		tooMany := policy.MaxFiles > 0 && report.Kept > policy.MaxFiles
+		tooLarge := policy.MaxTotalSize > 0 && report.KeptBytes > policy.MaxTotalSize
+		if !expired && !tooMany && !tooLarge {
+			// Newer files are not expired and limits are met
+			break
+		}
+
+		if !opts.dryRun {
+			if err := os.Remove(file.path); err != nil {
+				return report, ErrCleanDirectory.
+					SetError(err).
+					SetData(pathErrorContext{
+						Path:  file.path,
+						Error: err,
+					})
 			}
 		}
+
+		report.Removed = append(report.Removed, file.path)
+		report.RemovedBytes += file.info.Size()
+		report.Kept--
+		report.KeptBytes -= file.info.Size()
+	}
+
+	return report, nil
+}
+
+// removeEmptyTree removes empty directories below dir, deepest first, and reports
+// whether dir is left empty (or would be in a dry run)
+func removeEmptyTree(root, dir string, opts *cleanOptions, removed *[]string) (bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false, err
 	}
 
-	// Remove empty directories
-	for _, dir := range dirs {
-		empty, err := IsEmptyDirectory(dir)
+	empty := true
+	for _, entry := range entries {
+		// Files and symlinks keep their directory
+		if !entry.IsDir() {
+			empty = false
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		childEmpty, err := removeEmptyTree(root, path, opts, removed)
 		if err != nil {
+			return false, err
+		}
+
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return false, err
+		}
+
+		if !childEmpty || matchAnyPattern(filepath.ToSlash(relPath), opts.keepPatterns) {
+			empty = false
 			continue
 		}
 
-		if empty {
-			DeleteDirectory(dir)
+		if !opts.dryRun {
+			if err := os.Remove(path); err != nil {
+				empty = false
+				continue
+			}
 		}
+		*removed = append(*removed, path)
 	}
 
-	return nil
+	return empty, nil
 }
@@ -1,8 +1,12 @@
 package fsx
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -60,6 +64,113 @@ func TestAdvancedDirectoryOperations(t *testing.T) {
 		}
 	})
 
+	t.Run("MoveDirectory", func(t *testing.T) {
+		srcDir := filepath.Join(tmpDir, "move_src")
+		dstDir := filepath.Join(tmpDir, "moved", "dst")
+		if err := CreateFile(filepath.Join(srcDir, "sub", "file.txt"), []byte("moved"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		if err := MoveDirectory(srcDir, dstDir); err != nil {
+			t.Fatalf("Failed to move directory: %v", err)
+		}
+		if DirectoryExist(srcDir) {
+			t.Error("Source should be gone after move")
+		}
+		if content, _ := ReadFileString(filepath.Join(dstDir, "sub", "file.txt")); content != "moved" {
+			t.Errorf("Unexpected content after move: %q", content)
+		}
+
+		// Existing destination: refused by default, merged via copy fallback with overwrite
+		if err := CreateFile(filepath.Join(srcDir, "new.txt"), []byte("new"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := MoveDirectory(srcDir, dstDir); !errors.Is(err, ErrDestinationExists) {
+			t.Errorf("Expected ErrDestinationExists, got %v", err)
+		}
+
+		var progressCalls int
+		err := MoveDirectory(srcDir, dstDir, WithOverwrite(), WithProgress(func(current, total int64, file string) {
+			progressCalls++
+		}))
+		if err != nil {
+			t.Fatalf("Failed to merge directory: %v", err)
+		}
+		if DirectoryExist(srcDir) || !FileExist(filepath.Join(dstDir, "new.txt")) || !FileExist(filepath.Join(dstDir, "sub", "file.txt")) {
+			t.Error("Merge move did not produce the expected tree")
+		}
+		if progressCalls == 0 {
+			t.Error("Progress should be reported by the copy fallback")
+		}
+
+		// Moving into itself would delete the copy along with the source
+		for _, target := range []string{dstDir, filepath.Join(dstDir, "sub", "inner")} {
+			if err := MoveDirectory(dstDir, target, WithOverwrite()); !errors.Is(err, ErrMoveDirectory) {
+				t.Errorf("Expected ErrMoveDirectory moving into %s, got %v", target, err)
+			}
+		}
+		if content, _ := ReadFileString(filepath.Join(dstDir, "sub", "file.txt")); content != "moved" {
+			t.Errorf("Source should be untouched after a refused move, got %q", content)
+		}
+
+		// Only a rename across filesystems falls back to copying
+		blocker := filepath.Join(tmpDir, "move_blocker")
+		if err := CreateFile(blocker, []byte("file")); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := MoveDirectory(dstDir, blocker); !errors.Is(err, ErrMoveDirectory) {
+			t.Errorf("Expected ErrMoveDirectory when rename fails, got %v", err)
+		}
+		if !DirectoryExist(dstDir) {
+			t.Error("Source should be kept when rename fails")
+		}
+	})
+
+	t.Run("CopyDirectoryWithPatterns", func(t *testing.T) {
+		srcDir := filepath.Join(tmpDir, "pattern_src")
+		dstDir := filepath.Join(tmpDir, "pattern_dst")
+
+		for _, name := range []string{
+			"main.go",
+			"app.log",
+			"pkg/util/util.go",
+			"pkg/util/README.md",
+			"node_modules/lib/index.js",
+			"tmp/keep.go",
+		} {
+			if err := CreateFile(filepath.Join(srcDir, name), []byte(name), WithCreateDirs()); err != nil {
+				t.Fatalf("Failed to create %s: %v", name, err)
+			}
+		}
+
+		err := CopyDirectory(srcDir, dstDir,
+			WithCopyInclude("**/*.go", "*.log"),
+			WithCopyExclude("*.log", "node_modules/**"),
+			WithFilter(func(path string, info os.FileInfo) bool {
+				return info.Name() != "tmp"
+			}))
+		if err != nil {
+			t.Fatalf("Failed to copy directory: %v", err)
+		}
+
+		expected := map[string]bool{
+			"main.go":                   true,
+			"pkg/util/util.go":          true,
+			"app.log":                   false,
+			"pkg/util/README.md":        false,
+			"node_modules/lib/index.js": false,
+			"tmp/keep.go":               false,
+		}
+		for name, copied := range expected {
+			if FileExist(filepath.Join(dstDir, name)) != copied {
+				t.Errorf("%s: expected copied=%v", name, copied)
+			}
+		}
+		if DirectoryExist(filepath.Join(dstDir, "node_modules")) {
+			t.Error("Excluded directory should not be created")
+		}
+	})
+
 	t.Run("CopyDirectoryWithFilter", func(t *testing.T) {
 		srcDir := filepath.Join(tmpDir, "filter_src")
 		dstDir := filepath.Join(tmpDir, "filter_dst")
@@ -179,6 +290,102 @@ func TestAdvancedDirectoryOperations(t *testing.T) {
 		}
 	})
 
+	t.Run("SyncDirectoriesNoDelete", func(t *testing.T) {
+		srcDir := filepath.Join(tmpDir, "sync_nodelete_src")
+		dstDir := filepath.Join(tmpDir, "sync_nodelete_dst")
+		if err := CreateFile(filepath.Join(srcDir, "new.txt"), []byte("new"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := CreateFile(filepath.Join(dstDir, "extra", "keep.txt"), []byte("keep"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create extra file: %v", err)
+		}
+
+		if err := SyncDirectories(srcDir, dstDir, WithSyncNoDelete()); err != nil {
+			t.Fatalf("Failed to sync directories: %v", err)
+		}
+		if !FileExist(filepath.Join(dstDir, "new.txt")) {
+			t.Error("new.txt should be copied")
+		}
+		if !FileExist(filepath.Join(dstDir, "extra", "keep.txt")) {
+			t.Error("Extra files must be kept")
+		}
+
+		// Removing an extra directory must not fail the walk
+		if err := SyncDirectories(srcDir, dstDir); err != nil {
+			t.Fatalf("Failed to sync directories: %v", err)
+		}
+		if DirectoryExist(filepath.Join(dstDir, "extra")) {
+			t.Error("Extra directory should be removed without WithSyncNoDelete")
+		}
+	})
+
+	t.Run("SyncDirectoriesTrash", func(t *testing.T) {
+		srcDir := filepath.Join(tmpDir, "sync_trash_src")
+		dstDir := filepath.Join(tmpDir, "sync_trash_dst")
+		trashDir := filepath.Join(dstDir, ".trash")
+		if err := CreateFile(filepath.Join(srcDir, "keep.txt"), []byte("keep"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := CreateFile(filepath.Join(dstDir, "old", "gone.txt"), []byte("gone"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create extra file: %v", err)
+		}
+		if err := CreateFile(filepath.Join(dstDir, "stale.txt"), []byte("stale"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create extra file: %v", err)
+		}
+
+		if err := SyncDirectories(srcDir, dstDir, WithSyncTrash(trashDir)); err != nil {
+			t.Fatalf("Failed to sync directories: %v", err)
+		}
+		if FileExist(filepath.Join(dstDir, "stale.txt")) || DirectoryExist(filepath.Join(dstDir, "old")) {
+			t.Error("Extra files should be removed from the destination")
+		}
+
+		runs, err := os.ReadDir(trashDir)
+		if err != nil || len(runs) != 1 {
+			t.Fatalf("Expected one trash run, got %d: %v", len(runs), err)
+		}
+		run := filepath.Join(trashDir, runs[0].Name())
+		if content, _ := ReadFileString(filepath.Join(run, "old", "gone.txt")); content != "gone" {
+			t.Errorf("Removed directory should be in the trash, got %q", content)
+		}
+		if content, _ := ReadFileString(filepath.Join(run, "stale.txt")); content != "stale" {
+			t.Errorf("Removed file should be in the trash, got %q", content)
+		}
+	})
+
+	t.Run("SyncDirectoriesCompare", func(t *testing.T) {
+		srcDir := filepath.Join(tmpDir, "sync_compare_src")
+		dstDir := filepath.Join(tmpDir, "sync_compare_dst")
+		if err := CreateFile(filepath.Join(srcDir, "data.txt"), []byte("original"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := SyncDirectories(srcDir, dstDir); err != nil {
+			t.Fatalf("Failed to sync directories: %v", err)
+		}
+
+		// Same size and mtime, different content
+		dstFile := filepath.Join(dstDir, "data.txt")
+		info, _ := os.Stat(dstFile)
+		if err := WriteFileString(dstFile, "modified"); err != nil {
+			t.Fatalf("Failed to modify file: %v", err)
+		}
+		os.Chtimes(dstFile, info.ModTime(), info.ModTime())
+
+		if err := SyncDirectories(srcDir, dstDir, WithSyncCompare(SyncCompareSizeMTime)); err != nil {
+			t.Fatalf("Failed to sync directories: %v", err)
+		}
+		if content, _ := ReadFileString(dstFile); content != "modified" {
+			t.Errorf("Size and mtime match should skip the file, got %q", content)
+		}
+
+		if err := SyncDirectories(srcDir, dstDir, WithSyncCompare(SyncCompareChecksum)); err != nil {
+			t.Fatalf("Failed to sync directories: %v", err)
+		}
+		if content, _ := ReadFileString(dstFile); content != "original" {
+			t.Errorf("Checksum mismatch should copy the file, got %q", content)
+		}
+	})
+
 	t.Run("CompareDirectories", func(t *testing.T) {
 		leftDir := filepath.Join(tmpDir, "compare_left")
 		rightDir := filepath.Join(tmpDir, "compare_right")
@@ -246,6 +453,190 @@ func TestAdvancedDirectoryOperations(t *testing.T) {
 		}
 	})
 
+	t.Run("CompareDirectoriesWithOptions", func(t *testing.T) {
+		leftDir := filepath.Join(tmpDir, "compare_opts_left")
+		rightDir := filepath.Join(tmpDir, "compare_opts_right")
+
+		for _, dir := range []string{leftDir, rightDir} {
+			if err := CreateFile(filepath.Join(dir, "data.txt"), []byte("same"), WithCreateDirs()); err != nil {
+				t.Fatalf("Failed to create data.txt: %v", err)
+			}
+		}
+		if err := CreateFile(filepath.Join(rightDir, ".git", "HEAD"), []byte("ref"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create .git/HEAD: %v", err)
+		}
+
+		// Shift right mtime by one second, like FAT rounding does
+		baseTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+		os.Chtimes(filepath.Join(leftDir, "data.txt"), baseTime, baseTime)
+		os.Chtimes(filepath.Join(rightDir, "data.txt"), baseTime.Add(time.Second), baseTime.Add(time.Second))
+
+		differences, err := CompareDirectories(leftDir, rightDir,
+			WithCompareExclude(".git"),
+			WithMTimeTolerance(2*time.Second))
+		if err != nil {
+			t.Fatalf("Failed to compare directories: %v", err)
+		}
+
+		for _, diff := range differences {
+			if diff.Type != DiffSame {
+				t.Errorf("Unexpected difference: %s %s", diff.Type, diff.Path)
+			}
+		}
+
+		// Without options both the mtime and .git are reported
+		differences, _ = CompareDirectories(leftDir, rightDir)
+		var changes int
+		for _, diff := range differences {
+			if diff.Type != DiffSame {
+				changes++
+			}
+		}
+		if changes != 3 {
+			t.Errorf("Expected 3 changes without options, got %d", changes)
+		}
+	})
+
+	t.Run("CompareDirectoriesContent", func(t *testing.T) {
+		leftDir := filepath.Join(tmpDir, "compare_content_left")
+		rightDir := filepath.Join(tmpDir, "compare_content_right")
+
+		modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+		for i := 0; i < 20; i++ {
+			name := fmt.Sprintf("file%02d.txt", i)
+			leftContent, rightContent := "content-a", "content-a"
+			if i%5 == 0 {
+				rightContent = "content-b" // same size, different bytes
+			}
+
+			for dir, content := range map[string]string{leftDir: leftContent, rightDir: rightContent} {
+				path := filepath.Join(dir, name)
+				if err := CreateFile(path, []byte(content), WithCreateDirs()); err != nil {
+					t.Fatalf("Failed to create %s: %v", path, err)
+				}
+				os.Chtimes(path, modTime, modTime)
+			}
+		}
+
+		differences, err := CompareDirectories(leftDir, rightDir, WithCompareContent(), WithCompareWorkers(4))
+		if err != nil {
+			t.Fatalf("Failed to compare directories: %v", err)
+		}
+
+		var modified []string
+		for _, diff := range differences {
+			if diff.Type == DiffModified {
+				modified = append(modified, diff.Path)
+			}
+		}
+
+		expected := []string{"file00.txt", "file05.txt", "file10.txt", "file15.txt"}
+		if strings.Join(modified, ",") != strings.Join(expected, ",") {
+			t.Errorf("Unexpected modified files: %v", modified)
+		}
+
+		// Size + mtime comparison can't see these changes
+		differences, _ = CompareDirectories(leftDir, rightDir)
+		for _, diff := range differences {
+			if diff.Type == DiffModified {
+				t.Errorf("Unexpected modification without content check: %s", diff.Path)
+			}
+		}
+	})
+
+	t.Run("DiffReport", func(t *testing.T) {
+		leftDir := filepath.Join(tmpDir, "report_left")
+		rightDir := filepath.Join(tmpDir, "report_right")
+
+		if err := CreateFile(filepath.Join(leftDir, "old.txt"), []byte("old"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create old.txt: %v", err)
+		}
+		if err := CreateFile(filepath.Join(rightDir, "new.txt"), []byte("new"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create new.txt: %v", err)
+		}
+
+		differences, err := CompareDirectories(leftDir, rightDir)
+		if err != nil {
+			t.Fatalf("Failed to compare directories: %v", err)
+		}
+
+		report := DiffReport(differences)
+
+		data, err := report.JSON()
+		if err != nil {
+			t.Fatalf("Failed to marshal report: %v", err)
+		}
+
+		var decoded []map[string]any
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Failed to decode report: %v", err)
+		}
+		if len(decoded) != 2 || decoded[0]["path"] != "new.txt" || decoded[0]["right"] == nil {
+			t.Errorf("Unexpected JSON report: %s", data)
+		}
+
+		summary := report.Summary()
+		if !strings.HasPrefix(summary, "1 added, 1 removed, 0 modified") ||
+			!strings.Contains(summary, "+ new.txt") || !strings.Contains(summary, "- old.txt") {
+			t.Errorf("Unexpected summary: %s", summary)
+		}
+
+		if actions := report.Actions(); actions != "create new.txt\ndelete old.txt\n" {
+			t.Errorf("Unexpected actions: %q", actions)
+		}
+	})
+
+	t.Run("ApplyDifferences", func(t *testing.T) {
+		srcDir := filepath.Join(tmpDir, "apply_src")
+		dstDir := filepath.Join(tmpDir, "apply_dst")
+
+		srcFiles := map[string]string{
+			"keep.txt":       "keep",
+			"changed.txt":    "new content",
+			"added/file.txt": "added",
+		}
+		dstFiles := map[string]string{
+			"keep.txt":    "keep",
+			"changed.txt": "old",
+			"stale.txt":   "stale",
+		}
+		for name, content := range srcFiles {
+			if err := CreateFile(filepath.Join(srcDir, name), []byte(content), WithCreateDirs()); err != nil {
+				t.Fatalf("Failed to create %s: %v", name, err)
+			}
+		}
+		for name, content := range dstFiles {
+			if err := CreateFile(filepath.Join(dstDir, name), []byte(content), WithCreateDirs()); err != nil {
+				t.Fatalf("Failed to create %s: %v", name, err)
+			}
+		}
+
+		diffs, err := CompareDirectories(dstDir, srcDir)
+		if err != nil {
+			t.Fatalf("Failed to compare directories: %v", err)
+		}
+
+		if err := ApplyDifferences(srcDir, dstDir, diffs); err != nil {
+			t.Fatalf("Failed to apply differences: %v", err)
+		}
+
+		if FileExist(filepath.Join(dstDir, "stale.txt")) {
+			t.Error("stale.txt should be removed")
+		}
+		if content, _ := ReadFileString(filepath.Join(dstDir, "changed.txt")); content != "new content" {
+			t.Errorf("changed.txt not updated: %s", content)
+		}
+		if content, _ := ReadFileString(filepath.Join(dstDir, "added", "file.txt")); content != "added" {
+			t.Errorf("added/file.txt not copied: %s", content)
+		}
+
+		// Crafted differences can't escape the destination
+		evil := []Difference{{Path: "../outside.txt", Type: DiffRemoved}}
+		if err := ApplyDifferences(srcDir, dstDir, evil); !errors.Is(err, ErrUnsafeArchivePath) {
+			t.Errorf("Expected ErrUnsafeArchivePath, got %v", err)
+		}
+	})
+
 	t.Run("WalkDirectory", func(t *testing.T) {
 		walkDir := filepath.Join(tmpDir, "walk_test")
 
@@ -363,6 +754,59 @@ func TestAdvancedDirectoryOperations(t *testing.T) {
 		if checksum1 == checksum3 {
 			t.Error("Different directories should have different checksums")
 		}
+
+		// Shifting bytes between a name and its content must change the checksum
+		shifted := map[string]string{"ab": "c", "a": "bc"}
+		var sums []string
+		for name, content := range shifted {
+			dir := filepath.Join(tmpDir, "checksum_shift_"+name)
+			if err := CreateFile(filepath.Join(dir, name), []byte(content), WithCreateDirs()); err != nil {
+				t.Fatalf("Failed to create file in %s: %v", dir, err)
+			}
+			sum, err := DirectoryChecksum(dir)
+			if err != nil {
+				t.Fatalf("Failed to calculate checksum: %v", err)
+			}
+			sums = append(sums, sum)
+		}
+		if sums[0] == sums[1] {
+			t.Error("Name and content boundaries should be part of the checksum")
+		}
+	})
+
+	t.Run("DirectoryChecksumOptions", func(t *testing.T) {
+		dir := filepath.Join(tmpDir, "checksum_options")
+		if err := CreateFile(filepath.Join(dir, "a-b.txt"), []byte("first"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := CreateFile(filepath.Join(dir, "a", "c.txt"), []byte("second"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		sha, err := DirectoryChecksum(dir, WithChecksumHashType(HashSHA256))
+		if err != nil {
+			t.Fatalf("Failed to calculate checksum: %v", err)
+		}
+		if len(sha) != 64 {
+			t.Errorf("Expected SHA256 digest, got %s", sha)
+		}
+
+		plain, _ := DirectoryChecksum(dir)
+		withPerms, _ := DirectoryChecksum(dir, WithChecksumPermissions())
+
+		if err := os.Chmod(filepath.Join(dir, "a-b.txt"), 0600); err != nil {
+			t.Fatalf("Failed to chmod: %v", err)
+		}
+
+		plainAfter, _ := DirectoryChecksum(dir)
+		withPermsAfter, _ := DirectoryChecksum(dir, WithChecksumPermissions())
+
+		if plain != plainAfter {
+			t.Error("Permissions should not affect default checksum")
+		}
+		if withPerms == withPermsAfter {
+			t.Error("Permissions should affect checksum when included")
+		}
 	})
 
 	t.Run("FindDuplicateFiles", func(t *testing.T) {
@@ -410,6 +854,317 @@ func TestAdvancedDirectoryOperations(t *testing.T) {
 		}
 	})
 
+	t.Run("DeduplicateDirectory", func(t *testing.T) {
+		dedupDir := filepath.Join(tmpDir, "dedup")
+		content := strings.Repeat("duplicate payload ", 100)
+
+		for _, name := range []string{"a.bin", "sub/b.bin", "sub/deep/c.bin"} {
+			if err := CreateFile(filepath.Join(dedupDir, name), []byte(content), WithCreateDirs()); err != nil {
+				t.Fatalf("Failed to create %s: %v", name, err)
+			}
+		}
+		if err := CreateFile(filepath.Join(dedupDir, "unique.bin"), []byte(strings.Repeat("x", len(content)))); err != nil {
+			t.Fatalf("Failed to create unique file: %v", err)
+		}
+
+		report, err := DeduplicateDirectory(dedupDir, WithDedupDryRun())
+		if err != nil {
+			t.Fatalf("Failed to dry-run deduplication: %v", err)
+		}
+		if len(report.Links) != 2 || report.ReclaimedBytes != int64(2*len(content)) {
+			t.Errorf("Unexpected dry-run report: %+v", report)
+		}
+
+		report, err = DeduplicateDirectory(dedupDir)
+		if err != nil {
+			t.Fatalf("Failed to deduplicate: %v", err)
+		}
+		if report.Groups != 1 || len(report.Links) != 2 {
+			t.Errorf("Unexpected report: %+v", report)
+		}
+
+		first, _ := os.Stat(filepath.Join(dedupDir, "a.bin"))
+		for _, name := range []string{"sub/b.bin", "sub/deep/c.bin"} {
+			info, err := os.Stat(filepath.Join(dedupDir, name))
+			if err != nil {
+				t.Fatalf("Failed to stat %s: %v", name, err)
+			}
+			if !os.SameFile(first, info) {
+				t.Errorf("%s should be a hardlink to a.bin", name)
+			}
+		}
+
+		// Second run has nothing left to do
+		report, err = DeduplicateDirectory(dedupDir)
+		if err != nil {
+			t.Fatalf("Failed to deduplicate again: %v", err)
+		}
+		if len(report.Links) != 0 || report.ReclaimedBytes != 0 {
+			t.Errorf("Expected no changes on second run, got %+v", report)
+		}
+
+		// A stale cache entry must not link files with different bytes
+		staleDir := filepath.Join(tmpDir, "dedup_stale")
+		for _, name := range []string{"x.bin", "y.bin"} {
+			if err := CreateFile(filepath.Join(staleDir, name), []byte(content), WithCreateDirs()); err != nil {
+				t.Fatalf("Failed to create %s: %v", name, err)
+			}
+		}
+		cache, _ := NewChecksumCache("")
+		for _, name := range []string{"x.bin", "y.bin"} {
+			if _, err := cache.Checksum(filepath.Join(staleDir, name), HashSHA256); err != nil {
+				t.Fatalf("Failed to cache checksum: %v", err)
+			}
+		}
+		changed := filepath.Join(staleDir, "y.bin")
+		info, _ := os.Stat(changed)
+		os.WriteFile(changed, []byte(strings.Repeat("X", len(content))), 0644)
+		os.Chtimes(changed, info.ModTime(), info.ModTime())
+
+		report, err = DeduplicateDirectory(staleDir, WithDedupChecksumOptions(WithChecksumCache(cache)))
+		if err != nil {
+			t.Fatalf("Failed to deduplicate: %v", err)
+		}
+		if len(report.Links) != 0 {
+			t.Errorf("Files with different bytes were linked: %+v", report)
+		}
+		if data, _ := os.ReadFile(changed); string(data) == content {
+			t.Error("Changed file lost its content")
+		}
+	})
+
+	t.Run("AnalyzeDirectory", func(t *testing.T) {
+		analyzeDir := filepath.Join(tmpDir, "analyze")
+		now := time.Now()
+		files := []struct {
+			name    string
+			size    int
+			daysAgo int
+		}{
+			{"a.txt", 10, 5},
+			{"b.TXT", 30, 1},
+			{"docs/c.md", 20, 9},
+			{"docs/deep/d", 5, 3},
+			{"skip/e.txt", 100, 0},
+		}
+		for _, f := range files {
+			path := filepath.Join(analyzeDir, f.name)
+			if err := CreateFile(path, make([]byte, f.size), WithCreateDirs()); err != nil {
+				t.Fatalf("Failed to create %s: %v", f.name, err)
+			}
+			modTime := now.AddDate(0, 0, -f.daysAgo)
+			os.Chtimes(path, modTime, modTime)
+		}
+
+		analysis, err := AnalyzeDirectory(analyzeDir, WithAnalyzeTopN(2), WithAnalyzeExclude("skip"))
+		if err != nil {
+			t.Fatalf("Failed to analyze directory: %v", err)
+		}
+
+		if analysis.FileCount != 4 || analysis.DirCount != 2 || analysis.TotalSize != 65 {
+			t.Errorf("Unexpected totals: %+v", analysis)
+		}
+		if stats := analysis.Extensions[".txt"]; stats.Count != 2 || stats.Size != 40 {
+			t.Errorf("Expected 2 .txt files of 40 bytes, got %+v", stats)
+		}
+		if stats := analysis.Extensions[""]; stats.Count != 1 {
+			t.Errorf("Expected 1 file without extension, got %+v", stats)
+		}
+		if len(analysis.Largest) != 2 || filepath.Base(analysis.Largest[0].Path) != "b.TXT" || filepath.Base(analysis.Largest[1].Path) != "c.md" {
+			t.Errorf("Unexpected largest files: %+v", analysis.Largest)
+		}
+		if filepath.Base(analysis.Oldest[0].Path) != "c.md" || filepath.Base(analysis.Newest[0].Path) != "b.TXT" {
+			t.Errorf("Unexpected oldest/newest files: %+v / %+v", analysis.Oldest, analysis.Newest)
+		}
+		if analysis.MaxDepth != 3 || analysis.FilesByDepth[1] != 2 || analysis.AverageFileDepth != 1.75 {
+			t.Errorf("Unexpected depth statistics: max %d, by depth %v, average %v",
+				analysis.MaxDepth, analysis.FilesByDepth, analysis.AverageFileDepth)
+		}
+	})
+
+	t.Run("DiskUsageTree", func(t *testing.T) {
+		usageDir := filepath.Join(tmpDir, "usage")
+		for name, size := range map[string]int{
+			"root.bin":           1,
+			"small/a.bin":        10,
+			"big/b.bin":          100,
+			"big/nested/c.bin":   50,
+			"big/nested/x/d.bin": 5,
+		} {
+			if err := CreateFile(filepath.Join(usageDir, name), make([]byte, size), WithCreateDirs()); err != nil {
+				t.Fatalf("Failed to create %s: %v", name, err)
+			}
+		}
+
+		usage, err := DiskUsageTree(usageDir, 2)
+		if err != nil {
+			t.Fatalf("Failed to build usage tree: %v", err)
+		}
+
+		if usage.Size != 166 || usage.Files != 5 {
+			t.Errorf("Expected 166 bytes in 5 files, got %d in %d", usage.Size, usage.Files)
+		}
+		if len(usage.Children) != 2 || filepath.Base(usage.Children[0].Path) != "big" || usage.Children[0].Size != 155 {
+			t.Fatalf("Expected big first with 155 bytes, got %+v", usage.Children)
+		}
+
+		nested := usage.Children[0].Children
+		if len(nested) != 1 || nested[0].Size != 55 || len(nested[0].Children) != 0 {
+			t.Errorf("Expected nested at depth 2 with 55 bytes and no children, got %+v", nested)
+		}
+	})
+
+	t.Run("DiskFree", func(t *testing.T) {
+		space, err := DiskFree(tmpDir)
+		if err != nil {
+			t.Fatalf("Failed to query disk space: %v", err)
+		}
+		if space.Total == 0 || space.Available > space.Total || space.Free > space.Total {
+			t.Errorf("Unexpected disk space %+v", space)
+		}
+		if space.Used() != space.Total-space.Free {
+			t.Errorf("Expected used bytes %d, got %d", space.Total-space.Free, space.Used())
+		}
+
+		if _, err := DiskFree(filepath.Join(tmpDir, "missing")); !errors.Is(err, ErrDiskSpace) {
+			t.Errorf("Expected ErrDiskSpace, got %v", err)
+		}
+	})
+
+	t.Run("CheckSpaceFor", func(t *testing.T) {
+		srcDir := filepath.Join(tmpDir, "space_src")
+		if err := CreateFile(filepath.Join(srcDir, "data.bin"), make([]byte, 1024), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		// The destination doesn't exist yet, its closest existing parent is checked
+		dstDir := filepath.Join(tmpDir, "space_dst", "nested")
+		if err := CheckSpaceFor(srcDir, dstDir); err != nil {
+			t.Fatalf("Expected enough space, got %v", err)
+		}
+		if err := CopyDirectory(srcDir, dstDir, WithRequireSpace()); err != nil {
+			t.Fatalf("Failed to copy directory: %v", err)
+		}
+
+		space, err := DiskFree(tmpDir)
+		if err != nil {
+			t.Fatalf("Failed to query disk space: %v", err)
+		}
+		if space.Available < 1<<40 {
+			// A sparse file larger than the free space must not fit
+			huge := filepath.Join(tmpDir, "space_huge.bin")
+			file, err := os.Create(huge)
+			if err != nil {
+				t.Fatalf("Failed to create file: %v", err)
+			}
+			truncErr := file.Truncate(int64(space.Available) + 1<<30)
+			file.Close()
+			if truncErr != nil {
+				t.Skipf("Sparse files not supported: %v", truncErr)
+			}
+			defer os.Remove(huge)
+
+			if err := CheckSpaceFor(huge, dstDir); !errors.Is(err, ErrInsufficientSpace) {
+				t.Errorf("Expected ErrInsufficientSpace, got %v", err)
+			}
+		}
+	})
+
+	t.Run("CleanDirectory", func(t *testing.T) {
+		cacheDir := filepath.Join(tmpDir, "clean_cache")
+		now := time.Now()
+		for i, daysAgo := range []int{10, 8, 3, 2, 1} {
+			path := filepath.Join(cacheDir, fmt.Sprintf("%d.cache", i))
+			if err := CreateFile(path, []byte("0123456789"), WithCreateDirs()); err != nil {
+				t.Fatalf("Failed to create cache file: %v", err)
+			}
+			modTime := now.AddDate(0, 0, -daysAgo)
+			os.Chtimes(path, modTime, modTime)
+		}
+		for _, name := range []string{"notes.md", "pinned/0.cache"} {
+			path := filepath.Join(cacheDir, name)
+			if err := CreateFile(path, []byte("old"), WithCreateDirs()); err != nil {
+				t.Fatalf("Failed to create %s: %v", name, err)
+			}
+			os.Chtimes(path, now.AddDate(-1, 0, 0), now.AddDate(-1, 0, 0))
+		}
+
+		policy := CleanPolicy{
+			MaxAge:   5 * 24 * time.Hour,
+			MaxFiles: 2,
+			Patterns: []string{"*.cache"},
+		}
+
+		planned, err := CleanDirectory(cacheDir, policy, WithCleanDryRun(), WithCleanKeep("pinned"))
+		if err != nil {
+			t.Fatalf("Failed to plan cleanup: %v", err)
+		}
+		if len(planned.Removed) != 3 || planned.RemovedBytes != 30 || planned.Kept != 2 {
+			t.Errorf("Expected 3 files to be removed and 2 kept, got %+v", planned)
+		}
+		if !FileExist(planned.Removed[0]) {
+			t.Error("Dry run must not remove files")
+		}
+
+		report, err := CleanDirectory(cacheDir, CleanPolicy{MaxTotalSize: 25, Patterns: []string{"*.cache"}}, WithCleanKeep("pinned"))
+		if err != nil {
+			t.Fatalf("Failed to clean directory: %v", err)
+		}
+		if len(report.Removed) != 3 || report.KeptBytes != 20 {
+			t.Errorf("Expected the 3 oldest files to be removed, got %+v", report)
+		}
+		if FileExist(filepath.Join(cacheDir, "2.cache")) || !FileExist(filepath.Join(cacheDir, "3.cache")) {
+			t.Error("Oldest files should be removed first")
+		}
+		if !FileExist(filepath.Join(cacheDir, "notes.md")) || !FileExist(filepath.Join(cacheDir, "pinned", "0.cache")) {
+			t.Error("Unmanaged and kept files must remain")
+		}
+	})
+
+	t.Run("RemoveEmptyDirectories", func(t *testing.T) {
+		cleanDir := filepath.Join(tmpDir, "remove_empty_test")
+		for _, dir := range []string{"a/b/c", "logs", "data/empty"} {
+			if err := CreateDirectories(filepath.Join(cleanDir, dir)); err != nil {
+				t.Fatalf("Failed to create %s: %v", dir, err)
+			}
+		}
+		if err := CreateFile(filepath.Join(cleanDir, "data", "file.txt"), []byte("content")); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		expected := []string{
+			filepath.Join(cleanDir, "a", "b", "c"),
+			filepath.Join(cleanDir, "a", "b"),
+			filepath.Join(cleanDir, "a"),
+			filepath.Join(cleanDir, "data", "empty"),
+		}
+
+		planned, err := RemoveEmptyDirectories(cleanDir, WithCleanDryRun(), WithCleanKeep("logs"))
+		if err != nil {
+			t.Fatalf("Failed to plan cleanup: %v", err)
+		}
+		if !reflect.DeepEqual(planned, expected) {
+			t.Errorf("Expected %v, got %v", expected, planned)
+		}
+		if !DirectoryExist(filepath.Join(cleanDir, "a", "b", "c")) {
+			t.Error("Dry run must not remove directories")
+		}
+
+		removed, err := RemoveEmptyDirectories(cleanDir, WithCleanKeep("logs"))
+		if err != nil {
+			t.Fatalf("Failed to remove empty directories: %v", err)
+		}
+		if !reflect.DeepEqual(removed, expected) {
+			t.Errorf("Expected %v, got %v", expected, removed)
+		}
+		if DirectoryExist(filepath.Join(cleanDir, "a")) {
+			t.Error("Directory containing only empty directories should be removed")
+		}
+		if !DirectoryExist(filepath.Join(cleanDir, "logs")) {
+			t.Error("Kept placeholder directory should remain")
+		}
+	})
+
 	t.Run("CleanEmptyDirectories", func(t *testing.T) {
 		cleanDir := filepath.Join(tmpDir, "clean_test")
 
@@ -540,6 +1295,50 @@ func TestAdvancedDirectoryOperations(t *testing.T) {
 		}
 	})
 
+	t.Run("CopyDirectoryPreserveOwnership", func(t *testing.T) {
+		if os.Geteuid() != 0 {
+			t.Skip("Changing ownership requires root")
+		}
+
+		srcDir := filepath.Join(tmpDir, "owner_src")
+		dstDir := filepath.Join(tmpDir, "owner_dst")
+		srcFile := filepath.Join(srcDir, "sub", "file.txt")
+		if err := CreateFile(srcFile, []byte("owned"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		for _, path := range []string{srcDir, filepath.Dir(srcFile), srcFile} {
+			if err := os.Chown(path, 1234, 5678); err != nil {
+				t.Fatalf("Failed to chown %s: %v", path, err)
+			}
+		}
+		if err := os.Chmod(srcFile, 0755|os.ModeSetgid); err != nil {
+			t.Fatalf("Failed to chmod %s: %v", srcFile, err)
+		}
+
+		if err := CopyDirectory(srcDir, dstDir, WithPreserveOwnership(), WithPreservePermissions(true)); err != nil {
+			t.Fatalf("Failed to copy directory: %v", err)
+		}
+
+		// The owner is set before the mode, which would otherwise lose setgid
+		if info, err := os.Stat(filepath.Join(dstDir, "sub", "file.txt")); err != nil || info.Mode()&os.ModeSetgid == 0 {
+			t.Errorf("Expected setgid to be preserved, got %v (%v)", info.Mode(), err)
+		}
+
+		for _, name := range []string{"", "sub", "sub/file.txt"} {
+			info, err := os.Lstat(filepath.Join(dstDir, name))
+			if err != nil {
+				t.Fatalf("Failed to stat %s: %v", name, err)
+			}
+			uid, gid, ok := fileOwner(info)
+			if !ok {
+				t.Skip("Ownership not available on this platform")
+			}
+			if uid != 1234 || gid != 5678 {
+				t.Errorf("%s: expected owner 1234:5678, got %d:%d", name, uid, gid)
+			}
+		}
+	})
+
 	t.Run("CopyDirectorySkipErrors", func(t *testing.T) {
 		srcDir := filepath.Join(tmpDir, "skip_errors_src")
 		dstDir := filepath.Join(tmpDir, "skip_errors_dst")
@@ -580,4 +1379,145 @@ func TestAdvancedDirectoryOperations(t *testing.T) {
 		// Clean up permissions
 		os.Chmod(badFile, 0644)
 	})
+
+	t.Run("CopyDirectoryErrorHandler", func(t *testing.T) {
+		srcDir := filepath.Join(tmpDir, "error_handler_src")
+		dstDir := filepath.Join(tmpDir, "error_handler_dst")
+		for _, name := range []string{"good.txt", "blocked.txt"} {
+			if err := CreateFile(filepath.Join(srcDir, name), []byte(name), WithCreateDirs()); err != nil {
+				t.Fatalf("Failed to create %s: %v", name, err)
+			}
+		}
+
+		// A directory in place of a file makes its copy fail
+		if err := CreateDirectories(filepath.Join(dstDir, "blocked.txt")); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		skipped := make(map[string]error)
+		err := CopyDirectory(srcDir, dstDir, WithOverwrite(), WithSkipErrors(),
+			WithErrorHandler(func(path string, err error) {
+				skipped[path] = err
+			}))
+		if err != nil {
+			t.Fatalf("Copy with skip errors should not fail: %v", err)
+		}
+
+		if len(skipped) != 1 || skipped[filepath.Join(srcDir, "blocked.txt")] == nil {
+			t.Errorf("Expected blocked.txt to be reported, got %v", skipped)
+		}
+		if !FileExist(filepath.Join(dstDir, "good.txt")) {
+			t.Error("good.txt should be copied")
+		}
+	})
+
+	t.Run("CopyDirectoryPreserveHardlinks", func(t *testing.T) {
+		srcDir := filepath.Join(tmpDir, "hardlink_src")
+		if err := CreateFile(filepath.Join(srcDir, "a.txt"), []byte("shared"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := os.Link(filepath.Join(srcDir, "a.txt"), filepath.Join(srcDir, "b.txt")); err != nil {
+			t.Skipf("Hardlinks not supported: %v", err)
+		}
+
+		dstDir := filepath.Join(tmpDir, "hardlink_dst")
+		if err := CopyDirectory(srcDir, dstDir, WithPreserveHardlinks()); err != nil {
+			t.Fatalf("Failed to copy directory: %v", err)
+		}
+
+		a, errA := os.Stat(filepath.Join(dstDir, "a.txt"))
+		b, errB := os.Stat(filepath.Join(dstDir, "b.txt"))
+		if errA != nil || errB != nil {
+			t.Fatalf("Failed to stat copies: %v, %v", errA, errB)
+		}
+		if _, ok := fileIdentity(a); ok && !os.SameFile(a, b) {
+			t.Error("Hardlinked files should stay linked in the copy")
+		}
+
+		plainDir := filepath.Join(tmpDir, "hardlink_plain_dst")
+		if err := CopyDirectory(srcDir, plainDir); err != nil {
+			t.Fatalf("Failed to copy directory: %v", err)
+		}
+		a, _ = os.Stat(filepath.Join(plainDir, "a.txt"))
+		b, _ = os.Stat(filepath.Join(plainDir, "b.txt"))
+		if os.SameFile(a, b) {
+			t.Error("Files should be copied separately by default")
+		}
+	})
+
+	t.Run("CopyDirectorySkipIdentical", func(t *testing.T) {
+		srcDir := filepath.Join(tmpDir, "resume_src")
+		dstDir := filepath.Join(tmpDir, "resume_dst")
+		for _, name := range []string{"done.txt", "partial.txt"} {
+			if err := CreateFile(filepath.Join(srcDir, name), []byte("complete content"), WithCreateDirs()); err != nil {
+				t.Fatalf("Failed to create %s: %v", name, err)
+			}
+		}
+		if err := CopyDirectory(srcDir, dstDir); err != nil {
+			t.Fatalf("Failed to copy directory: %v", err)
+		}
+
+		// Simulate an interrupted copy and a file that was already copied
+		if err := os.Truncate(filepath.Join(dstDir, "partial.txt"), 4); err != nil {
+			t.Fatalf("Failed to truncate file: %v", err)
+		}
+		done := filepath.Join(dstDir, "done.txt")
+		info, _ := os.Stat(done)
+		if err := WriteFileString(done, "marker content!!"); err != nil {
+			t.Fatalf("Failed to write marker: %v", err)
+		}
+		os.Chtimes(done, info.ModTime(), info.ModTime())
+
+		if err := CopyDirectory(srcDir, dstDir, WithSkipIdentical()); err != nil {
+			t.Fatalf("Failed to resume copy: %v", err)
+		}
+		if content, _ := ReadFileString(filepath.Join(dstDir, "partial.txt")); content != "complete content" {
+			t.Errorf("Partial file should be copied again, got %q", content)
+		}
+		if content, _ := ReadFileString(done); content != "marker content!!" {
+			t.Errorf("File with same size and mtime should be skipped, got %q", content)
+		}
+
+		if err := CopyDirectory(srcDir, dstDir, WithSkipIdenticalChecksum(HashXXH64)); err != nil {
+			t.Fatalf("Failed to resume copy: %v", err)
+		}
+		if content, _ := ReadFileString(done); content != "complete content" {
+			t.Errorf("File with different checksum should be copied again, got %q", content)
+		}
+	})
+
+	t.Run("CopyDirectoryFollowSymlinks", func(t *testing.T) {
+		srcDir := filepath.Join(tmpDir, "follow_src")
+		shared := filepath.Join(tmpDir, "follow_shared")
+		if err := CreateFile(filepath.Join(srcDir, "a.txt"), []byte("a"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := CreateFile(filepath.Join(shared, "b.txt"), []byte("b"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := os.Symlink(shared, filepath.Join(srcDir, "shared")); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+
+		dstDir := filepath.Join(tmpDir, "follow_dst")
+		if err := CopyDirectory(srcDir, dstDir, WithFollowSymlinks()); err != nil {
+			t.Fatalf("Failed to copy directory: %v", err)
+		}
+		info, err := os.Lstat(filepath.Join(dstDir, "shared"))
+		if err != nil || !info.IsDir() {
+			t.Fatalf("Linked directory should be copied as a directory: %v", err)
+		}
+		if content, _ := ReadFileString(filepath.Join(dstDir, "shared", "b.txt")); content != "b" {
+			t.Errorf("Expected linked file content, got %q", content)
+		}
+
+		// A link back to the source root must stop the copy
+		if err := os.Symlink(srcDir, filepath.Join(shared, "back")); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+		err = CopyDirectory(srcDir, filepath.Join(tmpDir, "follow_loop_dst"), WithFollowSymlinks())
+		if !errors.Is(err, ErrSymlinkLoop) {
+			t.Errorf("Expected ErrSymlinkLoop, got %v", err)
+		}
+	})
 }
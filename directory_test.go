@@ -1,9 +1,13 @@
 package fsx
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDirectoryOperations(t *testing.T) {
@@ -288,6 +292,92 @@ func TestDirectoryOperations(t *testing.T) {
 		}
 	})
 
+	t.Run("ExactDirPermissions", func(t *testing.T) {
+		dirPath := filepath.Join(tmpDir, "exactperms")
+		nested := filepath.Join(dirPath, "a", "b")
+
+		if err := CreateDirectories(nested, WithDirPermissions(0777), WithExactDirPermissions()); err != nil {
+			t.Fatalf("Failed to create directories: %v", err)
+		}
+		for _, path := range []string{dirPath, filepath.Join(dirPath, "a"), nested} {
+			info, _ := os.Stat(path)
+			if info.Mode().Perm() != 0777 {
+				t.Errorf("Permission mismatch for %s: got %v, want %v", path, info.Mode().Perm(), os.FileMode(0777))
+			}
+		}
+
+		single := filepath.Join(dirPath, "single")
+		if err := CreateDirectory(single, WithDirPermissions(0777), WithExactDirPermissions()); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		info, _ := os.Stat(single)
+		if info.Mode().Perm() != 0777 {
+			t.Errorf("Permission mismatch: got %v, want %v", info.Mode().Perm(), os.FileMode(0777))
+		}
+	})
+
+	t.Run("ApplyPermissions", func(t *testing.T) {
+		dirPath := filepath.Join(tmpDir, "applyperms")
+		CreateFile(filepath.Join(dirPath, "top.txt"), []byte("top"), WithCreateDirs(), WithPermissions(0600))
+		CreateFile(filepath.Join(dirPath, "sub", "deep.txt"), []byte("deep"), WithCreateDirs(), WithPermissions(0600))
+		os.Chmod(filepath.Join(dirPath, "sub"), 0700)
+
+		assertMode := func(path string, want os.FileMode) {
+			t.Helper()
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatalf("Failed to stat %s: %v", path, err)
+			}
+			if info.Mode().Perm() != want {
+				t.Errorf("Permission mismatch for %s: got %v, want %v", path, info.Mode().Perm(), want)
+			}
+		}
+
+		if err := ApplyPermissions(dirPath, 0750, 0640); err != nil {
+			t.Fatalf("Failed to apply permissions: %v", err)
+		}
+		assertMode(dirPath, 0750)
+		assertMode(filepath.Join(dirPath, "top.txt"), 0640)
+		assertMode(filepath.Join(dirPath, "sub"), 0750)
+		assertMode(filepath.Join(dirPath, "sub", "deep.txt"), 0600)
+
+		if err := ApplyPermissions(dirPath, 0755, 0644, WithRecursive()); err != nil {
+			t.Fatalf("Failed to apply permissions: %v", err)
+		}
+		assertMode(filepath.Join(dirPath, "sub"), 0755)
+		assertMode(filepath.Join(dirPath, "sub", "deep.txt"), 0644)
+
+		if err := ApplyPermissions(filepath.Join(dirPath, "missing"), 0755, 0644); !errors.Is(err, ErrDirectoryNotExist) {
+			t.Errorf("Expected ErrDirectoryNotExist, got %v", err)
+		}
+
+		if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+			return
+		}
+
+		// Unreadable directories are opened up before they are read
+		locked := filepath.Join(dirPath, "sub", "locked")
+		CreateFile(filepath.Join(locked, "secret.txt"), []byte("secret"), WithCreateDirs(), WithPermissions(0600))
+		os.Chmod(locked, 0)
+		defer os.Chmod(locked, 0755)
+
+		if err := ApplyPermissions(dirPath, 0755, 0644, WithRecursive()); err != nil {
+			t.Fatalf("Failed to apply permissions to an unreadable directory: %v", err)
+		}
+		assertMode(locked, 0755)
+		assertMode(filepath.Join(locked, "secret.txt"), 0644)
+
+		// Restrictive modes still reach every level
+		if err := ApplyPermissions(dirPath, 0300, 0600, WithRecursive()); err != nil {
+			t.Fatalf("Failed to apply restrictive permissions: %v", err)
+		}
+		os.Chmod(dirPath, 0755)
+		os.Chmod(filepath.Join(dirPath, "sub"), 0755)
+		assertMode(locked, 0300)
+		os.Chmod(locked, 0755)
+		assertMode(filepath.Join(locked, "secret.txt"), 0600)
+	})
+
 	t.Run("ListDirectorySorted", func(t *testing.T) {
 		dirPath := filepath.Join(tmpDir, "sortdir")
 
@@ -321,4 +411,82 @@ func TestDirectoryOperations(t *testing.T) {
 			t.Error("Entries not sorted by size correctly")
 		}
 	})
+
+	t.Run("ListDirectoryOptions", func(t *testing.T) {
+		dirPath := filepath.Join(tmpDir, "listoptions")
+		base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+		// Modification times within one second must still be ordered
+		for i, name := range []string{"b.log", "sub/a.txt", "c.txt", "skip/d.txt"} {
+			path := filepath.Join(dirPath, name)
+			CreateFile(path, []byte(name), WithCreateDirs())
+			modTime := base.Add(time.Duration(3-i) * 100 * time.Millisecond)
+			os.Chtimes(path, modTime, modTime)
+		}
+
+		entries, err := ListDirectory(dirPath,
+			WithRecursive(),
+			WithFilesOnly(),
+			WithListFilter(func(path string, info os.FileInfo) bool {
+				return info.Name() != "skip"
+			}),
+			WithSortBy(SortByModTime, true))
+		if err != nil {
+			t.Fatalf("Failed to list directory: %v", err)
+		}
+
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name)
+		}
+		if strings.Join(names, ",") != "c.txt,a.txt,b.log" {
+			t.Errorf("Expected files by modification time, got %v", names)
+		}
+
+		dirs, err := ListDirectory(dirPath, WithDirsOnly(), WithSortBy(SortByName, false))
+		if err != nil {
+			t.Fatalf("Failed to list directory: %v", err)
+		}
+		if len(dirs) != 2 || dirs[0].Name != "sub" || dirs[1].Name != "skip" {
+			t.Errorf("Expected directories by name descending, got %+v", dirs)
+		}
+	})
+
+	t.Run("ListDirectoryEntryDetails", func(t *testing.T) {
+		dirPath := filepath.Join(tmpDir, "listdetails")
+		CreateFile(filepath.Join(dirPath, "page.HTML"), []byte("<html><body>hi</body></html>"), WithCreateDirs())
+		CreateDirectories(filepath.Join(dirPath, "sub.d"))
+		modTime := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+		os.Chtimes(filepath.Join(dirPath, "page.HTML"), modTime, modTime)
+
+		entries, err := ListDirectory(dirPath, WithSortBy(SortByName, true))
+		if err != nil {
+			t.Fatalf("Failed to list directory: %v", err)
+		}
+		if len(entries) != 2 {
+			t.Fatalf("Expected 2 entries, got %d", len(entries))
+		}
+
+		page, sub := entries[0], entries[1]
+		if page.Extension != ".html" || sub.Extension != "" {
+			t.Errorf("Expected extensions .html and none, got %q and %q", page.Extension, sub.Extension)
+		}
+		if !page.ModifiedAt.Equal(modTime) {
+			t.Errorf("Expected modification time %v, got %v", modTime, page.ModifiedAt)
+		}
+		if page.ContentType != "" {
+			t.Errorf("Expected no content type without option, got %q", page.ContentType)
+		}
+		if !strings.HasPrefix(page.DetectContentType(), "text/html") || sub.DetectContentType() != "" {
+			t.Errorf("Expected lazily detected text/html, got %q and %q", page.ContentType, sub.ContentType)
+		}
+
+		entries, err = ListDirectory(dirPath, WithFilesOnly(), WithListContentType())
+		if err != nil {
+			t.Fatalf("Failed to list directory: %v", err)
+		}
+		if len(entries) != 1 || !strings.HasPrefix(entries[0].ContentType, "text/html") {
+			t.Errorf("Expected detected text/html content type, got %+v", entries)
+		}
+	})
 }
@@ -0,0 +1,70 @@
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// DiskFree reports capacity and free space of the filesystem containing path,
+// like `df`. See DiskUsageTree for the space used by a directory tree
+func DiskFree(path string) (*DiskSpace, error) {
+	space, err := diskSpace(path)
+	if err != nil {
+		return nil, ErrDiskSpace.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  path,
+				Error: err,
+			})
+	}
+
+	return space, nil
+}
+
+// CheckSpaceFor fails with ErrInsufficientSpace when the filesystem holding dstDir
+// has fewer bytes available than the file or directory src takes. dstDir doesn't
+// have to exist yet. The estimate ignores filesystem overhead and files a copy replaces
+func CheckSpaceFor(src, dstDir string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return newStatFile(src, err)
+	}
+
+	required := info.Size()
+	if info.IsDir() {
+		if required, err = CalculateDirectorySize(src); err != nil {
+			return err
+		}
+	}
+
+	space, err := DiskFree(existingAncestor(dstDir))
+	if err != nil {
+		return err
+	}
+
+	if uint64(required) > space.Available {
+		return ErrInsufficientSpace.
+			SetData(spaceErrorContext{
+				Path:      dstDir,
+				Required:  uint64(required),
+				Available: space.Available,
+			})
+	}
+
+	return nil
+}
+
+// existingAncestor returns path or its closest ancestor that exists
+func existingAncestor(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package fsx
+
+import "errors"
+
+// diskSpace is not available on this platform
+func diskSpace(path string) (*DiskSpace, error) {
+	return nil, errors.ErrUnsupported
+}
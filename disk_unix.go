@@ -0,0 +1,22 @@
+//go:build linux || darwin || freebsd
+
+package fsx
+
+import "golang.org/x/sys/unix"
+
+// diskSpace queries the filesystem containing path with statfs
+func diskSpace(path string) (*DiskSpace, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return nil, err
+	}
+
+	blockSize := uint64(stat.Bsize)
+	return &DiskSpace{
+		Total:      uint64(stat.Blocks) * blockSize,
+		Free:       uint64(stat.Bfree) * blockSize,
+		Available:  uint64(stat.Bavail) * blockSize,
+		Inodes:     uint64(stat.Files),
+		FreeInodes: uint64(stat.Ffree),
+	}, nil
+}
@@ -0,0 +1,21 @@
+//go:build windows
+
+package fsx
+
+import "golang.org/x/sys/windows"
+
+// diskSpace queries the volume containing path with GetDiskFreeSpaceEx.
+// Windows has no inode counts
+func diskSpace(path string) (*DiskSpace, error) {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var space DiskSpace
+	if err = windows.GetDiskFreeSpaceEx(name, &space.Available, &space.Total, &space.Free); err != nil {
+		return nil, err
+	}
+
+	return &space, nil
+}
@@ -3,16 +3,60 @@ package fsx
 import (
 	"os"
 	"sync"
+	"time"
 )
 
+// SearchStats describes the work done by a search, see WithSearchStats
+type SearchStats struct {
+	DirsVisited   int           `json:"dirs_visited"`
+	FilesExamined int           `json:"files_examined"`
+	BytesScanned  int64         `json:"bytes_scanned"` // content read by content and byte searches
+	Matches       int           `json:"matches"`
+	Skipped       int           `json:"skipped"` // entries skipped due to errors
+	Duration      time.Duration `json:"duration"`
+}
+
 // DirectoryEntry represents a file or subdirectory in a directory
 type DirectoryEntry struct {
 	Name    string
 	Path    string
 	Size    int64
 	Mode    os.FileMode
-	ModTime string
+	ModTime string // Formatted as "2006-01-02 15:04:05", see ModifiedAt
 	IsDir   bool
+
+	Extension   string // Lower case with the dot, empty for directories
+	ContentType string // Detected MIME type, see WithListContentType and DetectContentType
+	ModifiedAt  time.Time
+	AccessedAt  time.Time // Zero where not available
+}
+
+// DetectContentType returns the MIME type of a file entry, detecting and keeping it
+// in ContentType on first use. Directories and unreadable files have none
+func (e *DirectoryEntry) DetectContentType() string {
+	if e.ContentType == "" && !e.IsDir {
+		e.ContentType, _ = DetectMIME(e.Path)
+	}
+
+	return e.ContentType
+}
+
+// TrashItem is a file or directory in the trash, see ListTrash
+type TrashItem struct {
+	Name         string    `json:"name"` // identifies the item for RestoreFromTrash
+	Path         string    `json:"path"` // current location inside the trash
+	OriginalPath string    `json:"original_path"`
+	DeletedAt    time.Time `json:"deleted_at"`
+	Size         int64     `json:"size"`
+	IsDir        bool      `json:"is_dir"`
+}
+
+// FileAttributes are file attributes that mode bits can't express, see GetAttributes
+type FileAttributes struct {
+	Hidden   bool
+	ReadOnly bool
+	System   bool // Windows only
+	Archive  bool // Windows only
 }
 
 // DirectoryInfo represents directory information
@@ -29,9 +73,30 @@ type DirectoryInfo struct {
 type SearchResult struct {
 	Path       string
 	Info       os.FileInfo
-	MatchedBy  string // What caused the match (name, content, size, etc.)
-	LineNumber int    // For content searches
-	Line       string // For content searches
+	MatchedBy  string  // What caused the match (name, content, size, etc.)
+	LineNumber int     // For content searches
+	Line       string  // For content searches
+	Offset     int64   // For byte searches, offset of the first match
+	Score      float64 // For fuzzy searches, 0 to 1 with 1 the best match
+
+	ContextBefore []string // Lines before the match, see WithContextLines
+	ContextAfter  []string // Lines after the match, see WithContextLines
+}
+
+// SearchEventType is the kind of change of a watched search, see WatchSearch
+type SearchEventType string
+
+const (
+	SearchEventAdded   SearchEventType = "added"
+	SearchEventRemoved SearchEventType = "removed"
+	SearchEventUpdated SearchEventType = "updated"
+)
+
+// SearchEvent reports a file entering, leaving or changing within a watched result set.
+// Removed events carry the last known result
+type SearchEvent struct {
+	Type   SearchEventType
+	Result SearchResult
 }
 
 // FileLock represents a file lock
@@ -41,3 +106,138 @@ type FileLock struct {
 	mu       sync.Mutex
 	isLocked bool
 }
+
+// ArchiveEntry represents a single entry inside an archive
+type ArchiveEntry struct {
+	Name           string
+	Size           int64
+	CompressedSize int64
+	Mode           os.FileMode
+	ModTime        time.Time
+	CRC32          uint32
+	IsDir          bool
+}
+
+// SplitManifest describes the parts produced by SplitFile
+type SplitManifest struct {
+	Source    string      `json:"source"`
+	Size      int64       `json:"size"`
+	ChunkSize int64       `json:"chunk_size"`
+	HashType  HashType    `json:"hash_type"`
+	Checksum  string      `json:"checksum"`
+	Parts     []SplitPart `json:"parts"`
+}
+
+// SplitPart describes a single part of a split file
+type SplitPart struct {
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"`
+}
+
+// DedupReport describes the result of DeduplicateDirectory
+type DedupReport struct {
+	Groups         int         `json:"groups"`
+	Links          []DedupLink `json:"links"`
+	ReclaimedBytes int64       `json:"reclaimed_bytes"`
+}
+
+// DedupLink is a duplicate replaced by a hardlink to Target
+type DedupLink struct {
+	Path   string `json:"path"`
+	Target string `json:"target"`
+	Size   int64  `json:"size"`
+}
+
+// DirectoryAnalysis holds storage statistics of a directory tree collected by AnalyzeDirectory
+type DirectoryAnalysis struct {
+	Path             string                    `json:"path"`
+	TotalSize        int64                     `json:"total_size"`
+	FileCount        int                       `json:"file_count"`
+	DirCount         int                       `json:"dir_count"`
+	Extensions       map[string]ExtensionStats `json:"extensions"` // by lower-case extension, "" for none
+	Largest          []AnalyzedFile            `json:"largest"`
+	Oldest           []AnalyzedFile            `json:"oldest"`
+	Newest           []AnalyzedFile            `json:"newest"`
+	MaxDepth         int                       `json:"max_depth"`
+	AverageFileDepth float64                   `json:"average_file_depth"`
+	FilesByDepth     map[int]int               `json:"files_by_depth"` // files directly in root have depth 1
+}
+
+// UsageNode is the aggregated size of a directory in a DiskUsageTree
+type UsageNode struct {
+	Path     string       `json:"path"`
+	Size     int64        `json:"size"`  // apparent size of all files below, hardlinks counted once
+	Files    int          `json:"files"` // files below, at any depth
+	Children []*UsageNode `json:"children,omitempty"`
+}
+
+// DiskSpace is the capacity of a filesystem reported by DiskFree
+type DiskSpace struct {
+	Total      uint64 `json:"total"`
+	Free       uint64 `json:"free"`        // free bytes, including those reserved for root
+	Available  uint64 `json:"available"`   // free bytes usable by the current user
+	Inodes     uint64 `json:"inodes"`      // zero where not available
+	FreeInodes uint64 `json:"free_inodes"` // zero where not available
+}
+
+// Used returns the number of bytes in use
+func (s *DiskSpace) Used() uint64 {
+	return s.Total - s.Free
+}
+
+// ExtensionStats counts files sharing an extension
+type ExtensionStats struct {
+	Count int   `json:"count"`
+	Size  int64 `json:"size"`
+}
+
+// AnalyzedFile is a file reported by AnalyzeDirectory
+type AnalyzedFile struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// CleanPolicy selects files removed by CleanDirectory. Zero fields are not applied.
+// Files older than MaxAge are removed, then the oldest files until at most MaxFiles
+// files and MaxTotalSize bytes remain
+type CleanPolicy struct {
+	MaxAge       time.Duration `json:"max_age"`
+	MaxTotalSize int64         `json:"max_total_size"`
+	MaxFiles     int           `json:"max_files"`
+	Patterns     []string      `json:"patterns"` // only matching files are managed, all when empty
+}
+
+// CleanReport describes the result of CleanDirectory
+type CleanReport struct {
+	Removed      []string `json:"removed"`
+	RemovedBytes int64    `json:"removed_bytes"`
+	Kept         int      `json:"kept"`
+	KeptBytes    int64    `json:"kept_bytes"`
+}
+
+// LineEndingReport describes the result of NormalizeLineEndings
+type LineEndingReport struct {
+	Checked int                `json:"checked"` // Text files looked at
+	Binary  int                `json:"binary"`  // Files skipped as binary
+	Changed []LineEndingChange `json:"changed"`
+}
+
+// LineEndingChange is a file converted by NormalizeLineEndings
+type LineEndingChange struct {
+	Path  string `json:"path"`
+	Lines int    `json:"lines"` // Converted line endings
+}
+
+// RenamePair is a rename applied by RenameAll
+type RenamePair struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// fileID identifies a file on disk by device and inode
+type fileID struct {
+	dev uint64
+	ino uint64
+}
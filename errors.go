@@ -14,35 +14,49 @@ var (
 	ErrReadFileLines               = errorx.New("fsx.file.read.lines")
 	ErrCreateFile                  = errorx.New("fsx.file.create")
 	ErrCreateBackupFile            = errorx.New("fsx.file.create.backup")
+	ErrFileVersion                 = errorx.New("fsx.file.version")
 	ErrAppendFile                  = errorx.New("fsx.file.append")
 	ErrDeleteFile                  = errorx.New("fsx.file.delete")
+	ErrShredFile                   = errorx.New("fsx.file.shred")
 	ErrStatFile                    = errorx.New("fsx.file.stat")
 	ErrCopyFile                    = errorx.New("fsx.file.copy")
 	ErrAtomicOperation             = errorx.New("fsx.file.atomic")
 	ErrTempFile                    = errorx.New("fsx.file.temp")
+	ErrInsecureTempDir             = errorx.New("fsx.file.temp.insecure_dir")
+	ErrTempJanitor                 = errorx.New("fsx.file.temp.janitor")
 	ErrFileLock                    = errorx.New("fsx.file.lock")
 	ErrStreamOperation             = errorx.New("fsx.file.stream")
 	ErrCompress                    = errorx.New("fsx.file.compress")
 	ErrDecompress                  = errorx.New("fsx.file.decompress")
 	ErrChecksum                    = errorx.New("fsx.file.checksum")
+	ErrChecksumCache               = errorx.New("fsx.file.checksum.cache")
 	ErrFileAlreadyLocked           = errorx.New("fsx.file.already_locked")
 	ErrFileNotLocked               = errorx.New("fsx.file.not_locked")
 	ErrInvalidArchive              = errorx.New("fsx.file.invalid_archive")
+	ErrSplitVerification           = errorx.New("fsx.file.split.verification")
+	ErrCompareFiles                = errorx.New("fsx.file.compare")
+	ErrSetFileTimes                = errorx.New("fsx.file.set_times")
+	ErrFileAttributes              = errorx.New("fsx.file.attributes")
 
 	ErrCreateDirectory            = errorx.New("fsx.file.create.directory")
 	ErrCreateDirectories          = errorx.New("fsx.file.create.directories")
 	ErrDeleteDirectory            = errorx.New("fsx.directory.delete")
 	ErrDeleteDirectoryNotEmpty    = errorx.New("fsx.directory.delete.not_empty")
 	ErrRenameDirectory            = errorx.New("fsx.directory.rename")
+	ErrMoveDirectory              = errorx.New("fsx.directory.move")
 	ErrListDirectory              = errorx.New("fsx.directory.list")
 	ErrReadDirectory              = errorx.New("fsx.directory.read")
 	ErrStatDirectory              = errorx.New("fsx.directory.stat")
 	ErrChangeDirectoryPermissions = errorx.New("fsx.directory.change_permissions")
+	ErrApplyPermissions           = errorx.New("fsx.directory.apply_permissions")
 	ErrDirectoryNotExist          = errorx.New("fsx.directory.not_exist")
 	ErrNotDirectory               = errorx.New("fsx.directory.not_directory")
 	ErrCopyDirectory              = errorx.New("fsx.directory.copy")
 	ErrSyncDirectory              = errorx.New("fsx.directory.sync")
 	ErrCompareDirectory           = errorx.New("fsx.directory.compare")
+	ErrApplyDifferences           = errorx.New("fsx.directory.apply_differences")
+	ErrDeduplicate                = errorx.New("fsx.directory.deduplicate")
+	ErrCleanDirectory             = errorx.New("fsx.directory.clean")
 	ErrWalkDirectory              = errorx.New("fsx.directory.walk")
 	ErrCalculateSize              = errorx.New("fsx.directory.calculate_size")
 	ErrSourceNotDirectory         = errorx.New("fsx.directory.source_not_directory")
@@ -53,6 +67,94 @@ var (
 	ErrInvalidPattern   = errorx.New("fsx.search.invalid_pattern")
 	ErrInvalidRegex     = errorx.New("fsx.search.invalid_regex")
 	ErrSearchDepthLimit = errorx.New("fsx.search.depth_limit")
+	ErrSymlinkLoop      = errorx.New("fsx.symlink.loop")
+	ErrCreateSymlink    = errorx.New("fsx.symlink.create")
+	ErrReadSymlink      = errorx.New("fsx.symlink.read")
+	ErrResolveSymlink   = errorx.New("fsx.symlink.resolve")
+	ErrNotSymlink       = errorx.New("fsx.symlink.not_symlink")
+	ErrCreateHardlink   = errorx.New("fsx.hardlink.create")
+	ErrLinkCount        = errorx.New("fsx.hardlink.count")
+	ErrSearchCanceled   = errorx.New("fsx.search.canceled")
+
+	ErrListArchive       = errorx.New("fsx.archive.list")
+	ErrUnsafeArchivePath = errorx.New("fsx.archive.unsafe_path")
+	ErrArchiveFileExists = errorx.New("fsx.archive.file_exists")
+	ErrArchiveCorrupted  = errorx.New("fsx.archive.corrupted")
+
+	ErrManifest     = errorx.New("fsx.manifest.generate")
+	ErrReadManifest = errorx.New("fsx.manifest.read")
+
+	ErrCreatePatch  = errorx.New("fsx.patch.create")
+	ErrApplyPatch   = errorx.New("fsx.patch.apply")
+	ErrInvalidPatch = errorx.New("fsx.patch.invalid")
+
+	ErrIntegrityBaseline = errorx.New("fsx.integrity.baseline")
+	ErrIntegrityCheck    = errorx.New("fsx.integrity.check")
+
+	ErrOpenIndex    = errorx.New("fsx.index.open")
+	ErrRefreshIndex = errorx.New("fsx.index.refresh")
+	ErrSaveIndex    = errorx.New("fsx.index.save")
+
+	ErrBackup            = errorx.New("fsx.backup.create")
+	ErrRestoreBackup     = errorx.New("fsx.backup.restore")
+	ErrBackupCatalog     = errorx.New("fsx.backup.catalog")
+	ErrBackupSetNotFound = errorx.New("fsx.backup.set_not_found")
+
+	ErrMirrorSnapshot   = errorx.New("fsx.snapshot.mirror")
+	ErrListSnapshots    = errorx.New("fsx.snapshot.list")
+	ErrSnapshotNotFound = errorx.New("fsx.snapshot.not_found")
+	ErrRestoreSnapshot  = errorx.New("fsx.snapshot.restore")
+	ErrPruneSnapshots   = errorx.New("fsx.snapshot.prune")
+
+	ErrMoveToTrash       = errorx.New("fsx.trash.move")
+	ErrListTrash         = errorx.New("fsx.trash.list")
+	ErrRestoreFromTrash  = errorx.New("fsx.trash.restore")
+	ErrEmptyTrash        = errorx.New("fsx.trash.empty")
+	ErrTrashItemNotFound = errorx.New("fsx.trash.not_found")
+
+	ErrJournal        = errorx.New("fsx.journal.open")
+	ErrJournalAppend  = errorx.New("fsx.journal.append")
+	ErrJournalCorrupt = errorx.New("fsx.journal.corrupt")
+	ErrJournalClosed  = errorx.New("fsx.journal.closed")
+
+	ErrQueue        = errorx.New("fsx.queue.open")
+	ErrQueueEnqueue = errorx.New("fsx.queue.enqueue")
+	ErrQueueDequeue = errorx.New("fsx.queue.dequeue")
+	ErrQueueEmpty   = errorx.New("fsx.queue.empty")
+	ErrQueueCorrupt = errorx.New("fsx.queue.corrupt")
+
+	ErrEncrypt               = errorx.New("fsx.crypto.encrypt")
+	ErrDecrypt               = errorx.New("fsx.crypto.decrypt")
+	ErrDecryptAuthentication = errorx.New("fsx.crypto.decrypt.authentication")
+
+	ErrLoadConfig              = errorx.New("fsx.config.load")
+	ErrUnsupportedConfigFormat = errorx.New("fsx.config.unsupported_format")
+
+	ErrRenderTemplate = errorx.New("fsx.template.render")
+
+	ErrReserveFile = errorx.New("fsx.file.reserve")
+	ErrFileExists  = errorx.New("fsx.file.exists")
+
+	ErrWorkspace       = errorx.New("fsx.workspace")
+	ErrWorkspaceClosed = errorx.New("fsx.workspace.closed")
+	ErrWorkspaceQuota  = errorx.New("fsx.workspace.quota")
+
+	ErrRename         = errorx.New("fsx.rename")
+	ErrInvalidRename  = errorx.New("fsx.rename.invalid")
+	ErrRenameConflict = errorx.New("fsx.rename.conflict")
+
+	ErrUnsupportedFilenameCase = errorx.New("fsx.rename.unsupported_case")
+
+	ErrNormalizeLineEndings = errorx.New("fsx.file.line_endings")
+
+	ErrOps = errorx.New("fsx.ops")
+
+	ErrDiskSpace         = errorx.New("fsx.disk.space")
+	ErrInsufficientSpace = errorx.New("fsx.disk.insufficient_space")
+
+	ErrExpandHome    = errorx.New("fsx.path.expand_home")
+	ErrNormalizePath = errorx.New("fsx.path.normalize")
+	ErrCanonicalPath = errorx.New("fsx.path.canonical")
 )
 
 type failedChangePermissionsContext struct {
@@ -164,6 +266,15 @@ func newDeleteFile(path string, err error) error {
 		})
 }
 
+func newFileAttributesError(path string, err error) error {
+	return ErrFileAttributes.
+		SetError(err).
+		SetData(pathErrorContext{
+			Path:  path,
+			Error: err,
+		})
+}
+
 func newStatFile(path string, err error) error {
 	return ErrStatFile.
 		SetError(err).
@@ -182,6 +293,12 @@ func newCopyFile(path string, err error) error {
 		})
 }
 
+type spaceErrorContext struct {
+	Path      string `json:"path"`
+	Required  uint64 `json:"required"`
+	Available uint64 `json:"available"`
+}
+
 type moveErrorContext struct {
 	Source      string `json:"source"`
 	Destination string `json:"destination"`
@@ -3,16 +3,19 @@ package fsx
 import (
 	"archive/zip"
 	"bufio"
+	"bytes"
 	"compress/gzip"
-	"crypto/md5"
-	"crypto/sha1"
-	"crypto/sha256"
+	"context"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"hash"
 	"io"
 	"os"
+	"os/user"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -51,10 +54,18 @@ func AnyFileExist(paths ...string) bool {
 type FileOption func(*fileOptions)
 
 type fileOptions struct {
-	perm       os.FileMode
-	createDirs bool
-	backup     bool
-	bufferSize int
+	perm              os.FileMode
+	createDirs        bool
+	backup            bool
+	versions          int
+	timestampVersions bool
+	bufferSize        int
+	accessTime        time.Time
+	modTime           time.Time
+	noCreate          bool
+	exactPerm         bool
+	encrypt           Cipher
+	decrypt           Cipher
 }
 
 // defaultFileOptions returns default options for file operations
@@ -63,17 +74,27 @@ func defaultFileOptions() *fileOptions {
 		perm:       0644,
 		createDirs: false,
 		backup:     false,
+		versions:   0,
 		bufferSize: 32 * 1024, // 32KB
 	}
 }
 
-// WithPermissions sets custom file permissions
+// WithPermissions sets custom file permissions. Like open(2), the process umask
+// is applied to them on creation unless WithExactPermissions is given
 func WithPermissions(perm os.FileMode) FileOption {
 	return func(opts *fileOptions) {
 		opts.perm = perm
 	}
 }
 
+// WithExactPermissions changes the mode of written files to exactly the WithPermissions
+// mode (0644 by default) after writing, so the process umask doesn't apply
+func WithExactPermissions() FileOption {
+	return func(opts *fileOptions) {
+		opts.exactPerm = true
+	}
+}
+
 // WithCreateDirs creates parent directories if they don't exist
 func WithCreateDirs() FileOption {
 	return func(opts *fileOptions) {
@@ -88,6 +109,23 @@ func WithBackup() FileOption {
 	}
 }
 
+// WithVersioning keeps up to keep previous versions when overwriting:
+// name.1 is the most recent, name.<keep> the oldest. Only the run name.1, name.2...
+// without gaps is treated as versions; other numbered files are left alone
+func WithVersioning(keep int) FileOption {
+	return func(opts *fileOptions) {
+		opts.versions = keep
+	}
+}
+
+// WithTimestampedVersions names versions kept by WithVersioning after the time
+// they were replaced (name.20060102T150405.000000000) instead of numbering them
+func WithTimestampedVersions() FileOption {
+	return func(opts *fileOptions) {
+		opts.timestampVersions = true
+	}
+}
+
 // WithBufferSize sets custom buffer size for operations
 func WithBufferSize(size int) FileOption {
 	return func(opts *fileOptions) {
@@ -95,6 +133,48 @@ func WithBufferSize(size int) FileOption {
 	}
 }
 
+// WithTimes sets the access and modification times TouchFile applies instead of
+// the current time. A zero time leaves that time unchanged
+func WithTimes(accessTime, modTime time.Time) FileOption {
+	return func(opts *fileOptions) {
+		opts.accessTime = accessTime
+		opts.modTime = modTime
+	}
+}
+
+// WithNoCreate makes TouchFile skip missing files instead of creating them (touch -c)
+func WithNoCreate() FileOption {
+	return func(opts *fileOptions) {
+		opts.noCreate = true
+	}
+}
+
+// WithEncryption encrypts the files written by CopyFile and CompressFile
+// like EncryptFile, so copies and backups are encrypted at rest
+func WithEncryption(options ...CryptoOption) FileOption {
+	return WithEncryptionCipher(NewAESCipher(options...))
+}
+
+// WithEncryptionCipher encrypts the files written by CopyFile and CompressFile with cipher
+func WithEncryptionCipher(cipher Cipher) FileOption {
+	return func(opts *fileOptions) {
+		opts.encrypt = cipher
+	}
+}
+
+// WithDecryption decrypts the source of CopyFile and DecompressFile like DecryptFile
+func WithDecryption(options ...CryptoOption) FileOption {
+	return WithDecryptionCipher(NewAESCipher(options...))
+}
+
+// WithDecryptionCipher decrypts the source of CopyFile and DecompressFile with cipher.
+// The destination is only replaced once the whole source decrypted
+func WithDecryptionCipher(cipher Cipher) FileOption {
+	return func(opts *fileOptions) {
+		opts.decrypt = cipher
+	}
+}
+
 // CreateFile creates a new file with optional content
 func CreateFile(path string, content []byte, options ...FileOption) error {
 	opts := defaultFileOptions()
@@ -109,7 +189,20 @@ func CreateFile(path string, content []byte, options ...FileOption) error {
 		}
 	}
 
-	return os.WriteFile(path, content, opts.perm)
+	if err := os.WriteFile(path, content, opts.perm); err != nil {
+		return err
+	}
+
+	return applyExactPermissions(path, opts)
+}
+
+// applyExactPermissions changes the mode of path to opts.perm when WithExactPermissions is set
+func applyExactPermissions(path string, opts *fileOptions) error {
+	if !opts.exactPerm {
+		return nil
+	}
+
+	return ChangeFilePermissions(path, opts.perm)
 }
 
 // ReadFile reads entire file content as bytes
@@ -160,11 +253,8 @@ func WriteFile(path string, data []byte, options ...FileOption) error {
 		opt(opts)
 	}
 
-	if opts.backup && FileExist(path) {
-		backupPath := path + ".backup"
-		if err := CopyFile(path, backupPath); err != nil {
-			return newCreateBackupFileError(path, err)
-		}
+	if err := backupBeforeOverwrite(path, opts); err != nil {
+		return err
 	}
 
 	if opts.createDirs {
@@ -174,7 +264,11 @@ func WriteFile(path string, data []byte, options ...FileOption) error {
 		}
 	}
 
-	return os.WriteFile(path, data, opts.perm)
+	if err := os.WriteFile(path, data, opts.perm); err != nil {
+		return err
+	}
+
+	return applyExactPermissions(path, opts)
 }
 
 // WriteFileString writes string content to file
@@ -212,7 +306,7 @@ func AppendFile(path string, data []byte, options ...FileOption) error {
 		return newAppendFile(path, err)
 	}
 
-	return nil
+	return applyExactPermissions(path, opts)
 }
 
 // AppendFileString appends string to file
@@ -247,11 +341,8 @@ func MoveFile(src, dst string, options ...FileOption) error {
 		}
 	}
 
-	if opts.backup && FileExist(dst) {
-		backupPath := dst + ".backup"
-		if err := CopyFile(dst, backupPath); err != nil {
-			return newCreateBackupFileError(dst, err)
-		}
+	if err := backupBeforeOverwrite(dst, opts); err != nil {
+		return err
 	}
 
 	if err := os.Rename(src, dst); err != nil {
@@ -282,10 +373,11 @@ func CopyFile(src, dst string, options ...FileOption) error {
 		}
 	}
 
-	if opts.backup && FileExist(dst) {
-		backupPath := dst + ".backup"
-		if err := CopyFile(dst, backupPath); err != nil {
-			return newCreateBackupFileError(dst, err)
+	// Decrypted content is trusted once every chunk authenticated, so it is written
+	// next to dst and replaces it (after the backups) only then
+	if opts.decrypt == nil {
+		if err := backupBeforeOverwrite(dst, opts); err != nil {
+			return err
 		}
 	}
 
@@ -302,28 +394,106 @@ func CopyFile(src, dst string, options ...FileOption) error {
 	}
 
 	// Create destination file
-	destFile, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, sourceInfo.Mode())
+	var destFile *os.File
+	if opts.decrypt != nil {
+		destFile, err = createReplacement(dst)
+	} else {
+		destFile, err = os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, sourceInfo.Mode())
+	}
 	if err != nil {
 		return newOpenFileError(dst, err)
 	}
 	defer destFile.Close()
+	if opts.decrypt != nil {
+		defer os.Remove(destFile.Name()) // no-op once renamed into place
+	}
+
+	var reader io.Reader = sourceFile
+	if opts.decrypt != nil {
+		if reader, err = opts.decrypt.DecryptReader(sourceFile); err != nil {
+			return newCopyFile(dst, err)
+		}
+	}
+
+	var writer io.Writer = destFile
+	var encrypter io.WriteCloser
+	if opts.encrypt != nil {
+		if encrypter, err = opts.encrypt.EncryptWriter(destFile); err != nil {
+			return newCopyFile(dst, err)
+		}
+		writer = encrypter
+	}
 
 	// Copy with buffer
 	buf := make([]byte, opts.bufferSize)
-	if _, err := io.CopyBuffer(destFile, sourceFile, buf); err != nil {
+	if _, err := io.CopyBuffer(writer, reader, buf); err != nil {
 		return newCopyFile(dst, err)
 	}
 
+	if encrypter != nil {
+		if err := encrypter.Close(); err != nil {
+			return newCopyFile(dst, err)
+		}
+	}
+
+	if opts.decrypt != nil {
+		if err := replaceFile(destFile, dst, sourceInfo.Mode().Perm(), opts); err != nil {
+			return newCopyFile(dst, err)
+		}
+	}
+
 	return nil
 }
 
+// createReplacement creates a temp file next to path, to be moved over it by replaceFile
+func createReplacement(path string) (*os.File, error) {
+	return os.CreateTemp(filepath.Dir(path), ".tmp-*")
+}
+
+// replaceFile closes the replacement tmp and renames it over path with perm,
+// taking the backups and versions of path first. tmp is removed on failure
+func replaceFile(tmp *os.File, path string, perm os.FileMode, opts *fileOptions) error {
+	err := tmp.Close()
+	if err == nil {
+		err = os.Chmod(tmp.Name(), perm)
+	}
+	if err == nil {
+		err = backupBeforeOverwrite(path, opts)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+
+	return err
+}
+
 // FileInfo represents file information
 type FileInfo struct {
 	Path    string
 	Size    int64
 	Mode    os.FileMode
-	ModTime string
+	ModTime string // Formatted as "2006-01-02 15:04:05", see ModifiedAt
 	IsDir   bool
+
+	ModifiedAt time.Time
+	AccessedAt time.Time // Zero where not available
+	UID        int       // -1 where not available
+	GID        int       // -1 where not available
+	Owner      string    // User name, empty when unknown
+	Group      string    // Group name, empty when unknown
+	Inode      uint64    // Zero where not available
+	Links      uint64    // Number of hard links, zero where not available
+
+	birthTime    time.Time
+	hasBirthTime bool
+}
+
+// BirthTime returns the creation time and whether the platform and filesystem record it
+func (fi *FileInfo) BirthTime() (time.Time, bool) {
+	return fi.birthTime, fi.hasBirthTime
 }
 
 // GetFileInfo returns detailed file information
@@ -333,13 +503,38 @@ func GetFileInfo(path string) (*FileInfo, error) {
 		return nil, newStatFile(path, err)
 	}
 
-	return &FileInfo{
-		Path:    path,
-		Size:    info.Size(),
-		Mode:    info.Mode(),
-		ModTime: info.ModTime().Format("2006-01-02 15:04:05"),
-		IsDir:   info.IsDir(),
-	}, nil
+	fileInfo := &FileInfo{
+		Path:       path,
+		Size:       info.Size(),
+		Mode:       info.Mode(),
+		ModTime:    info.ModTime().Format("2006-01-02 15:04:05"),
+		IsDir:      info.IsDir(),
+		ModifiedAt: info.ModTime(),
+		UID:        -1,
+		GID:        -1,
+	}
+
+	if uid, gid, ok := fileOwner(info); ok {
+		fileInfo.UID, fileInfo.GID = uid, gid
+		if account, err := user.LookupId(strconv.Itoa(uid)); err == nil {
+			fileInfo.Owner = account.Username
+		}
+		if group, err := user.LookupGroupId(strconv.Itoa(gid)); err == nil {
+			fileInfo.Group = group.Name
+		}
+	}
+	if id, ok := fileIdentity(info); ok {
+		fileInfo.Inode = id.ino
+	}
+	if links, ok := fileLinkCount(info); ok {
+		fileInfo.Links = links
+	}
+	if accessed, ok := fileAccessTime(info); ok {
+		fileInfo.AccessedAt = accessed
+	}
+	fileInfo.birthTime, fileInfo.hasBirthTime = fileBirthTime(path, info)
+
+	return fileInfo, nil
 }
 
 // ChangeFilePermissions changes file permissions
@@ -351,20 +546,66 @@ func ChangeFilePermissions(path string, mode os.FileMode) error {
 	return nil
 }
 
-// TouchFile creates an empty file or updates its modification time
+// TouchFile creates an empty file or updates its access and modification times,
+// to the current time unless WithTimes is given
 func TouchFile(path string, options ...FileOption) error {
-	if FileExist(path) {
-		// Update modification time
-		currentTime := time.Now()
-		return os.Chtimes(path, currentTime, currentTime)
+	opts := defaultFileOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	accessTime, modTime := opts.accessTime, opts.modTime
+	explicit := !accessTime.IsZero() || !modTime.IsZero()
+	if !explicit {
+		accessTime = time.Now()
+		modTime = accessTime
 	}
 
-	// Create empty file
-	return CreateFile(path, []byte{}, options...)
+	if _, err := os.Stat(path); err != nil {
+		if !os.IsNotExist(err) {
+			return newStatFile(path, err)
+		}
+		if opts.noCreate {
+			return nil
+		}
+
+		// Create empty file
+		if err = CreateFile(path, []byte{}, options...); err != nil || !explicit {
+			return err
+		}
+	}
+
+	return SetFileTimes(path, accessTime, modTime)
+}
+
+// SetFileTimes sets the access and modification times of a file or directory.
+// A zero time leaves that time unchanged
+func SetFileTimes(path string, accessTime, modTime time.Time) error {
+	if err := os.Chtimes(path, accessTime, modTime); err != nil {
+		return ErrSetFileTimes.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  path,
+				Error: err,
+			})
+	}
+
+	return nil
 }
 
-// AtomicWriteFile writes data to a file atomically
-func AtomicWriteFile(path string, data []byte, perm os.FileMode) error {
+// AtomicWriteFile writes data to a file atomically with exactly the perm mode,
+// the umask doesn't apply. Backup and versioning options are honoured,
+// other file options are ignored
+func AtomicWriteFile(path string, data []byte, perm os.FileMode, options ...FileOption) error {
+	opts := defaultFileOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if err := backupBeforeOverwrite(path, opts); err != nil {
+		return err
+	}
+
 	dir := filepath.Dir(path)
 
 	// Create temporary file in the same directory
@@ -443,16 +684,26 @@ func AtomicWriteFile(path string, data []byte, perm os.FileMode) error {
 }
 
 // AtomicWriteFileString writes string data atomically
-func AtomicWriteFileString(path string, content string, perm os.FileMode) error {
-	return AtomicWriteFile(path, []byte(content), perm)
+func AtomicWriteFileString(path string, content string, perm os.FileMode, options ...FileOption) error {
+	return AtomicWriteFile(path, []byte(content), perm, options...)
 }
 
-// CreateTempFile creates a temporary file with optional prefix/suffix
-func CreateTempFile(dir, pattern string, content []byte) (string, error) {
+// CreateTempFile creates a temporary file with optional prefix/suffix,
+// readable and writable by the owner only unless WithTempPermissions is given
+func CreateTempFile(dir, pattern string, content []byte, options ...TempOption) (string, error) {
+	opts := defaultTempOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
 	if dir == "" {
 		dir = os.TempDir()
 	}
 
+	if err := prepareTemp(dir, opts); err != nil {
+		return "", err
+	}
+
 	file, err := os.CreateTemp(dir, pattern)
 	if err != nil {
 		return "", ErrTempFile.
@@ -493,15 +744,29 @@ func CreateTempFile(dir, pattern string, content []byte) (string, error) {
 			})
 	}
 
+	if err := finishTemp(path, opts); err != nil {
+		return "", err
+	}
+
 	return path, nil
 }
 
-// CreateTempDirectory creates a temporary directory
-func CreateTempDirectory(dir, pattern string) (string, error) {
+// CreateTempDirectory creates a temporary directory, accessible by the owner
+// only unless WithTempPermissions is given
+func CreateTempDirectory(dir, pattern string, options ...TempOption) (string, error) {
+	opts := defaultTempOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
 	if dir == "" {
 		dir = os.TempDir()
 	}
 
+	if err := prepareTemp(dir, opts); err != nil {
+		return "", err
+	}
+
 	path, err := os.MkdirTemp(dir, pattern)
 	if err != nil {
 		return "", ErrTempFile.
@@ -517,6 +782,10 @@ func CreateTempDirectory(dir, pattern string) (string, error) {
 			})
 	}
 
+	if err := finishTemp(path, opts); err != nil {
+		return "", err
+	}
+
 	return path, nil
 }
 
@@ -737,7 +1006,12 @@ func StreamCopyWithBuffer(src, dst string, bufferSize int, processor func([]byte
 }
 
 // CompressFile compresses a file using gzip
-func CompressFile(src, dst string) error {
+func CompressFile(src, dst string, options ...FileOption) error {
+	opts := defaultFileOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
 	srcFile, err := os.Open(src)
 	if err != nil {
 		return ErrCompress.
@@ -760,11 +1034,28 @@ func CompressFile(src, dst string) error {
 	}
 	defer dstFile.Close()
 
-	gzWriter := gzip.NewWriter(dstFile)
+	var writer io.Writer = dstFile
+	var encrypter io.WriteCloser
+	if opts.encrypt != nil {
+		if encrypter, err = opts.encrypt.EncryptWriter(dstFile); err != nil {
+			return ErrCompress.
+				SetError(err).
+				SetData(pathErrorContext{
+					Path:  dst,
+					Error: err,
+				})
+		}
+		writer = encrypter
+	}
+
+	gzWriter := gzip.NewWriter(writer)
 	defer gzWriter.Close()
 
-	// Set the original filename in gzip header
+	// Set the original filename and modification time in gzip header
 	gzWriter.Name = filepath.Base(src)
+	if info, err := srcFile.Stat(); err == nil {
+		gzWriter.ModTime = info.ModTime()
+	}
 
 	if _, err := io.Copy(gzWriter, srcFile); err != nil {
 		return ErrCompress.
@@ -776,11 +1067,35 @@ func CompressFile(src, dst string) error {
 			})
 	}
 
+	// The gzip footer has to be written before the last encrypted chunk
+	if encrypter != nil {
+		err = gzWriter.Close()
+		if err == nil {
+			err = encrypter.Close()
+		}
+		if err != nil {
+			return ErrCompress.
+				SetError(err).
+				SetData(moveErrorContext{
+					Source:      src,
+					Destination: dst,
+					Error:       err,
+				})
+		}
+	}
+
 	return nil
 }
 
-// DecompressFile decompresses a gzip file
-func DecompressFile(src, dst string) error {
+// DecompressFile decompresses a gzip file.
+// If dst is an existing directory, the file is restored inside it using the
+// original name and modification time stored in the gzip header
+func DecompressFile(src, dst string, options ...FileOption) error {
+	opts := defaultFileOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
 	srcFile, err := os.Open(src)
 	if err != nil {
 		return ErrDecompress.
@@ -792,7 +1107,19 @@ func DecompressFile(src, dst string) error {
 	}
 	defer srcFile.Close()
 
-	gzReader, err := gzip.NewReader(srcFile)
+	var reader io.Reader = srcFile
+	if opts.decrypt != nil {
+		if reader, err = opts.decrypt.DecryptReader(srcFile); err != nil {
+			return ErrDecompress.
+				SetError(err).
+				SetData(pathErrorContext{
+					Path:  src,
+					Error: err,
+				})
+		}
+	}
+
+	gzReader, err := gzip.NewReader(reader)
 	if err != nil {
 		return ErrDecompress.
 			SetError(err).
@@ -803,7 +1130,19 @@ func DecompressFile(src, dst string) error {
 	}
 	defer gzReader.Close()
 
-	dstFile, err := os.Create(dst)
+	restoreHeader := DirectoryExist(dst)
+	if restoreHeader {
+		dst = filepath.Join(dst, gzipOriginalName(src, gzReader.Name))
+	}
+
+	// Decrypted content is written next to dst and replaces it once every chunk
+	// authenticated, so a tampered stream leaves no unauthenticated plaintext
+	var dstFile *os.File
+	if opts.decrypt != nil {
+		dstFile, err = createReplacement(dst)
+	} else {
+		dstFile, err = os.Create(dst)
+	}
 	if err != nil {
 		return ErrDecompress.
 			SetError(err).
@@ -813,6 +1152,9 @@ func DecompressFile(src, dst string) error {
 			})
 	}
 	defer dstFile.Close()
+	if opts.decrypt != nil {
+		defer os.Remove(dstFile.Name()) // no-op once renamed into place
+	}
 
 	if _, err := io.Copy(dstFile, gzReader); err != nil {
 		return ErrDecompress.
@@ -824,11 +1166,62 @@ func DecompressFile(src, dst string) error {
 			})
 	}
 
+	if opts.decrypt != nil {
+		err = replaceFile(dstFile, dst, 0644, defaultFileOptions())
+	} else {
+		err = dstFile.Close()
+	}
+	if err != nil {
+		return ErrDecompress.
+			SetError(err).
+			SetData(moveErrorContext{
+				Source:      src,
+				Destination: dst,
+				Error:       err,
+			})
+	}
+
+	if restoreHeader && !gzReader.ModTime.IsZero() {
+		if err := os.Chtimes(dst, gzReader.ModTime, gzReader.ModTime); err != nil {
+			return ErrDecompress.
+				SetError(err).
+				SetData(pathErrorContext{
+					Path:  dst,
+					Error: err,
+				})
+		}
+	}
+
 	return nil
 }
 
+// gzipOriginalName returns a safe file name from the gzip header,
+// falling back to the source name without its .gz extension
+func gzipOriginalName(src, headerName string) string {
+	name := filepath.Base(filepath.FromSlash(headerName))
+	if headerName == "" || name == "." || name == ".." || name == string(filepath.Separator) {
+		name = strings.TrimSuffix(filepath.Base(src), filepath.Ext(src))
+	}
+
+	return name
+}
+
 // CalculateFileChecksum calculates checksum of a file
-func CalculateFileChecksum(path string, hashType HashType) (string, error) {
+func CalculateFileChecksum(path string, hashType HashType, options ...ChecksumOption) (string, error) {
+	opts := defaultChecksumOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if opts.cache != nil {
+		return opts.cache.Checksum(path, hashType)
+	}
+
+	return calculateFileChecksum(path, hashType)
+}
+
+// calculateFileChecksum reads the whole file and calculates its checksum
+func calculateFileChecksum(path string, hashType HashType) (string, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return "", ErrChecksum.
@@ -840,15 +1233,8 @@ func CalculateFileChecksum(path string, hashType HashType) (string, error) {
 	}
 	defer file.Close()
 
-	var h hash.Hash
-	switch hashType {
-	case HashMD5:
-		h = md5.New()
-	case HashSHA1:
-		h = sha1.New()
-	case HashSHA256:
-		h = sha256.New()
-	default:
+	h, err := newHasher(hashType)
+	if err != nil {
 		return "", ErrChecksum.
 			SetData(struct {
 				Path     string   `json:"path"`
@@ -881,6 +1267,78 @@ func VerifyFileChecksum(path string, expectedChecksum string, hashType HashType)
 	return actualChecksum == expectedChecksum, nil
 }
 
+// CompareFiles compares two files byte by byte.
+// firstDiffOffset is the offset of the first differing byte, or -1 when the
+// files are equal or their sizes differ (sizes are compared before reading content)
+func CompareFiles(a, b string) (equal bool, firstDiffOffset int64, err error) {
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false, -1, newCompareFilesError(a, err)
+	}
+
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false, -1, newCompareFilesError(b, err)
+	}
+
+	if aInfo.Size() != bInfo.Size() {
+		return false, -1, nil
+	}
+
+	aFile, err := os.Open(a)
+	if err != nil {
+		return false, -1, newCompareFilesError(a, err)
+	}
+	defer aFile.Close()
+
+	bFile, err := os.Open(b)
+	if err != nil {
+		return false, -1, newCompareFilesError(b, err)
+	}
+	defer bFile.Close()
+
+	const bufferSize = 64 * 1024
+	aBuf := make([]byte, bufferSize)
+	bBuf := make([]byte, bufferSize)
+
+	var offset int64
+	for {
+		aRead, aErr := io.ReadFull(aFile, aBuf)
+		bRead, bErr := io.ReadFull(bFile, bBuf)
+
+		if aErr != nil && aErr != io.EOF && aErr != io.ErrUnexpectedEOF {
+			return false, -1, newCompareFilesError(a, aErr)
+		}
+		if bErr != nil && bErr != io.EOF && bErr != io.ErrUnexpectedEOF {
+			return false, -1, newCompareFilesError(b, bErr)
+		}
+
+		if !bytes.Equal(aBuf[:aRead], bBuf[:bRead]) {
+			for i := 0; i < aRead && i < bRead; i++ {
+				if aBuf[i] != bBuf[i] {
+					return false, offset + int64(i), nil
+				}
+			}
+			// One file ended early (changed while comparing)
+			return false, offset + int64(min(aRead, bRead)), nil
+		}
+
+		offset += int64(aRead)
+		if aErr != nil || bErr != nil {
+			return true, -1, nil
+		}
+	}
+}
+
+func newCompareFilesError(path string, err error) error {
+	return ErrCompareFiles.
+		SetError(err).
+		SetData(pathErrorContext{
+			Path:  path,
+			Error: err,
+		})
+}
+
 // CreateZipArchive creates a zip archive from files
 func CreateZipArchive(zipPath string, files []string) error {
 	zipFile, err := os.Create(zipPath)
@@ -956,8 +1414,14 @@ func addFileToZip(zipWriter *zip.Writer, filename string) error {
 	return err
 }
 
-// ExtractZipArchive extracts a zip archive
-func ExtractZipArchive(zipPath, destDir string) error {
+// ExtractZipArchive extracts a zip archive.
+// Entries that would be written outside destDir are rejected
+func ExtractZipArchive(zipPath, destDir string, options ...ExtractOption) error {
+	opts := defaultExtractOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
 	reader, err := zip.OpenReader(zipPath)
 	if err != nil {
 		return ErrDecompress.
@@ -970,14 +1434,27 @@ func ExtractZipArchive(zipPath, destDir string) error {
 	defer reader.Close()
 
 	for _, file := range reader.File {
-		path := filepath.Join(destDir, file.Name)
+		path, err := SafeJoin(destDir, file.Name)
+		if err != nil {
+			return err
+		}
+
+		if len(opts.patterns) > 0 {
+			matched, err := matchZipEntry(file.Name, opts.patterns)
+			if err != nil {
+				return err
+			}
+			if !matched {
+				continue
+			}
+		}
 
 		if file.FileInfo().IsDir() {
 			os.MkdirAll(path, file.Mode())
 			continue
 		}
 
-		if err := extractZipFile(file, path); err != nil {
+		if err := extractZipFile(file, path, opts); err != nil {
 			return err
 		}
 	}
@@ -986,7 +1463,12 @@ func ExtractZipArchive(zipPath, destDir string) error {
 }
 
 // extractZipFile is a helper to extract individual files from zip
-func extractZipFile(file *zip.File, destPath string) error {
+func extractZipFile(file *zip.File, destPath string, opts *extractOptions) error {
+	skip, err := checkExtractTarget(destPath, opts)
+	if err != nil || skip {
+		return err
+	}
+
 	// Create directory if needed
 	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
 		return ErrDecompress.
@@ -1023,8 +1505,95 @@ func extractZipFile(file *zip.File, destPath string) error {
 	return err
 }
 
-// SplitFile splits a large file into smaller chunks
-func SplitFile(path string, chunkSize int64) ([]string, error) {
+// checkExtractTarget applies the overwrite policy to an existing destination file
+func checkExtractTarget(destPath string, opts *extractOptions) (bool, error) {
+	if !FileExist(destPath) {
+		return false, nil
+	}
+
+	switch opts.overwritePolicy {
+	case SkipExisting:
+		return true, nil
+	case FailOnExisting:
+		return false, ErrArchiveFileExists.
+			SetData(pathErrorContext{
+				Path:  destPath,
+				Error: os.ErrExist,
+			})
+	default:
+		return false, nil
+	}
+}
+
+// SafeJoin joins name to base and ensures the result stays inside base,
+// also when symlinks already present below base are resolved
+func SafeJoin(base, name string) (string, error) {
+	name = filepath.FromSlash(name)
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", ErrUnsafeArchivePath.
+			SetData(struct {
+				Base string `json:"base"`
+				Name string `json:"name"`
+			}{
+				Base: base,
+				Name: name,
+			})
+	}
+
+	joined := filepath.Join(base, name)
+	if !isWithin(filepath.Clean(base), joined) || escapesThroughLinks(base, joined) {
+		return "", ErrUnsafeArchivePath.
+			SetData(struct {
+				Base string `json:"base"`
+				Name string `json:"name"`
+			}{
+				Base: base,
+				Name: name,
+			})
+	}
+
+	return joined, nil
+}
+
+// escapesThroughLinks reports whether the existing part of joined, a path below base,
+// resolves outside of base
+func escapesThroughLinks(base, joined string) bool {
+	canonicalBase, err := CanonicalPath(base)
+	if err != nil {
+		return false // nothing exists that could lead outside
+	}
+
+	existing := existingAncestor(joined)
+	if !isWithin(filepath.Clean(base), existing) {
+		return false
+	}
+
+	canonical, err := CanonicalPath(existing)
+	return err == nil && !isWithin(canonicalBase, canonical)
+}
+
+// splitManifestSuffix is appended to the source path to name the split manifest
+const splitManifestSuffix = ".manifest.json"
+
+// SplitFile splits a large file into smaller chunks.
+// With WithSplitManifest a manifest is written to path + ".manifest.json"
+func SplitFile(path string, chunkSize int64, options ...SplitOption) ([]string, error) {
+	opts := defaultSplitOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if chunkSize <= 0 {
+		return nil, ErrStreamOperation.
+			SetData(struct {
+				Path      string `json:"path"`
+				ChunkSize int64  `json:"chunk_size"`
+			}{
+				Path:      path,
+				ChunkSize: chunkSize,
+			})
+	}
+
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, ErrStreamOperation.
@@ -1046,86 +1615,179 @@ func SplitFile(path string, chunkSize int64) ([]string, error) {
 			})
 	}
 
+	manifest := &SplitManifest{
+		Source:    filepath.Base(path),
+		Size:      fileInfo.Size(),
+		ChunkSize: chunkSize,
+		HashType:  opts.hashType,
+	}
+
+	// Cancellation is checked before every read, not only between parts
+	var reader io.Reader = contextReader{ctx: opts.ctx, r: file}
+	var totalHash hash.Hash
+	if opts.manifest {
+		if totalHash, err = newHasher(opts.hashType); err != nil {
+			return nil, err
+		}
+		reader = io.TeeReader(reader, totalHash)
+	}
+
 	var chunks []string
-	buffer := make([]byte, chunkSize)
+	var written int64
 
 	for i := 0; ; i++ {
-		chunkPath := fmt.Sprintf("%s.part%d", path, i)
-		chunkFile, err := os.Create(chunkPath)
-		if err != nil {
-			// Clean up created chunks on error
-			for _, chunk := range chunks {
-				os.Remove(chunk)
-			}
+		if err := opts.ctx.Err(); err != nil {
+			removeFiles(chunks)
 			return nil, ErrStreamOperation.
 				SetError(err).
 				SetData(pathErrorContext{
-					Path:  chunkPath,
+					Path:  path,
 					Error: err,
 				})
 		}
 
-		written := int64(0)
-		for written < chunkSize {
-			toRead := chunkSize - written
-			if toRead > int64(len(buffer)) {
-				toRead = int64(len(buffer))
-			}
+		chunkPath := fmt.Sprintf(opts.namePattern, path, i)
+		part, err := writeSplitPart(reader, chunkPath, chunkSize, opts)
+		if err != nil {
+			removeFiles(chunks)
+			return nil, err
+		}
 
-			n, err := file.Read(buffer[:toRead])
-			if err == io.EOF {
-				if written == 0 {
-					chunkFile.Close()
-					os.Remove(chunkPath)
-					return chunks, nil
-				}
-				break
-			}
-			if err != nil {
-				chunkFile.Close()
-				// Clean up
-				for _, chunk := range chunks {
-					os.Remove(chunk)
-				}
-				return nil, ErrStreamOperation.
-					SetError(err).
-					SetData(pathErrorContext{
-						Path:  path,
-						Error: err,
-					})
-			}
+		if part.Size == 0 {
+			os.Remove(chunkPath)
+			break
+		}
 
-			if _, err := chunkFile.Write(buffer[:n]); err != nil {
-				chunkFile.Close()
-				// Clean up
-				for _, chunk := range chunks {
-					os.Remove(chunk)
-				}
-				return nil, ErrStreamOperation.
-					SetError(err).
-					SetData(pathErrorContext{
-						Path:  chunkPath,
-						Error: err,
-					})
-			}
+		chunks = append(chunks, chunkPath)
+		written += part.Size
 
-			written += int64(n)
+		if relPath, err := filepath.Rel(filepath.Dir(path), chunkPath); err == nil {
+			part.Name = filepath.ToSlash(relPath)
 		}
+		manifest.Parts = append(manifest.Parts, *part)
 
-		chunkFile.Close()
-		chunks = append(chunks, chunkPath)
+		if opts.progressHandler != nil {
+			opts.progressHandler(written, fileInfo.Size(), chunkPath)
+		}
 
-		// Check if we've read the entire file
-		if file, _ := file.Seek(0, 1); file >= fileInfo.Size() {
+		if part.Size < chunkSize {
 			break
 		}
 	}
 
+	if opts.manifest {
+		manifest.Checksum = hex.EncodeToString(totalHash.Sum(nil))
+		if err := writeSplitManifest(path+splitManifestSuffix, manifest); err != nil {
+			removeFiles(chunks)
+			return nil, err
+		}
+	}
+
 	return chunks, nil
 }
 
-// MergeFiles merges multiple files into one
-func MergeFiles(files []string, destPath string) error {
+// contextReader fails reads with the context error once ctx is done
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// writeSplitPart copies up to chunkSize bytes from r into a new part file
+func writeSplitPart(r io.Reader, chunkPath string, chunkSize int64, opts *splitOptions) (*SplitPart, error) {
+	chunkFile, err := os.Create(chunkPath)
+	if err != nil {
+		return nil, ErrStreamOperation.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  chunkPath,
+				Error: err,
+			})
+	}
+
+	var writer io.Writer = chunkFile
+	var partHash hash.Hash
+	if opts.manifest {
+		partHash, _ = newHasher(opts.hashType)
+		writer = io.MultiWriter(chunkFile, partHash)
+	}
+
+	n, err := io.CopyN(writer, r, chunkSize)
+	if err == io.EOF {
+		err = nil // Last (short) part
+	}
+	if closeErr := chunkFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(chunkPath)
+		return nil, ErrStreamOperation.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  chunkPath,
+				Error: err,
+			})
+	}
+
+	part := &SplitPart{
+		Name: chunkPath,
+		Size: n,
+	}
+	if partHash != nil {
+		part.Checksum = hex.EncodeToString(partHash.Sum(nil))
+	}
+
+	return part, nil
+}
+
+// writeSplitManifest stores the split manifest as JSON
+func writeSplitManifest(path string, manifest *SplitManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return ErrStreamOperation.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  path,
+				Error: err,
+			})
+	}
+
+	return AtomicWriteFile(path, data, 0644)
+}
+
+// removeFiles removes files ignoring errors, used for cleanup
+func removeFiles(paths []string) {
+	for _, path := range paths {
+		os.Remove(path)
+	}
+}
+
+// MergeFiles merges multiple files into one.
+// With WithMergeManifest parts and the result are verified against a SplitFile manifest
+func MergeFiles(files []string, destPath string, options ...SplitOption) error {
+	opts := defaultSplitOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	var manifest *SplitManifest
+	if opts.manifestPath != "" {
+		var err error
+		if manifest, err = ReadSplitManifest(opts.manifestPath); err != nil {
+			return err
+		}
+
+		if len(manifest.Parts) != len(files) {
+			return newSplitVerificationError(destPath, "parts count mismatch")
+		}
+	}
+
 	destFile, err := os.Create(destPath)
 	if err != nil {
 		return ErrStreamOperation.
@@ -1135,32 +1797,210 @@ func MergeFiles(files []string, destPath string) error {
 				Error: err,
 			})
 	}
-	defer destFile.Close()
 
-	for _, file := range files {
-		srcFile, err := os.Open(file)
-		if err != nil {
+	if err := mergeParts(destFile, files, destPath, manifest, opts); err != nil {
+		destFile.Close()
+		if manifest != nil {
+			os.Remove(destPath)
+		}
+		return err
+	}
+
+	if err := destFile.Sync(); err != nil {
+		destFile.Close()
+		return err
+	}
+
+	return destFile.Close()
+}
+
+// MergeFilesGlob merges all files matching the glob pattern in natural order
+// (part2 before part10)
+func MergeFilesGlob(pattern, destPath string, options ...SplitOption) error {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return ErrInvalidPattern.
+			SetError(err).
+			SetData(struct {
+				Pattern string `json:"pattern"`
+				Error   error  `json:"error"`
+			}{
+				Pattern: pattern,
+				Error:   err,
+			})
+	}
+
+	if len(files) == 0 {
+		return ErrStreamOperation.
+			SetData(pathErrorContext{
+				Path:  pattern,
+				Error: os.ErrNotExist,
+			})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return naturalLess(files[i], files[j])
+	})
+
+	return MergeFiles(files, destPath, options...)
+}
+
+// mergeParts appends each part to dest, verifying against the manifest if given
+func mergeParts(dest io.Writer, files []string, destPath string, manifest *SplitManifest, opts *splitOptions) error {
+	var totalSize, written int64
+	if opts.progressHandler != nil {
+		for _, file := range files {
+			if info, err := os.Stat(file); err == nil {
+				totalSize += info.Size()
+			}
+		}
+	}
+
+	var totalHash hash.Hash
+	if manifest != nil {
+		var err error
+		if totalHash, err = newHasher(manifest.HashType); err != nil {
+			return err
+		}
+		dest = io.MultiWriter(dest, totalHash)
+	}
+
+	for i, file := range files {
+		if err := opts.ctx.Err(); err != nil {
 			return ErrStreamOperation.
 				SetError(err).
 				SetData(pathErrorContext{
-					Path:  file,
+					Path:  destPath,
 					Error: err,
 				})
 		}
 
-		if _, err := io.Copy(destFile, srcFile); err != nil {
-			srcFile.Close()
-			return ErrStreamOperation.
-				SetError(err).
-				SetData(moveErrorContext{
-					Source:      file,
-					Destination: destPath,
-					Error:       err,
-				})
+		var expected *SplitPart
+		if manifest != nil {
+			expected = &manifest.Parts[i]
 		}
 
-		srcFile.Close()
+		n, err := mergePart(dest, file, destPath, manifest, expected)
+		if err != nil {
+			return err
+		}
+
+		written += n
+		if opts.progressHandler != nil {
+			opts.progressHandler(written, totalSize, file)
+		}
+	}
+
+	if manifest != nil && hex.EncodeToString(totalHash.Sum(nil)) != manifest.Checksum {
+		return newSplitVerificationError(destPath, "checksum mismatch")
+	}
+
+	return nil
+}
+
+// mergePart appends a single part to dest and verifies it against the expected part
+func mergePart(dest io.Writer, file, destPath string, manifest *SplitManifest, expected *SplitPart) (int64, error) {
+	srcFile, err := os.Open(file)
+	if err != nil {
+		return 0, ErrStreamOperation.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  file,
+				Error: err,
+			})
+	}
+	defer srcFile.Close()
+
+	var partHash hash.Hash
+	if expected != nil {
+		partHash, _ = newHasher(manifest.HashType)
+		dest = io.MultiWriter(dest, partHash)
+	}
+
+	n, err := io.Copy(dest, srcFile)
+	if err != nil {
+		return n, ErrStreamOperation.
+			SetError(err).
+			SetData(moveErrorContext{
+				Source:      file,
+				Destination: destPath,
+				Error:       err,
+			})
+	}
+
+	if expected != nil {
+		if n != expected.Size || hex.EncodeToString(partHash.Sum(nil)) != expected.Checksum {
+			return n, newSplitVerificationError(file, "part mismatch")
+		}
 	}
 
-	return destFile.Sync()
+	return n, nil
+}
+
+// ReadSplitManifest reads a manifest written by SplitFile
+func ReadSplitManifest(path string) (*SplitManifest, error) {
+	data, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest SplitManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, ErrStreamOperation.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  path,
+				Error: err,
+			})
+	}
+
+	return &manifest, nil
+}
+
+func newSplitVerificationError(path, reason string) error {
+	return ErrSplitVerification.
+		SetData(struct {
+			Path   string `json:"path"`
+			Reason string `json:"reason"`
+		}{
+			Path:   path,
+			Reason: reason,
+		})
+}
+
+// naturalLess compares strings so that embedded numbers are ordered numerically
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		aDigits := leadingDigits(a)
+		bDigits := leadingDigits(b)
+
+		if aDigits != "" && bDigits != "" {
+			aNum := strings.TrimLeft(aDigits, "0")
+			bNum := strings.TrimLeft(bDigits, "0")
+			if len(aNum) != len(bNum) {
+				return len(aNum) < len(bNum)
+			}
+			if aNum != bNum {
+				return aNum < bNum
+			}
+			a, b = a[len(aDigits):], b[len(bDigits):]
+			continue
+		}
+
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+
+	return len(a) < len(b)
+}
+
+// leadingDigits returns the run of ASCII digits at the start of s
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
 }
@@ -2,12 +2,18 @@ package fsx
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestAdvancedFileOperations(t *testing.T) {
@@ -49,6 +55,87 @@ func TestAdvancedFileOperations(t *testing.T) {
 		}
 	})
 
+	t.Run("Versioning", func(t *testing.T) {
+		configPath := filepath.Join(tmpDir, "app.conf")
+
+		for i := 1; i <= 4; i++ {
+			content := fmt.Sprintf("v%d", i)
+			if err := AtomicWriteFileString(configPath, content, 0644, WithVersioning(2)); err != nil {
+				t.Fatalf("Failed to write %s: %v", content, err)
+			}
+		}
+
+		versions, err := FileVersions(configPath)
+		if err != nil {
+			t.Fatalf("Failed to list versions: %v", err)
+		}
+		if len(versions) != 2 || versions[0] != configPath+".1" || versions[1] != configPath+".2" {
+			t.Fatalf("Unexpected versions: %v", versions)
+		}
+		if content, _ := ReadFileString(versions[1]); content != "v2" {
+			t.Errorf("Oldest kept version should be v2, got %s", content)
+		}
+
+		if err := RestoreVersion(configPath, 1); err != nil {
+			t.Fatalf("Failed to restore version: %v", err)
+		}
+		if content, _ := ReadFileString(configPath); content != "v3" {
+			t.Errorf("Expected restored v3, got %s", content)
+		}
+
+		if err := RestoreVersion(configPath, 3); !errors.Is(err, ErrFileVersion) {
+			t.Errorf("Expected ErrFileVersion, got %v", err)
+		}
+	})
+
+	t.Run("VersioningKeepsNumberedFiles", func(t *testing.T) {
+		reportPath := filepath.Join(tmpDir, "report")
+		yearPath := reportPath + ".2024"
+		if err := WriteFileString(yearPath, "last year"); err != nil {
+			t.Fatalf("Failed to write %s: %v", yearPath, err)
+		}
+
+		for i := 1; i <= 3; i++ {
+			if err := WriteFileString(reportPath, fmt.Sprintf("v%d", i), WithVersioning(2)); err != nil {
+				t.Fatalf("Failed to write report: %v", err)
+			}
+		}
+
+		if content, _ := ReadFileString(yearPath); content != "last year" {
+			t.Errorf("Expected %s to be left alone, got %q", yearPath, content)
+		}
+		versions, err := FileVersions(reportPath)
+		if err != nil {
+			t.Fatalf("Failed to list versions: %v", err)
+		}
+		if len(versions) != 2 || versions[1] != reportPath+".2" {
+			t.Errorf("Expected only report.1 and report.2 as versions, got %v", versions)
+		}
+	})
+
+	t.Run("TimestampedVersions", func(t *testing.T) {
+		dataPath := filepath.Join(tmpDir, "data.json")
+
+		for i := 1; i <= 4; i++ {
+			content := fmt.Sprintf("v%d", i)
+			if err := WriteFileString(dataPath, content, WithVersioning(2), WithTimestampedVersions()); err != nil {
+				t.Fatalf("Failed to write %s: %v", content, err)
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		versions, err := FileVersions(dataPath)
+		if err != nil {
+			t.Fatalf("Failed to list versions: %v", err)
+		}
+		if len(versions) != 2 {
+			t.Fatalf("Expected 2 versions, got %v", versions)
+		}
+		if content, _ := ReadFileString(versions[0]); content != "v3" {
+			t.Errorf("Newest version should be v3, got %s", content)
+		}
+	})
+
 	t.Run("TempFile", func(t *testing.T) {
 		// Create temp file with content
 		content := []byte("temp content")
@@ -93,6 +180,188 @@ func TestAdvancedFileOperations(t *testing.T) {
 		}
 	})
 
+	t.Run("SecureTemp", func(t *testing.T) {
+		// tmpDir is owner-only, as created by os.MkdirTemp
+		secretDir, err := CreateTempDirectory(tmpDir, "secret-*", WithPrivateParent(), WithTempCleanup())
+		if err != nil {
+			t.Fatalf("Failed to create private temp directory: %v", err)
+		}
+		secret, err := CreateTempFile(secretDir, "key-*", []byte("key"), WithPrivateParent(), WithTempPermissions(0400), WithTempCleanup())
+		if err != nil {
+			t.Fatalf("Failed to create private temp file: %v", err)
+		}
+
+		if runtime.GOOS != "windows" {
+			info, _ := os.Stat(secret)
+			if info.Mode().Perm() != 0400 {
+				t.Errorf("Permission mismatch: got %v, want %v", info.Mode().Perm(), os.FileMode(0400))
+			}
+
+			sharedDir := filepath.Join(tmpDir, "shared")
+			CreateDirectories(sharedDir, WithDirPermissions(0777), WithExactDirPermissions())
+			if _, err := CreateTempFile(sharedDir, "key-*", nil, WithPrivateParent()); !errors.Is(err, ErrInsecureTempDir) {
+				t.Errorf("Expected ErrInsecureTempDir for a shared parent, got %v", err)
+			}
+		}
+
+		if err := CleanupTempFiles(); err != nil {
+			t.Fatalf("Failed to clean up temp files: %v", err)
+		}
+		if FileExist(secret) || DirectoryExist(secretDir) {
+			t.Error("Expected registered temp paths to be removed")
+		}
+	})
+
+	t.Run("TempManager", func(t *testing.T) {
+		managerDir := filepath.Join(tmpDir, "managed")
+		if err := CreateDirectories(managerDir); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		// Leftovers of a dead and of a running process, and unrelated files: one without the
+		// marker and one of a manager whose prefix extends ours
+		dead := filepath.Join(managerDir, "upload-fsx99999999-123.tmp")
+		alive := filepath.Join(managerDir, fmt.Sprintf("upload-fsx%d-456.tmp", os.Getppid()))
+		others := []string{
+			filepath.Join(managerDir, "upload-notes.tmp"),
+			filepath.Join(managerDir, "upload-99999999-123.tmp"),
+			filepath.Join(managerDir, "upload-1fsx99999999-123.tmp"),
+		}
+		for _, path := range append([]string{dead, alive}, others...) {
+			if err := WriteFileString(path, "leftover"); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+		}
+
+		// A leftover of another user: its process ID means nothing to us
+		if os.Geteuid() == 0 {
+			foreign := filepath.Join(managerDir, "upload-fsx99999998-123.tmp")
+			if err := WriteFileString(foreign, "leftover"); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+			if err := os.Chown(foreign, 1234, 1234); err != nil {
+				t.Fatalf("Failed to chown %s: %v", foreign, err)
+			}
+			others = append(others, foreign)
+		}
+
+		for _, pattern := range []string{"", "*.tmp", "a/b-*"} {
+			if _, err := NewTempManager(managerDir, pattern); !errors.Is(err, ErrTempFile) {
+				t.Errorf("%q: expected ErrTempFile, got %v", pattern, err)
+			}
+		}
+
+		manager, err := NewTempManager(managerDir, "upload-*.tmp")
+		if err != nil {
+			t.Fatalf("Failed to create temp manager: %v", err)
+		}
+		if !FileExist(dead) {
+			t.Error("Creating a manager should not sweep")
+		}
+		if swept, err := manager.Sweep(); err != nil || len(swept) != 1 || swept[0] != dead {
+			t.Errorf("Expected only the leftover of a dead process to be swept, got %v (%v)", swept, err)
+		}
+		for _, path := range append([]string{alive}, others...) {
+			if !FileExist(path) {
+				t.Errorf("%s should not be swept", filepath.Base(path))
+			}
+		}
+
+		file, removeFile, err := manager.CreateFile([]byte("data"))
+		if err != nil {
+			t.Fatalf("Failed to create temp file: %v", err)
+		}
+		if name := filepath.Base(file); !strings.HasPrefix(name, fmt.Sprintf("upload-fsx%d-", os.Getpid())) || !strings.HasSuffix(name, ".tmp") {
+			t.Errorf("Unexpected temp name: %s", name)
+		}
+		dir, _, err := manager.CreateDirectory()
+		if err != nil {
+			t.Fatalf("Failed to create temp directory: %v", err)
+		}
+
+		if err := removeFile(); err != nil || FileExist(file) {
+			t.Errorf("Cleanup func should remove the file: %v", err)
+		}
+		if paths := manager.Paths(); len(paths) != 1 || paths[0] != dir {
+			t.Errorf("Expected only the directory to be tracked, got %v", paths)
+		}
+
+		// Files of the current process are never swept
+		if swept, err := manager.Sweep(); err != nil || len(swept) != 0 {
+			t.Errorf("Unexpected sweep: %v, %v", swept, err)
+		}
+
+		if err := manager.Cleanup(); err != nil || DirectoryExist(dir) {
+			t.Errorf("Cleanup should remove tracked paths: %v", err)
+		}
+
+		path, cleanup, err := CreateTempFileWithCleanup(managerDir, "single-*", nil)
+		if err != nil {
+			t.Fatalf("Failed to create temp file: %v", err)
+		}
+		if err := cleanup(); err != nil || FileExist(path) {
+			t.Errorf("Cleanup func should remove the file: %v", err)
+		}
+	})
+
+	t.Run("TempJanitor", func(t *testing.T) {
+		janitorDir := filepath.Join(tmpDir, "janitor")
+		old := time.Now().Add(-2 * time.Hour)
+
+		staleFile := filepath.Join(janitorDir, ".tmp-123")
+		staleDir := filepath.Join(janitorDir, "fsx-workspace-1")
+		activeDir := filepath.Join(janitorDir, "fsx-workspace-2")
+		freshFile := filepath.Join(janitorDir, ".reserve-1")
+		custom := filepath.Join(janitorDir, "job-1.part")
+		unrelated := filepath.Join(janitorDir, "notes.txt")
+
+		for _, path := range []string{staleFile, filepath.Join(staleDir, "a"), filepath.Join(activeDir, "a"), freshFile, custom, unrelated} {
+			if err := WriteFileString(path, "x", WithCreateDirs()); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+		}
+		// Everything is old except freshFile and the file inside activeDir
+		for _, path := range []string{staleFile, filepath.Join(staleDir, "a"), staleDir, activeDir, custom, unrelated} {
+			os.Chtimes(path, old, old)
+		}
+
+		var handled []string
+		var mu sync.Mutex
+		janitor, err := StartTempJanitor(janitorDir, time.Hour, time.Hour,
+			WithJanitorPatterns("job-*.part"),
+			WithJanitorHandler(func(path string, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				handled = append(handled, path)
+			}))
+		if err != nil {
+			t.Fatalf("Failed to start janitor: %v", err)
+		}
+		janitor.Stop()
+		janitor.Stop()
+
+		for _, path := range []string{staleFile, staleDir, custom} {
+			if FileExist(path) || DirectoryExist(path) {
+				t.Errorf("Stale entry should be removed: %s", path)
+			}
+		}
+		for _, path := range []string{activeDir, freshFile, unrelated} {
+			if !FileExist(path) && !DirectoryExist(path) {
+				t.Errorf("Entry should be kept: %s", path)
+			}
+		}
+		if len(handled) != 3 {
+			t.Errorf("Expected 3 handled entries, got %v", handled)
+		}
+
+		if _, err := StartTempJanitor(janitorDir, 0, time.Hour); !errors.Is(err, ErrTempJanitor) {
+			t.Errorf("Expected ErrTempJanitor for zero max age, got %v", err)
+		}
+		if _, err := StartTempJanitor(filepath.Join(tmpDir, "missing"), time.Hour, time.Hour); !errors.Is(err, ErrReadDirectory) {
+			t.Errorf("Expected ErrReadDirectory for missing directory, got %v", err)
+		}
+	})
+
 	t.Run("FileLock", func(t *testing.T) {
 		lockPath := filepath.Join(tmpDir, "locked.txt")
 
@@ -241,6 +510,42 @@ func TestAdvancedFileOperations(t *testing.T) {
 		}
 	})
 
+	t.Run("DecompressFileToDirectory", func(t *testing.T) {
+		originalPath := filepath.Join(tmpDir, "original_name.txt")
+		if err := WriteFileString(originalPath, "restore me"); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		if err := os.Chtimes(originalPath, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set times: %v", err)
+		}
+
+		compressedPath := filepath.Join(tmpDir, "renamed.gz")
+		if err := CompressFile(originalPath, compressedPath); err != nil {
+			t.Fatalf("Failed to compress file: %v", err)
+		}
+
+		restoreDir := filepath.Join(tmpDir, "restore_dir")
+		if err := CreateDirectory(restoreDir); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		if err := DecompressFile(compressedPath, restoreDir); err != nil {
+			t.Fatalf("Failed to decompress file: %v", err)
+		}
+
+		restoredPath := filepath.Join(restoreDir, "original_name.txt")
+		info, err := os.Stat(restoredPath)
+		if err != nil {
+			t.Fatalf("Restored file should use original name: %v", err)
+		}
+
+		if !info.ModTime().Equal(modTime) {
+			t.Errorf("Modification time not restored: got %v, want %v", info.ModTime(), modTime)
+		}
+	})
+
 	t.Run("FileChecksum", func(t *testing.T) {
 		path := filepath.Join(tmpDir, "checksum.txt")
 		content := []byte("checksum test content")
@@ -287,6 +592,110 @@ func TestAdvancedFileOperations(t *testing.T) {
 		}
 	})
 
+	t.Run("ExtendedHashTypes", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "extended_hash.txt")
+		if err := CreateFile(path, []byte("abc")); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		expected := map[HashType]string{
+			HashSHA512: "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a" +
+				"2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f",
+			HashBLAKE2b: "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d1" +
+				"7d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923",
+			HashXXH64: "44bc2cf5ad770999",
+			HashCRC32: "352441c2",
+		}
+
+		for hashType, want := range expected {
+			got, err := CalculateFileChecksum(path, hashType)
+			if err != nil {
+				t.Fatalf("Failed to calculate %s: %v", hashType, err)
+			}
+			if got != want {
+				t.Errorf("%s mismatch: got %s, want %s", hashType, got, want)
+			}
+		}
+
+		if _, err := CalculateFileChecksum(path, HashType("unknown")); err == nil {
+			t.Error("Expected error for unknown hash type")
+		}
+	})
+
+	t.Run("ChecksumReaderAndBytes", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "reader_checksum.txt")
+		content := []byte("in-memory payload")
+		if err := CreateFile(path, content); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		fileSum, _ := CalculateFileChecksum(path, HashSHA256)
+
+		readerSum, err := ChecksumReader(bytes.NewReader(content), HashSHA256)
+		if err != nil {
+			t.Fatalf("Failed to checksum reader: %v", err)
+		}
+
+		bytesSum, err := ChecksumBytes(content, HashSHA256)
+		if err != nil {
+			t.Fatalf("Failed to checksum bytes: %v", err)
+		}
+
+		if readerSum != fileSum || bytesSum != fileSum {
+			t.Error("Reader, bytes and file checksums should match")
+		}
+
+		if _, err := ChecksumBytes(content, HashType("unknown")); err == nil {
+			t.Error("Expected error for unknown hash type")
+		}
+	})
+
+	t.Run("ChecksumCache", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "cached.txt")
+		if err := CreateFile(path, []byte("cache me")); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		indexPath := filepath.Join(tmpDir, "cache", "checksums.json")
+		cache, err := NewChecksumCache(indexPath)
+		if err != nil {
+			t.Fatalf("Failed to create cache: %v", err)
+		}
+
+		first, err := CalculateFileChecksum(path, HashSHA256, WithChecksumCache(cache))
+		if err != nil {
+			t.Fatalf("Failed to calculate checksum: %v", err)
+		}
+
+		if err := cache.Save(); err != nil {
+			t.Fatalf("Failed to save cache: %v", err)
+		}
+
+		reloaded, err := NewChecksumCache(indexPath)
+		if err != nil {
+			t.Fatalf("Failed to reload cache: %v", err)
+		}
+		if reloaded.Len() != 1 {
+			t.Fatalf("Expected 1 cached file, got %d", reloaded.Len())
+		}
+
+		// Unchanged file must be served from the cache
+		reloaded.entries[checksumCacheKey(path)].Checksums[HashSHA256] = "cached-value"
+		cached, _ := CalculateFileChecksum(path, HashSHA256, WithChecksumCache(reloaded))
+		if cached != "cached-value" {
+			t.Error("Unchanged file should be served from cache")
+		}
+
+		// Changed size invalidates the entry
+		if err := WriteFileString(path, "changed content"); err != nil {
+			t.Fatalf("Failed to modify file: %v", err)
+		}
+		changed, _ := CalculateFileChecksum(path, HashSHA256, WithChecksumCache(reloaded))
+		if changed == "cached-value" || changed == first {
+			t.Error("Changed file should be rehashed")
+		}
+	})
+
 	t.Run("ZipArchive", func(t *testing.T) {
 		// Create test files
 		file1 := filepath.Join(tmpDir, "zip1.txt")
@@ -377,6 +786,140 @@ func TestAdvancedFileOperations(t *testing.T) {
 		}
 	})
 
+	t.Run("SplitFileWithManifest", func(t *testing.T) {
+		originalPath := filepath.Join(tmpDir, "manifest_split.bin")
+		content := strings.Repeat("0123456789", 25) // 250 bytes
+		if err := WriteFileString(originalPath, content); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		var progressCalls int
+		chunks, err := SplitFile(originalPath, 100,
+			WithSplitNamePattern("%s.%03d"),
+			WithSplitManifest(HashSHA256),
+			WithSplitProgress(func(current, total int64, currentFile string) {
+				progressCalls++
+			}))
+		if err != nil {
+			t.Fatalf("Failed to split file: %v", err)
+		}
+
+		if len(chunks) != 3 {
+			t.Fatalf("Expected 3 chunks, got %d", len(chunks))
+		}
+		if filepath.Base(chunks[2]) != "manifest_split.bin.002" {
+			t.Errorf("Unexpected chunk name: %s", chunks[2])
+		}
+		if progressCalls != 3 {
+			t.Errorf("Expected 3 progress calls, got %d", progressCalls)
+		}
+
+		data, err := ReadFile(originalPath + ".manifest.json")
+		if err != nil {
+			t.Fatalf("Failed to read manifest: %v", err)
+		}
+
+		var manifest SplitManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			t.Fatalf("Failed to parse manifest: %v", err)
+		}
+
+		expectedSum, _ := CalculateFileChecksum(originalPath, HashSHA256)
+		if manifest.Checksum != expectedSum {
+			t.Error("Manifest checksum mismatch")
+		}
+		if len(manifest.Parts) != 3 || manifest.Parts[2].Size != 50 {
+			t.Errorf("Unexpected manifest parts: %+v", manifest.Parts)
+		}
+	})
+
+	t.Run("SplitFileCanceled", func(t *testing.T) {
+		originalPath := filepath.Join(tmpDir, "canceled_split.bin")
+		if err := WriteFileString(originalPath, strings.Repeat("x", 300)); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := SplitFile(originalPath, 100, WithSplitContext(ctx)); err == nil {
+			t.Error("Expected error for canceled context")
+		}
+		if FileExist(originalPath + ".part0") {
+			t.Error("No parts should remain after cancellation")
+		}
+
+		// Parts are copied through a reader that notices cancellation between buffers
+		ctx, cancel = context.WithCancel(context.Background())
+		reader := contextReader{ctx: ctx, r: strings.NewReader("abcdef")}
+		buf := make([]byte, 3)
+		if n, err := reader.Read(buf); n != 3 || err != nil {
+			t.Fatalf("Failed to read before cancellation: %d, %v", n, err)
+		}
+		cancel()
+		if _, err := reader.Read(buf); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled after cancellation, got %v", err)
+		}
+	})
+
+	t.Run("MergeFilesGlobWithManifest", func(t *testing.T) {
+		mergeDir := filepath.Join(tmpDir, "merge_glob")
+		if err := CreateDirectories(mergeDir); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		originalPath := filepath.Join(mergeDir, "data.bin")
+		content := strings.Repeat("abcdefghij", 12) // 12 parts of 10 bytes
+		if err := WriteFileString(originalPath, content); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		if _, err := SplitFile(originalPath, 10, WithSplitManifest(HashSHA256)); err != nil {
+			t.Fatalf("Failed to split file: %v", err)
+		}
+
+		mergedPath := filepath.Join(tmpDir, "merge_glob_result.bin")
+		err := MergeFilesGlob(originalPath+".part*", mergedPath,
+			WithMergeManifest(originalPath+".manifest.json"))
+		if err != nil {
+			t.Fatalf("Failed to merge files: %v", err)
+		}
+
+		mergedContent, _ := ReadFileString(mergedPath)
+		if mergedContent != content {
+			t.Error("Merged content doesn't match original")
+		}
+
+		// Corrupt a part and verify merge fails
+		if err := WriteFileString(originalPath+".part10", "corrupted!"); err != nil {
+			t.Fatalf("Failed to corrupt part: %v", err)
+		}
+
+		err = MergeFilesGlob(originalPath+".part*", mergedPath,
+			WithMergeManifest(originalPath+".manifest.json"))
+		if !errors.Is(err, ErrSplitVerification) {
+			t.Errorf("Expected ErrSplitVerification, got %v", err)
+		}
+		if FileExist(mergedPath) {
+			t.Error("Unverified merge result should be removed")
+		}
+	})
+
+	t.Run("NaturalOrdering", func(t *testing.T) {
+		names := []string{"a.part10", "a.part2", "a.part1", "a.part002x"}
+		sort.Slice(names, func(i, j int) bool {
+			return naturalLess(names[i], names[j])
+		})
+
+		expected := []string{"a.part1", "a.part2", "a.part002x", "a.part10"}
+		for i := range expected {
+			if names[i] != expected[i] {
+				t.Errorf("Unexpected order: %v", names)
+				break
+			}
+		}
+	})
+
 	t.Run("ConcurrentFileLocks", func(t *testing.T) {
 		lockPath := filepath.Join(tmpDir, "concurrent.txt")
 
@@ -418,6 +961,55 @@ func TestAdvancedFileOperations(t *testing.T) {
 		}
 	})
 
+	t.Run("ReserveFile", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "reserved.bin")
+		size := int64(3*1024*1024 + 5)
+
+		// Only one of several concurrent claimants gets the slot
+		var wg sync.WaitGroup
+		results := make(chan error, 8)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results <- ReserveFile(path, size)
+			}()
+		}
+		wg.Wait()
+		close(results)
+
+		claimed := 0
+		for err := range results {
+			switch {
+			case err == nil:
+				claimed++
+			case !errors.Is(err, ErrFileExists):
+				t.Errorf("Expected ErrFileExists, got %v", err)
+			}
+		}
+		if claimed != 1 {
+			t.Errorf("Expected exactly one claim, got %d", claimed)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil || info.Size() != size {
+			t.Fatalf("Expected reserved size %d, got %v, %v", size, info, err)
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm() != 0644 {
+			t.Errorf("Expected mode 0644 like an in-place reservation, got %v", info.Mode().Perm())
+		}
+
+		if err := ReserveFile(filepath.Join(tmpDir, "empty.bin"), 0); err != nil {
+			t.Errorf("Failed to reserve empty file: %v", err)
+		}
+
+		// No temp placeholders are left behind
+		leftovers, _ := filepath.Glob(filepath.Join(tmpDir, ".reserve-*"))
+		if len(leftovers) != 0 {
+			t.Errorf("Temp placeholders left: %v", leftovers)
+		}
+	})
+
 	t.Run("LargeFileStream", func(t *testing.T) {
 		// Create a large file
 		largePath := filepath.Join(tmpDir, "large.txt")
@@ -1,9 +1,13 @@
 package fsx
 
 import (
+	"bytes"
+	"errors"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
 )
 
 func TestFileOperations(t *testing.T) {
@@ -174,6 +178,36 @@ func TestFileOperations(t *testing.T) {
 		}
 	})
 
+	t.Run("ShredFile", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "shred_me.txt")
+		secret := bytes.Repeat([]byte("secret"), 20000)
+		if err := CreateFile(path, secret); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		// A second link keeps the overwritten content visible
+		witness := filepath.Join(tmpDir, "shred_witness.txt")
+		linked := os.Link(path, witness) == nil
+
+		if err := ShredFile(path, 2); err != nil {
+			t.Fatalf("Failed to shred file: %v", err)
+		}
+		if FileExist(path) {
+			t.Error("File should not exist after shredding")
+		}
+
+		if linked {
+			content, _ := ReadFile(witness)
+			if len(content) != len(secret) || bytes.Contains(content, []byte("secret")) {
+				t.Errorf("Expected %d random bytes, got %d bytes still containing the secret", len(secret), len(content))
+			}
+		}
+
+		if err := ShredFile(tmpDir, 1); !errors.Is(err, ErrShredFile) {
+			t.Errorf("Expected ErrShredFile for a directory, got %v", err)
+		}
+	})
+
 	t.Run("FileInfo", func(t *testing.T) {
 		path := filepath.Join(tmpDir, "info.txt")
 		content := []byte("File for info test")
@@ -196,6 +230,22 @@ func TestFileOperations(t *testing.T) {
 		if info.IsDir {
 			t.Error("File should not be directory")
 		}
+
+		stat, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Failed to stat file: %v", err)
+		}
+		if !info.ModifiedAt.Equal(stat.ModTime()) {
+			t.Errorf("ModifiedAt mismatch: got %v, want %v", info.ModifiedAt, stat.ModTime())
+		}
+		if _, ok := fileIdentity(stat); ok {
+			if info.Inode == 0 || info.Links != 1 || info.UID != os.Getuid() {
+				t.Errorf("Expected inode, one link and owner %d, got %+v", os.Getuid(), info)
+			}
+		}
+		if birth, ok := info.BirthTime(); ok && birth.After(time.Now().Add(time.Minute)) {
+			t.Errorf("Unexpected birth time %v", birth)
+		}
 	})
 
 	t.Run("ChangePermissions", func(t *testing.T) {
@@ -218,4 +268,167 @@ func TestFileOperations(t *testing.T) {
 			t.Errorf("Permission mismatch: got %v, want %v", info.Mode.Perm(), newMode)
 		}
 	})
+
+	t.Run("TouchFile", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "touch.txt")
+		accessed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		modified := time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC)
+
+		if err := TouchFile(path, WithNoCreate()); err != nil {
+			t.Fatalf("Failed to touch file: %v", err)
+		}
+		if FileExist(path) {
+			t.Fatal("Expected no file with WithNoCreate")
+		}
+
+		if err := TouchFile(path, WithTimes(accessed, modified)); err != nil {
+			t.Fatalf("Failed to touch file: %v", err)
+		}
+		info, err := GetFileInfo(path)
+		if err != nil {
+			t.Fatalf("Failed to get file info: %v", err)
+		}
+		if !info.ModifiedAt.Equal(modified) {
+			t.Errorf("Expected modification time %v, got %v", modified, info.ModifiedAt)
+		}
+		if !info.AccessedAt.IsZero() && !info.AccessedAt.Equal(accessed) {
+			t.Errorf("Expected access time %v, got %v", accessed, info.AccessedAt)
+		}
+
+		// A zero time leaves the modification time alone
+		if err := SetFileTimes(path, time.Now(), time.Time{}); err != nil {
+			t.Fatalf("Failed to set file times: %v", err)
+		}
+		info, _ = GetFileInfo(path)
+		if !info.ModifiedAt.Equal(modified) {
+			t.Errorf("Expected unchanged modification time %v, got %v", modified, info.ModifiedAt)
+		}
+
+		if err := TouchFile(path, WithNoCreate()); err != nil {
+			t.Fatalf("Failed to touch file: %v", err)
+		}
+		info, _ = GetFileInfo(path)
+		if time.Since(info.ModifiedAt) > time.Minute {
+			t.Errorf("Expected current modification time, got %v", info.ModifiedAt)
+		}
+
+		if err := SetFileTimes(filepath.Join(tmpDir, "missing.txt"), accessed, modified); !errors.Is(err, ErrSetFileTimes) {
+			t.Errorf("Expected ErrSetFileTimes, got %v", err)
+		}
+	})
+
+	t.Run("ExactPermissions", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "exact.txt")
+
+		if err := CreateFile(path, []byte("exact"), WithPermissions(0666), WithExactPermissions()); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		info, _ := os.Stat(path)
+		if info.Mode().Perm() != 0666 {
+			t.Errorf("Permission mismatch: got %v, want %v", info.Mode().Perm(), os.FileMode(0666))
+		}
+
+		if err := WriteFile(path, []byte("rewritten"), WithPermissions(0640), WithExactPermissions()); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		info, _ = os.Stat(path)
+		if info.Mode().Perm() != 0640 {
+			t.Errorf("Permission mismatch: got %v, want %v", info.Mode().Perm(), os.FileMode(0640))
+		}
+	})
+
+	t.Run("FileAttributes", func(t *testing.T) {
+		path := filepath.Join(tmpDir, ".attrs.txt")
+		if err := CreateFile(path, []byte("attrs")); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		if err := SetHidden(path, true); err != nil {
+			t.Fatalf("Failed to set hidden: %v", err)
+		}
+		if err := SetReadOnly(path, true); err != nil {
+			t.Fatalf("Failed to set read-only: %v", err)
+		}
+		attrs, err := GetAttributes(path)
+		if err != nil {
+			t.Fatalf("Failed to get attributes: %v", err)
+		}
+		if !attrs.Hidden || !attrs.ReadOnly {
+			t.Errorf("Expected hidden read-only file, got %+v", attrs)
+		}
+
+		if err := SetReadOnly(path, false); err != nil {
+			t.Fatalf("Failed to clear read-only: %v", err)
+		}
+		if attrs, _ = GetAttributes(path); attrs.ReadOnly {
+			t.Errorf("Expected writable file, got %+v", attrs)
+		}
+		if err := AppendFile(path, []byte(" more")); err != nil {
+			t.Errorf("Failed to write cleared read-only file: %v", err)
+		}
+
+		if _, err := GetAttributes(filepath.Join(tmpDir, "missing.txt")); !errors.Is(err, ErrStatFile) {
+			t.Errorf("Expected ErrStatFile, got %v", err)
+		}
+	})
+
+	t.Run("AccessChecks", func(t *testing.T) {
+		script := filepath.Join(tmpDir, "access.sh")
+		if err := CreateFile(script, []byte("#!/bin/sh\n"), WithPermissions(0755)); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		data := filepath.Join(tmpDir, "access.txt")
+		if err := CreateFile(data, []byte("data"), WithPermissions(0644)); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		if !IsReadable(data) || !IsWritable(data) || !IsReadable(tmpDir) || !IsWritable(tmpDir) {
+			t.Error("Expected readable and writable file and directory")
+		}
+		if runtime.GOOS != "windows" {
+			if !IsExecutable(script) || IsExecutable(data) {
+				t.Error("Expected only the script to be executable")
+			}
+		}
+
+		missing := filepath.Join(tmpDir, "missing.txt")
+		if IsReadable(missing) || IsWritable(missing) || IsExecutable(missing) {
+			t.Error("Expected no access to a missing file")
+		}
+	})
+
+	t.Run("CompareFiles", func(t *testing.T) {
+		base := bytes.Repeat([]byte("0123456789"), 10000) // spans several buffers
+		changed := append([]byte{}, base...)
+		changed[70000] = 'X'
+
+		pathA := filepath.Join(tmpDir, "compare_a.bin")
+		pathB := filepath.Join(tmpDir, "compare_b.bin")
+		pathC := filepath.Join(tmpDir, "compare_c.bin")
+		pathD := filepath.Join(tmpDir, "compare_d.bin")
+		for path, data := range map[string][]byte{pathA: base, pathB: base, pathC: changed, pathD: base[:10]} {
+			if err := CreateFile(path, data); err != nil {
+				t.Fatalf("Failed to create %s: %v", path, err)
+			}
+		}
+
+		equal, offset, err := CompareFiles(pathA, pathB)
+		if err != nil || !equal || offset != -1 {
+			t.Errorf("Identical files: equal=%v offset=%d err=%v", equal, offset, err)
+		}
+
+		equal, offset, err = CompareFiles(pathA, pathC)
+		if err != nil || equal || offset != 70000 {
+			t.Errorf("Changed files: equal=%v offset=%d err=%v", equal, offset, err)
+		}
+
+		equal, offset, err = CompareFiles(pathA, pathD)
+		if err != nil || equal || offset != -1 {
+			t.Errorf("Different sizes: equal=%v offset=%d err=%v", equal, offset, err)
+		}
+
+		if _, _, err := CompareFiles(pathA, filepath.Join(tmpDir, "missing.bin")); err == nil {
+			t.Error("Expected error for missing file")
+		}
+	})
 }
@@ -0,0 +1,244 @@
+package fsx
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// FilenameCase is a naming style applied by NormalizeFilenameCase
+type FilenameCase int
+
+const (
+	// CaseLower lower cases the whole name: "Logo Big.PNG" -> "logo big.png"
+	CaseLower FilenameCase = iota
+	// CaseUpper upper cases the whole name: "readme.md" -> "README.MD"
+	CaseUpper
+	// CaseSnake joins the words of the name with underscores: "LogoBig-v2.PNG" -> "logo_big_v2.png"
+	CaseSnake
+	// CaseKebab joins the words of the name with dashes: "LogoBig_v2.PNG" -> "logo-big-v2.png"
+	CaseKebab
+)
+
+// NormalizeFilenameCase renames the entries below root, recursively, to the given case.
+// Entries whose new names collide with each other or with kept names (e.g. "Logo.png"
+// and "logo.png", which can't both exist on case-insensitive filesystems) are handled
+// by the collision strategy; case-only renames are not collisions in directories probed
+// as case-insensitive. Each depth is renamed with RenameAll, deepest first, and the renamed
+// depths are undone if a later one fails. The applied (or, with WithFilenameCaseDryRun,
+// planned) renames are returned with their final paths
+func NormalizeFilenameCase(root string, style FilenameCase, options ...FilenameCaseOption) ([]RenamePair, error) {
+	opts := defaultFilenameCaseOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if style < CaseLower || style > CaseKebab {
+		return nil, ErrUnsupportedFilenameCase.
+			SetData(struct {
+				Path string       `json:"path"`
+				Case FilenameCase `json:"case"`
+			}{
+				Path: root,
+				Case: style,
+			})
+	}
+	if opts.collision == CollisionOverwrite {
+		err := errors.New("overwriting collisions is not supported")
+		return nil, ErrInvalidRename.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+
+	root = filepath.Clean(root)
+
+	// Candidates by depth; renaming an entry doesn't change the paths of deeper ones
+	// once those are renamed first
+	var levels [][]*renameCandidate
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root || (opts.filesOnly && entry.IsDir()) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		depth := strings.Count(rel, string(filepath.Separator))
+		for len(levels) <= depth {
+			levels = append(levels, nil)
+		}
+
+		name := convertFilenameCase(entry.Name(), style, entry.IsDir())
+		levels[depth] = append(levels[depth], &renameCandidate{
+			path:   path,
+			name:   entry.Name(),
+			target: filepath.Join(filepath.Dir(path), name),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, ErrReadDirectory.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+
+	// Every directory is probed for case sensitivity, so case-only renames are
+	// collisions exactly where the filesystem can't hold both names
+	keys := newRenameKeys()
+	plan := make([][]RenamePair, len(levels))
+	for depth, candidates := range levels {
+		if plan[depth], err = resolveRenameCollisions(candidates, opts.collision, keys); err != nil {
+			return nil, err
+		}
+	}
+
+	renameOptions := []RenameOption{}
+	if opts.dryRun {
+		renameOptions = append(renameOptions, WithRenameDryRun())
+	}
+
+	for depth := len(plan) - 1; depth >= 0; depth-- {
+		if err := RenameAll(plan[depth], renameOptions...); err != nil {
+			if opts.dryRun {
+				return nil, err
+			}
+			if undoErr := undoRenameLevels(plan[depth+1:]); undoErr != nil {
+				return nil, ErrRename.
+					SetError(errors.Join(err, undoErr)).
+					SetData(pathErrorContext{
+						Path:  root,
+						Error: undoErr,
+					})
+			}
+			return nil, err
+		}
+	}
+
+	return finalRenamePaths(root, plan), nil
+}
+
+// undoRenameLevels reverts renamed depths, shallowest first, so the parents of
+// each depth have their original names again
+func undoRenameLevels(levels [][]RenamePair) error {
+	for _, level := range levels {
+		inverse := make([]RenamePair, len(level))
+		for i, pair := range level {
+			inverse[i] = RenamePair{From: pair.To, To: pair.From}
+		}
+
+		if err := RenameAll(inverse); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// finalRenamePaths returns the renames of every depth with destinations inside the
+// renamed parent directories
+func finalRenamePaths(root string, plan [][]RenamePair) []RenamePair {
+	renamed := make(map[string]string)
+
+	var resolve func(path string) string
+	resolve = func(path string) string {
+		if path == root || filepath.Dir(path) == path {
+			return path
+		}
+		if to, ok := renamed[path]; ok {
+			return to
+		}
+		return filepath.Join(resolve(filepath.Dir(path)), filepath.Base(path))
+	}
+
+	var pairs []RenamePair
+	for _, level := range plan {
+		for _, pair := range level {
+			to := filepath.Join(resolve(filepath.Dir(pair.From)), filepath.Base(pair.To))
+			renamed[pair.From] = to
+			pairs = append(pairs, RenamePair{From: pair.From, To: to})
+		}
+	}
+
+	return pairs
+}
+
+// convertFilenameCase returns name in the given case. Snake and kebab case keep
+// leading dots and, for files, the lower cased extension
+func convertFilenameCase(name string, style FilenameCase, dir bool) string {
+	switch style {
+	case CaseLower:
+		return strings.ToLower(name)
+	case CaseUpper:
+		return strings.ToUpper(name)
+	}
+
+	stem := strings.TrimLeft(name, ".")
+	dots := name[:len(name)-len(stem)]
+
+	ext := ""
+	if !dir {
+		ext = filepath.Ext(stem)
+		stem = strings.TrimSuffix(stem, ext)
+	}
+
+	words := filenameWords(stem)
+	if len(words) == 0 {
+		return name
+	}
+
+	separator := "_"
+	if style == CaseKebab {
+		separator = "-"
+	}
+
+	return dots + strings.Join(words, separator) + strings.ToLower(ext)
+}
+
+// filenameWords splits name into lower cased words at separators, lower to upper case
+// changes and the end of upper case runs ("HTMLParser" -> "html", "parser")
+func filenameWords(name string) []string {
+	var (
+		words []string
+		word  []rune
+	)
+
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+
+		if i > 0 && unicode.IsUpper(r) {
+			previous := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextLower) {
+				flush()
+			}
+		}
+
+		word = append(word, r)
+	}
+	flush()
+
+	return words
+}
@@ -0,0 +1,29 @@
+//go:build darwin
+
+package fsx
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// fileBirthTime returns the creation time of a file
+func fileBirthTime(path string, info os.FileInfo) (time.Time, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(stat.Birthtimespec.Sec, stat.Birthtimespec.Nsec), true
+}
+
+// fileAccessTime returns the last access time of a file
+func fileAccessTime(info os.FileInfo) (time.Time, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(stat.Atimespec.Sec, stat.Atimespec.Nsec), true
+}
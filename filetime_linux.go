@@ -0,0 +1,34 @@
+//go:build linux
+
+package fsx
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// fileBirthTime returns the creation time of path from statx, when the filesystem records it
+func fileBirthTime(path string, info os.FileInfo) (time.Time, bool) {
+	var stat unix.Statx_t
+	if err := unix.Statx(unix.AT_FDCWD, path, 0, unix.STATX_BTIME, &stat); err != nil {
+		return time.Time{}, false
+	}
+	if stat.Mask&unix.STATX_BTIME == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(stat.Btime.Sec, int64(stat.Btime.Nsec)), true
+}
+
+// fileAccessTime returns the last access time of a file
+func fileAccessTime(info os.FileInfo) (time.Time, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(int64(stat.Atim.Sec), int64(stat.Atim.Nsec)), true
+}
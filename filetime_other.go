@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !windows
+
+package fsx
+
+import (
+	"os"
+	"time"
+)
+
+// fileBirthTime is not available on this platform
+func fileBirthTime(path string, info os.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
+
+// fileAccessTime is not available on this platform
+func fileAccessTime(info os.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
@@ -0,0 +1,29 @@
+//go:build windows
+
+package fsx
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// fileBirthTime returns the creation time of a file
+func fileBirthTime(path string, info os.FileInfo) (time.Time, bool) {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, data.CreationTime.Nanoseconds()), true
+}
+
+// fileAccessTime returns the last access time of a file
+func fileAccessTime(info os.FileInfo) (time.Time, bool) {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, data.LastAccessTime.Nanoseconds()), true
+}
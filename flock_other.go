@@ -0,0 +1,16 @@
+//go:build !unix && !windows
+
+package fsx
+
+import "os"
+
+// lockHandle does nothing on platforms without file locks; callers
+// only exclude goroutines of the same process then
+func lockHandle(file *os.File) error {
+	return nil
+}
+
+// unlockHandle does nothing on platforms without file locks
+func unlockHandle(file *os.File) error {
+	return nil
+}
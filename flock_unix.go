@@ -0,0 +1,24 @@
+//go:build unix
+
+package fsx
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockHandle takes an exclusive advisory lock on file, blocking until other processes release it
+func lockHandle(file *os.File) error {
+	for {
+		err := unix.Flock(int(file.Fd()), unix.LOCK_EX)
+		if err != unix.EINTR {
+			return err
+		}
+	}
+}
+
+// unlockHandle releases a lock taken by lockHandle
+func unlockHandle(file *os.File) error {
+	return unix.Flock(int(file.Fd()), unix.LOCK_UN)
+}
@@ -0,0 +1,22 @@
+//go:build windows
+
+package fsx
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockHandle takes an exclusive lock on the first byte of file, blocking until
+// other processes release it
+func lockHandle(file *os.File) error {
+	var overlapped windows.Overlapped
+	return windows.LockFileEx(windows.Handle(file.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, &overlapped)
+}
+
+// unlockHandle releases a lock taken by lockHandle
+func unlockHandle(file *os.File) error {
+	var overlapped windows.Overlapped
+	return windows.UnlockFileEx(windows.Handle(file.Fd()), 0, 1, 0, &overlapped)
+}
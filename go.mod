@@ -4,6 +4,12 @@ go 1.23.0
 
 toolchain go1.24.4
 
-require github.com/boostgo/errorx v1.0.2
+require (
+	github.com/boostgo/errorx v1.0.2
+	github.com/cespare/xxhash/v2 v2.3.0
+	golang.org/x/crypto v0.36.0
+	golang.org/x/sys v0.31.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require github.com/boostgo/convert v1.0.2 // indirect
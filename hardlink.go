@@ -0,0 +1,71 @@
+package fsx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// CreateHardlink creates dst as another name of the file src. Both must be on the same
+// filesystem. WithCreateDirs creates missing parent directories
+func CreateHardlink(src, dst string, options ...FileOption) error {
+	opts := defaultFileOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if opts.createDirs {
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return newCreateDirectories(dst, err)
+		}
+	}
+
+	if err := os.Link(src, dst); err != nil {
+		return ErrCreateHardlink.
+			SetError(err).
+			SetData(moveErrorContext{
+				Source:      src,
+				Destination: dst,
+				Error:       err,
+			})
+	}
+
+	return nil
+}
+
+// IsSameFile reports whether two paths name the same file (same device and inode),
+// e.g. hard links of each other. Symlinks are followed
+func IsSameFile(a, b string) (bool, error) {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false, newStatFile(a, err)
+	}
+
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false, newStatFile(b, err)
+	}
+
+	return os.SameFile(infoA, infoB), nil
+}
+
+// LinkCount returns the number of hard links to a file, symlinks are not followed.
+// It fails with ErrLinkCount on platforms without link counts
+func LinkCount(path string) (uint64, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return 0, newStatFile(path, err)
+	}
+
+	links, ok := fileLinkCount(info)
+	if !ok {
+		return 0, ErrLinkCount.
+			SetError(errors.ErrUnsupported).
+			SetData(pathErrorContext{
+				Path:  path,
+				Error: errors.ErrUnsupported,
+			})
+	}
+
+	return links, nil
+}
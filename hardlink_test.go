@@ -0,0 +1,72 @@
+package fsx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHardlinks(t *testing.T) {
+	// Create a temporary directory for tests
+	tmpDir, err := os.MkdirTemp("", "fsx_hardlink_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "original.txt")
+	if err := CreateFile(src, []byte("data")); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	other := filepath.Join(tmpDir, "other.txt")
+	if err := CreateFile(other, []byte("data")); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	dst := filepath.Join(tmpDir, "nested", "linked.txt")
+	if err := CreateHardlink(src, dst, WithCreateDirs()); err != nil {
+		t.Skipf("Hard links not supported: %v", err)
+	}
+
+	t.Run("IsSameFile", func(t *testing.T) {
+		same, err := IsSameFile(src, dst)
+		if err != nil {
+			t.Fatalf("Failed to compare files: %v", err)
+		}
+		if !same {
+			t.Error("Expected hard links to be the same file")
+		}
+
+		same, err = IsSameFile(src, other)
+		if err != nil {
+			t.Fatalf("Failed to compare files: %v", err)
+		}
+		if same {
+			t.Error("Expected files with equal content to be different files")
+		}
+
+		if _, err := IsSameFile(src, filepath.Join(tmpDir, "missing")); !errors.Is(err, ErrStatFile) {
+			t.Errorf("Expected ErrStatFile, got %v", err)
+		}
+	})
+
+	t.Run("LinkCount", func(t *testing.T) {
+		links, err := LinkCount(src)
+		if errors.Is(err, ErrLinkCount) {
+			t.Skipf("Link counts not supported: %v", err)
+		}
+		if err != nil {
+			t.Fatalf("Failed to get link count: %v", err)
+		}
+		if links != 2 {
+			t.Errorf("Expected 2 links, got %d", links)
+		}
+	})
+
+	t.Run("CreateHardlinkExisting", func(t *testing.T) {
+		if err := CreateHardlink(src, other); !errors.Is(err, ErrCreateHardlink) {
+			t.Errorf("Expected ErrCreateHardlink, got %v", err)
+		}
+	})
+}
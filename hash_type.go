@@ -1,10 +1,53 @@
 package fsx
 
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"hash/crc32"
+
+	"github.com/cespare/xxhash/v2"
+	"golang.org/x/crypto/blake2b"
+)
+
 // HashType represents the type of hash algorithm
 type HashType string
 
 const (
-	HashMD5    HashType = "md5"
-	HashSHA1   HashType = "sha1"
-	HashSHA256 HashType = "sha256"
+	HashMD5     HashType = "md5"
+	HashSHA1    HashType = "sha1"
+	HashSHA256  HashType = "sha256"
+	HashSHA512  HashType = "sha512"
+	HashBLAKE2b HashType = "blake2b"
+	HashXXH64   HashType = "xxh64"
+	HashCRC32   HashType = "crc32"
 )
+
+// newHasher creates hash implementation for the given hash type
+func newHasher(hashType HashType) (hash.Hash, error) {
+	switch hashType {
+	case HashMD5:
+		return md5.New(), nil
+	case HashSHA1:
+		return sha1.New(), nil
+	case HashSHA256:
+		return sha256.New(), nil
+	case HashSHA512:
+		return sha512.New(), nil
+	case HashBLAKE2b:
+		return blake2b.New512(nil)
+	case HashXXH64:
+		return xxhash.New(), nil
+	case HashCRC32:
+		return crc32.NewIEEE(), nil
+	default:
+		return nil, ErrChecksum.
+			SetData(struct {
+				HashType HashType `json:"hash_type"`
+			}{
+				HashType: hashType,
+			})
+	}
+}
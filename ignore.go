@@ -0,0 +1,275 @@
+package fsx
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// IgnoreMatcher matches paths against .gitignore style rules: "#" comments,
+// "!" negation, trailing "/" for directories only, leading or inner "/" to anchor
+// a pattern to the directory of its ignore file and "**" for any number of directories.
+// The last matching rule wins and nothing inside an ignored directory can be re-included
+type IgnoreMatcher struct {
+	root  string
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	base     string   // slash-separated directory of the rule relative to root, "" for root
+	segments []string // anchored pattern split by "/"
+	name     string   // unanchored pattern matched against the base name
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// NewIgnoreMatcher creates a matcher for paths under root with optional root-level patterns
+func NewIgnoreMatcher(root string, patterns ...string) *IgnoreMatcher {
+	matcher := &IgnoreMatcher{
+		root: root,
+	}
+	matcher.AddPatterns("", patterns...)
+
+	return matcher
+}
+
+// LoadIgnoreMatcher creates a matcher from ignore files in root, e.g. ".gitignore"
+// and ".fsxignore". Missing files are skipped
+func LoadIgnoreMatcher(root string, names ...string) (*IgnoreMatcher, error) {
+	matcher := NewIgnoreMatcher(root)
+	for _, name := range names {
+		ignorePath := filepath.Join(root, name)
+		if !FileExist(ignorePath) {
+			continue
+		}
+
+		if err := matcher.AddFile(ignorePath); err != nil {
+			return nil, err
+		}
+	}
+
+	return matcher, nil
+}
+
+// AddFile adds rules of an ignore file. Anchored rules are relative to the file's directory
+func (m *IgnoreMatcher) AddFile(ignorePath string) error {
+	file, err := os.Open(ignorePath)
+	if err != nil {
+		return newOpenFileError(ignorePath, err)
+	}
+	defer file.Close()
+
+	base := ""
+	if relDir, err := filepath.Rel(m.root, filepath.Dir(ignorePath)); err == nil && relDir != "." {
+		base = filepath.ToSlash(relDir)
+	}
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return newReadFileLinesError(ignorePath, err)
+	}
+
+	m.AddPatterns(base, patterns...)
+	return nil
+}
+
+// AddPatterns adds rules for the slash-separated directory base relative to root ("" for root)
+func (m *IgnoreMatcher) AddPatterns(base string, patterns ...string) {
+	for _, pattern := range patterns {
+		if rule, ok := parseIgnoreRule(base, pattern); ok {
+			m.rules = append(m.rules, rule)
+		}
+	}
+}
+
+// gitignoreRules loads .gitignore files while a search walks the tree. Rules of a directory
+// are loaded when it is visited, before its entries, so they apply to its whole subtree
+type gitignoreRules struct {
+	mu      sync.Mutex
+	matcher *IgnoreMatcher
+	loaded  map[string]bool
+}
+
+// ignored reports whether a walked path is ignored. The search root is derived from
+// the first path and its depth, rules of the enclosing repository apply as well
+func (g *gitignoreRules) ignored(filePath string, info os.FileInfo, depth int) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.matcher == nil {
+		root := filePath
+		for i := 0; i < depth; i++ {
+			root = filepath.Dir(root)
+		}
+		g.init(root)
+	}
+
+	isDir := info.IsDir()
+	if isDir && depth > 0 && info.Name() == ".git" {
+		return true
+	}
+	if g.matcher.IgnoredPath(filePath, isDir) {
+		return true
+	}
+
+	if isDir {
+		g.load(filePath)
+	}
+	return false
+}
+
+// init creates the matcher at the repository root, the nearest directory containing
+// ".git", or at root, and loads ignore files of directories from there down to root
+func (g *gitignoreRules) init(root string) {
+	g.loaded = make(map[string]bool)
+
+	var chain []string
+	for dir := root; ; dir = filepath.Dir(dir) {
+		chain = append(chain, dir)
+		// ".git" is a directory, or a file in worktrees and submodules
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+
+		if filepath.Dir(dir) == dir {
+			chain = []string{root}
+			break
+		}
+	}
+
+	g.matcher = NewIgnoreMatcher(chain[len(chain)-1])
+	for i := len(chain) - 1; i >= 0; i-- {
+		g.load(chain[i])
+	}
+}
+
+// load adds the .gitignore file of dir once, unreadable files are skipped
+func (g *gitignoreRules) load(dir string) {
+	if g.loaded[dir] {
+		return
+	}
+	g.loaded[dir] = true
+
+	ignorePath := filepath.Join(dir, ".gitignore")
+	if FileExist(ignorePath) {
+		_ = g.matcher.AddFile(ignorePath)
+	}
+}
+
+// parseIgnoreRule parses a single ignore file line
+func parseIgnoreRule(base, line string) (ignoreRule, bool) {
+	rule := ignoreRule{
+		base: base,
+	}
+
+	// Trailing spaces are ignored unless escaped
+	if !strings.HasSuffix(line, "\\ ") {
+		line = strings.TrimRight(line, " \t\r")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule, false
+	}
+
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule, false
+	}
+
+	// gitignore negates character classes with "[!", path.Match with "[^"
+	line = strings.ReplaceAll(line, "[!", "[^")
+
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		rule.segments = strings.Split(strings.TrimPrefix(line, "/"), "/")
+	} else {
+		rule.name = line
+	}
+
+	return rule, true
+}
+
+// Match reports whether a slash-separated path relative to root is ignored
+func (m *IgnoreMatcher) Match(relPath string, isDir bool) bool {
+	relPath = strings.Trim(relPath, "/")
+	if relPath == "" || relPath == "." {
+		return false
+	}
+
+	// A path inside an ignored directory is ignored as well
+	parts := strings.Split(relPath, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.matchRules(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+
+	return m.matchRules(relPath, isDir)
+}
+
+// IgnoredPath reports whether a filesystem path under root is ignored
+func (m *IgnoreMatcher) IgnoredPath(filePath string, isDir bool) bool {
+	relPath, err := filepath.Rel(m.root, filePath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return false
+	}
+
+	return m.Match(filepath.ToSlash(relPath), isDir)
+}
+
+// Filter returns a FilterFunc accepting paths that are not ignored,
+// e.g. for CopyDirectory when the matcher root is the copy source
+func (m *IgnoreMatcher) Filter() FilterFunc {
+	return func(filePath string, info os.FileInfo) bool {
+		return !m.IgnoredPath(filePath, info.IsDir())
+	}
+}
+
+// matchRules applies rules to a single path, the last matching rule wins
+func (m *IgnoreMatcher) matchRules(relPath string, isDir bool) bool {
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.matches(relPath, isDir) {
+			ignored = !rule.negate
+		}
+	}
+
+	return ignored
+}
+
+func (r *ignoreRule) matches(relPath string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	if r.base != "" {
+		if !strings.HasPrefix(relPath, r.base+"/") {
+			return false
+		}
+		relPath = strings.TrimPrefix(relPath, r.base+"/")
+	}
+
+	if r.anchored {
+		return matchGlobSegments(r.segments, strings.Split(relPath, "/"))
+	}
+
+	matched, _ := path.Match(r.name, path.Base(relPath))
+	return matched
+}
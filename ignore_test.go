@@ -0,0 +1,172 @@
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	// Create a temporary directory for tests
+	tmpDir, err := os.MkdirTemp("", "fsx_ignore_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	t.Run("Rules", func(t *testing.T) {
+		matcher := NewIgnoreMatcher(tmpDir,
+			"# comment",
+			"*.log",
+			"!important.log",
+			"build/",
+			"/config.local",
+			"docs/**/*.tmp",
+			"cache",
+			"\\#hash",
+		)
+
+		tests := []struct {
+			path    string
+			isDir   bool
+			ignored bool
+		}{
+			{"app.log", false, true},
+			{"logs/debug.log", false, true},
+			{"important.log", false, false},
+			{"build", true, true},
+			{"build", false, false},
+			{"src/build/out.o", false, true},
+			{"config.local", false, true},
+			{"sub/config.local", false, false},
+			{"docs/a/b/x.tmp", false, true},
+			{"docs/x.tmp", false, true},
+			{"other/x.tmp", false, false},
+			{"cache/item", false, true},
+			{"#hash", false, true},
+			{"main.go", false, false},
+		}
+
+		for _, tt := range tests {
+			if got := matcher.Match(tt.path, tt.isDir); got != tt.ignored {
+				t.Errorf("Match(%q, dir=%v) = %v, want %v", tt.path, tt.isDir, got, tt.ignored)
+			}
+		}
+	})
+
+	t.Run("NoReincludeInsideIgnoredDirectory", func(t *testing.T) {
+		matcher := NewIgnoreMatcher(tmpDir, "vendor/", "!vendor/keep.go")
+		if !matcher.Match("vendor/keep.go", false) {
+			t.Error("Files inside an ignored directory can't be re-included")
+		}
+
+		matcher = NewIgnoreMatcher(tmpDir, "vendor/*", "!vendor/keep.go")
+		if matcher.Match("vendor/keep.go", false) {
+			t.Error("Negation should re-include when only the contents are ignored")
+		}
+	})
+
+	root := filepath.Join(tmpDir, "project")
+	for name, content := range map[string]string{
+		".gitignore":         "*.log\nbin/\n",
+		"sub/.fsxignore":     "/local.txt\n",
+		"main.go":            "package main",
+		"app.log":            "log",
+		"bin/app":            "binary",
+		"sub/local.txt":      "local",
+		"sub/shared.txt":     "shared",
+		"sub/deep/local.txt": "deep",
+	} {
+		if err := CreateFile(filepath.Join(root, name), []byte(content), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	t.Run("CopyAndSearch", func(t *testing.T) {
+		matcher, err := LoadIgnoreMatcher(root, ".gitignore", ".fsxignore")
+		if err != nil {
+			t.Fatalf("Failed to load ignore files: %v", err)
+		}
+		if err := matcher.AddFile(filepath.Join(root, "sub", ".fsxignore")); err != nil {
+			t.Fatalf("Failed to add nested ignore file: %v", err)
+		}
+
+		dst := filepath.Join(tmpDir, "copy")
+		if err := CopyDirectory(root, dst, WithFilter(matcher.Filter())); err != nil {
+			t.Fatalf("Failed to copy directory: %v", err)
+		}
+
+		expected := map[string]bool{
+			"main.go":            true,
+			"sub/shared.txt":     true,
+			"sub/deep/local.txt": true,
+			"app.log":            false,
+			"bin/app":            false,
+			"sub/local.txt":      false,
+		}
+		for name, copied := range expected {
+			if FileExist(filepath.Join(dst, name)) != copied {
+				t.Errorf("%s: expected copied=%v", name, copied)
+			}
+		}
+
+		results, err := FindFiles(root, "*", WithIgnoreMatcher(matcher), WithIgnoreHidden())
+		if err != nil {
+			t.Fatalf("Failed to find files: %v", err)
+		}
+		if len(results) != 3 {
+			t.Errorf("Expected 3 files not ignored, got %d: %+v", len(results), results)
+		}
+	})
+
+	t.Run("RespectGitignore", func(t *testing.T) {
+		repo := filepath.Join(tmpDir, "repo")
+		files := map[string]string{
+			".git/HEAD":                 "ref: refs/heads/main\n",
+			".gitignore":                "node_modules/\n*.log\n",
+			"main.go":                   "package main\n",
+			"app.log":                   "log\n",
+			"node_modules/pkg/index.js": "module\n",
+			"sub/.gitignore":            "generated.go\n",
+			"sub/generated.go":          "package sub\n",
+			"sub/keep.go":               "package sub\n",
+			"sub/debug.log":             "log\n",
+		}
+		for name, content := range files {
+			path := filepath.Join(repo, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatalf("Failed to create directory: %v", err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to create file: %v", err)
+			}
+		}
+
+		for _, workers := range []int{0, 4} {
+			results, err := FindFiles(repo, "*", WithRespectGitignore(), WithSearchWorkers(workers), WithSortResults(SortByPath, true))
+			if err != nil {
+				t.Fatalf("Failed to find files: %v", err)
+			}
+
+			var found []string
+			for _, result := range results {
+				rel, _ := filepath.Rel(repo, result.Path)
+				found = append(found, filepath.ToSlash(rel))
+			}
+			expected := []string{".gitignore", "main.go", "sub/.gitignore", "sub/keep.go"}
+			if !reflect.DeepEqual(found, expected) {
+				t.Errorf("Workers %d: expected %v, got %v", workers, expected, found)
+			}
+		}
+
+		// Rules of the enclosing repository apply when searching a subdirectory
+		results, err := FindFiles(filepath.Join(repo, "sub"), "*.log", WithRespectGitignore())
+		if err != nil {
+			t.Fatalf("Failed to find files: %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("Expected ignored logs to be skipped, got %+v", results)
+		}
+	})
+}
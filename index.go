@@ -0,0 +1,282 @@
+package fsx
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Index is a persistent path -> (size, mtime, checksum) index of a directory tree.
+// Refresh only rehashes files whose size or mtime changed since the previous run
+type Index struct {
+	path    string
+	root    string
+	opts    *indexOptions
+	mu      sync.RWMutex
+	entries map[string]IndexEntry
+}
+
+// IndexEntry describes an indexed file by its slash-separated path relative to the root
+type IndexEntry struct {
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mod_time"`
+	Checksum string    `json:"checksum"`
+}
+
+// IndexChanges lists paths changed by a refresh
+type IndexChanges struct {
+	Added     []string `json:"added"`
+	Updated   []string `json:"updated"`
+	Removed   []string `json:"removed"`
+	Unchanged int      `json:"unchanged"`
+}
+
+// indexFile is the on-disk format of an index
+type indexFile struct {
+	Root     string       `json:"root"`
+	HashType HashType     `json:"hash_type"`
+	Entries  []IndexEntry `json:"entries"`
+}
+
+// OpenIndex opens the index of root stored at indexPath. An existing index file is
+// loaded; an empty indexPath creates an in-memory index. Call Refresh to bring it up to date
+func OpenIndex(indexPath, root string, options ...IndexOption) (*Index, error) {
+	opts := defaultIndexOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if _, err := newHasher(opts.hashType); err != nil {
+		return nil, err
+	}
+
+	index := &Index{
+		path:    indexPath,
+		root:    root,
+		opts:    opts,
+		entries: make(map[string]IndexEntry),
+	}
+
+	if indexPath == "" || !FileExist(indexPath) {
+		return index, nil
+	}
+
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		return nil, ErrOpenIndex.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  indexPath,
+				Error: err,
+			})
+	}
+
+	var stored indexFile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, ErrOpenIndex.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  indexPath,
+				Error: err,
+			})
+	}
+
+	// Checksums of another algorithm are useless, start over
+	if stored.HashType != opts.hashType {
+		return index, nil
+	}
+
+	for _, entry := range stored.Entries {
+		index.entries[entry.Path] = entry
+	}
+
+	return index, nil
+}
+
+// Root returns the indexed directory
+func (ix *Index) Root() string {
+	return ix.root
+}
+
+// HashType returns the hash algorithm of stored checksums
+func (ix *Index) HashType() HashType {
+	return ix.opts.hashType
+}
+
+// Refresh walks the root, hashes new and changed files and drops removed ones
+func (ix *Index) Refresh() (*IndexChanges, error) {
+	var indexAbs string
+	if ix.path != "" {
+		indexAbs, _ = filepath.Abs(ix.path)
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	changes := &IndexChanges{
+		Added:   []string{},
+		Updated: []string{},
+		Removed: []string{},
+	}
+	seen := make(map[string]bool, len(ix.entries))
+
+	err := walkTree(ix.root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if filePath == ix.root {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(ix.root, filePath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if matchAnyPattern(relPath, ix.opts.excludePatterns) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		if absPath, _ := filepath.Abs(filePath); indexAbs != "" && absPath == indexAbs {
+			return nil
+		}
+
+		seen[relPath] = true
+
+		entry, exists := ix.entries[relPath]
+		if exists && entry.Size == info.Size() && entry.ModTime.Equal(info.ModTime()) {
+			changes.Unchanged++
+			return nil
+		}
+
+		checksum, err := calculateFileChecksum(filePath, ix.opts.hashType)
+		if err != nil {
+			return err
+		}
+
+		ix.entries[relPath] = IndexEntry{
+			Path:     relPath,
+			Size:     info.Size(),
+			ModTime:  info.ModTime(),
+			Checksum: checksum,
+		}
+
+		if exists {
+			changes.Updated = append(changes.Updated, relPath)
+		} else {
+			changes.Added = append(changes.Added, relPath)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, ErrRefreshIndex.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  ix.root,
+				Error: err,
+			})
+	}
+
+	for relPath := range ix.entries {
+		if !seen[relPath] {
+			delete(ix.entries, relPath)
+			changes.Removed = append(changes.Removed, relPath)
+		}
+	}
+	sort.Strings(changes.Removed)
+
+	return changes, nil
+}
+
+// Get returns the entry of a slash-separated relative path
+func (ix *Index) Get(relPath string) (IndexEntry, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	entry, exists := ix.entries[relPath]
+	return entry, exists
+}
+
+// Entries returns all entries sorted by path
+func (ix *Index) Entries() []IndexEntry {
+	return ix.Query(func(IndexEntry) bool {
+		return true
+	})
+}
+
+// Query returns entries accepted by match, sorted by path
+func (ix *Index) Query(match func(entry IndexEntry) bool) []IndexEntry {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	entries := []IndexEntry{}
+	for _, entry := range ix.entries {
+		if match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+
+	return entries
+}
+
+// FindByChecksum returns entries with the given checksum
+func (ix *Index) FindByChecksum(checksum string) []IndexEntry {
+	return ix.Query(func(entry IndexEntry) bool {
+		return entry.Checksum == checksum
+	})
+}
+
+// Len returns number of indexed files
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	return len(ix.entries)
+}
+
+// Save persists the index atomically. In-memory indexes are not saved
+func (ix *Index) Save() error {
+	if ix.path == "" {
+		return nil
+	}
+
+	stored := indexFile{
+		Root:     ix.root,
+		HashType: ix.opts.hashType,
+		Entries:  ix.Entries(),
+	}
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return ErrSaveIndex.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  ix.path,
+				Error: err,
+			})
+	}
+
+	if err := CreateDirectories(filepath.Dir(ix.path)); err != nil {
+		return err
+	}
+
+	return AtomicWriteFile(ix.path, data, 0644)
+}
@@ -0,0 +1,112 @@
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIndex(t *testing.T) {
+	// Create a temporary directory for tests
+	tmpDir, err := os.MkdirTemp("", "fsx_index_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	root := filepath.Join(tmpDir, "data")
+	files := map[string]string{
+		"a.txt":     "alpha",
+		"b.txt":     "beta",
+		"sub/c.txt": "alpha",
+		"tmp/x.tmp": "scratch",
+	}
+	for name, content := range files {
+		if err := CreateFile(filepath.Join(root, name), []byte(content), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	// The index lives inside the indexed tree and must not index itself
+	indexPath := filepath.Join(root, ".fsx-index.json")
+
+	t.Run("InitialRefresh", func(t *testing.T) {
+		index, err := OpenIndex(indexPath, root, WithIndexExclude("tmp"))
+		if err != nil {
+			t.Fatalf("Failed to open index: %v", err)
+		}
+
+		changes, err := index.Refresh()
+		if err != nil {
+			t.Fatalf("Failed to refresh index: %v", err)
+		}
+		if len(changes.Added) != 3 || index.Len() != 3 {
+			t.Errorf("Expected 3 added files, got %+v", changes)
+		}
+
+		entry, ok := index.Get("a.txt")
+		if !ok {
+			t.Fatal("a.txt should be indexed")
+		}
+		same := index.FindByChecksum(entry.Checksum)
+		if len(same) != 2 || same[0].Path != "a.txt" || same[1].Path != "sub/c.txt" {
+			t.Errorf("Unexpected entries by checksum: %+v", same)
+		}
+
+		if err := index.Save(); err != nil {
+			t.Fatalf("Failed to save index: %v", err)
+		}
+	})
+
+	t.Run("IncrementalRefresh", func(t *testing.T) {
+		future := time.Now().Add(time.Hour)
+		if err := WriteFileString(filepath.Join(root, "b.txt"), "beta v2"); err != nil {
+			t.Fatalf("Failed to modify file: %v", err)
+		}
+		os.Chtimes(filepath.Join(root, "b.txt"), future, future)
+		os.Remove(filepath.Join(root, "sub/c.txt"))
+		CreateFile(filepath.Join(root, "d.txt"), []byte("delta"))
+
+		index, err := OpenIndex(indexPath, root, WithIndexExclude("tmp"))
+		if err != nil {
+			t.Fatalf("Failed to open index: %v", err)
+		}
+		if index.Len() != 3 {
+			t.Fatalf("Expected 3 loaded entries, got %d", index.Len())
+		}
+
+		changes, err := index.Refresh()
+		if err != nil {
+			t.Fatalf("Failed to refresh index: %v", err)
+		}
+
+		expected := &IndexChanges{
+			Added:     []string{"d.txt"},
+			Updated:   []string{"b.txt"},
+			Removed:   []string{"sub/c.txt"},
+			Unchanged: 1,
+		}
+		if !reflect.DeepEqual(changes, expected) {
+			t.Errorf("Expected %+v, got %+v", expected, changes)
+		}
+
+		large := index.Query(func(entry IndexEntry) bool {
+			return entry.Size > 5
+		})
+		if len(large) != 1 || large[0].Path != "b.txt" {
+			t.Errorf("Unexpected query result: %+v", large)
+		}
+	})
+
+	t.Run("HashTypeChange", func(t *testing.T) {
+		index, err := OpenIndex(indexPath, root, WithIndexHashType(HashXXH64))
+		if err != nil {
+			t.Fatalf("Failed to open index: %v", err)
+		}
+		if index.Len() != 0 {
+			t.Errorf("Index with another hash type must start empty, got %d entries", index.Len())
+		}
+	})
+}
@@ -0,0 +1,276 @@
+package fsx
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// IntegrityBaseline is the recorded state of a monitored directory tree
+type IntegrityBaseline struct {
+	Root      string                    `json:"root"`
+	HashType  HashType                  `json:"hash_type"`
+	CreatedAt time.Time                 `json:"created_at"`
+	Entries   map[string]IntegrityEntry `json:"entries"`
+}
+
+// IntegrityEntry describes a monitored path. Checksum holds the content digest
+// for regular files and the target digest for symlinks
+type IntegrityEntry struct {
+	Size     int64       `json:"size"`
+	Mode     os.FileMode `json:"mode"`
+	Checksum string      `json:"checksum,omitempty"`
+}
+
+// IntegrityChange is a path whose recorded and current state differ
+type IntegrityChange struct {
+	Path     string         `json:"path"`
+	Baseline IntegrityEntry `json:"baseline"`
+	Current  IntegrityEntry `json:"current"`
+}
+
+// IntegrityReport lists changes found since the baseline was recorded
+type IntegrityReport struct {
+	Added             []string          `json:"added"`
+	Removed           []string          `json:"removed"`
+	Modified          []IntegrityChange `json:"modified"`
+	PermissionChanged []IntegrityChange `json:"permission_changed"`
+}
+
+// OK reports whether the tree still matches its baseline
+func (r *IntegrityReport) OK() bool {
+	return len(r.Added) == 0 &&
+		len(r.Removed) == 0 &&
+		len(r.Modified) == 0 &&
+		len(r.PermissionChanged) == 0
+}
+
+// IntegrityMonitor records a baseline of a directory tree and reports
+// additions, removals, content and permission changes against it. Files are always
+// hashed in full: a checksum cache trusts size and modification time, which tampering
+// can restore
+type IntegrityMonitor struct {
+	root     string
+	opts     *integrityOptions
+	baseline *IntegrityBaseline
+}
+
+// NewIntegrityMonitor creates a monitor for root. Call RecordBaseline before Check
+func NewIntegrityMonitor(root string, options ...IntegrityOption) *IntegrityMonitor {
+	opts := defaultIntegrityOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	return &IntegrityMonitor{
+		root: root,
+		opts: opts,
+	}
+}
+
+// LoadIntegrityMonitor creates a monitor from a baseline saved with SaveBaseline.
+// The root and hash type are taken from the baseline
+func LoadIntegrityMonitor(baselinePath string, options ...IntegrityOption) (*IntegrityMonitor, error) {
+	data, err := ReadFile(baselinePath)
+	if err != nil {
+		return nil, ErrIntegrityBaseline.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  baselinePath,
+				Error: err,
+			})
+	}
+
+	var baseline IntegrityBaseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, ErrIntegrityBaseline.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  baselinePath,
+				Error: err,
+			})
+	}
+
+	monitor := NewIntegrityMonitor(baseline.Root, options...)
+	monitor.opts.hashType = baseline.HashType
+	monitor.baseline = &baseline
+
+	return monitor, nil
+}
+
+// Baseline returns the recorded baseline or nil
+func (m *IntegrityMonitor) Baseline() *IntegrityBaseline {
+	return m.baseline
+}
+
+// RecordBaseline scans the tree and stores its current state as the baseline
+func (m *IntegrityMonitor) RecordBaseline() (*IntegrityBaseline, error) {
+	entries, err := m.scan()
+	if err != nil {
+		return nil, ErrIntegrityBaseline.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  m.root,
+				Error: err,
+			})
+	}
+
+	m.baseline = &IntegrityBaseline{
+		Root:      m.root,
+		HashType:  m.opts.hashType,
+		CreatedAt: time.Now(),
+		Entries:   entries,
+	}
+
+	return m.baseline, nil
+}
+
+// SaveBaseline writes the baseline as JSON atomically
+func (m *IntegrityMonitor) SaveBaseline(path string) error {
+	if m.baseline == nil {
+		return ErrIntegrityBaseline.
+			SetData(pathErrorContext{
+				Path: path,
+			})
+	}
+
+	data, err := json.MarshalIndent(m.baseline, "", "  ")
+	if err != nil {
+		return ErrIntegrityBaseline.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  path,
+				Error: err,
+			})
+	}
+
+	return AtomicWriteFile(path, data, 0600)
+}
+
+// Check scans the tree and compares it with the baseline
+func (m *IntegrityMonitor) Check() (*IntegrityReport, error) {
+	if m.baseline == nil {
+		return nil, ErrIntegrityCheck.
+			SetData(pathErrorContext{
+				Path: m.root,
+			})
+	}
+
+	current, err := m.scan()
+	if err != nil {
+		return nil, ErrIntegrityCheck.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  m.root,
+				Error: err,
+			})
+	}
+
+	report := &IntegrityReport{
+		Added:             []string{},
+		Removed:           []string{},
+		Modified:          []IntegrityChange{},
+		PermissionChanged: []IntegrityChange{},
+	}
+
+	for relPath, recorded := range m.baseline.Entries {
+		entry, exists := current[relPath]
+		if !exists {
+			report.Removed = append(report.Removed, relPath)
+			continue
+		}
+
+		change := IntegrityChange{
+			Path:     relPath,
+			Baseline: recorded,
+			Current:  entry,
+		}
+
+		if recorded.Mode.Type() != entry.Mode.Type() ||
+			recorded.Size != entry.Size ||
+			recorded.Checksum != entry.Checksum {
+			report.Modified = append(report.Modified, change)
+		} else if recorded.Mode != entry.Mode {
+			report.PermissionChanged = append(report.PermissionChanged, change)
+		}
+	}
+
+	for relPath := range current {
+		if _, exists := m.baseline.Entries[relPath]; !exists {
+			report.Added = append(report.Added, relPath)
+		}
+	}
+
+	sort.Strings(report.Added)
+	sort.Strings(report.Removed)
+	sort.Slice(report.Modified, func(i, j int) bool {
+		return report.Modified[i].Path < report.Modified[j].Path
+	})
+	sort.Slice(report.PermissionChanged, func(i, j int) bool {
+		return report.PermissionChanged[i].Path < report.PermissionChanged[j].Path
+	})
+
+	return report, nil
+}
+
+// scan walks the tree and records every entry keyed by its slash-separated relative path
+func (m *IntegrityMonitor) scan() (map[string]IntegrityEntry, error) {
+	if _, err := newHasher(m.opts.hashType); err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]IntegrityEntry)
+
+	err := walkTree(m.root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if filePath == m.root {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(m.root, filePath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if matchAnyPattern(relPath, m.opts.excludePatterns) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		entry := IntegrityEntry{
+			Mode: info.Mode(),
+		}
+
+		switch {
+		case info.Mode().IsRegular():
+			entry.Size = info.Size()
+			if entry.Checksum, err = CalculateFileChecksum(filePath, m.opts.hashType); err != nil {
+				return err
+			}
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(filePath)
+			if err != nil {
+				return err
+			}
+			if entry.Checksum, err = ChecksumBytes([]byte(target), m.opts.hashType); err != nil {
+				return err
+			}
+		}
+
+		entries[relPath] = entry
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
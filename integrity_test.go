@@ -0,0 +1,111 @@
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestIntegrityMonitor(t *testing.T) {
+	// Create a temporary directory for tests
+	tmpDir, err := os.MkdirTemp("", "fsx_integrity_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	root := filepath.Join(tmpDir, "etc")
+	files := map[string]string{
+		"passwd":        "root:x:0:0",
+		"hosts":         "127.0.0.1 localhost",
+		"ssh/sshd.conf": "PermitRootLogin no",
+		"cache/state":   "volatile",
+	}
+	for name, content := range files {
+		if err := CreateFile(filepath.Join(root, name), []byte(content), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	baselinePath := filepath.Join(tmpDir, "baseline.json")
+
+	t.Run("RecordBaseline", func(t *testing.T) {
+		monitor := NewIntegrityMonitor(root, WithIntegrityExclude("cache"))
+		baseline, err := monitor.RecordBaseline()
+		if err != nil {
+			t.Fatalf("Failed to record baseline: %v", err)
+		}
+
+		if _, exists := baseline.Entries["cache/state"]; exists {
+			t.Error("Excluded path must not be recorded")
+		}
+		if entry := baseline.Entries["ssh/sshd.conf"]; entry.Checksum == "" || entry.Size != 18 {
+			t.Errorf("Unexpected entry: %+v", entry)
+		}
+
+		report, err := monitor.Check()
+		if err != nil {
+			t.Fatalf("Failed to check: %v", err)
+		}
+		if !report.OK() {
+			t.Errorf("Unchanged tree reported changes: %+v", report)
+		}
+
+		if err := monitor.SaveBaseline(baselinePath); err != nil {
+			t.Fatalf("Failed to save baseline: %v", err)
+		}
+	})
+
+	t.Run("DetectChanges", func(t *testing.T) {
+		if err := WriteFileString(filepath.Join(root, "hosts"), "10.0.0.1 evil"); err != nil {
+			t.Fatalf("Failed to modify file: %v", err)
+		}
+		if err := os.Remove(filepath.Join(root, "passwd")); err != nil {
+			t.Fatalf("Failed to remove file: %v", err)
+		}
+		if err := CreateFile(filepath.Join(root, "ssh/authorized_keys"), []byte("ssh-rsa AAA")); err != nil {
+			t.Fatalf("Failed to add file: %v", err)
+		}
+		if err := WriteFileString(filepath.Join(root, "cache/state"), "changed"); err != nil {
+			t.Fatalf("Failed to modify excluded file: %v", err)
+		}
+		if runtime.GOOS != "windows" {
+			if err := os.Chmod(filepath.Join(root, "ssh/sshd.conf"), 0666); err != nil {
+				t.Fatalf("Failed to chmod: %v", err)
+			}
+		}
+
+		monitor, err := LoadIntegrityMonitor(baselinePath, WithIntegrityExclude("cache"))
+		if err != nil {
+			t.Fatalf("Failed to load baseline: %v", err)
+		}
+
+		report, err := monitor.Check()
+		if err != nil {
+			t.Fatalf("Failed to check: %v", err)
+		}
+
+		if !reflect.DeepEqual(report.Added, []string{"ssh/authorized_keys"}) {
+			t.Errorf("Unexpected added: %v", report.Added)
+		}
+		if !reflect.DeepEqual(report.Removed, []string{"passwd"}) {
+			t.Errorf("Unexpected removed: %v", report.Removed)
+		}
+		if len(report.Modified) != 1 || report.Modified[0].Path != "hosts" {
+			t.Errorf("Unexpected modified: %+v", report.Modified)
+		}
+		if runtime.GOOS != "windows" {
+			if len(report.PermissionChanged) != 1 || report.PermissionChanged[0].Path != "ssh/sshd.conf" {
+				t.Errorf("Unexpected permission changes: %+v", report.PermissionChanged)
+			}
+		}
+	})
+
+	t.Run("CheckWithoutBaseline", func(t *testing.T) {
+		if _, err := NewIntegrityMonitor(root).Check(); err == nil {
+			t.Error("Expected error when checking without a baseline")
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package fsx
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// TempJanitor periodically removes stale temporary files and directories, so
+// leftovers of crashed jobs don't slowly fill the temp directory
+type TempJanitor struct {
+	dir    string
+	maxAge time.Duration
+	opts   *janitorOptions
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// StartTempJanitor sweeps dir (the system temp directory when empty) now and then every
+// interval, removing direct entries matching fsx temp patterns (see WithJanitorPatterns)
+// that weren't modified for maxAge. A directory is stale only when nothing inside it was
+// modified for maxAge. Call Stop to end it
+func StartTempJanitor(dir string, maxAge, interval time.Duration, options ...JanitorOption) (*TempJanitor, error) {
+	opts := defaultJanitorOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	if maxAge <= 0 || interval <= 0 {
+		return nil, ErrTempJanitor.
+			SetData(struct {
+				Dir      string        `json:"dir"`
+				MaxAge   time.Duration `json:"max_age"`
+				Interval time.Duration `json:"interval"`
+			}{
+				Dir:      dir,
+				MaxAge:   maxAge,
+				Interval: interval,
+			})
+	}
+
+	for _, pattern := range opts.patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, ErrTempJanitor.
+				SetError(err).
+				SetData(pathErrorContext{
+					Path:  pattern,
+					Error: err,
+				})
+		}
+	}
+
+	janitor := &TempJanitor{
+		dir:    dir,
+		maxAge: maxAge,
+		opts:   opts,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	if _, err := janitor.Sweep(); err != nil && errors.Is(err, ErrReadDirectory) {
+		return nil, err
+	}
+
+	go janitor.run(interval)
+
+	return janitor, nil
+}
+
+func (j *TempJanitor) run(interval time.Duration) {
+	defer close(j.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-j.stop:
+			return
+		case <-ticker.C:
+		}
+
+		// Failures are reported to the handler and retried next round
+		j.Sweep()
+	}
+}
+
+// Sweep removes the stale entries now and returns them
+func (j *TempJanitor) Sweep() ([]string, error) {
+	entries, err := os.ReadDir(j.dir)
+	if err != nil {
+		return nil, ErrReadDirectory.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  j.dir,
+				Error: err,
+			})
+	}
+
+	cutoff := time.Now().Add(-j.maxAge)
+
+	var (
+		removed []string
+		errs    []error
+	)
+	for _, entry := range entries {
+		if !j.matches(entry.Name()) {
+			continue
+		}
+
+		path := filepath.Join(j.dir, entry.Name())
+		if !staleSince(path, cutoff) {
+			continue
+		}
+
+		err := os.RemoveAll(path)
+		if j.opts.handler != nil {
+			j.opts.handler(path, err)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		removed = append(removed, path)
+	}
+
+	if len(errs) > 0 {
+		return removed, ErrTempJanitor.SetError(errors.Join(errs...))
+	}
+
+	return removed, nil
+}
+
+// Stop ends the janitor and waits for a running sweep to finish. It is safe to call more than once
+func (j *TempJanitor) Stop() {
+	j.stopOnce.Do(func() {
+		close(j.stop)
+	})
+	<-j.done
+}
+
+func (j *TempJanitor) matches(name string) bool {
+	for _, pattern := range j.opts.patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// staleSince reports whether path and, for directories, everything inside it
+// was last modified before cutoff
+func staleSince(path string, cutoff time.Time) bool {
+	stale := true
+	filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		if !info.ModTime().Before(cutoff) {
+			stale = false
+			return filepath.SkipAll
+		}
+
+		return nil
+	})
+
+	return stale
+}
@@ -0,0 +1,337 @@
+package fsx
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// journalHeaderSize is the size of the frame before each record:
+	// the record length and its CRC-32C, both little endian uint32
+	journalHeaderSize = 8
+
+	// maxJournalRecord is the largest record Append accepts
+	maxJournalRecord = 1 << 30
+
+	// journalReadChunk is how much of a record is read at a time
+	journalReadChunk = 1 << 20
+)
+
+var (
+	journalTable = crc32.MakeTable(crc32.Castagnoli)
+
+	// errJournalChecksum marks a record whose content doesn't match its checksum
+	errJournalChecksum = errors.New("record checksum mismatch")
+)
+
+// Journal is an append-only file of checksummed records for write-ahead logging.
+// It is safe for concurrent use within a process
+type Journal struct {
+	mu       sync.Mutex
+	path     string
+	file     *os.File
+	opts     *journalOptions
+	dirty    bool
+	closed   bool
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// OpenJournal opens or creates the journal at path. A record torn by a crash at the
+// end is cut off, as is everything from the first record failing its checksum
+func OpenJournal(path string, options ...JournalOption) (*Journal, error) {
+	opts := defaultJournalOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, newJournalError(path, err)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, newJournalError(path, err)
+	}
+
+	if _, err := recoverJournal(file, 0); err != nil {
+		file.Close()
+		return nil, newJournalError(path, err)
+	}
+
+	journal := &Journal{
+		path: path,
+		file: file,
+		opts: opts,
+	}
+
+	if opts.syncInterval > 0 && !opts.noSync {
+		journal.stop = make(chan struct{})
+		journal.done = make(chan struct{})
+		go journal.syncLoop()
+	}
+
+	return journal, nil
+}
+
+// recoverJournal truncates file after its last valid record, reading records from
+// offset start on, and positions it there. It returns the resulting size. Only torn
+// and corrupt records are cut off; read errors are returned without truncating
+func recoverJournal(file *os.File, start int64) (int64, error) {
+	if _, err := file.Seek(start, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	valid := start
+	reader := bufio.NewReader(file)
+	for {
+		record, err := readJournalRecord(reader)
+		if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, errJournalChecksum) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		valid += journalHeaderSize + int64(len(record))
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if info.Size() != valid {
+		if err := file.Truncate(valid); err != nil {
+			return 0, err
+		}
+	}
+
+	_, err = file.Seek(valid, io.SeekStart)
+	return valid, err
+}
+
+// Append writes record to the end of the journal and, unless a sync interval or
+// no-sync is configured, syncs it to disk before returning
+func (j *Journal) Append(record []byte) error {
+	frame, err := encodeJournalRecord(record)
+	if err != nil {
+		return newJournalAppendError(j.path, err)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return ErrJournalClosed.
+			SetData(pathErrorContext{
+				Path:  j.path,
+				Error: nil,
+			})
+	}
+
+	offset, err := j.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return newJournalAppendError(j.path, err)
+	}
+
+	// A torn frame would hide every later record from recovery, so it is cut off
+	if _, err := j.file.Write(frame); err != nil {
+		if truncErr := j.file.Truncate(offset); truncErr != nil {
+			err = errors.Join(err, truncErr)
+		} else if _, seekErr := j.file.Seek(offset, io.SeekStart); seekErr != nil {
+			err = errors.Join(err, seekErr)
+		}
+		return newJournalAppendError(j.path, err)
+	}
+
+	if j.opts.noSync || j.opts.syncInterval > 0 {
+		j.dirty = true
+		return nil
+	}
+
+	if err := j.file.Sync(); err != nil {
+		return newJournalAppendError(j.path, err)
+	}
+
+	return nil
+}
+
+// Sync writes appended records to disk
+func (j *Journal) Sync() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.syncLocked()
+}
+
+func (j *Journal) syncLocked() error {
+	if j.closed || !j.dirty {
+		return nil
+	}
+
+	if err := j.file.Sync(); err != nil {
+		return newJournalAppendError(j.path, err)
+	}
+	j.dirty = false
+
+	return nil
+}
+
+// syncLoop syncs the journal every sync interval until Close
+func (j *Journal) syncLoop() {
+	defer close(j.done)
+
+	ticker := time.NewTicker(j.opts.syncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-j.stop:
+			return
+		case <-ticker.C:
+			_ = j.Sync()
+		}
+	}
+}
+
+// Replay iterates over the records in the journal, see ReplayJournal
+func (j *Journal) Replay() iter.Seq2[[]byte, error] {
+	return ReplayJournal(j.path)
+}
+
+// Close syncs and closes the journal
+func (j *Journal) Close() error {
+	if j.stop != nil {
+		j.stopOnce.Do(func() {
+			close(j.stop)
+			<-j.done
+		})
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return nil
+	}
+
+	err := j.syncLocked()
+	j.closed = true
+	if closeErr := j.file.Close(); err == nil && closeErr != nil {
+		err = newJournalError(j.path, closeErr)
+	}
+
+	return err
+}
+
+// ReplayJournal iterates over the records of the journal at path in the order they
+// were appended. A record torn at the end is skipped; a record failing its checksum
+// ends the iteration with ErrJournalCorrupt
+func ReplayJournal(path string) iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		file, err := os.Open(path)
+		if err != nil {
+			yield(nil, newJournalError(path, err))
+			return
+		}
+		defer file.Close()
+
+		reader := bufio.NewReader(file)
+		for {
+			record, err := readJournalRecord(reader)
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return
+			}
+			if err != nil {
+				yield(nil, ErrJournalCorrupt.
+					SetError(err).
+					SetData(pathErrorContext{
+						Path:  path,
+						Error: err,
+					}))
+				return
+			}
+
+			if !yield(record, nil) {
+				return
+			}
+		}
+	}
+}
+
+// encodeJournalRecord frames record with its length and checksum
+func encodeJournalRecord(record []byte) ([]byte, error) {
+	if len(record) > maxJournalRecord {
+		return nil, fmt.Errorf("record of %d bytes exceeds %d bytes", len(record), maxJournalRecord)
+	}
+
+	frame := make([]byte, journalHeaderSize, journalHeaderSize+len(record))
+	binary.LittleEndian.PutUint32(frame[0:], uint32(len(record)))
+	binary.LittleEndian.PutUint32(frame[4:], crc32.Checksum(record, journalTable))
+
+	return append(frame, record...), nil
+}
+
+// readJournalRecord reads the next framed record. It returns io.EOF at the end,
+// io.ErrUnexpectedEOF for a torn record and errJournalChecksum for a damaged one
+func readJournalRecord(reader io.Reader) ([]byte, error) {
+	header := make([]byte, journalHeaderSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+
+	length := binary.LittleEndian.Uint32(header[0:])
+	if length > maxJournalRecord {
+		return nil, errJournalChecksum
+	}
+
+	// Grow the record as its bytes arrive, so a torn length can't make it
+	// allocate much more than what is left to read
+	record := make([]byte, 0, min(length, journalReadChunk))
+	for len(record) < int(length) {
+		chunk := min(int(length)-len(record), journalReadChunk)
+		record = slices.Grow(record, chunk)
+		n, err := io.ReadFull(reader, record[len(record):len(record)+chunk])
+		record = record[:len(record)+n]
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+
+	if crc32.Checksum(record, journalTable) != binary.LittleEndian.Uint32(header[4:]) {
+		return nil, errJournalChecksum
+	}
+
+	return record, nil
+}
+
+func newJournalError(path string, err error) error {
+	return ErrJournal.
+		SetError(err).
+		SetData(pathErrorContext{
+			Path:  path,
+			Error: err,
+		})
+}
+
+func newJournalAppendError(path string, err error) error {
+	return ErrJournalAppend.
+		SetError(err).
+		SetData(pathErrorContext{
+			Path:  path,
+			Error: err,
+		})
+}
@@ -0,0 +1,172 @@
+package fsx
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestJournal(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "fsx_journal_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	replay := func(t *testing.T, path string) ([]string, error) {
+		t.Helper()
+		var records []string
+		for record, err := range ReplayJournal(path) {
+			if err != nil {
+				return records, err
+			}
+			records = append(records, string(record))
+		}
+		return records, nil
+	}
+
+	t.Run("AppendAndReplay", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "wal", "append.log")
+		journal, err := OpenJournal(path)
+		if err != nil {
+			t.Fatalf("Failed to open journal: %v", err)
+		}
+		for i := 0; i < 3; i++ {
+			if err := journal.Append([]byte(fmt.Sprintf("record-%d", i))); err != nil {
+				t.Fatalf("Failed to append: %v", err)
+			}
+		}
+		if err := journal.Append(nil); err != nil {
+			t.Fatalf("Failed to append empty record: %v", err)
+		}
+		journal.Close()
+
+		// Reopening appends after the existing records
+		journal, err = OpenJournal(path, WithJournalSyncInterval(10*time.Millisecond))
+		if err != nil {
+			t.Fatalf("Failed to reopen journal: %v", err)
+		}
+		journal.Append([]byte("record-3"))
+
+		var records []string
+		for record, err := range journal.Replay() {
+			if err != nil {
+				t.Fatalf("Failed to replay: %v", err)
+			}
+			records = append(records, string(record))
+		}
+		if fmt.Sprint(records) != "[record-0 record-1 record-2  record-3]" {
+			t.Errorf("Unexpected records %q", records)
+		}
+
+		if err := journal.Close(); err != nil {
+			t.Fatalf("Failed to close journal: %v", err)
+		}
+		if err := journal.Close(); err != nil {
+			t.Errorf("Expected a second Close to succeed, got %v", err)
+		}
+		if err := journal.Append([]byte("late")); !errors.Is(err, ErrJournalClosed) {
+			t.Errorf("Expected ErrJournalClosed, got %v", err)
+		}
+	})
+
+	t.Run("TornAndCorruptRecords", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "torn.log")
+		journal, err := OpenJournal(path, WithJournalNoSync())
+		if err != nil {
+			t.Fatalf("Failed to open journal: %v", err)
+		}
+		journal.Append([]byte("kept"))
+		journal.Append([]byte("torn record"))
+		journal.Close()
+
+		// Cut the last record in half, as a crash during a write would
+		info, _ := os.Stat(path)
+		os.Truncate(path, info.Size()-4)
+
+		if records, err := replay(t, path); err != nil || fmt.Sprint(records) != "[kept]" {
+			t.Errorf("Expected the torn record to be skipped, got %q (%v)", records, err)
+		}
+
+		journal, err = OpenJournal(path)
+		if err != nil {
+			t.Fatalf("Failed to reopen journal: %v", err)
+		}
+		journal.Append([]byte("after"))
+		journal.Close()
+		if records, err := replay(t, path); err != nil || fmt.Sprint(records) != "[kept after]" {
+			t.Errorf("Expected the torn record to be cut off on open, got %q (%v)", records, err)
+		}
+
+		// A torn header claiming a huge record must not allocate it up front
+		file, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+		header := make([]byte, journalHeaderSize)
+		binary.LittleEndian.PutUint32(header, maxJournalRecord)
+		file.Write(append(header, "partial"...))
+		file.Close()
+
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		records, err := replay(t, path)
+		runtime.ReadMemStats(&after)
+		if err != nil || fmt.Sprint(records) != "[kept after]" {
+			t.Errorf("Expected the torn header to be skipped, got %q (%v)", records, err)
+		}
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 64<<20 {
+			t.Errorf("Expected a small allocation for a torn header, got %d bytes", allocated)
+		}
+
+		// Flip a byte of the first record
+		data, _ := os.ReadFile(path)
+		data[journalHeaderSize] ^= 0xff
+		os.WriteFile(path, data, 0644)
+		if _, err := replay(t, path); !errors.Is(err, ErrJournalCorrupt) {
+			t.Errorf("Expected ErrJournalCorrupt, got %v", err)
+		}
+	})
+
+	t.Run("FailedAppend", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "failed.log")
+		journal, err := OpenJournal(path, WithJournalNoSync())
+		if err != nil {
+			t.Fatalf("Failed to open journal: %v", err)
+		}
+		journal.Append([]byte("first"))
+
+		// Swap in a read-only handle so the next write fails
+		file := journal.file
+		readOnly, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("Failed to open journal file: %v", err)
+		}
+		journal.file = readOnly
+		if err := journal.Append([]byte("lost")); !errors.Is(err, ErrJournalAppend) {
+			t.Errorf("Expected ErrJournalAppend, got %v", err)
+		}
+		readOnly.Close()
+		journal.file = file
+
+		journal.Append([]byte("second"))
+		journal.Close()
+		if records, err := replay(t, path); err != nil || fmt.Sprint(records) != "[first second]" {
+			t.Errorf("Expected appends after a failed one to be kept, got %q (%v)", records, err)
+		}
+	})
+
+	t.Run("RecoverReadError", func(t *testing.T) {
+		dir, err := os.Open(tmpDir)
+		if err != nil {
+			t.Fatalf("Failed to open directory: %v", err)
+		}
+		defer dir.Close()
+
+		if _, err := recoverJournal(dir, 0); err == nil {
+			t.Error("Expected a read error to be returned instead of truncating")
+		}
+	})
+}
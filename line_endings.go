@@ -0,0 +1,139 @@
+package fsx
+
+import (
+	"bytes"
+	"os"
+)
+
+// LineEnding is the line terminator written by NormalizeLineEndings
+type LineEnding int
+
+const (
+	// LineEndingLF terminates lines with "\n"
+	LineEndingLF LineEnding = iota
+	// LineEndingCRLF terminates lines with "\r\n"
+	LineEndingCRLF
+)
+
+// NormalizeLineEndings converts the line endings of the text files below root in place.
+// Files are selected with the search options and sniffed with IsTextFile; binary files
+// and files already using the line ending are left alone. Lone "\r" characters are kept.
+// Files are rewritten atomically keeping their permissions and owner; files with several
+// hard links are rewritten in place so that every link sees the conversion.
+// WithLineEndingDryRun only reports the files that would change
+func NormalizeLineEndings(root string, ending LineEnding, options ...SearchOption) (*LineEndingReport, error) {
+	opts := defaultSearchOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if ending != LineEndingLF && ending != LineEndingCRLF {
+		return nil, ErrNormalizeLineEndings.
+			SetData(struct {
+				Path   string     `json:"path"`
+				Ending LineEnding `json:"ending"`
+			}{
+				Path:   root,
+				Ending: ending,
+			})
+	}
+
+	files, err := FindFilesBy(root, func(path string, info os.FileInfo) bool {
+		return info.Mode().IsRegular()
+	}, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &LineEndingReport{}
+	for _, file := range files {
+		if !IsTextFile(file.Path) {
+			report.Binary++
+			continue
+		}
+
+		data, err := os.ReadFile(file.Path)
+		if err != nil {
+			return report, newReadFileError(file.Path, err)
+		}
+
+		// The sniffer only reads the start of the file
+		if bytes.IndexByte(data, 0) >= 0 {
+			report.Binary++
+			continue
+		}
+		report.Checked++
+
+		converted, lines := convertLineEndings(data, ending)
+		if lines == 0 {
+			continue
+		}
+
+		if !opts.lineEndingDryRun {
+			if err := rewriteTextFile(file.Path, converted, file.Info); err != nil {
+				return report, ErrNormalizeLineEndings.
+					SetError(err).
+					SetData(pathErrorContext{
+						Path:  file.Path,
+						Error: err,
+					})
+			}
+		}
+
+		report.Changed = append(report.Changed, LineEndingChange{
+			Path:  file.Path,
+			Lines: lines,
+		})
+	}
+
+	return report, nil
+}
+
+// rewriteTextFile replaces the content of a file described by info. Hard-linked files are
+// truncated and written in place, others are replaced atomically and get their owner back
+func rewriteTextFile(path string, data []byte, info os.FileInfo) error {
+	if links, ok := fileLinkCount(info); ok && links > 1 {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+		if err != nil {
+			return err
+		}
+		if _, err := file.Write(data); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+
+	if err := AtomicWriteFile(path, data, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return preserveOwnership(path, info)
+}
+
+// convertLineEndings returns data with ending and the number of converted line endings
+func convertLineEndings(data []byte, ending LineEnding) ([]byte, int) {
+	var (
+		out     bytes.Buffer
+		changed int
+	)
+	out.Grow(len(data))
+
+	for i := 0; i < len(data); i++ {
+		switch {
+		case data[i] == '\r' && i+1 < len(data) && data[i+1] == '\n':
+			if ending == LineEndingLF {
+				changed++
+				continue // the '\n' is written next
+			}
+			out.WriteString("\r\n")
+			i++
+			continue
+		case data[i] == '\n' && ending == LineEndingCRLF:
+			out.WriteByte('\r')
+			changed++
+		}
+		out.WriteByte(data[i])
+	}
+
+	return out.Bytes(), changed
+}
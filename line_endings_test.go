@@ -0,0 +1,109 @@
+package fsx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeLineEndings(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "fsx_line_endings_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := map[string]string{
+		"unix.txt":        "a\nb\n",
+		"windows.txt":     "a\r\nb\r\n",
+		"mixed.go":        "a\r\nb\nc\rd",
+		"sub/nested.md":   "x\r\ny",
+		"image.bin":       "\x00\x01\r\n",
+		"vendor/skip.txt": "skip\r\n",
+	}
+	for name, content := range files {
+		if err := WriteFileString(filepath.Join(tmpDir, name), content, WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	expect := func(t *testing.T, name, content string) {
+		t.Helper()
+		if got, _ := ReadFileString(filepath.Join(tmpDir, name)); got != content {
+			t.Errorf("%s: expected %q, got %q", name, content, got)
+		}
+	}
+	search := WithExcludePatterns("vendor")
+
+	t.Run("DryRun", func(t *testing.T) {
+		report, err := NormalizeLineEndings(tmpDir, LineEndingLF, search, WithLineEndingDryRun())
+		if err != nil {
+			t.Fatalf("Failed to normalize line endings: %v", err)
+		}
+		if report.Checked != 4 || report.Binary != 1 || len(report.Changed) != 3 {
+			t.Errorf("Unexpected report: %+v", report)
+		}
+		expect(t, "windows.txt", files["windows.txt"])
+	})
+
+	t.Run("LF", func(t *testing.T) {
+		report, err := NormalizeLineEndings(tmpDir, LineEndingLF, search)
+		if err != nil {
+			t.Fatalf("Failed to normalize line endings: %v", err)
+		}
+		if len(report.Changed) != 3 {
+			t.Errorf("Expected 3 changed files, got %+v", report.Changed)
+		}
+
+		expect(t, "windows.txt", "a\nb\n")
+		expect(t, "mixed.go", "a\nb\nc\rd")
+		expect(t, "sub/nested.md", "x\ny")
+		expect(t, "image.bin", files["image.bin"])
+		expect(t, "vendor/skip.txt", files["vendor/skip.txt"])
+	})
+
+	t.Run("CRLF", func(t *testing.T) {
+		report, err := NormalizeLineEndings(tmpDir, LineEndingCRLF, search)
+		if err != nil {
+			t.Fatalf("Failed to normalize line endings: %v", err)
+		}
+		for _, change := range report.Changed {
+			if filepath.Base(change.Path) == "unix.txt" && change.Lines != 2 {
+				t.Errorf("Expected 2 converted lines, got %d", change.Lines)
+			}
+		}
+
+		expect(t, "unix.txt", "a\r\nb\r\n")
+		expect(t, "mixed.go", "a\r\nb\r\nc\rd")
+
+		again, err := NormalizeLineEndings(tmpDir, LineEndingCRLF, search)
+		if err != nil || len(again.Changed) != 0 {
+			t.Errorf("Expected nothing left to convert: %+v, %v", again, err)
+		}
+	})
+
+	t.Run("Hardlinks", func(t *testing.T) {
+		linkDir := filepath.Join(tmpDir, "links")
+		original := filepath.Join(linkDir, "original.txt")
+		if err := WriteFileString(original, "a\r\nb\r\n", WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		alias := filepath.Join(tmpDir, "alias.txt")
+		if err := os.Link(original, alias); err != nil {
+			t.Skipf("Hard links not supported: %v", err)
+		}
+
+		if _, err := NormalizeLineEndings(linkDir, LineEndingLF); err != nil {
+			t.Fatalf("Failed to normalize line endings: %v", err)
+		}
+		if content, _ := ReadFileString(alias); content != "a\nb\n" {
+			t.Errorf("Expected the conversion to be visible through the other link, got %q", content)
+		}
+	})
+
+	t.Run("InvalidEnding", func(t *testing.T) {
+		if _, err := NormalizeLineEndings(tmpDir, LineEnding(7)); !errors.Is(err, ErrNormalizeLineEndings) {
+			t.Errorf("Expected ErrNormalizeLineEndings, got %v", err)
+		}
+	})
+}
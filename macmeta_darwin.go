@@ -0,0 +1,91 @@
+//go:build darwin
+
+package fsx
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// macXattrPrefix marks extended attributes of Finder and Spotlight metadata
+const macXattrPrefix = "com.apple."
+
+// macSystemXattrs are com.apple.* attributes managed by the system that cannot be copied
+var macSystemXattrs = map[string]bool{
+	"com.apple.rootless":   true,
+	"com.apple.provenance": true,
+}
+
+// copyMacXattrs copies com.apple.* extended attributes (Finder and Spotlight metadata)
+// from src to dst
+func copyMacXattrs(src, dst string) error {
+	names, err := listXattrs(src)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if !strings.HasPrefix(name, macXattrPrefix) || macSystemXattrs[name] {
+			continue
+		}
+
+		value, err := getXattr(src, name)
+		if err != nil {
+			return err
+		}
+		if err := unix.Lsetxattr(dst, name, value, 0); err != nil {
+			return &os.PathError{Op: "setxattr " + name, Path: dst, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// copyMacFlags copies the creation time and the BSD file flags Finder shows as hidden
+// and locked from src to dst. It runs after every other change, since setting the
+// modification time moves the creation time and a locked file rejects changes
+func copyMacFlags(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	if err := setBirthtime(dst, stat.Birthtimespec); err != nil {
+		return err
+	}
+
+	if info.Mode()&os.ModeSymlink == 0 && stat.Flags != 0 {
+		if err := unix.Chflags(dst, int(stat.Flags)); err != nil && !errors.Is(err, unix.EPERM) {
+			return &os.PathError{Op: "chflags", Path: dst, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// setBirthtime sets the creation time of path with setattrlist
+func setBirthtime(path string, birthtime syscall.Timespec) error {
+	attrs := unix.Attrlist{
+		Bitmapcount: unix.ATTR_BIT_MAP_COUNT,
+		Commonattr:  unix.ATTR_CMN_CRTIME,
+	}
+
+	buf := make([]byte, 16)
+	binary.LittleEndian.PutUint64(buf[0:], uint64(birthtime.Sec))
+	binary.LittleEndian.PutUint64(buf[8:], uint64(birthtime.Nsec))
+
+	if err := unix.Setattrlist(path, &attrs, buf, unix.FSOPT_NOFOLLOW); err != nil {
+		return &os.PathError{Op: "setattrlist", Path: path, Err: err}
+	}
+
+	return nil
+}
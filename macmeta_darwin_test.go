@@ -0,0 +1,115 @@
+//go:build darwin
+
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestCopyMacMetadata(t *testing.T) {
+	// Create a temporary directory for tests
+	tmpDir, err := os.MkdirTemp("", "fsx_macmeta_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	srcDir := filepath.Join(tmpDir, "src")
+	srcFile := filepath.Join(srcDir, "file.txt")
+	if err := CreateFile(srcFile, []byte("finder"), WithCreateDirs()); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := unix.Lsetxattr(srcFile, "com.apple.metadata:fsx", []byte("green"), 0); err != nil {
+		t.Skipf("Extended attributes not supported: %v", err)
+	}
+	birthtime := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	if err := setBirthtime(srcFile, syscall.NsecToTimespec(birthtime.UnixNano())); err != nil {
+		t.Fatalf("Failed to set creation time: %v", err)
+	}
+	if err := unix.Chflags(srcFile, unix.UF_HIDDEN); err != nil {
+		t.Fatalf("Failed to set flags: %v", err)
+	}
+
+	statOf := func(path string) *syscall.Stat_t {
+		t.Helper()
+		info, err := os.Lstat(path)
+		if err != nil {
+			t.Fatalf("Failed to stat %s: %v", path, err)
+		}
+		return info.Sys().(*syscall.Stat_t)
+	}
+
+	t.Run("DroppedByDefault", func(t *testing.T) {
+		dstDir := filepath.Join(tmpDir, "plain")
+		if err := CopyDirectory(srcDir, dstDir); err != nil {
+			t.Fatalf("Failed to copy directory: %v", err)
+		}
+
+		copied := filepath.Join(dstDir, "file.txt")
+		if _, err := getXattr(copied, "com.apple.metadata:fsx"); err == nil {
+			t.Error("Mac metadata should only be copied on request")
+		}
+		if statOf(copied).Flags&unix.UF_HIDDEN != 0 {
+			t.Error("Finder flags should only be copied on request")
+		}
+	})
+
+	t.Run("WithPreserveMacMetadata", func(t *testing.T) {
+		dstDir := filepath.Join(tmpDir, "meta")
+		if err := CopyDirectory(srcDir, dstDir, WithPreserveMacMetadata(), WithPreserveTimes(true)); err != nil {
+			t.Fatalf("Failed to copy directory: %v", err)
+		}
+
+		copied := filepath.Join(dstDir, "file.txt")
+		value, err := getXattr(copied, "com.apple.metadata:fsx")
+		if err != nil || string(value) != "green" {
+			t.Errorf("Expected xattr 'green', got %q (%v)", value, err)
+		}
+
+		stat := statOf(copied)
+		if stat.Flags&unix.UF_HIDDEN == 0 {
+			t.Error("Expected the hidden flag to be copied")
+		}
+		// The creation time is set last, so the modification time can't move it
+		if got := time.Unix(stat.Birthtimespec.Unix()); !got.Equal(birthtime) {
+			t.Errorf("Expected creation time %v, got %v", birthtime, got)
+		}
+	})
+
+	t.Run("LockedFile", func(t *testing.T) {
+		// Flags are copied last, a locked copy would reject the other changes
+		lockedDir := filepath.Join(tmpDir, "locked_src")
+		locked := filepath.Join(lockedDir, "locked.txt")
+		if err := CreateFile(locked, []byte("locked"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := unix.Lsetxattr(locked, "com.apple.metadata:fsx", []byte("red"), 0); err != nil {
+			t.Fatalf("Failed to set xattr: %v", err)
+		}
+		if err := unix.Chflags(locked, unix.UF_IMMUTABLE); err != nil {
+			t.Fatalf("Failed to lock file: %v", err)
+		}
+		defer unix.Chflags(locked, 0)
+
+		dstDir := filepath.Join(tmpDir, "locked_dst")
+		copied := filepath.Join(dstDir, "locked.txt")
+		defer unix.Chflags(copied, 0)
+		if err := CopyDirectory(lockedDir, dstDir, WithPreserveMacMetadata(), WithPreserveTimes(true)); err != nil {
+			t.Fatalf("Failed to copy directory: %v", err)
+		}
+
+		value, err := getXattr(copied, "com.apple.metadata:fsx")
+		if err != nil || string(value) != "red" {
+			t.Errorf("Expected xattr 'red', got %q (%v)", value, err)
+		}
+		if statOf(copied).Flags&unix.UF_IMMUTABLE == 0 {
+			t.Error("Expected the locked flag to be copied")
+		}
+	})
+}
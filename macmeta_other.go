@@ -0,0 +1,13 @@
+//go:build !darwin
+
+package fsx
+
+// copyMacXattrs is a no-op on platforms other than macOS
+func copyMacXattrs(src, dst string) error {
+	return nil
+}
+
+// copyMacFlags is a no-op on platforms other than macOS
+func copyMacFlags(src, dst string) error {
+	return nil
+}
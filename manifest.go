@@ -0,0 +1,558 @@
+package fsx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Manifest lists files of a directory tree with their sizes and digests
+type Manifest struct {
+	HashType HashType        `json:"hash_type"`
+	Entries  []ManifestEntry `json:"entries"`
+}
+
+// ManifestEntry describes a single file in a manifest
+type ManifestEntry struct {
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"`
+}
+
+// GenerateManifest walks root and returns a manifest of all regular files
+// sorted by their slash-separated relative path
+func GenerateManifest(root string, hashType HashType, options ...ManifestOption) (*Manifest, error) {
+	opts := defaultManifestOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if _, err := newHasher(hashType); err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{
+		HashType: hashType,
+		Entries:  []ManifestEntry{},
+	}
+
+	err := walkTree(root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if filePath == root {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if matchAnyPattern(relPath, opts.excludePatterns) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		if len(opts.includePatterns) > 0 && !matchAnyPattern(relPath, opts.includePatterns) {
+			return nil
+		}
+
+		checksum, err := CalculateFileChecksum(filePath, hashType, opts.checksumOptions...)
+		if err != nil {
+			return err
+		}
+
+		manifest.Entries = append(manifest.Entries, ManifestEntry{
+			Path:     relPath,
+			Size:     info.Size(),
+			Checksum: checksum,
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, ErrManifest.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+
+	sort.Slice(manifest.Entries, func(i, j int) bool {
+		return manifest.Entries[i].Path < manifest.Entries[j].Path
+	})
+
+	return manifest, nil
+}
+
+// WriteSums writes the manifest in SHA256SUMS style ("<digest>  <path>"),
+// compatible with sha256sum -c and friends
+func (m *Manifest) WriteSums(w io.Writer) error {
+	for _, entry := range m.Entries {
+		if _, err := fmt.Fprintf(w, "%s  %s\n", entry.Checksum, entry.Path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SaveManifest writes the manifest atomically. Paths ending with .json get the
+// JSON format (with sizes), anything else gets the SHA256SUMS style format
+func SaveManifest(manifestPath string, manifest *Manifest) error {
+	var buf bytes.Buffer
+
+	if strings.EqualFold(filepath.Ext(manifestPath), ".json") {
+		encoder := json.NewEncoder(&buf)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(manifest); err != nil {
+			return ErrManifest.
+				SetError(err).
+				SetData(pathErrorContext{
+					Path:  manifestPath,
+					Error: err,
+				})
+		}
+	} else if err := manifest.WriteSums(&buf); err != nil {
+		return ErrManifest.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  manifestPath,
+				Error: err,
+			})
+	}
+
+	return AtomicWriteFile(manifestPath, buf.Bytes(), 0644)
+}
+
+// StreamManifest walks root and writes a JSON line per regular file
+// ({"path","size","checksum"}) to w as it goes, without keeping entries in memory.
+// Entries come in walk order; the output can be read back with ReadManifest
+func StreamManifest(root string, hashType HashType, w io.Writer, options ...ManifestOption) (int, error) {
+	opts := defaultManifestOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if _, err := newHasher(hashType); err != nil {
+		return 0, err
+	}
+
+	writer := bufio.NewWriter(w)
+	encoder := json.NewEncoder(writer)
+	count := 0
+
+	err := filepath.WalkDir(root, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if filePath == root {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if matchAnyPattern(relPath, opts.excludePatterns) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		if len(opts.includePatterns) > 0 && !matchAnyPattern(relPath, opts.includePatterns) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		checksum, err := CalculateFileChecksum(filePath, hashType, opts.checksumOptions...)
+		if err != nil {
+			return err
+		}
+
+		if err := encoder.Encode(ManifestEntry{
+			Path:     relPath,
+			Size:     info.Size(),
+			Checksum: checksum,
+		}); err != nil {
+			return err
+		}
+		count++
+
+		return nil
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		return count, ErrManifest.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+
+	return count, nil
+}
+
+// ReadManifest reads a manifest in JSON, JSON lines (StreamManifest) or SHA256SUMS style format.
+// SUMS manifests carry no sizes (Size is -1). SUMS and JSON lines use hashType as their
+// algorithm; an empty hashType is taken from well-known names (B2SUMS, SHA512SUMS, ...)
+// or detected from the digest length
+func ReadManifest(manifestPath string, hashType HashType) (*Manifest, error) {
+	data, err := ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if hashType == "" {
+		hashType = sumsHashType(manifestPath)
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return readJSONManifest(manifestPath, data, hashType)
+	}
+
+	manifest := &Manifest{
+		HashType: hashType,
+		Entries:  []ManifestEntry{},
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		checksum, filePath, found := strings.Cut(line, " ")
+		if !found {
+			return nil, newReadManifestError(manifestPath, fmt.Errorf("invalid line: %q", line))
+		}
+
+		if manifest.HashType == "" {
+			manifest.HashType = detectHashType(checksum)
+		}
+
+		manifest.Entries = append(manifest.Entries, ManifestEntry{
+			// Binary mode marker ("*path") is accepted as well
+			Path:     strings.TrimPrefix(strings.TrimLeft(filePath, " "), "*"),
+			Size:     -1,
+			Checksum: checksum,
+		})
+	}
+
+	return manifest, nil
+}
+
+// readJSONManifest reads a JSON manifest document or JSON lines written by StreamManifest
+func readJSONManifest(manifestPath string, data []byte, hashType HashType) (*Manifest, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	var first map[string]json.RawMessage
+	if err := decoder.Decode(&first); err != nil {
+		return nil, newReadManifestError(manifestPath, err)
+	}
+
+	if _, isEntry := first["path"]; !isEntry {
+		var manifest Manifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, newReadManifestError(manifestPath, err)
+		}
+		return &manifest, nil
+	}
+
+	manifest := &Manifest{
+		HashType: hashType,
+		Entries:  []ManifestEntry{},
+	}
+
+	decoder = json.NewDecoder(bytes.NewReader(data))
+	for decoder.More() {
+		var entry ManifestEntry
+		if err := decoder.Decode(&entry); err != nil {
+			return nil, newReadManifestError(manifestPath, err)
+		}
+
+		if manifest.HashType == "" {
+			manifest.HashType = detectHashType(entry.Checksum)
+		}
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+
+	return manifest, nil
+}
+
+// ManifestReport is the result of verifying a directory against a manifest
+type ManifestReport struct {
+	Verified  []string
+	Missing   []string
+	Extra     []string
+	Corrupted []string
+}
+
+// OK reports whether the directory matches the manifest exactly
+func (r *ManifestReport) OK() bool {
+	return len(r.Missing) == 0 && len(r.Extra) == 0 && len(r.Corrupted) == 0
+}
+
+// VerifyManifest verifies files under root against a manifest written by SaveManifest.
+// Include/exclude options limit which unlisted files are reported as extra. Entries
+// pointing outside root fail verification with ErrReadManifest
+func VerifyManifest(root, manifestPath string, options ...ManifestOption) (*ManifestReport, error) {
+	opts := defaultManifestOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	manifest, err := ReadManifest(manifestPath, opts.hashType)
+	if err != nil {
+		return nil, err
+	}
+
+	return verifyManifestEntries(root, manifestPath, manifest, opts)
+}
+
+// VerifyDirectoryCopy confirms that every file of src exists in dst with a matching
+// digest, e.g. after a large CopyDirectory. Files only present in dst are reported as extra
+func VerifyDirectoryCopy(src, dst string, hashType HashType, options ...ManifestOption) (*ManifestReport, error) {
+	opts := defaultManifestOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	manifest, err := GenerateManifest(src, hashType, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return verifyManifestEntries(dst, "", manifest, opts)
+}
+
+// verifyManifestEntries checks files under root against manifest entries
+func verifyManifestEntries(root, manifestPath string, manifest *Manifest, opts *manifestOptions) (*ManifestReport, error) {
+	report := &ManifestReport{}
+	listed := make(map[string]bool, len(manifest.Entries))
+
+	for _, entry := range manifest.Entries {
+		listed[entry.Path] = true
+		filePath, err := SafeJoin(root, entry.Path)
+		if err != nil {
+			return nil, newReadManifestError(manifestPath, err)
+		}
+
+		info, err := os.Stat(filePath)
+		if err != nil || !info.Mode().IsRegular() {
+			report.Missing = append(report.Missing, entry.Path)
+			continue
+		}
+
+		if entry.Size >= 0 && info.Size() != entry.Size {
+			report.Corrupted = append(report.Corrupted, entry.Path)
+			continue
+		}
+
+		checksum, err := CalculateFileChecksum(filePath, manifest.HashType, opts.checksumOptions...)
+		if err != nil || checksum != entry.Checksum {
+			report.Corrupted = append(report.Corrupted, entry.Path)
+			continue
+		}
+
+		report.Verified = append(report.Verified, entry.Path)
+	}
+
+	extra, err := collectUnlistedFiles(root, manifestPath, listed, opts)
+	if err != nil {
+		return nil, err
+	}
+	report.Extra = extra
+
+	return report, nil
+}
+
+// collectUnlistedFiles returns files under root that are not listed in the manifest
+func collectUnlistedFiles(root, manifestPath string, listed map[string]bool, opts *manifestOptions) ([]string, error) {
+	var manifestAbs string
+	if manifestPath != "" {
+		manifestAbs, _ = filepath.Abs(manifestPath)
+	}
+
+	var extra []string
+	err := walkTree(root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if filePath == root {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if matchAnyPattern(relPath, opts.excludePatterns) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !info.Mode().IsRegular() || listed[relPath] {
+			return nil
+		}
+
+		if len(opts.includePatterns) > 0 && !matchAnyPattern(relPath, opts.includePatterns) {
+			return nil
+		}
+
+		if absPath, _ := filepath.Abs(filePath); manifestAbs != "" && absPath == manifestAbs {
+			return nil
+		}
+
+		extra = append(extra, relPath)
+		return nil
+	})
+	if err != nil {
+		return nil, ErrManifest.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+
+	return extra, nil
+}
+
+// sumsHashType returns the algorithm of well-known SUMS file names, or "" for others
+func sumsHashType(manifestPath string) HashType {
+	switch strings.ToUpper(filepath.Base(manifestPath)) {
+	case "B2SUMS":
+		return HashBLAKE2b
+	case "SHA512SUMS":
+		return HashSHA512
+	case "SHA256SUMS":
+		return HashSHA256
+	case "SHA1SUMS":
+		return HashSHA1
+	case "MD5SUMS":
+		return HashMD5
+	default:
+		return ""
+	}
+}
+
+// detectHashType guesses hash algorithm from hex digest length. 128 digits may also
+// be BLAKE2b, which needs the name or WithManifestHashType
+func detectHashType(checksum string) HashType {
+	switch len(checksum) {
+	case 8:
+		return HashCRC32
+	case 16:
+		return HashXXH64
+	case 32:
+		return HashMD5
+	case 40:
+		return HashSHA1
+	case 128:
+		return HashSHA512
+	default:
+		return HashSHA256
+	}
+}
+
+func newReadManifestError(path string, err error) error {
+	return ErrReadManifest.
+		SetError(err).
+		SetData(pathErrorContext{
+			Path:  path,
+			Error: err,
+		})
+}
+
+// matchAnyPattern checks slash-separated relative path and its base name against patterns.
+// Patterns use path.Match syntax, and a "**" segment matches any number of directories.
+// This is the syntax the exclude, include and keep options of tree operations refer to
+// as Path Patterns in the README
+func matchAnyPattern(relPath string, patterns []string) bool {
+	name := path.Base(relPath)
+	for _, pattern := range patterns {
+		if matchGlob(pattern, relPath) {
+			return true
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchGlob matches a slash-separated path against a pattern where "**"
+// as a whole segment matches any number of directories
+func matchGlob(pattern, name string) bool {
+	if !strings.Contains(pattern, "**") {
+		matched, _ := path.Match(pattern, name)
+		return matched
+	}
+
+	return matchGlobSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchGlobSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchGlobSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], name[0]); !matched {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
@@ -0,0 +1,258 @@
+package fsx
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestManifestOperations(t *testing.T) {
+	// Create a temporary directory for tests
+	tmpDir, err := os.MkdirTemp("", "fsx_manifest_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	root := filepath.Join(tmpDir, "release")
+	files := map[string]string{
+		"bin/app":         "binary",
+		"README.md":       "readme",
+		"docs/guide.md":   "guide",
+		"logs/debug.log":  "debug",
+		"cache/tmp/a.bin": "cached",
+	}
+	for name, content := range files {
+		if err := CreateFile(filepath.Join(root, name), []byte(content), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	t.Run("GenerateManifest", func(t *testing.T) {
+		manifest, err := GenerateManifest(root, HashSHA256,
+			WithManifestExclude("*.log", "cache"))
+		if err != nil {
+			t.Fatalf("Failed to generate manifest: %v", err)
+		}
+
+		expected := []string{"README.md", "bin/app", "docs/guide.md"}
+		if len(manifest.Entries) != len(expected) {
+			t.Fatalf("Expected %d entries, got %+v", len(expected), manifest.Entries)
+		}
+
+		for i, entry := range manifest.Entries {
+			if entry.Path != expected[i] {
+				t.Errorf("Entry %d: got %s, want %s", i, entry.Path, expected[i])
+			}
+		}
+
+		checksum, _ := CalculateFileChecksum(filepath.Join(root, "bin", "app"), HashSHA256)
+		if manifest.Entries[1].Checksum != checksum || manifest.Entries[1].Size != int64(len("binary")) {
+			t.Errorf("Unexpected entry: %+v", manifest.Entries[1])
+		}
+	})
+
+	t.Run("GenerateManifestInclude", func(t *testing.T) {
+		manifest, err := GenerateManifest(root, HashMD5, WithManifestInclude("*.md"))
+		if err != nil {
+			t.Fatalf("Failed to generate manifest: %v", err)
+		}
+
+		if len(manifest.Entries) != 2 {
+			t.Errorf("Expected 2 markdown entries, got %d", len(manifest.Entries))
+		}
+	})
+
+	t.Run("SaveAndReadManifest", func(t *testing.T) {
+		manifest, err := GenerateManifest(root, HashSHA256)
+		if err != nil {
+			t.Fatalf("Failed to generate manifest: %v", err)
+		}
+
+		for _, name := range []string{"manifest.json", "SHA256SUMS"} {
+			manifestPath := filepath.Join(tmpDir, name)
+			if err := SaveManifest(manifestPath, manifest); err != nil {
+				t.Fatalf("Failed to save %s: %v", name, err)
+			}
+
+			read, err := ReadManifest(manifestPath, HashSHA256)
+			if err != nil {
+				t.Fatalf("Failed to read %s: %v", name, err)
+			}
+
+			if len(read.Entries) != len(manifest.Entries) {
+				t.Fatalf("%s: expected %d entries, got %d", name, len(manifest.Entries), len(read.Entries))
+			}
+
+			for i := range read.Entries {
+				if read.Entries[i].Path != manifest.Entries[i].Path ||
+					read.Entries[i].Checksum != manifest.Entries[i].Checksum {
+					t.Errorf("%s: entry %d mismatch", name, i)
+				}
+			}
+		}
+	})
+
+	t.Run("StreamManifest", func(t *testing.T) {
+		var buf bytes.Buffer
+		count, err := StreamManifest(root, HashSHA256, &buf, WithManifestExclude("*.log", "cache"))
+		if err != nil {
+			t.Fatalf("Failed to stream manifest: %v", err)
+		}
+		if count != 3 || strings.Count(buf.String(), "\n") != 3 {
+			t.Fatalf("Expected 3 records, got %d: %s", count, buf.String())
+		}
+
+		streamPath := filepath.Join(tmpDir, "stream.jsonl")
+		if err := CreateFile(streamPath, buf.Bytes()); err != nil {
+			t.Fatalf("Failed to write stream: %v", err)
+		}
+
+		streamed, err := ReadManifest(streamPath, "")
+		if err != nil {
+			t.Fatalf("Failed to read streamed manifest: %v", err)
+		}
+		generated, err := GenerateManifest(root, HashSHA256, WithManifestExclude("*.log", "cache"))
+		if err != nil {
+			t.Fatalf("Failed to generate manifest: %v", err)
+		}
+
+		sort.Slice(streamed.Entries, func(i, j int) bool {
+			return streamed.Entries[i].Path < streamed.Entries[j].Path
+		})
+		if !reflect.DeepEqual(streamed, generated) {
+			t.Errorf("Streamed manifest differs:\n%+v\n%+v", streamed, generated)
+		}
+	})
+
+	t.Run("VerifyManifest", func(t *testing.T) {
+		verifyRoot := filepath.Join(tmpDir, "deployed")
+		if err := CopyDirectory(root, verifyRoot); err != nil {
+			t.Fatalf("Failed to copy directory: %v", err)
+		}
+
+		manifest, err := GenerateManifest(verifyRoot, HashSHA256)
+		if err != nil {
+			t.Fatalf("Failed to generate manifest: %v", err)
+		}
+
+		manifestPath := filepath.Join(tmpDir, "deployed.sha256")
+		if err := SaveManifest(manifestPath, manifest); err != nil {
+			t.Fatalf("Failed to save manifest: %v", err)
+		}
+
+		report, err := VerifyManifest(verifyRoot, manifestPath)
+		if err != nil {
+			t.Fatalf("Failed to verify manifest: %v", err)
+		}
+		if !report.OK() {
+			t.Fatalf("Fresh copy should verify: %+v", report)
+		}
+
+		// Introduce every kind of discrepancy
+		if err := DeleteFile(filepath.Join(verifyRoot, "README.md")); err != nil {
+			t.Fatalf("Failed to delete file: %v", err)
+		}
+		if err := WriteFileString(filepath.Join(verifyRoot, "bin", "app"), "tampered"); err != nil {
+			t.Fatalf("Failed to modify file: %v", err)
+		}
+		if err := WriteFileString(filepath.Join(verifyRoot, "new.txt"), "extra"); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		report, err = VerifyManifest(verifyRoot, manifestPath)
+		if err != nil {
+			t.Fatalf("Failed to verify manifest: %v", err)
+		}
+
+		if report.OK() {
+			t.Error("Report should not be OK")
+		}
+		if len(report.Missing) != 1 || report.Missing[0] != "README.md" {
+			t.Errorf("Unexpected missing: %v", report.Missing)
+		}
+		if len(report.Corrupted) != 1 || report.Corrupted[0] != "bin/app" {
+			t.Errorf("Unexpected corrupted: %v", report.Corrupted)
+		}
+		if len(report.Extra) != 1 || report.Extra[0] != "new.txt" {
+			t.Errorf("Unexpected extra: %v", report.Extra)
+		}
+	})
+
+	t.Run("VerifyManifestHashType", func(t *testing.T) {
+		manifest, err := GenerateManifest(root, HashBLAKE2b)
+		if err != nil {
+			t.Fatalf("Failed to generate manifest: %v", err)
+		}
+
+		// 128 hex digits are BLAKE2b by name or by option, not SHA-512
+		for name, options := range map[string][]ManifestOption{
+			"B2SUMS":         nil,
+			"release.b2sums": {WithManifestHashType(HashBLAKE2b)},
+		} {
+			manifestPath := filepath.Join(tmpDir, name)
+			if err := SaveManifest(manifestPath, manifest); err != nil {
+				t.Fatalf("Failed to save manifest: %v", err)
+			}
+			report, err := VerifyManifest(root, manifestPath, options...)
+			if err != nil {
+				t.Fatalf("Failed to verify manifest: %v", err)
+			}
+			if len(report.Corrupted) != 0 || len(report.Verified) != len(files) {
+				t.Errorf("%s: unexpected report %+v", name, report)
+			}
+		}
+	})
+
+	t.Run("VerifyManifestEscape", func(t *testing.T) {
+		manifestPath := filepath.Join(tmpDir, "escape.sha256")
+		sums := "0000000000000000000000000000000000000000000000000000000000000000  ../escape.sha256\n"
+		if err := WriteFileString(manifestPath, sums); err != nil {
+			t.Fatalf("Failed to write manifest: %v", err)
+		}
+
+		if _, err := VerifyManifest(root, manifestPath); !errors.Is(err, ErrReadManifest) {
+			t.Errorf("Expected ErrReadManifest for an escaping entry, got %v", err)
+		}
+	})
+
+	t.Run("VerifyDirectoryCopy", func(t *testing.T) {
+		src := filepath.Join(tmpDir, "copy_src")
+		dst := filepath.Join(tmpDir, "copy_dst")
+		for name, content := range map[string]string{"a.txt": "a", "sub/b.txt": "b", "sub/c.txt": "c"} {
+			if err := CreateFile(filepath.Join(src, name), []byte(content), WithCreateDirs()); err != nil {
+				t.Fatalf("Failed to create %s: %v", name, err)
+			}
+		}
+		if err := CopyDirectory(src, dst); err != nil {
+			t.Fatalf("Failed to copy directory: %v", err)
+		}
+
+		report, err := VerifyDirectoryCopy(src, dst, HashSHA256)
+		if err != nil {
+			t.Fatalf("Failed to verify copy: %v", err)
+		}
+		if !report.OK() || len(report.Verified) != 3 {
+			t.Fatalf("Expected a clean copy, got %+v", report)
+		}
+
+		os.Remove(filepath.Join(dst, "a.txt"))
+		WriteFileString(filepath.Join(dst, "sub/b.txt"), "x")
+		WriteFileString(filepath.Join(dst, "extra.txt"), "extra")
+
+		report, err = VerifyDirectoryCopy(src, dst, HashSHA256)
+		if err != nil {
+			t.Fatalf("Failed to verify copy: %v", err)
+		}
+		if !reflect.DeepEqual(report.Missing, []string{"a.txt"}) ||
+			!reflect.DeepEqual(report.Corrupted, []string{"sub/b.txt"}) ||
+			!reflect.DeepEqual(report.Extra, []string{"extra.txt"}) {
+			t.Errorf("Unexpected report: %+v", report)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package fsx
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// sniffLength is the number of leading bytes considered by http.DetectContentType
+	sniffLength = 512
+	// textSniffLength is the number of leading bytes considered by IsTextFile
+	textSniffLength = 8 * 1024
+)
+
+// DetectMIME returns the MIME type of a file detected from its leading bytes, e.g. "image/png"
+// or "text/plain; charset=utf-8". Unrecognized content falls back to the extension
+func DetectMIME(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", newOpenFileError(path, err)
+	}
+	defer file.Close()
+
+	buf := make([]byte, sniffLength)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", newReadFileError(path, err)
+	}
+
+	mimeType := http.DetectContentType(buf[:n])
+	if mimeType == "application/octet-stream" {
+		if byExtension := mime.TypeByExtension(filepath.Ext(path)); byExtension != "" {
+			return byExtension, nil
+		}
+	}
+
+	return mimeType, nil
+}
+
+// MatchMIME reports whether a MIME type matches one of types: a full type ("image/png"),
+// a wildcard ("image/*") or a top-level type ("image", "video", "audio", "text")
+func MatchMIME(mimeType string, types ...string) bool {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		mediaType = strings.ToLower(mimeType)
+	}
+	topLevel, _, _ := strings.Cut(mediaType, "/")
+
+	for _, t := range types {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == mediaType || strings.TrimSuffix(t, "/*") == topLevel {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsTextFile reports whether a file looks like text: its first 8 KiB contain
+// no NUL bytes and are valid UTF-8. Empty files are text, unreadable files are not
+func IsTextFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	buf := make([]byte, textSniffLength)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false
+	}
+	buf = buf[:n]
+
+	if bytes.IndexByte(buf, 0) >= 0 {
+		return false
+	}
+
+	// The sample may end in the middle of a multi-byte character
+	if n == textSniffLength {
+		for i := len(buf) - 1; i >= len(buf)-utf8.UTFMax; i-- {
+			if utf8.RuneStart(buf[i]) {
+				if !utf8.FullRune(buf[i:]) {
+					buf = buf[:i]
+				}
+				break
+			}
+		}
+	}
+
+	return utf8.Valid(buf)
+}
@@ -0,0 +1,233 @@
+package fsx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// OpErrorPolicy describes what a batch does when an operation fails
+type OpErrorPolicy int
+
+const (
+	// StopOnError skips the remaining operations and fails the batch (default)
+	StopOnError OpErrorPolicy = iota
+	// ContinueOnError runs the remaining operations and fails the batch at the end
+	ContinueOnError
+	// IgnoreError runs the remaining operations; the failure is only recorded in its result
+	IgnoreError
+)
+
+// OpStatus is the outcome of an operation
+type OpStatus string
+
+const (
+	OpDone    OpStatus = "done"
+	OpFailed  OpStatus = "failed"
+	OpSkipped OpStatus = "skipped" // not run after a stop or cancellation
+	OpPlanned OpStatus = "planned" // dry run
+)
+
+// OpResult describes an operation run by Ops.Run
+type OpResult struct {
+	Index    int           `json:"index"`
+	Op       string        `json:"op"`
+	Source   string        `json:"source,omitempty"`
+	Target   string        `json:"target"`
+	Status   OpStatus      `json:"status"`
+	Err      error         `json:"-"`
+	Duration time.Duration `json:"duration"`
+}
+
+// batchOp is an operation added to Ops
+type batchOp struct {
+	name   string
+	source string
+	target string
+	policy *OpErrorPolicy
+	run    func() error
+}
+
+// Ops is a batch of file system operations built by chaining calls and run at once:
+//
+//	results, err := fsx.NewOps().Mkdir("out").Copy("a.txt", "out/a.txt").Delete("tmp").Run()
+type Ops struct {
+	ops []*batchOp
+}
+
+// NewOps returns an empty batch
+func NewOps() *Ops {
+	return &Ops{}
+}
+
+// Len returns the number of operations in the batch
+func (o *Ops) Len() int {
+	return len(o.ops)
+}
+
+func (o *Ops) add(name, source, target string, run func() error) *Ops {
+	o.ops = append(o.ops, &batchOp{
+		name:   name,
+		source: source,
+		target: target,
+		run:    run,
+	})
+	return o
+}
+
+// Copy copies a file or directory tree, creating missing parent directories of dst
+func (o *Ops) Copy(src, dst string) *Ops {
+	return o.add("copy", src, dst, func() error {
+		if DirectoryExist(src) {
+			return CopyDirectory(src, dst)
+		}
+		return CopyFile(src, dst, WithCreateDirs())
+	})
+}
+
+// Move moves a file or directory tree, creating missing parent directories of dst
+func (o *Ops) Move(src, dst string) *Ops {
+	return o.add("move", src, dst, func() error {
+		if DirectoryExist(src) {
+			return MoveDirectory(src, dst)
+		}
+		return MoveFile(src, dst, WithCreateDirs())
+	})
+}
+
+// Mkdir creates a directory and its parents
+func (o *Ops) Mkdir(path string) *Ops {
+	return o.add("mkdir", "", path, func() error {
+		return CreateDirectories(path)
+	})
+}
+
+// Delete removes a file or a directory with its content. Missing paths are not an error
+func (o *Ops) Delete(path string) *Ops {
+	return o.add("delete", "", path, func() error {
+		info, err := os.Lstat(path)
+		switch {
+		case os.IsNotExist(err):
+			return nil
+		case err != nil:
+			return newStatFile(path, err)
+		case info.IsDir():
+			return DeleteDirectory(path, WithRecursive())
+		default:
+			return DeleteFile(path)
+		}
+	})
+}
+
+// Write writes data to a file atomically, creating missing parent directories
+func (o *Ops) Write(path string, data []byte, perm os.FileMode) *Ops {
+	return o.add("write", "", path, func() error {
+		if err := CreateDirectories(filepath.Dir(path)); err != nil {
+			return err
+		}
+		return AtomicWriteFile(path, data, perm)
+	})
+}
+
+// Touch creates a file or updates its times, see TouchFile
+func (o *Ops) Touch(path string, options ...FileOption) *Ops {
+	return o.add("touch", "", path, func() error {
+		return TouchFile(path, options...)
+	})
+}
+
+// Chmod changes the mode of a file or directory
+func (o *Ops) Chmod(path string, mode os.FileMode) *Ops {
+	return o.add("chmod", "", path, func() error {
+		return ChangeFilePermissions(path, mode)
+	})
+}
+
+// Do adds a custom operation named name on target
+func (o *Ops) Do(name, target string, fn func() error) *Ops {
+	return o.add(name, "", target, fn)
+}
+
+// OnError sets the error policy of the last added operation
+func (o *Ops) OnError(policy OpErrorPolicy) *Ops {
+	if len(o.ops) > 0 {
+		o.ops[len(o.ops)-1].policy = &policy
+	}
+	return o
+}
+
+// Run runs the operations in order and returns a result for each of them. The error
+// is ErrOps wrapping the failures that stopped or, with ContinueOnError, failed the batch
+func (o *Ops) Run(options ...OpsOption) ([]OpResult, error) {
+	opts := defaultOpsOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	results := make([]OpResult, len(o.ops))
+	var failures []error
+	stopped := false
+
+	for i, op := range o.ops {
+		result := &results[i]
+		*result = OpResult{
+			Index:  i,
+			Op:     op.name,
+			Source: op.source,
+			Target: op.target,
+			Status: OpSkipped,
+		}
+
+		switch {
+		case stopped:
+			continue
+		case opts.ctx.Err() != nil:
+			failures = append(failures, opts.ctx.Err())
+			stopped = true
+			continue
+		case opts.dryRun:
+			result.Status = OpPlanned
+		default:
+			start := time.Now()
+			result.Err = op.run()
+			result.Duration = time.Since(start)
+			result.Status = OpDone
+		}
+
+		if result.Err != nil {
+			result.Status = OpFailed
+
+			policy := opts.errorPolicy
+			if op.policy != nil {
+				policy = *op.policy
+			}
+
+			switch policy {
+			case StopOnError:
+				failures = append(failures, result.Err)
+				stopped = true
+			case ContinueOnError:
+				failures = append(failures, result.Err)
+			}
+		}
+
+		if opts.progressHandler != nil {
+			opts.progressHandler(int64(i+1), int64(len(o.ops)), op.target)
+		}
+	}
+
+	if len(failures) > 0 {
+		return results, ErrOps.
+			SetError(errors.Join(failures...)).
+			SetData(struct {
+				Operations int `json:"operations"`
+				Failures   int `json:"failures"`
+			}{
+				Operations: len(o.ops),
+				Failures:   len(failures),
+			})
+	}
+
+	return results, nil
+}
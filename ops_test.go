@@ -0,0 +1,124 @@
+package fsx
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOps(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "fsx_ops_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := func(name string) string {
+		return filepath.Join(tmpDir, name)
+	}
+
+	statuses := func(results []OpResult) []OpStatus {
+		var list []OpStatus
+		for _, result := range results {
+			list = append(list, result.Status)
+		}
+		return list
+	}
+
+	equal := func(got, want []OpStatus) bool {
+		if len(got) != len(want) {
+			return false
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	t.Run("Run", func(t *testing.T) {
+		var progress []int64
+		results, err := NewOps().
+			Write(path("src/a.txt"), []byte("alpha"), 0644).
+			Mkdir(path("out/logs")).
+			Copy(path("src/a.txt"), path("out/copy/a.txt")).
+			Copy(path("src"), path("tree")).
+			Move(path("tree/a.txt"), path("moved/a.txt")).
+			Touch(path("out/stamp")).
+			Delete(path("src")).
+			Delete(path("missing")).
+			Run(WithOpsProgress(func(current, total int64, file string) {
+				progress = append(progress, current)
+			}))
+		if err != nil {
+			t.Fatalf("Failed to run ops: %v", err)
+		}
+
+		if len(results) != 8 || len(progress) != 8 || results[2].Op != "copy" || results[2].Source != path("src/a.txt") {
+			t.Errorf("Unexpected results: %+v", results)
+		}
+		for _, name := range []string{"out/copy/a.txt", "moved/a.txt", "out/stamp"} {
+			if !FileExist(path(name)) {
+				t.Errorf("%s should exist", name)
+			}
+		}
+		if !DirectoryExist(path("out/logs")) || DirectoryExist(path("src")) || FileExist(path("tree/a.txt")) {
+			t.Error("Unexpected tree after ops")
+		}
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		results, err := NewOps().Mkdir(path("dry")).Delete(path("out")).Run(WithOpsDryRun())
+		if err != nil {
+			t.Fatalf("Failed to run ops: %v", err)
+		}
+		if !equal(statuses(results), []OpStatus{OpPlanned, OpPlanned}) {
+			t.Errorf("Unexpected statuses: %v", statuses(results))
+		}
+		if DirectoryExist(path("dry")) || !DirectoryExist(path("out")) {
+			t.Error("Dry run must not change anything")
+		}
+	})
+
+	t.Run("ErrorPolicies", func(t *testing.T) {
+		failure := errors.New("boom")
+		fail := func() error { return failure }
+		ok := func() error { return nil }
+
+		results, err := NewOps().Do("a", "a", ok).Do("b", "b", fail).Do("c", "c", ok).Run()
+		if !errors.Is(err, ErrOps) || !errors.Is(err, failure) {
+			t.Errorf("Expected ErrOps wrapping the failure, got %v", err)
+		}
+		if !equal(statuses(results), []OpStatus{OpDone, OpFailed, OpSkipped}) || results[1].Err != failure {
+			t.Errorf("Unexpected statuses for stop: %v", statuses(results))
+		}
+
+		results, err = NewOps().Do("a", "a", fail).Do("b", "b", fail).OnError(StopOnError).Do("c", "c", ok).
+			Run(WithOpsErrorPolicy(ContinueOnError))
+		if !errors.Is(err, ErrOps) || !equal(statuses(results), []OpStatus{OpFailed, OpFailed, OpSkipped}) {
+			t.Errorf("Unexpected statuses for continue: %v, %v", statuses(results), err)
+		}
+
+		results, err = NewOps().Do("a", "a", fail).OnError(IgnoreError).Do("b", "b", ok).Run()
+		if err != nil || !equal(statuses(results), []OpStatus{OpFailed, OpDone}) {
+			t.Errorf("Unexpected statuses for ignore: %v, %v", statuses(results), err)
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		results, err := NewOps().
+			Do("cancel", "", func() error { cancel(); return nil }).
+			Mkdir(path("canceled")).
+			Run(WithOpsContext(ctx))
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+		if !equal(statuses(results), []OpStatus{OpDone, OpSkipped}) || DirectoryExist(path("canceled")) {
+			t.Errorf("Unexpected statuses: %v", statuses(results))
+		}
+	})
+}
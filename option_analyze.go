@@ -0,0 +1,32 @@
+package fsx
+
+// AnalyzeOption represents options for AnalyzeDirectory
+type AnalyzeOption func(*analyzeOptions)
+
+type analyzeOptions struct {
+	topN            int
+	excludePatterns []string
+}
+
+// defaultAnalyzeOptions returns default analysis options
+func defaultAnalyzeOptions() *analyzeOptions {
+	return &analyzeOptions{
+		topN:            10,
+		excludePatterns: []string{},
+	}
+}
+
+// WithAnalyzeTopN sets how many largest, oldest and newest files are reported (default: 10)
+func WithAnalyzeTopN(n int) AnalyzeOption {
+	return func(opts *analyzeOptions) {
+		opts.topN = n
+	}
+}
+
+// WithAnalyzeExclude adds patterns of files and directories left out of the analysis.
+// See Path Patterns in the README for the pattern syntax
+func WithAnalyzeExclude(patterns ...string) AnalyzeOption {
+	return func(opts *analyzeOptions) {
+		opts.excludePatterns = append(opts.excludePatterns, patterns...)
+	}
+}
@@ -0,0 +1,38 @@
+package fsx
+
+// ArchiveOption represents options for archive creation
+type ArchiveOption func(*archiveOptions)
+
+type archiveOptions struct {
+	filter       FilterFunc
+	requireSpace bool
+	cipher       Cipher
+}
+
+// defaultArchiveOptions returns default archive options
+func defaultArchiveOptions() *archiveOptions {
+	return &archiveOptions{}
+}
+
+// WithArchiveFilter sets a filter function selecting entries to archive
+func WithArchiveFilter(filter FilterFunc) ArchiveOption {
+	return func(opts *archiveOptions) {
+		opts.filter = filter
+	}
+}
+
+// WithArchiveRequireSpace makes CompressDirectory check with CheckSpaceFor that the
+// archive directory has room for the uncompressed source before writing the archive
+func WithArchiveRequireSpace() ArchiveOption {
+	return func(opts *archiveOptions) {
+		opts.requireSpace = true
+	}
+}
+
+// WithArchiveCipher encrypts archives written by CompressDirectory and
+// CreateZipFromDirectory with cipher
+func WithArchiveCipher(cipher Cipher) ArchiveOption {
+	return func(opts *archiveOptions) {
+		opts.cipher = cipher
+	}
+}
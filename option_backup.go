@@ -0,0 +1,49 @@
+package fsx
+
+// BackupOption represents options for backup operations
+type BackupOption func(*backupOptions)
+
+type backupOptions struct {
+	full            bool
+	excludePatterns []string
+	progressHandler ProgressFunc
+	cipher          Cipher
+}
+
+// defaultBackupOptions returns default backup options
+func defaultBackupOptions() *backupOptions {
+	return &backupOptions{
+		full:            false,
+		excludePatterns: []string{},
+	}
+}
+
+// WithFullBackup stores every file instead of only files changed since the previous set
+func WithFullBackup() BackupOption {
+	return func(opts *backupOptions) {
+		opts.full = true
+	}
+}
+
+// WithBackupExclude adds patterns of files and directories left out of backups.
+// See Path Patterns in the README for the pattern syntax
+func WithBackupExclude(patterns ...string) BackupOption {
+	return func(opts *backupOptions) {
+		opts.excludePatterns = append(opts.excludePatterns, patterns...)
+	}
+}
+
+// WithBackupProgress sets a progress handler called after each stored or restored file
+func WithBackupProgress(handler ProgressFunc) BackupOption {
+	return func(opts *backupOptions) {
+		opts.progressHandler = handler
+	}
+}
+
+// WithBackupCipher encrypts files stored by BackupDirectory with cipher and decrypts
+// them on RestoreBackup. Catalog and manifests stay readable
+func WithBackupCipher(cipher Cipher) BackupOption {
+	return func(opts *backupOptions) {
+		opts.cipher = cipher
+	}
+}
@@ -0,0 +1,71 @@
+package fsx
+
+// CollisionStrategy describes how BatchRename handles new names that are taken
+type CollisionStrategy int
+
+const (
+	// CollisionFail fails the batch without renaming anything (default)
+	CollisionFail CollisionStrategy = iota
+	// CollisionSkip leaves the colliding file under its current name
+	CollisionSkip
+	// CollisionOverwrite replaces existing files that are not renamed by the batch;
+	// existing directories fail the batch
+	CollisionOverwrite
+	// CollisionUniquify adds a number to the new name (name.2.ext, name.3.ext, ...)
+	CollisionUniquify
+)
+
+// BatchRenameOption represents options for pattern-based batch renames
+type BatchRenameOption func(*batchRenameOptions)
+
+type batchRenameOptions struct {
+	regex     bool
+	recursive bool
+	dryRun    bool
+	collision CollisionStrategy
+	start     int
+}
+
+// defaultBatchRenameOptions returns default batch rename options
+func defaultBatchRenameOptions() *batchRenameOptions {
+	return &batchRenameOptions{
+		collision: CollisionFail,
+		start:     1,
+	}
+}
+
+// WithBatchRenameRegex treats the pattern as a regular expression. The replacement
+// may then refer to submatches ($1, ${name}) before tokens are expanded
+func WithBatchRenameRegex() BatchRenameOption {
+	return func(opts *batchRenameOptions) {
+		opts.regex = true
+	}
+}
+
+// WithBatchRenameRecursive also renames matching files in subdirectories, in place
+func WithBatchRenameRecursive() BatchRenameOption {
+	return func(opts *batchRenameOptions) {
+		opts.recursive = true
+	}
+}
+
+// WithBatchRenameDryRun returns the renames without applying them
+func WithBatchRenameDryRun() BatchRenameOption {
+	return func(opts *batchRenameOptions) {
+		opts.dryRun = true
+	}
+}
+
+// WithCollisionStrategy sets how taken names are handled
+func WithCollisionStrategy(strategy CollisionStrategy) BatchRenameOption {
+	return func(opts *batchRenameOptions) {
+		opts.collision = strategy
+	}
+}
+
+// WithBatchRenameStart sets the first {seq} number (1 by default)
+func WithBatchRenameStart(start int) BatchRenameOption {
+	return func(opts *batchRenameOptions) {
+		opts.start = start
+	}
+}
@@ -0,0 +1,48 @@
+package fsx
+
+// ChecksumOption represents options for checksum operations
+type ChecksumOption func(*checksumOptions)
+
+type checksumOptions struct {
+	cache           *ChecksumCache
+	hashType        HashType
+	includePerms    bool
+	includeModTimes bool
+}
+
+// defaultChecksumOptions returns default checksum options
+func defaultChecksumOptions() *checksumOptions {
+	return &checksumOptions{
+		hashType:        HashMD5,
+		includePerms:    false,
+		includeModTimes: false,
+	}
+}
+
+// WithChecksumCache reuses checksums of files whose size and mtime are unchanged
+func WithChecksumCache(cache *ChecksumCache) ChecksumOption {
+	return func(opts *checksumOptions) {
+		opts.cache = cache
+	}
+}
+
+// WithChecksumHashType sets hash algorithm used by DirectoryChecksum
+func WithChecksumHashType(hashType HashType) ChecksumOption {
+	return func(opts *checksumOptions) {
+		opts.hashType = hashType
+	}
+}
+
+// WithChecksumPermissions includes permission bits in DirectoryChecksum
+func WithChecksumPermissions() ChecksumOption {
+	return func(opts *checksumOptions) {
+		opts.includePerms = true
+	}
+}
+
+// WithChecksumModTimes includes modification times in DirectoryChecksum
+func WithChecksumModTimes() ChecksumOption {
+	return func(opts *checksumOptions) {
+		opts.includeModTimes = true
+	}
+}
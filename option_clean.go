@@ -0,0 +1,32 @@
+package fsx
+
+// CleanOption represents options for cleanup operations
+type CleanOption func(*cleanOptions)
+
+type cleanOptions struct {
+	dryRun       bool
+	keepPatterns []string
+}
+
+// defaultCleanOptions returns default cleanup options
+func defaultCleanOptions() *cleanOptions {
+	return &cleanOptions{
+		dryRun:       false,
+		keepPatterns: []string{},
+	}
+}
+
+// WithCleanDryRun reports what would be removed without removing anything
+func WithCleanDryRun() CleanOption {
+	return func(opts *cleanOptions) {
+		opts.dryRun = true
+	}
+}
+
+// WithCleanKeep adds patterns of paths never removed, e.g. "logs" to keep an empty
+// placeholder directory. See Path Patterns in the README for the pattern syntax
+func WithCleanKeep(patterns ...string) CleanOption {
+	return func(opts *cleanOptions) {
+		opts.keepPatterns = append(opts.keepPatterns, patterns...)
+	}
+}
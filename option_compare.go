@@ -0,0 +1,53 @@
+package fsx
+
+import "time"
+
+// CompareOption represents options for directory comparison
+type CompareOption func(*compareOptions)
+
+type compareOptions struct {
+	excludePatterns []string
+	mtimeTolerance  time.Duration
+	compareContent  bool
+	workers         int
+}
+
+// defaultCompareOptions returns default compare options
+func defaultCompareOptions() *compareOptions {
+	return &compareOptions{
+		excludePatterns: []string{},
+		mtimeTolerance:  0,
+		compareContent:  false,
+		workers:         0, // runtime.NumCPU()
+	}
+}
+
+// WithCompareExclude skips paths matching any of the patterns (e.g. ".git", "node_modules").
+// See Path Patterns in the README for the pattern syntax
+func WithCompareExclude(patterns ...string) CompareOption {
+	return func(opts *compareOptions) {
+		opts.excludePatterns = append(opts.excludePatterns, patterns...)
+	}
+}
+
+// WithMTimeTolerance treats modification times within tolerance as equal
+// (e.g. 2s for FAT filesystems)
+func WithMTimeTolerance(tolerance time.Duration) CompareOption {
+	return func(opts *compareOptions) {
+		opts.mtimeTolerance = tolerance
+	}
+}
+
+// WithCompareContent compares content of same-size files instead of their mtimes
+func WithCompareContent() CompareOption {
+	return func(opts *compareOptions) {
+		opts.compareContent = true
+	}
+}
+
+// WithCompareWorkers sets the number of concurrent content comparisons
+func WithCompareWorkers(workers int) CompareOption {
+	return func(opts *compareOptions) {
+		opts.workers = workers
+	}
+}
@@ -0,0 +1,78 @@
+package fsx
+
+import (
+	"context"
+	"time"
+)
+
+// ConfigChangeFunc receives the config reloaded after a change: a new value of the type
+// passed to LoadConfig, or the error that prevented reloading it
+type ConfigChangeFunc func(config any, err error)
+
+// ConfigOption represents options for config loading
+type ConfigOption func(*configOptions)
+
+type configOptions struct {
+	format        ConfigFormat
+	lookupEnv     func(name string) (string, bool)
+	requireEnv    bool
+	strict        bool
+	watchContext  context.Context
+	onChange      ConfigChangeFunc
+	watchInterval time.Duration
+}
+
+// defaultConfigOptions returns default config options
+func defaultConfigOptions() *configOptions {
+	return &configOptions{
+		format:        ConfigAuto,
+		lookupEnv:     lookupEnvironment,
+		watchInterval: time.Second,
+	}
+}
+
+// WithConfigFormat sets the config format instead of detecting it by extension
+func WithConfigFormat(format ConfigFormat) ConfigOption {
+	return func(opts *configOptions) {
+		opts.format = format
+	}
+}
+
+// WithConfigLookup sets the function resolving ${NAME} placeholders (os.LookupEnv by default)
+func WithConfigLookup(lookup func(name string) (string, bool)) ConfigOption {
+	return func(opts *configOptions) {
+		opts.lookupEnv = lookup
+	}
+}
+
+// WithConfigRequireEnv fails loading when a placeholder without default is not set,
+// instead of expanding it to an empty string
+func WithConfigRequireEnv() ConfigOption {
+	return func(opts *configOptions) {
+		opts.requireEnv = true
+	}
+}
+
+// WithConfigStrict rejects fields that don't exist in the target type
+func WithConfigStrict() ConfigOption {
+	return func(opts *configOptions) {
+		opts.strict = true
+	}
+}
+
+// WithConfigWatch reloads the config whenever the file content changes, until ctx is
+// done, and passes each reloaded config to onChange
+func WithConfigWatch(ctx context.Context, onChange ConfigChangeFunc) ConfigOption {
+	return func(opts *configOptions) {
+		opts.watchContext = ctx
+		opts.onChange = onChange
+	}
+}
+
+// WithConfigWatchInterval sets how often WithConfigWatch checks the file (1s by default).
+// LoadConfig rejects an interval that isn't positive
+func WithConfigWatchInterval(interval time.Duration) ConfigOption {
+	return func(opts *configOptions) {
+		opts.watchInterval = interval
+	}
+}
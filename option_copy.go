@@ -4,13 +4,27 @@ package fsx
 type CopyOption func(*copyOptions)
 
 type copyOptions struct {
-	overwrite       bool
-	preservePerms   bool
-	preserveTimes   bool
-	skipErrors      bool
-	followSymlinks  bool
-	filter          FilterFunc
-	progressHandler ProgressFunc
+	overwrite           bool
+	preservePerms       bool
+	preserveTimes       bool
+	preserveOwner       bool
+	preserveXattrs      bool
+	preserveACLs        bool
+	preserveMacMetadata bool
+	preserveLinks       bool
+	skipIdentical       bool
+	identicalHash       HashType
+	skipErrors          bool
+	followSymlinks      bool
+	copyJunctions       bool
+	filter              FilterFunc
+	includePatterns     []string
+	excludePatterns     []string
+	progressHandler     ProgressFunc
+	errorHandler        ErrorFunc
+	syncNoDelete        bool
+	syncTrash           string
+	requireSpace        bool
 }
 
 // defaultCopyOptions returns default copy options
@@ -45,6 +59,39 @@ func WithPreserveTimes(preserve bool) CopyOption {
 	}
 }
 
+// WithPreserveOwnership sets the owner (uid/gid) of copies to the owner of the source.
+// Without the privileges to give files away the owner is left unchanged; other chown
+// failures are reported, or passed to the error handler with WithSkipErrors
+func WithPreserveOwnership() CopyOption {
+	return func(opts *copyOptions) {
+		opts.preserveOwner = true
+	}
+}
+
+// WithPreserveXattrs copies extended attributes (user.*, security.* like SELinux labels).
+// Supported on Linux, a no-op elsewhere
+func WithPreserveXattrs() CopyOption {
+	return func(opts *copyOptions) {
+		opts.preserveXattrs = true
+	}
+}
+
+// WithPreserveACLs copies POSIX ACLs (access and default). Supported on Linux, a no-op elsewhere
+func WithPreserveACLs() CopyOption {
+	return func(opts *copyOptions) {
+		opts.preserveACLs = true
+	}
+}
+
+// WithPreserveMacMetadata copies Finder flags (hidden, locked), the creation time and
+// com.apple.* extended attributes such as Finder info, tags and resource forks.
+// Supported on macOS, a no-op elsewhere
+func WithPreserveMacMetadata() CopyOption {
+	return func(opts *copyOptions) {
+		opts.preserveMacMetadata = true
+	}
+}
+
 // WithSkipErrors continues operation on errors
 func WithSkipErrors() CopyOption {
 	return func(opts *copyOptions) {
@@ -52,13 +99,96 @@ func WithSkipErrors() CopyOption {
 	}
 }
 
-// WithFollowSymlinks follows symbolic links
+// WithPreserveHardlinks recreates files sharing an inode in the source as hardlinks
+// in the destination instead of copying their content again (Unix only)
+func WithPreserveHardlinks() CopyOption {
+	return func(opts *copyOptions) {
+		opts.preserveLinks = true
+	}
+}
+
+// WithSkipIdentical replaces existing destination files unless they have the size
+// and modification time of the source, so an interrupted copy can be resumed
+func WithSkipIdentical() CopyOption {
+	return func(opts *copyOptions) {
+		opts.overwrite = true
+		opts.skipIdentical = true
+	}
+}
+
+// WithSkipIdenticalChecksum is like WithSkipIdentical but compares checksums
+// of same-size files instead of modification times
+func WithSkipIdenticalChecksum(hashType HashType) CopyOption {
+	return func(opts *copyOptions) {
+		opts.overwrite = true
+		opts.skipIdentical = true
+		opts.identicalHash = hashType
+	}
+}
+
+// SyncCompareMode selects how SyncDirectories detects changed files
+type SyncCompareMode int
+
+const (
+	SyncCopyAll          SyncCompareMode = iota // copy every file (default)
+	SyncCompareSizeMTime                        // skip files with the same size and modification time
+	SyncCompareChecksum                         // skip files with the same size and checksum
+)
+
+// WithSyncCompare makes SyncDirectories transfer only files that changed
+func WithSyncCompare(mode SyncCompareMode) CopyOption {
+	return func(opts *copyOptions) {
+		switch mode {
+		case SyncCompareSizeMTime:
+			WithSkipIdentical()(opts)
+		case SyncCompareChecksum:
+			WithSkipIdenticalChecksum(HashXXH64)(opts)
+		default:
+			opts.skipIdentical = false
+			opts.identicalHash = ""
+		}
+	}
+}
+
+// WithSyncNoDelete makes SyncDirectories only add and update files,
+// keeping destination files that are missing in the source
+func WithSyncNoDelete() CopyOption {
+	return func(opts *copyOptions) {
+		opts.syncNoDelete = true
+	}
+}
+
+// WithSyncTrash makes SyncDirectories move removed files into a timestamped
+// directory under trashDir instead of deleting them
+func WithSyncTrash(trashDir string) CopyOption {
+	return func(opts *copyOptions) {
+		opts.syncTrash = trashDir
+	}
+}
+
+// WithRequireSpace makes CopyDirectory check with CheckSpaceFor that the destination
+// filesystem can hold the source before copying anything
+func WithRequireSpace() CopyOption {
+	return func(opts *copyOptions) {
+		opts.requireSpace = true
+	}
+}
+
+// WithFollowSymlinks follows symbolic links and Windows junctions
 func WithFollowSymlinks() CopyOption {
 	return func(opts *copyOptions) {
 		opts.followSymlinks = true
 	}
 }
 
+// WithCopyJunctions copies Windows junctions as symbolic links to their targets.
+// Without it junctions are skipped unless WithFollowSymlinks is set
+func WithCopyJunctions() CopyOption {
+	return func(opts *copyOptions) {
+		opts.copyJunctions = true
+	}
+}
+
 // WithFilter sets a filter function for selective operations
 func WithFilter(filter FilterFunc) CopyOption {
 	return func(opts *copyOptions) {
@@ -66,6 +196,43 @@ func WithFilter(filter FilterFunc) CopyOption {
 	}
 }
 
+// WithCopyInclude copies only files matching any of the patterns; directories are
+// still traversed. Patterns are matched against the name and the slash-separated
+// relative path, "**" matches any number of directories (e.g. "src/**/*.go")
+func WithCopyInclude(patterns ...string) CopyOption {
+	return func(opts *copyOptions) {
+		opts.includePatterns = append(opts.includePatterns, patterns...)
+	}
+}
+
+// WithCopyExclude skips files and directories matching any of the patterns
+// (e.g. "*.log", "node_modules/**"). Combines with WithFilter and WithCopyInclude
+func WithCopyExclude(patterns ...string) CopyOption {
+	return func(opts *copyOptions) {
+		opts.excludePatterns = append(opts.excludePatterns, patterns...)
+	}
+}
+
+// WithErrorHandler reports paths skipped because of errors when WithSkipErrors is set
+func WithErrorHandler(handler ErrorFunc) CopyOption {
+	return func(opts *copyOptions) {
+		opts.errorHandler = handler
+	}
+}
+
+// skipError reports whether an error on path should be skipped and
+// passes skipped errors to the error handler
+func (opts *copyOptions) skipError(path string, err error) bool {
+	if !opts.skipErrors {
+		return false
+	}
+
+	if opts.errorHandler != nil {
+		opts.errorHandler(path, err)
+	}
+	return true
+}
+
 // WithProgress sets a progress handler
 func WithProgress(handler ProgressFunc) CopyOption {
 	return func(opts *copyOptions) {
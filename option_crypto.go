@@ -0,0 +1,60 @@
+package fsx
+
+// CryptoOption represents options for file encryption
+type CryptoOption func(*cryptoOptions)
+
+type cryptoOptions struct {
+	key           []byte
+	passphrase    string
+	argonTime     uint32
+	argonMemory   uint32
+	argonThreads  uint8
+	chunkSize     int
+	hasPassphrase bool
+}
+
+// defaultCryptoOptions returns default encryption options: 64KB chunks and the
+// Argon2id parameters recommended by RFC 9106 for memory constrained environments
+func defaultCryptoOptions() *cryptoOptions {
+	return &cryptoOptions{
+		argonTime:    3,
+		argonMemory:  64 * 1024, // 64MB
+		argonThreads: 4,
+		chunkSize:    64 * 1024, // 64KB
+	}
+}
+
+// WithEncryptionKey encrypts with a raw AES-256 key of 32 bytes
+func WithEncryptionKey(key []byte) CryptoOption {
+	return func(opts *cryptoOptions) {
+		opts.key = key
+	}
+}
+
+// WithPassphrase derives the key from passphrase with Argon2id and a random salt
+// stored in the encrypted file
+func WithPassphrase(passphrase string) CryptoOption {
+	return func(opts *cryptoOptions) {
+		opts.passphrase = passphrase
+		opts.hasPassphrase = true
+	}
+}
+
+// WithArgon2Params sets the Argon2id iterations, memory in KiB and threads used to
+// derive keys from passphrases when encrypting. Decryption uses the stored parameters.
+// Iterations range from 1 to 64, threads from 1 and memory from 8 KiB per thread to 4GB;
+// other values fail encryption
+func WithArgon2Params(time, memory uint32, threads uint8) CryptoOption {
+	return func(opts *cryptoOptions) {
+		opts.argonTime = time
+		opts.argonMemory = memory
+		opts.argonThreads = threads
+	}
+}
+
+// WithEncryptionChunkSize sets the plaintext size of each authenticated chunk
+func WithEncryptionChunkSize(size int) CryptoOption {
+	return func(opts *cryptoOptions) {
+		opts.chunkSize = size
+	}
+}
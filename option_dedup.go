@@ -0,0 +1,39 @@
+package fsx
+
+// DedupOption represents options for directory deduplication
+type DedupOption func(*dedupOptions)
+
+type dedupOptions struct {
+	dryRun          bool
+	minSize         int64
+	checksumOptions []ChecksumOption
+}
+
+// defaultDedupOptions returns default deduplication options
+func defaultDedupOptions() *dedupOptions {
+	return &dedupOptions{
+		dryRun:  false,
+		minSize: 1,
+	}
+}
+
+// WithDedupDryRun reports what would be linked without changing anything
+func WithDedupDryRun() DedupOption {
+	return func(opts *dedupOptions) {
+		opts.dryRun = true
+	}
+}
+
+// WithDedupMinSize ignores files smaller than size bytes
+func WithDedupMinSize(size int64) DedupOption {
+	return func(opts *dedupOptions) {
+		opts.minSize = size
+	}
+}
+
+// WithDedupChecksumOptions passes checksum options (e.g. a cache) to hashing
+func WithDedupChecksumOptions(options ...ChecksumOption) DedupOption {
+	return func(opts *dedupOptions) {
+		opts.checksumOptions = append(opts.checksumOptions, options...)
+	}
+}
@@ -6,11 +6,29 @@ import "os"
 type DirectoryOption func(*directoryOptions)
 
 type directoryOptions struct {
-	perm      os.FileMode
-	recursive bool
-	force     bool
+	perm          os.FileMode
+	recursive     bool
+	force         bool
+	listFilter    FilterFunc
+	filesOnly     bool
+	dirsOnly      bool
+	sortBy        SortKey
+	sortAscending bool
+	contentType   bool
+	exactPerm     bool
 }
 
+// SortKey selects the order of ListDirectory entries
+type SortKey int
+
+const (
+	SortNone      SortKey = iota // directory order (default)
+	SortByName                   // by name
+	SortBySize                   // by size in bytes
+	SortByModTime                // by modification time
+	SortByPath                   // by full path
+)
+
 // defaultDirectoryOptions returns default options for directory operations
 func defaultDirectoryOptions() *directoryOptions {
 	return &directoryOptions{
@@ -20,13 +38,22 @@ func defaultDirectoryOptions() *directoryOptions {
 	}
 }
 
-// WithDirPermissions sets custom directory permissions
+// WithDirPermissions sets custom directory permissions. Like mkdir(2), the process
+// umask is applied to them on creation unless WithExactDirPermissions is given
 func WithDirPermissions(perm os.FileMode) DirectoryOption {
 	return func(opts *directoryOptions) {
 		opts.perm = perm
 	}
 }
 
+// WithExactDirPermissions changes the mode of created directories to exactly the
+// WithDirPermissions mode (0755 by default), so the process umask doesn't apply
+func WithExactDirPermissions() DirectoryOption {
+	return func(opts *directoryOptions) {
+		opts.exactPerm = true
+	}
+}
+
 // WithRecursive enables recursive operations
 func WithRecursive() DirectoryOption {
 	return func(opts *directoryOptions) {
@@ -34,6 +61,45 @@ func WithRecursive() DirectoryOption {
 	}
 }
 
+// WithListFilter lists only entries accepted by filter; rejected directories
+// are not listed recursively either
+func WithListFilter(filter FilterFunc) DirectoryOption {
+	return func(opts *directoryOptions) {
+		opts.listFilter = filter
+	}
+}
+
+// WithFilesOnly lists only files; directories are still listed recursively
+func WithFilesOnly() DirectoryOption {
+	return func(opts *directoryOptions) {
+		opts.filesOnly = true
+		opts.dirsOnly = false
+	}
+}
+
+// WithDirsOnly lists only directories
+func WithDirsOnly() DirectoryOption {
+	return func(opts *directoryOptions) {
+		opts.dirsOnly = true
+		opts.filesOnly = false
+	}
+}
+
+// WithListContentType detects the MIME type of listed files, see DirectoryEntry.ContentType
+func WithListContentType() DirectoryOption {
+	return func(opts *directoryOptions) {
+		opts.contentType = true
+	}
+}
+
+// WithSortBy sorts listed entries by key in ascending or descending order
+func WithSortBy(key SortKey, ascending bool) DirectoryOption {
+	return func(opts *directoryOptions) {
+		opts.sortBy = key
+		opts.sortAscending = ascending
+	}
+}
+
 // WithForce forces operation (e.g., delete non-empty directories)
 func WithForce() DirectoryOption {
 	return func(opts *directoryOptions) {
@@ -0,0 +1,50 @@
+package fsx
+
+// OverwritePolicy describes how extraction handles files that already exist
+type OverwritePolicy int
+
+const (
+	// OverwriteExisting replaces existing files (default)
+	OverwriteExisting OverwritePolicy = iota
+	// SkipExisting keeps existing files untouched
+	SkipExisting
+	// FailOnExisting stops extraction with an error
+	FailOnExisting
+)
+
+// ExtractOption represents options for archive extraction
+type ExtractOption func(*extractOptions)
+
+type extractOptions struct {
+	overwritePolicy OverwritePolicy
+	patterns        []string
+	cipher          Cipher
+}
+
+// defaultExtractOptions returns default extract options
+func defaultExtractOptions() *extractOptions {
+	return &extractOptions{
+		overwritePolicy: OverwriteExisting,
+	}
+}
+
+// WithOverwritePolicy sets how existing files are handled during extraction
+func WithOverwritePolicy(policy OverwritePolicy) ExtractOption {
+	return func(opts *extractOptions) {
+		opts.overwritePolicy = policy
+	}
+}
+
+// WithExtractPatterns extracts only entries matching any of the patterns
+func WithExtractPatterns(patterns ...string) ExtractOption {
+	return func(opts *extractOptions) {
+		opts.patterns = append(opts.patterns, patterns...)
+	}
+}
+
+// WithExtractCipher decrypts archives read by DecompressToDirectory with cipher
+func WithExtractCipher(cipher Cipher) ExtractOption {
+	return func(opts *extractOptions) {
+		opts.cipher = cipher
+	}
+}
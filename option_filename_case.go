@@ -0,0 +1,39 @@
+package fsx
+
+// FilenameCaseOption represents options for NormalizeFilenameCase
+type FilenameCaseOption func(*filenameCaseOptions)
+
+type filenameCaseOptions struct {
+	dryRun    bool
+	filesOnly bool
+	collision CollisionStrategy
+}
+
+// defaultFilenameCaseOptions returns default filename case options
+func defaultFilenameCaseOptions() *filenameCaseOptions {
+	return &filenameCaseOptions{
+		collision: CollisionFail,
+	}
+}
+
+// WithFilenameCaseDryRun returns the renames without applying them
+func WithFilenameCaseDryRun() FilenameCaseOption {
+	return func(opts *filenameCaseOptions) {
+		opts.dryRun = true
+	}
+}
+
+// WithFilenameCaseFilesOnly keeps directory names and only renames files
+func WithFilenameCaseFilesOnly() FilenameCaseOption {
+	return func(opts *filenameCaseOptions) {
+		opts.filesOnly = true
+	}
+}
+
+// WithFilenameCaseCollision sets how converted names that are taken are handled.
+// CollisionOverwrite is not supported, as it would drop one of two entries
+func WithFilenameCaseCollision(strategy CollisionStrategy) FilenameCaseOption {
+	return func(opts *filenameCaseOptions) {
+		opts.collision = strategy
+	}
+}
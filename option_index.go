@@ -0,0 +1,33 @@
+package fsx
+
+// IndexOption represents options for a checksum index
+type IndexOption func(*indexOptions)
+
+type indexOptions struct {
+	hashType        HashType
+	excludePatterns []string
+}
+
+// defaultIndexOptions returns default index options
+func defaultIndexOptions() *indexOptions {
+	return &indexOptions{
+		hashType:        HashSHA256,
+		excludePatterns: []string{},
+	}
+}
+
+// WithIndexHashType sets the hash stored in the index (SHA256 by default).
+// Changing it on an existing index rehashes every file on the next refresh
+func WithIndexHashType(hashType HashType) IndexOption {
+	return func(opts *indexOptions) {
+		opts.hashType = hashType
+	}
+}
+
+// WithIndexExclude adds patterns of files and directories left out of the index.
+// See Path Patterns in the README for the pattern syntax
+func WithIndexExclude(patterns ...string) IndexOption {
+	return func(opts *indexOptions) {
+		opts.excludePatterns = append(opts.excludePatterns, patterns...)
+	}
+}
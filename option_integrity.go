@@ -0,0 +1,32 @@
+package fsx
+
+// IntegrityOption represents options for integrity monitoring
+type IntegrityOption func(*integrityOptions)
+
+type integrityOptions struct {
+	hashType        HashType
+	excludePatterns []string
+}
+
+// defaultIntegrityOptions returns default integrity options
+func defaultIntegrityOptions() *integrityOptions {
+	return &integrityOptions{
+		hashType:        HashSHA256,
+		excludePatterns: []string{},
+	}
+}
+
+// WithIntegrityHashType sets the hash used for the baseline (SHA256 by default)
+func WithIntegrityHashType(hashType HashType) IntegrityOption {
+	return func(opts *integrityOptions) {
+		opts.hashType = hashType
+	}
+}
+
+// WithIntegrityExclude adds patterns of files and directories that are not monitored.
+// See Path Patterns in the README for the pattern syntax
+func WithIntegrityExclude(patterns ...string) IntegrityOption {
+	return func(opts *integrityOptions) {
+		opts.excludePatterns = append(opts.excludePatterns, patterns...)
+	}
+}
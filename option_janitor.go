@@ -0,0 +1,40 @@
+package fsx
+
+// JanitorOption represents options for temp janitors
+type JanitorOption func(*janitorOptions)
+
+type janitorOptions struct {
+	patterns []string
+	handler  func(path string, err error)
+}
+
+// defaultJanitorOptions returns default janitor options: the names of temporary
+// files and directories fsx creates itself
+func defaultJanitorOptions() *janitorOptions {
+	return &janitorOptions{
+		patterns: []string{
+			".tmp-*",          // AtomicWriteFile, binary patches
+			".reserve-*",      // ReserveFile
+			".fsx-access-*",   // IsWritable probes
+			"fsx-zip-*",       // decrypted zip archives
+			"fsx-workspace-*", // Workspace roots
+			".fsx-rename-*",   // RenameAll staging names
+		},
+	}
+}
+
+// WithJanitorPatterns adds name patterns (filepath.Match) of entries the janitor removes,
+// such as the patterns given to CreateTempFile or NewTempManager
+func WithJanitorPatterns(patterns ...string) JanitorOption {
+	return func(opts *janitorOptions) {
+		opts.patterns = append(opts.patterns, patterns...)
+	}
+}
+
+// WithJanitorHandler sets a function called for every stale entry with the
+// result of removing it
+func WithJanitorHandler(handler func(path string, err error)) JanitorOption {
+	return func(opts *janitorOptions) {
+		opts.handler = handler
+	}
+}
@@ -0,0 +1,35 @@
+package fsx
+
+import "time"
+
+// JournalOption represents options for OpenJournal
+type JournalOption func(*journalOptions)
+
+type journalOptions struct {
+	syncInterval time.Duration
+	noSync       bool
+}
+
+// defaultJournalOptions returns default journal options: every Append is synced to disk
+func defaultJournalOptions() *journalOptions {
+	return &journalOptions{
+		syncInterval: 0,
+		noSync:       false,
+	}
+}
+
+// WithJournalSyncInterval syncs appended records to disk in the background every interval
+// instead of on every Append, trading the records of the last interval for throughput
+func WithJournalSyncInterval(interval time.Duration) JournalOption {
+	return func(opts *journalOptions) {
+		opts.syncInterval = interval
+	}
+}
+
+// WithJournalNoSync leaves syncing to the OS; records survive a process crash but not
+// a power loss. Sync and Close still sync
+func WithJournalNoSync() JournalOption {
+	return func(opts *journalOptions) {
+		opts.noSync = true
+	}
+}
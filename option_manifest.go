@@ -0,0 +1,50 @@
+package fsx
+
+// ManifestOption represents options for manifest generation
+type ManifestOption func(*manifestOptions)
+
+type manifestOptions struct {
+	includePatterns []string
+	excludePatterns []string
+	checksumOptions []ChecksumOption
+	hashType        HashType
+}
+
+// defaultManifestOptions returns default manifest options
+func defaultManifestOptions() *manifestOptions {
+	return &manifestOptions{
+		includePatterns: []string{},
+		excludePatterns: []string{},
+	}
+}
+
+// WithManifestInclude adds patterns that files must match to be listed.
+// See Path Patterns in the README for the pattern syntax
+func WithManifestInclude(patterns ...string) ManifestOption {
+	return func(opts *manifestOptions) {
+		opts.includePatterns = append(opts.includePatterns, patterns...)
+	}
+}
+
+// WithManifestExclude adds patterns of files and directories to leave out
+func WithManifestExclude(patterns ...string) ManifestOption {
+	return func(opts *manifestOptions) {
+		opts.excludePatterns = append(opts.excludePatterns, patterns...)
+	}
+}
+
+// WithManifestHashType sets the algorithm of SUMS and JSON lines manifests read by
+// VerifyManifest. Without it, the algorithm comes from well-known names (B2SUMS,
+// SHA512SUMS, ...) or is guessed from the digest length
+func WithManifestHashType(hashType HashType) ManifestOption {
+	return func(opts *manifestOptions) {
+		opts.hashType = hashType
+	}
+}
+
+// WithManifestChecksumOptions passes checksum options (e.g. a cache) to hashing
+func WithManifestChecksumOptions(options ...ChecksumOption) ManifestOption {
+	return func(opts *manifestOptions) {
+		opts.checksumOptions = append(opts.checksumOptions, options...)
+	}
+}
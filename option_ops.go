@@ -0,0 +1,50 @@
+package fsx
+
+import "context"
+
+// OpsOption represents options for running batch operations
+type OpsOption func(*opsOptions)
+
+type opsOptions struct {
+	ctx             context.Context
+	dryRun          bool
+	errorPolicy     OpErrorPolicy
+	progressHandler ProgressFunc
+}
+
+// defaultOpsOptions returns default options: stop at the first failure
+func defaultOpsOptions() *opsOptions {
+	return &opsOptions{
+		ctx:         context.Background(),
+		errorPolicy: StopOnError,
+	}
+}
+
+// WithOpsDryRun reports the operations as planned without running them
+func WithOpsDryRun() OpsOption {
+	return func(opts *opsOptions) {
+		opts.dryRun = true
+	}
+}
+
+// WithOpsErrorPolicy sets the error policy of operations without their own (see Ops.OnError)
+func WithOpsErrorPolicy(policy OpErrorPolicy) OpsOption {
+	return func(opts *opsOptions) {
+		opts.errorPolicy = policy
+	}
+}
+
+// WithOpsProgress sets a progress handler called after each operation with
+// the number of finished operations, their total and the operation target
+func WithOpsProgress(handler ProgressFunc) OpsOption {
+	return func(opts *opsOptions) {
+		opts.progressHandler = handler
+	}
+}
+
+// WithOpsContext stops running operations when ctx is done; the rest are skipped
+func WithOpsContext(ctx context.Context) OpsOption {
+	return func(opts *opsOptions) {
+		opts.ctx = ctx
+	}
+}
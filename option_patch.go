@@ -0,0 +1,23 @@
+package fsx
+
+// PatchOption represents options for binary patch creation
+type PatchOption func(*patchOptions)
+
+type patchOptions struct {
+	blockSize int
+}
+
+// defaultPatchOptions returns default patch options
+func defaultPatchOptions() *patchOptions {
+	return &patchOptions{
+		blockSize: 4096,
+	}
+}
+
+// WithPatchBlockSize sets the block size used to find data shared with the old file.
+// Smaller blocks find more matches at the cost of a bigger index
+func WithPatchBlockSize(size int) PatchOption {
+	return func(opts *patchOptions) {
+		opts.blockSize = size
+	}
+}
@@ -0,0 +1,88 @@
+package fsx
+
+// PathOption represents options for path normalization
+type PathOption func(*pathOptions)
+
+type pathOptions struct {
+	expandEnv    bool
+	forwardSlash bool
+	keepRelative bool
+	cache        *PathCache
+}
+
+// defaultPathOptions returns default path options
+func defaultPathOptions() *pathOptions {
+	return &pathOptions{
+		expandEnv:    false,
+		forwardSlash: false,
+		keepRelative: false,
+	}
+}
+
+// WithExpandEnv expands $VAR and ${VAR} environment variables
+func WithExpandEnv() PathOption {
+	return func(opts *pathOptions) {
+		opts.expandEnv = true
+	}
+}
+
+// WithForwardSlashes returns paths with "/" separators on every platform
+func WithForwardSlashes() PathOption {
+	return func(opts *pathOptions) {
+		opts.forwardSlash = true
+	}
+}
+
+// WithKeepRelative cleans relative paths without making them absolute
+func WithKeepRelative() PathOption {
+	return func(opts *pathOptions) {
+		opts.keepRelative = true
+	}
+}
+
+// WithPathCache makes CanonicalPath look up and store results in cache
+func WithPathCache(cache *PathCache) PathOption {
+	return func(opts *pathOptions) {
+		opts.cache = cache
+	}
+}
+
+// SanitizeOption represents options for SanitizeFilename
+type SanitizeOption func(*sanitizeOptions)
+
+type sanitizeOptions struct {
+	replacement string
+	maxLength   int
+	target      string
+}
+
+// defaultSanitizeOptions returns portable sanitize options valid on every platform
+func defaultSanitizeOptions() *sanitizeOptions {
+	return &sanitizeOptions{
+		replacement: "_",
+		maxLength:   255,
+		target:      "windows",
+	}
+}
+
+// WithSanitizeReplacement sets the replacement of invalid characters (default "_"), "" strips them
+func WithSanitizeReplacement(replacement string) SanitizeOption {
+	return func(opts *sanitizeOptions) {
+		opts.replacement = replacement
+	}
+}
+
+// WithSanitizeMaxLength sets the maximum name length in bytes (default 255), the extension is kept
+func WithSanitizeMaxLength(length int) SanitizeOption {
+	return func(opts *sanitizeOptions) {
+		opts.maxLength = length
+	}
+}
+
+// WithSanitizeTarget sets the operating system names must be valid on, e.g. runtime.GOOS.
+// The default applies Windows rules, which are the strictest, so names are valid everywhere
+func WithSanitizeTarget(goos string) SanitizeOption {
+	return func(opts *sanitizeOptions) {
+		opts.target = goos
+	}
+}
@@ -0,0 +1,33 @@
+package fsx
+
+// QueueOption represents options for OpenQueue
+type QueueOption func(*queueOptions)
+
+type queueOptions struct {
+	segmentSize int64
+	noSync      bool
+}
+
+// defaultQueueOptions returns default queue options
+func defaultQueueOptions() *queueOptions {
+	return &queueOptions{
+		segmentSize: 64 * 1024 * 1024, // 64MB
+		noSync:      false,
+	}
+}
+
+// WithQueueSegmentSize sets the size after which Enqueue starts a new segment file.
+// Segments are removed once every item in them is dequeued
+func WithQueueSegmentSize(size int64) QueueOption {
+	return func(opts *queueOptions) {
+		opts.segmentSize = size
+	}
+}
+
+// WithQueueNoSync doesn't sync enqueued items to disk; they survive a process crash
+// but not a power loss
+func WithQueueNoSync() QueueOption {
+	return func(opts *queueOptions) {
+		opts.noSync = true
+	}
+}
@@ -0,0 +1,38 @@
+package fsx
+
+// RenameOption represents options for bulk renames
+type RenameOption func(*renameOptions)
+
+type renameOptions struct {
+	overwrite  bool
+	createDirs bool
+	dryRun     bool
+}
+
+// defaultRenameOptions returns default rename options
+func defaultRenameOptions() *renameOptions {
+	return &renameOptions{}
+}
+
+// WithRenameOverwrite replaces existing files instead of rejecting the batch; existing
+// directories are never replaced. Replaced entries are kept until the batch succeeds,
+// so a rollback restores them
+func WithRenameOverwrite() RenameOption {
+	return func(opts *renameOptions) {
+		opts.overwrite = true
+	}
+}
+
+// WithRenameCreateDirs creates missing destination directories
+func WithRenameCreateDirs() RenameOption {
+	return func(opts *renameOptions) {
+		opts.createDirs = true
+	}
+}
+
+// WithRenameDryRun only validates the batch
+func WithRenameDryRun() RenameOption {
+	return func(opts *renameOptions) {
+		opts.dryRun = true
+	}
+}
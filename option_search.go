@@ -1,18 +1,51 @@
 package fsx
 
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
 // SearchOption represents options for search operations
 type SearchOption func(*searchOptions)
 
 type searchOptions struct {
-	maxDepth        int
-	minDepth        int
-	followSymlinks  bool
-	caseSensitive   bool
-	wholeWord       bool
-	ignoreHidden    bool
-	limitResults    int
-	includePatterns []string
-	excludePatterns []string
+	maxDepth             int
+	minDepth             int
+	followSymlinks       bool
+	caseSensitive        bool
+	wholeWord            bool
+	ignoreHidden         bool
+	limitResults         int
+	includePatterns      []string
+	excludePatterns      []string
+	deleteBrokenSymlinks bool
+	ignoreMatcher        *IgnoreMatcher
+	maxFileSize          int64
+	maxLineLength        int
+	contextLines         int
+	workers              int
+	sortBy               SortKey
+	sortAscending        bool
+	stats                *searchCounters
+	gitignore            *gitignoreRules
+	fileTypes            []string
+	walked               *walkState
+	ctx                  context.Context
+	deadline             time.Time
+	watchInterval        time.Duration
+	lineEndingDryRun     bool
+}
+
+// searchCounters collects SearchStats; searches with workers update it concurrently
+type searchCounters struct {
+	target  *SearchStats
+	start   time.Time
+	dirs    atomic.Int64
+	files   atomic.Int64
+	bytes   atomic.Int64
+	skipped atomic.Int64
 }
 
 // defaultSearchOptions returns default search options
@@ -27,6 +60,11 @@ func defaultSearchOptions() *searchOptions {
 		limitResults:    -1, // No limit
 		includePatterns: []string{},
 		excludePatterns: []string{},
+		maxFileSize:     -1, // No limit
+		maxLineLength:   1024 * 1024,
+		walked:          newWalkState(),
+		ctx:             context.Background(),
+		watchInterval:   time.Second,
 	}
 }
 
@@ -44,7 +82,8 @@ func WithMinDepth(depth int) SearchOption {
 	}
 }
 
-// WithSearchFollowSymlinks enables following symbolic links
+// WithSearchFollowSymlinks enables following symbolic links. Directories are walked once,
+// links looping back to an enclosing directory are reported as "symlink_loop" results
 func WithSearchFollowSymlinks() SearchOption {
 	return func(opts *searchOptions) {
 		opts.followSymlinks = true
@@ -65,7 +104,7 @@ func WithWholeWord() SearchOption {
 	}
 }
 
-// WithIgnoreHidden ignores hidden files and directories
+// WithIgnoreHidden ignores hidden files and directories, see IsHidden
 func WithIgnoreHidden() SearchOption {
 	return func(opts *searchOptions) {
 		opts.ignoreHidden = true
@@ -86,9 +125,190 @@ func WithIncludePatterns(patterns ...string) SearchOption {
 	}
 }
 
+// WithFileType keeps files whose detected MIME type matches one of types,
+// e.g. "image", "video", "text" or "application/pdf", see MatchMIME
+func WithFileType(types ...string) SearchOption {
+	return func(opts *searchOptions) {
+		opts.fileTypes = append(opts.fileTypes, types...)
+	}
+}
+
 // WithExcludePatterns adds patterns that files must not match
 func WithExcludePatterns(patterns ...string) SearchOption {
 	return func(opts *searchOptions) {
 		opts.excludePatterns = append(opts.excludePatterns, patterns...)
 	}
 }
+
+// WithIgnoreMatcher skips files and directories ignored by .gitignore style rules
+func WithIgnoreMatcher(matcher *IgnoreMatcher) SearchOption {
+	return func(opts *searchOptions) {
+		opts.ignoreMatcher = matcher
+	}
+}
+
+// WithMaxFileSize skips files larger than size bytes in content searches
+func WithMaxFileSize(size int64) SearchOption {
+	return func(opts *searchOptions) {
+		opts.maxFileSize = size
+	}
+}
+
+// WithMaxLineLength caps the bytes of a line scanned by content searches (default: 1 MiB).
+// Longer lines are truncated, so matches past the cap are not found
+func WithMaxLineLength(length int) SearchOption {
+	return func(opts *searchOptions) {
+		opts.maxLineLength = length
+	}
+}
+
+// WithContextLines adds n lines of context before and after each match
+// of FindContentRegex, like grep -C
+func WithContextLines(n int) SearchOption {
+	return func(opts *searchOptions) {
+		opts.contextLines = n
+	}
+}
+
+// WithSearchWorkers walks the top-level subdirectories of the search root concurrently
+// with up to n workers in FindFiles, FindFilesBy, FindFilesByContent, FindContentRegex
+// and FindFilesByBytes. Results keep the order of a serial walk
+func WithSearchWorkers(n int) SearchOption {
+	return func(opts *searchOptions) {
+		opts.workers = n
+	}
+}
+
+// WithSortResults sorts search results by key (SortByPath, SortByName, SortBySize
+// or SortByModTime) in ascending or descending order
+func WithSortResults(key SortKey, ascending bool) SearchOption {
+	return func(opts *searchOptions) {
+		opts.sortBy = key
+		opts.sortAscending = ascending
+	}
+}
+
+// WithSearchContext stops the search with ErrSearchCanceled once ctx is done
+func WithSearchContext(ctx context.Context) SearchOption {
+	return func(opts *searchOptions) {
+		opts.ctx = ctx
+	}
+}
+
+// WithSearchTimeout stops the search with ErrSearchCanceled after d,
+// e.g. to bound an accidental search of a whole machine
+func WithSearchTimeout(d time.Duration) SearchOption {
+	return func(opts *searchOptions) {
+		opts.deadline = time.Now().Add(d)
+	}
+}
+
+// WithWatchInterval sets how often WatchSearch repeats the search (default 1s). It must be positive
+func WithWatchInterval(d time.Duration) SearchOption {
+	return func(opts *searchOptions) {
+		opts.watchInterval = d
+	}
+}
+
+// WithLineEndingDryRun makes NormalizeLineEndings report the files that would change
+// without writing them
+func WithLineEndingDryRun() SearchOption {
+	return func(opts *searchOptions) {
+		opts.lineEndingDryRun = true
+	}
+}
+
+// WithRespectGitignore skips paths ignored by .gitignore files of the searched tree
+// and its enclosing repository, as well as ".git" directories
+func WithRespectGitignore() SearchOption {
+	return func(opts *searchOptions) {
+		opts.gitignore = &gitignoreRules{}
+	}
+}
+
+// ignored reports whether a walked path is excluded by ignore rules
+func (opts *searchOptions) ignored(path string, info os.FileInfo, depth int) bool {
+	if opts.ignoreMatcher != nil && opts.ignoreMatcher.IgnoredPath(path, info.IsDir()) {
+		return true
+	}
+
+	return opts.gitignore != nil && opts.gitignore.ignored(path, info, depth)
+}
+
+// WithSearchStats fills stats with the work done by a successful search:
+// directories and files walked, bytes scanned, matches, errors skipped and duration
+func WithSearchStats(stats *SearchStats) SearchOption {
+	return func(opts *searchOptions) {
+		opts.stats = &searchCounters{
+			target: stats,
+			start:  time.Now(),
+		}
+	}
+}
+
+// walkFunc wraps a walk function to stop the walk once the search is canceled
+// and to count walked entries when statistics are requested
+func (opts *searchOptions) walkFunc(fn func(path string, info os.FileInfo, depth int, err error) error) func(path string, info os.FileInfo, depth int, err error) error {
+	return func(path string, info os.FileInfo, depth int, err error) error {
+		if cancelErr := opts.canceled(); cancelErr != nil {
+			return cancelErr
+		}
+
+		if opts.stats == nil {
+			return fn(path, info, depth, err)
+		}
+
+		switch {
+		case err != nil:
+			opts.stats.skipped.Add(1)
+		case info.IsDir():
+			opts.stats.dirs.Add(1)
+		default:
+			opts.stats.files.Add(1)
+		}
+		return fn(path, info, depth, err)
+	}
+}
+
+// canceled returns ErrSearchCanceled once the search context is done or its timeout expired
+func (opts *searchOptions) canceled() error {
+	err := opts.ctx.Err()
+	if err == nil && !opts.deadline.IsZero() && time.Now().After(opts.deadline) {
+		err = context.DeadlineExceeded
+	}
+	if err != nil {
+		return ErrSearchCanceled.SetError(err)
+	}
+
+	return nil
+}
+
+// addScanned counts bytes read by content searches
+func (opts *searchOptions) addScanned(n int64) {
+	if opts.stats != nil {
+		opts.stats.bytes.Add(n)
+	}
+}
+
+// finish writes the collected statistics
+func (c *searchCounters) finish(matches int) {
+	if c.target == nil {
+		return
+	}
+
+	*c.target = SearchStats{
+		DirsVisited:   int(c.dirs.Load()),
+		FilesExamined: int(c.files.Load()),
+		BytesScanned:  c.bytes.Load(),
+		Matches:       matches,
+		Skipped:       int(c.skipped.Load()),
+		Duration:      time.Since(c.start),
+	}
+}
+
+// WithDeleteBrokenSymlinks removes the links found by FindBrokenSymlinks
+func WithDeleteBrokenSymlinks() SearchOption {
+	return func(opts *searchOptions) {
+		opts.deleteBrokenSymlinks = true
+	}
+}
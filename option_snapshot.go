@@ -0,0 +1,23 @@
+package fsx
+
+// SnapshotOption represents options for snapshot operations
+type SnapshotOption func(*snapshotOptions)
+
+type snapshotOptions struct {
+	excludePatterns []string
+}
+
+// defaultSnapshotOptions returns default snapshot options
+func defaultSnapshotOptions() *snapshotOptions {
+	return &snapshotOptions{
+		excludePatterns: []string{},
+	}
+}
+
+// WithSnapshotExclude adds patterns of files and directories left out of snapshots.
+// See Path Patterns in the README for the pattern syntax
+func WithSnapshotExclude(patterns ...string) SnapshotOption {
+	return func(opts *snapshotOptions) {
+		opts.excludePatterns = append(opts.excludePatterns, patterns...)
+	}
+}
@@ -0,0 +1,62 @@
+package fsx
+
+import "context"
+
+// SplitOption represents options for split and merge operations
+type SplitOption func(*splitOptions)
+
+type splitOptions struct {
+	ctx             context.Context
+	namePattern     string
+	manifest        bool
+	hashType        HashType
+	manifestPath    string
+	progressHandler ProgressFunc
+}
+
+// defaultSplitOptions returns default split options
+func defaultSplitOptions() *splitOptions {
+	return &splitOptions{
+		ctx:         context.Background(),
+		namePattern: "%s.part%d",
+		manifest:    false,
+		hashType:    HashSHA256,
+	}
+}
+
+// WithSplitContext sets a context to cancel long-running split/merge operations
+func WithSplitContext(ctx context.Context) SplitOption {
+	return func(opts *splitOptions) {
+		opts.ctx = ctx
+	}
+}
+
+// WithSplitNamePattern sets the part naming pattern. The pattern is a fmt
+// format receiving the source path and the part index, e.g. "%s.%03d"
+func WithSplitNamePattern(pattern string) SplitOption {
+	return func(opts *splitOptions) {
+		opts.namePattern = pattern
+	}
+}
+
+// WithSplitManifest writes a manifest with part sizes and checksums next to the parts
+func WithSplitManifest(hashType HashType) SplitOption {
+	return func(opts *splitOptions) {
+		opts.manifest = true
+		opts.hashType = hashType
+	}
+}
+
+// WithSplitProgress sets a progress handler
+func WithSplitProgress(handler ProgressFunc) SplitOption {
+	return func(opts *splitOptions) {
+		opts.progressHandler = handler
+	}
+}
+
+// WithMergeManifest verifies merged parts against a manifest written by SplitFile
+func WithMergeManifest(manifestPath string) SplitOption {
+	return func(opts *splitOptions) {
+		opts.manifestPath = manifestPath
+	}
+}
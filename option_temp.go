@@ -0,0 +1,41 @@
+package fsx
+
+import "os"
+
+// TempOption represents options for temporary files and directories
+type TempOption func(*tempOptions)
+
+type tempOptions struct {
+	perm          os.FileMode
+	privateParent bool
+	cleanup       bool
+}
+
+// defaultTempOptions returns default temp options. A zero perm keeps the
+// owner-only modes os.CreateTemp (0600) and os.MkdirTemp (0700) use
+func defaultTempOptions() *tempOptions {
+	return &tempOptions{}
+}
+
+// WithTempPermissions sets the exact mode of the temporary file or directory, the umask doesn't apply
+func WithTempPermissions(perm os.FileMode) TempOption {
+	return func(opts *tempOptions) {
+		opts.perm = perm
+	}
+}
+
+// WithPrivateParent fails with ErrInsecureTempDir unless the parent directory is owned
+// by the current user and closed to group and others, so no one else can swap or read
+// the temporary path. Ownership can't be checked on Windows
+func WithPrivateParent() TempOption {
+	return func(opts *tempOptions) {
+		opts.privateParent = true
+	}
+}
+
+// WithTempCleanup registers the temporary path for removal by CleanupTempFiles
+func WithTempCleanup() TempOption {
+	return func(opts *tempOptions) {
+		opts.cleanup = true
+	}
+}
@@ -0,0 +1,65 @@
+package fsx
+
+import (
+	"os"
+	"text/template"
+)
+
+// TemplateOption represents options for template rendering
+type TemplateOption func(*templateOptions)
+
+type templateOptions struct {
+	funcs       template.FuncMap
+	leftDelim   string
+	rightDelim  string
+	strict      bool
+	perm        os.FileMode
+	fileOptions []FileOption
+}
+
+// defaultTemplateOptions returns default template options
+func defaultTemplateOptions() *templateOptions {
+	return &templateOptions{
+		funcs: template.FuncMap{},
+	}
+}
+
+// WithTemplateFuncs adds functions available to the template
+func WithTemplateFuncs(funcs template.FuncMap) TemplateOption {
+	return func(opts *templateOptions) {
+		for name, fn := range funcs {
+			opts.funcs[name] = fn
+		}
+	}
+}
+
+// WithTemplateDelims sets the action delimiters instead of {{ and }}
+func WithTemplateDelims(left, right string) TemplateOption {
+	return func(opts *templateOptions) {
+		opts.leftDelim = left
+		opts.rightDelim = right
+	}
+}
+
+// WithTemplateStrict fails rendering when the template uses a missing map key
+func WithTemplateStrict() TemplateOption {
+	return func(opts *templateOptions) {
+		opts.strict = true
+	}
+}
+
+// WithTemplatePermissions sets the mode of the output file. By default an existing
+// output keeps its mode and new outputs get 0644
+func WithTemplatePermissions(perm os.FileMode) TemplateOption {
+	return func(opts *templateOptions) {
+		opts.perm = perm
+	}
+}
+
+// WithTemplateFileOptions passes file options such as WithBackup or WithVersioning
+// to the atomic write of the output
+func WithTemplateFileOptions(options ...FileOption) TemplateOption {
+	return func(opts *templateOptions) {
+		opts.fileOptions = append(opts.fileOptions, options...)
+	}
+}
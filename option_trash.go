@@ -0,0 +1,21 @@
+package fsx
+
+// TrashOption represents options for trash operations
+type TrashOption func(*trashOptions)
+
+type trashOptions struct {
+	dir string
+}
+
+// defaultTrashOptions returns default trash options using the trash of the OS
+func defaultTrashOptions() *trashOptions {
+	return &trashOptions{}
+}
+
+// WithTrashDir uses dir instead of the trash of the OS. It is laid out like the
+// freedesktop.org trash, with deleted entries in files/ and their origin in info/
+func WithTrashDir(dir string) TrashOption {
+	return func(opts *trashOptions) {
+		opts.dir = dir
+	}
+}
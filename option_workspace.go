@@ -0,0 +1,40 @@
+package fsx
+
+// WorkspaceOption represents options for workspaces
+type WorkspaceOption func(*workspaceOptions)
+
+type workspaceOptions struct {
+	dir     string
+	pattern string
+	quota   int64
+}
+
+// defaultWorkspaceOptions returns default workspace options: an unlimited
+// workspace in the system temp directory
+func defaultWorkspaceOptions() *workspaceOptions {
+	return &workspaceOptions{
+		pattern: "fsx-workspace-*",
+	}
+}
+
+// WithWorkspaceDir sets the directory the workspace root is created in
+func WithWorkspaceDir(dir string) WorkspaceOption {
+	return func(opts *workspaceOptions) {
+		opts.dir = dir
+	}
+}
+
+// WithWorkspacePattern sets the name pattern of the workspace root, as in os.MkdirTemp
+func WithWorkspacePattern(pattern string) WorkspaceOption {
+	return func(opts *workspaceOptions) {
+		opts.pattern = pattern
+	}
+}
+
+// WithWorkspaceQuota limits the bytes stored in the workspace. Writes through the
+// workspace that would exceed it fail with ErrWorkspaceQuota
+func WithWorkspaceQuota(bytes int64) WorkspaceOption {
+	return func(opts *workspaceOptions) {
+		opts.quota = bytes
+	}
+}
@@ -0,0 +1,499 @@
+package fsx
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// Binary patch format: patchMagic followed by a gzip stream of
+//
+//	old size, old SHA-256
+//	ops: patchOpCopy offset length | patchOpData length bytes | patchOpEnd
+//	new size, new SHA-256
+//
+// Integers are unsigned varints
+const (
+	patchMagic      = "FSXPTCH1"
+	patchOpEnd      = 0
+	patchOpCopy     = 1
+	patchOpData     = 2
+	patchMaxLiteral = 64 * 1024
+)
+
+// patchBlock is a full block of the old file indexed by its weak checksum
+type patchBlock struct {
+	offset int64
+	strong uint64
+}
+
+// CreateBinaryPatch writes a delta that turns oldPath into newPath.
+// Blocks shared with the old file are stored as references, everything else as literal data
+func CreateBinaryPatch(oldPath, newPath, patchPath string, options ...PatchOption) error {
+	opts := defaultPatchOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if opts.blockSize <= 0 {
+		return ErrCreatePatch.
+			SetData(struct {
+				Path      string `json:"path"`
+				BlockSize int    `json:"block_size"`
+			}{
+				Path:      patchPath,
+				BlockSize: opts.blockSize,
+			})
+	}
+
+	oldSize, oldSum, index, err := indexPatchBlocks(oldPath, opts.blockSize)
+	if err != nil {
+		return newCreatePatchError(oldPath, err)
+	}
+
+	newFile, err := os.Open(newPath)
+	if err != nil {
+		return newCreatePatchError(newPath, err)
+	}
+	defer newFile.Close()
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(patchPath), ".tmp-*")
+	if err != nil {
+		return newCreatePatchError(patchPath, err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	if err := writeBinaryPatch(tmpFile, newFile, oldSize, oldSum, index, opts.blockSize); err != nil {
+		tmpFile.Close()
+		return newCreatePatchError(patchPath, err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return newCreatePatchError(patchPath, err)
+	}
+
+	if err := os.Rename(tmpPath, patchPath); err != nil {
+		return newCreatePatchError(patchPath, err)
+	}
+
+	return nil
+}
+
+// indexPatchBlocks hashes every full block of the old file and the file as a whole
+func indexPatchBlocks(path string, blockSize int) (int64, []byte, map[uint32][]patchBlock, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer file.Close()
+
+	fileHash := sha256.New()
+	reader := bufio.NewReaderSize(io.TeeReader(file, fileHash), 64*1024)
+	index := make(map[uint32][]patchBlock)
+	block := make([]byte, blockSize)
+
+	var offset int64
+	for {
+		n, err := io.ReadFull(reader, block)
+		if n == blockSize {
+			weak := weakChecksum(block)
+			index[weak] = append(index[weak], patchBlock{
+				offset: offset,
+				strong: xxhash.Sum64(block),
+			})
+		}
+		offset += int64(n)
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return 0, nil, nil, err
+		}
+	}
+
+	return offset, fileHash.Sum(nil), index, nil
+}
+
+// writeBinaryPatch scans the new file with a rolling checksum and encodes it against the index
+func writeBinaryPatch(w io.Writer, newFile io.Reader, oldSize int64, oldSum []byte, index map[uint32][]patchBlock, blockSize int) error {
+	if _, err := io.WriteString(w, patchMagic); err != nil {
+		return err
+	}
+
+	gzWriter := gzip.NewWriter(w)
+	enc := &patchEncoder{w: bufio.NewWriterSize(gzWriter, 64*1024)}
+	enc.uvarint(uint64(oldSize))
+	enc.w.Write(oldSum)
+
+	newHash := sha256.New()
+	reader := bufio.NewReaderSize(io.TeeReader(newFile, newHash), 64*1024)
+
+	// window is a ring buffer: the current block starts at head
+	window := make([]byte, blockSize)
+	ordered := make([]byte, blockSize)
+	var newSize int64
+
+	for {
+		n, err := io.ReadFull(reader, window)
+		newSize += int64(n)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+
+		// Tail shorter than a block can only be stored as data
+		if n < blockSize {
+			enc.data(window[:n]...)
+			break
+		}
+
+		head := 0
+		weak := weakChecksum(window)
+		eof := false
+
+		for {
+			if candidates, ok := index[weak]; ok {
+				copy(ordered, window[head:])
+				copy(ordered[blockSize-head:], window[:head])
+				if offset, ok := enc.match(candidates, xxhash.Sum64(ordered)); ok {
+					enc.copyBlock(offset, int64(blockSize))
+					break
+				}
+			}
+
+			c, err := reader.ReadByte()
+			if err == io.EOF {
+				eof = true
+				break
+			}
+			if err != nil {
+				return err
+			}
+			newSize++
+
+			out := window[head]
+			enc.data(out)
+			window[head] = c
+			head = (head + 1) % blockSize
+			weak = rollChecksum(weak, out, c, blockSize)
+		}
+
+		if eof {
+			enc.data(window[head:]...)
+			enc.data(window[:head]...)
+			break
+		}
+	}
+
+	enc.flush()
+	enc.w.WriteByte(patchOpEnd)
+	enc.uvarint(uint64(newSize))
+	enc.w.Write(newHash.Sum(nil))
+
+	if err := enc.w.Flush(); err != nil {
+		return err
+	}
+
+	return gzWriter.Close()
+}
+
+// patchEncoder merges adjacent copies and batches literal bytes into patch ops.
+// Write errors are sticky in bufio.Writer and surface on Flush
+type patchEncoder struct {
+	w        *bufio.Writer
+	literal  []byte
+	copyFrom int64
+	copyLen  int64
+}
+
+// match picks the candidate block with the given strong hash, preferring one that extends the pending copy
+func (e *patchEncoder) match(candidates []patchBlock, strong uint64) (int64, bool) {
+	found := false
+	var offset int64
+
+	for _, candidate := range candidates {
+		if candidate.strong != strong {
+			continue
+		}
+		if e.copyLen > 0 && candidate.offset == e.copyFrom+e.copyLen {
+			return candidate.offset, true
+		}
+		if !found {
+			offset = candidate.offset
+			found = true
+		}
+	}
+
+	return offset, found
+}
+
+func (e *patchEncoder) copyBlock(offset, length int64) {
+	e.flushLiteral()
+	if e.copyLen > 0 && e.copyFrom+e.copyLen == offset {
+		e.copyLen += length
+		return
+	}
+
+	e.flushCopy()
+	e.copyFrom = offset
+	e.copyLen = length
+}
+
+func (e *patchEncoder) data(b ...byte) {
+	e.flushCopy()
+	for len(b) > 0 {
+		n := min(len(b), patchMaxLiteral-len(e.literal))
+		e.literal = append(e.literal, b[:n]...)
+		b = b[n:]
+		if len(e.literal) == patchMaxLiteral {
+			e.flushLiteral()
+		}
+	}
+}
+
+func (e *patchEncoder) flush() {
+	e.flushCopy()
+	e.flushLiteral()
+}
+
+func (e *patchEncoder) flushCopy() {
+	if e.copyLen == 0 {
+		return
+	}
+
+	e.w.WriteByte(patchOpCopy)
+	e.uvarint(uint64(e.copyFrom))
+	e.uvarint(uint64(e.copyLen))
+	e.copyLen = 0
+}
+
+func (e *patchEncoder) flushLiteral() {
+	if len(e.literal) == 0 {
+		return
+	}
+
+	e.w.WriteByte(patchOpData)
+	e.uvarint(uint64(len(e.literal)))
+	e.w.Write(e.literal)
+	e.literal = e.literal[:0]
+}
+
+func (e *patchEncoder) uvarint(v uint64) {
+	e.w.Write(binary.AppendUvarint(nil, v))
+}
+
+// weakChecksum computes the rsync-style rolling checksum of a block
+func weakChecksum(block []byte) uint32 {
+	var a, b uint32
+	n := uint32(len(block))
+	for i, c := range block {
+		a += uint32(c)
+		b += (n - uint32(i)) * uint32(c)
+	}
+
+	return (a & 0xffff) | (b&0xffff)<<16
+}
+
+// rollChecksum slides the checksum window by one byte
+func rollChecksum(sum uint32, out, in byte, blockSize int) uint32 {
+	a := sum & 0xffff
+	b := sum >> 16
+	a = (a - uint32(out) + uint32(in)) & 0xffff
+	b = (b - uint32(blockSize)*uint32(out) + a) & 0xffff
+
+	return a | b<<16
+}
+
+// ApplyBinaryPatch rebuilds the new file from oldPath and a patch created by CreateBinaryPatch.
+// Both the old file and the result are verified against the checksums stored in the patch
+func ApplyBinaryPatch(oldPath, patchPath, outPath string) error {
+	patchFile, err := os.Open(patchPath)
+	if err != nil {
+		return newApplyPatchError(patchPath, err)
+	}
+	defer patchFile.Close()
+
+	magic := make([]byte, len(patchMagic))
+	if _, err := io.ReadFull(patchFile, magic); err != nil || string(magic) != patchMagic {
+		return newInvalidPatchError(patchPath, "not a binary patch")
+	}
+
+	gzReader, err := gzip.NewReader(patchFile)
+	if err != nil {
+		return newInvalidPatchError(patchPath, "not a binary patch")
+	}
+	defer gzReader.Close()
+	reader := bufio.NewReader(gzReader)
+
+	oldSize, oldSum, err := readPatchChecksum(reader)
+	if err != nil {
+		return newInvalidPatchError(patchPath, "truncated header")
+	}
+
+	oldFile, err := os.Open(oldPath)
+	if err != nil {
+		return newApplyPatchError(oldPath, err)
+	}
+	defer oldFile.Close()
+
+	oldInfo, err := oldFile.Stat()
+	if err != nil {
+		return newApplyPatchError(oldPath, err)
+	}
+
+	oldHash := sha256.New()
+	if _, err := io.Copy(oldHash, oldFile); err != nil {
+		return newApplyPatchError(oldPath, err)
+	}
+	if oldInfo.Size() != int64(oldSize) || !bytes.Equal(oldHash.Sum(nil), oldSum) {
+		return newInvalidPatchError(patchPath, "old file does not match the patch")
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(outPath), ".tmp-*")
+	if err != nil {
+		return newApplyPatchError(outPath, err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	newHash := sha256.New()
+	writer := bufio.NewWriterSize(io.MultiWriter(tmpFile, newHash), 64*1024)
+
+	written, err := applyPatchOps(reader, writer, oldFile, int64(oldSize))
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		tmpFile.Close()
+		return newApplyPatchError(patchPath, err)
+	}
+
+	newSize, newSum, err := readPatchChecksum(reader)
+	if err != nil {
+		tmpFile.Close()
+		return newInvalidPatchError(patchPath, "truncated trailer")
+	}
+	if written != int64(newSize) || !bytes.Equal(newHash.Sum(nil), newSum) {
+		tmpFile.Close()
+		return newInvalidPatchError(patchPath, "result does not match the patch checksum")
+	}
+
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return newApplyPatchError(outPath, err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return newApplyPatchError(outPath, err)
+	}
+
+	if err := os.Chmod(tmpPath, oldInfo.Mode().Perm()); err != nil {
+		return newApplyPatchError(outPath, err)
+	}
+
+	if err := os.Rename(tmpPath, outPath); err != nil {
+		return newApplyPatchError(outPath, err)
+	}
+
+	return nil
+}
+
+// applyPatchOps executes patch ops until patchOpEnd and returns the number of bytes written
+func applyPatchOps(r *bufio.Reader, w io.Writer, old io.ReaderAt, oldSize int64) (int64, error) {
+	var written int64
+
+	for {
+		op, err := r.ReadByte()
+		if err != nil {
+			return written, io.ErrUnexpectedEOF
+		}
+
+		switch op {
+		case patchOpEnd:
+			return written, nil
+		case patchOpCopy:
+			offset, err := binary.ReadUvarint(r)
+			if err != nil {
+				return written, io.ErrUnexpectedEOF
+			}
+			length, err := binary.ReadUvarint(r)
+			if err != nil {
+				return written, io.ErrUnexpectedEOF
+			}
+			if offset > uint64(oldSize) || length > uint64(oldSize)-offset {
+				return written, ErrInvalidPatch
+			}
+
+			n, err := io.Copy(w, io.NewSectionReader(old, int64(offset), int64(length)))
+			written += n
+			if err != nil {
+				return written, err
+			}
+		case patchOpData:
+			length, err := binary.ReadUvarint(r)
+			if err != nil {
+				return written, io.ErrUnexpectedEOF
+			}
+
+			n, err := io.CopyN(w, r, int64(length))
+			written += n
+			if err != nil {
+				return written, err
+			}
+		default:
+			return written, ErrInvalidPatch
+		}
+	}
+}
+
+// readPatchChecksum reads a size and SHA-256 pair
+func readPatchChecksum(r *bufio.Reader) (uint64, []byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	sum := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(r, sum); err != nil {
+		return 0, nil, err
+	}
+
+	return size, sum, nil
+}
+
+func newCreatePatchError(path string, err error) error {
+	return ErrCreatePatch.
+		SetError(err).
+		SetData(pathErrorContext{
+			Path:  path,
+			Error: err,
+		})
+}
+
+func newApplyPatchError(path string, err error) error {
+	return ErrApplyPatch.
+		SetError(err).
+		SetData(pathErrorContext{
+			Path:  path,
+			Error: err,
+		})
+}
+
+func newInvalidPatchError(path, reason string) error {
+	return ErrInvalidPatch.
+		SetData(struct {
+			Path   string `json:"path"`
+			Reason string `json:"reason"`
+		}{
+			Path:   path,
+			Reason: reason,
+		})
+}
@@ -0,0 +1,116 @@
+package fsx
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBinaryPatch(t *testing.T) {
+	// Create a temporary directory for tests
+	tmpDir, err := os.MkdirTemp("", "fsx_patch_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	rng := rand.New(rand.NewSource(1))
+	oldData := make([]byte, 512*1024)
+	rng.Read(oldData)
+
+	// New version: a few edits, an insertion, a removal and an appended tail
+	newData := append([]byte{}, oldData[:100000]...)
+	newData = append(newData, []byte("inserted bytes")...)
+	newData = append(newData, oldData[100000:300000]...)
+	newData = append(newData, oldData[310000:]...)
+	newData[200000] ^= 0xff
+	newData = append(newData, []byte("appended tail")...)
+
+	oldPath := filepath.Join(tmpDir, "app-v1.bin")
+	newPath := filepath.Join(tmpDir, "app-v2.bin")
+	if err := CreateFile(oldPath, oldData); err != nil {
+		t.Fatalf("Failed to create old file: %v", err)
+	}
+	if err := CreateFile(newPath, newData); err != nil {
+		t.Fatalf("Failed to create new file: %v", err)
+	}
+
+	t.Run("CreateAndApply", func(t *testing.T) {
+		patchPath := filepath.Join(tmpDir, "v1-v2.patch")
+		if err := CreateBinaryPatch(oldPath, newPath, patchPath); err != nil {
+			t.Fatalf("Failed to create patch: %v", err)
+		}
+
+		info, err := os.Stat(patchPath)
+		if err != nil {
+			t.Fatalf("Failed to stat patch: %v", err)
+		}
+		if info.Size() > int64(len(newData))/10 {
+			t.Errorf("Patch too large: %d bytes for %d bytes file", info.Size(), len(newData))
+		}
+
+		outPath := filepath.Join(tmpDir, "app-v2-patched.bin")
+		if err := ApplyBinaryPatch(oldPath, patchPath, outPath); err != nil {
+			t.Fatalf("Failed to apply patch: %v", err)
+		}
+
+		equal, offset, err := CompareFiles(newPath, outPath)
+		if err != nil {
+			t.Fatalf("Failed to compare files: %v", err)
+		}
+		if !equal {
+			t.Errorf("Patched file differs at offset %d", offset)
+		}
+	})
+
+	t.Run("SmallAndEmptyFiles", func(t *testing.T) {
+		emptyPath := filepath.Join(tmpDir, "empty.bin")
+		smallPath := filepath.Join(tmpDir, "small.bin")
+		if err := CreateFile(emptyPath, nil); err != nil {
+			t.Fatalf("Failed to create empty file: %v", err)
+		}
+		if err := CreateFile(smallPath, []byte("tiny")); err != nil {
+			t.Fatalf("Failed to create small file: %v", err)
+		}
+
+		patchPath := filepath.Join(tmpDir, "empty-small.patch")
+		if err := CreateBinaryPatch(emptyPath, smallPath, patchPath); err != nil {
+			t.Fatalf("Failed to create patch: %v", err)
+		}
+
+		// Patch in place
+		if err := ApplyBinaryPatch(emptyPath, patchPath, emptyPath); err != nil {
+			t.Fatalf("Failed to apply patch: %v", err)
+		}
+
+		content, _ := ReadFileString(emptyPath)
+		if content != "tiny" {
+			t.Errorf("Expected 'tiny', got %q", content)
+		}
+	})
+
+	t.Run("WrongBaseFile", func(t *testing.T) {
+		patchPath := filepath.Join(tmpDir, "wrong-base.patch")
+		if err := CreateBinaryPatch(oldPath, newPath, patchPath, WithPatchBlockSize(1024)); err != nil {
+			t.Fatalf("Failed to create patch: %v", err)
+		}
+
+		outPath := filepath.Join(tmpDir, "wrong-base.bin")
+		err := ApplyBinaryPatch(newPath, patchPath, outPath)
+		if !errors.Is(err, ErrInvalidPatch) {
+			t.Errorf("Expected ErrInvalidPatch, got %v", err)
+		}
+		if FileExist(outPath) {
+			t.Error("Output must not be created for a wrong base file")
+		}
+	})
+
+	t.Run("NotAPatch", func(t *testing.T) {
+		err := ApplyBinaryPatch(oldPath, newPath, filepath.Join(tmpDir, "out.bin"))
+		if !errors.Is(err, ErrInvalidPatch) {
+			t.Errorf("Expected ErrInvalidPatch, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,261 @@
+package fsx
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// caseInsensitivePaths is set where filesystems ignore the case of names by default
+const caseInsensitivePaths = runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+
+// caseInsensitiveDir reports whether the filesystem of dir matches names ignoring case,
+// found by looking up an entry of dir, or dir itself, under another case. Without names
+// to probe it falls back to the platform default
+func caseInsensitiveDir(dir string) bool {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+
+	if file, err := os.Open(dir); err == nil {
+		names, _ := file.Readdirnames(64)
+		file.Close()
+
+		for _, name := range names {
+			if folded, ok := probeCase(filepath.Join(dir, name)); ok {
+				return folded
+			}
+		}
+	}
+
+	for path := dir; filepath.Dir(path) != path; path = filepath.Dir(path) {
+		if folded, ok := probeCase(path); ok {
+			return folded
+		}
+	}
+
+	return caseInsensitivePaths
+}
+
+// probeCase reports whether path is found with the case of its name swapped;
+// ok is false when the name has no case or path can't be probed
+func probeCase(path string) (folded, ok bool) {
+	name := filepath.Base(path)
+	swapped := strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, name)
+	if swapped == name {
+		return false, false
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false, false
+	}
+
+	other, err := os.Lstat(filepath.Join(filepath.Dir(path), swapped))
+	if err != nil {
+		return false, os.IsNotExist(err)
+	}
+
+	return os.SameFile(info, other), true
+}
+
+// ExpandHome replaces a leading "~" with the current user's home directory
+// and "~name" with the home directory of user name. Other paths are returned as is
+func ExpandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+
+	name, rest := path[1:], ""
+	if i := strings.IndexFunc(name, func(r rune) bool { return r == '/' || r == filepath.Separator }); i >= 0 {
+		name, rest = name[:i], name[i:]
+	}
+
+	var home string
+	if name == "" {
+		dir, err := os.UserHomeDir()
+		if err != nil {
+			return "", newExpandHomeError(path, err)
+		}
+		home = dir
+	} else {
+		account, err := user.Lookup(name)
+		if err != nil {
+			return "", newExpandHomeError(path, err)
+		}
+		home = account.HomeDir
+	}
+
+	return home + filepath.FromSlash(rest), nil
+}
+
+// NormalizePath expands "~", converts "/" to the platform separator, cleans the path
+// and makes it absolute. Environment variables are expanded with WithExpandEnv
+func NormalizePath(path string, options ...PathOption) (string, error) {
+	opts := defaultPathOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	normalized := path
+	if opts.expandEnv {
+		normalized = os.ExpandEnv(normalized)
+	}
+
+	normalized, err := ExpandHome(normalized)
+	if err != nil {
+		return "", err
+	}
+
+	normalized = filepath.Clean(filepath.FromSlash(normalized))
+	if !opts.keepRelative {
+		normalized, err = filepath.Abs(normalized)
+		if err != nil {
+			return "", ErrNormalizePath.
+				SetError(err).
+				SetData(pathErrorContext{
+					Path:  path,
+					Error: err,
+				})
+		}
+	}
+
+	if opts.forwardSlash {
+		normalized = filepath.ToSlash(normalized)
+	}
+
+	return normalized, nil
+}
+
+// PathCache keeps CanonicalPath results, see WithPathCache. Entries go stale when
+// links are changed; Clear drops them. It is safe for concurrent use
+type PathCache struct {
+	mu    sync.RWMutex
+	paths map[string]string
+}
+
+// NewPathCache creates an empty PathCache
+func NewPathCache() *PathCache {
+	return &PathCache{
+		paths: make(map[string]string),
+	}
+}
+
+// Clear drops all cached paths
+func (c *PathCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.paths = make(map[string]string)
+}
+
+func (c *PathCache) get(path string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	canonical, ok := c.paths[path]
+	return canonical, ok
+}
+
+func (c *PathCache) put(path, canonical string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.paths[path] = canonical
+}
+
+// CanonicalPath makes path absolute, resolves every symlink in it and, where filesystems
+// are case-insensitive (macOS, Windows), spells each name the way it is stored, so that
+// equal files have equal canonical paths. The path must exist. WithPathCache reuses results
+func CanonicalPath(path string, options ...PathOption) (string, error) {
+	opts := defaultPathOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", newCanonicalPathError(path, err)
+	}
+
+	if opts.cache != nil {
+		if canonical, ok := opts.cache.get(absPath); ok {
+			return canonical, nil
+		}
+	}
+
+	canonical, err := filepath.EvalSymlinks(absPath)
+	if err != nil {
+		return "", newCanonicalPathError(path, err)
+	}
+	if caseInsensitivePaths {
+		canonical = storedCase(canonical)
+	}
+
+	if opts.cache != nil {
+		opts.cache.put(absPath, canonical)
+	}
+
+	return canonical, nil
+}
+
+// storedCase returns the absolute path with each name spelled as in its directory listing
+func storedCase(path string) string {
+	volume := filepath.VolumeName(path)
+	current := volume + string(filepath.Separator)
+
+	for _, name := range strings.Split(path[len(volume):], string(filepath.Separator)) {
+		if name == "" {
+			continue
+		}
+
+		stored := name
+		if entries, err := os.ReadDir(current); err == nil {
+			for _, entry := range entries {
+				if entry.Name() == name {
+					stored = name
+					break
+				}
+				if strings.EqualFold(entry.Name(), name) {
+					stored = entry.Name()
+				}
+			}
+		}
+		current = filepath.Join(current, stored)
+	}
+
+	return current
+}
+
+// isWithin reports whether path is base or lies below it, both cleaned
+func isWithin(base, path string) bool {
+	rel, err := filepath.Rel(base, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func newCanonicalPathError(path string, err error) error {
+	return ErrCanonicalPath.
+		SetError(err).
+		SetData(pathErrorContext{
+			Path:  path,
+			Error: err,
+		})
+}
+
+func newExpandHomeError(path string, err error) error {
+	return ErrExpandHome.
+		SetError(err).
+		SetData(pathErrorContext{
+			Path:  path,
+			Error: err,
+		})
+}
@@ -0,0 +1,180 @@
+package fsx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestPathUtilities(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skipf("Home directory unknown: %v", err)
+	}
+
+	t.Run("ExpandHome", func(t *testing.T) {
+		tests := map[string]string{
+			"~":          home,
+			"~/data":     filepath.Join(home, "data"),
+			"/tmp/~data": "/tmp/~data",
+			"relative/~": "relative/~",
+		}
+		for input, expected := range tests {
+			expanded, err := ExpandHome(input)
+			if err != nil {
+				t.Fatalf("Failed to expand %s: %v", input, err)
+			}
+			if expanded != expected {
+				t.Errorf("%s: expected %s, got %s", input, expected, expanded)
+			}
+		}
+
+		if _, err := ExpandHome("~fsx-no-such-user/data"); err == nil {
+			t.Error("Expected error for unknown user")
+		}
+		// U+012F truncates to '/' as a byte, but is part of the user name
+		if expanded, err := ExpandHome("~\u012ffsx-no-such-user"); err == nil {
+			t.Errorf("Expected error for unknown user, got %s", expanded)
+		}
+	})
+
+	t.Run("NormalizePath", func(t *testing.T) {
+		t.Setenv("FSX_TEST_DIR", "env")
+
+		normalized, err := NormalizePath("~/a/../b//$FSX_TEST_DIR/", WithExpandEnv())
+		if err != nil {
+			t.Fatalf("Failed to normalize path: %v", err)
+		}
+		if expected := filepath.Join(home, "b", "env"); normalized != expected {
+			t.Errorf("Expected %s, got %s", expected, normalized)
+		}
+
+		normalized, err = NormalizePath("a/./b/../c")
+		if err != nil {
+			t.Fatalf("Failed to normalize path: %v", err)
+		}
+		if !filepath.IsAbs(normalized) || filepath.Base(normalized) != "c" {
+			t.Errorf("Expected absolute path ending in c, got %s", normalized)
+		}
+
+		normalized, err = NormalizePath("a/./b/../$FSX_TEST_DIR", WithKeepRelative(), WithForwardSlashes())
+		if err != nil {
+			t.Fatalf("Failed to normalize path: %v", err)
+		}
+		if normalized != "a/$FSX_TEST_DIR" {
+			t.Errorf("Expected variables kept without WithExpandEnv, got %s", normalized)
+		}
+	})
+
+	t.Run("SanitizeFilename", func(t *testing.T) {
+		tests := map[string]string{
+			"report.pdf":          "report.pdf",
+			`a/b\c:d*e?f"g<h>i|j`: "a_b_c_d_e_f_g_h_i_j",
+			"tab\there":           "tab_here",
+			"trailing. . ":        "trailing",
+			"CON":                 "CON_",
+			"com1.txt":            "com1_.txt",
+			"CON.tar.gz":          "CON_.tar.gz",
+			"nul .txt":            "nul _.txt",
+			"console.txt":         "console.txt",
+			"..":                  "_",
+			"":                    "_",
+			"bad\xffutf8":         "bad_utf8",
+		}
+		for input, expected := range tests {
+			if sanitized := SanitizeFilename(input); sanitized != expected {
+				t.Errorf("%q: expected %q, got %q", input, expected, sanitized)
+			}
+		}
+
+		if sanitized := SanitizeFilename("a:b/c", WithSanitizeTarget("linux"), WithSanitizeReplacement("")); sanitized != "a:bc" {
+			t.Errorf("Expected only / stripped for linux, got %q", sanitized)
+		}
+
+		long := SanitizeFilename(strings.Repeat("é", 200)+".txt", WithSanitizeMaxLength(100))
+		if len(long) > 100 || !strings.HasSuffix(long, ".txt") || !utf8.ValidString(long) {
+			t.Errorf("Expected valid name of at most 100 bytes keeping .txt, got %q (%d bytes)", long, len(long))
+		}
+	})
+
+	t.Run("CanonicalPath", func(t *testing.T) {
+		tmpDir, err := os.MkdirTemp("", "fsx_path_test_*")
+		if err != nil {
+			t.Fatalf("Failed to create temp dir: %v", err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		realDir := filepath.Join(tmpDir, "Real")
+		CreateFile(filepath.Join(realDir, "data.txt"), []byte("data"), WithCreateDirs())
+		if err := os.Symlink(realDir, filepath.Join(tmpDir, "link")); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+
+		expected, err := CanonicalPath(filepath.Join(realDir, "data.txt"))
+		if err != nil {
+			t.Fatalf("Failed to canonicalize path: %v", err)
+		}
+		if !filepath.IsAbs(expected) || filepath.Base(expected) != "data.txt" {
+			t.Errorf("Expected absolute path to data.txt, got %s", expected)
+		}
+
+		cache := NewPathCache()
+		viaLink, err := CanonicalPath(filepath.Join(tmpDir, "link", "data.txt"), WithPathCache(cache))
+		if err != nil {
+			t.Fatalf("Failed to canonicalize path: %v", err)
+		}
+		if viaLink != expected {
+			t.Errorf("Expected %s through the link, got %s", expected, viaLink)
+		}
+
+		// Cached results are returned until the cache is cleared
+		os.Remove(filepath.Join(tmpDir, "link"))
+		if cached, err := CanonicalPath(filepath.Join(tmpDir, "link", "data.txt"), WithPathCache(cache)); err != nil || cached != expected {
+			t.Errorf("Expected cached %s, got %s (%v)", expected, cached, err)
+		}
+		cache.Clear()
+		if _, err := CanonicalPath(filepath.Join(tmpDir, "link", "data.txt"), WithPathCache(cache)); !errors.Is(err, ErrCanonicalPath) {
+			t.Errorf("Expected ErrCanonicalPath after clearing the cache, got %v", err)
+		}
+
+		if caseInsensitivePaths {
+			folded, err := CanonicalPath(filepath.Join(tmpDir, "REAL", "DATA.TXT"))
+			if err != nil {
+				t.Fatalf("Failed to canonicalize path: %v", err)
+			}
+			if folded != expected {
+				t.Errorf("Expected stored case %s, got %s", expected, folded)
+			}
+		}
+	})
+
+	t.Run("SafeJoinThroughLinks", func(t *testing.T) {
+		tmpDir, err := os.MkdirTemp("", "fsx_path_test_*")
+		if err != nil {
+			t.Fatalf("Failed to create temp dir: %v", err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		base := filepath.Join(tmpDir, "base")
+		outside := filepath.Join(tmpDir, "outside")
+		CreateDirectories(filepath.Join(base, "inner"))
+		CreateDirectories(outside)
+		if err := os.Symlink(outside, filepath.Join(base, "escape")); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+		os.Symlink(filepath.Join(base, "inner"), filepath.Join(base, "shortcut"))
+
+		if _, err := SafeJoin(base, "escape/passwd"); !errors.Is(err, ErrUnsafeArchivePath) {
+			t.Errorf("Expected ErrUnsafeArchivePath through a link leaving base, got %v", err)
+		}
+		if joined, err := SafeJoin(base, "shortcut/new/file.txt"); err != nil || joined != filepath.Join(base, "shortcut", "new", "file.txt") {
+			t.Errorf("Expected a link inside base to be allowed, got %s (%v)", joined, err)
+		}
+		if _, err := SafeJoin(filepath.Join(tmpDir, "missing"), "a/b.txt"); err != nil {
+			t.Errorf("Expected a missing base to be allowed, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,31 @@
+//go:build darwin
+
+package fsx
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// preallocate allocates size bytes of disk space for file and sets its size
+func preallocate(file *os.File, size int64) error {
+	if size == 0 {
+		return nil
+	}
+
+	store := unix.Fstore_t{
+		Flags:   unix.F_ALLOCATEALL,
+		Posmode: unix.F_PEOFPOSMODE,
+		Length:  size,
+	}
+
+	if err := unix.FcntlFstore(file.Fd(), unix.F_PREALLOCATE, &store); err != nil {
+		if err != unix.ENOTSUP {
+			return err
+		}
+		return writeZeros(file, size)
+	}
+
+	return file.Truncate(size)
+}
@@ -0,0 +1,30 @@
+//go:build linux
+
+package fsx
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// preallocate allocates size bytes of disk space for file and sets its size
+func preallocate(file *os.File, size int64) error {
+	if size == 0 {
+		return nil
+	}
+
+	for {
+		err := unix.Fallocate(int(file.Fd()), 0, 0, size)
+		switch err {
+		case nil:
+			return nil
+		case unix.EINTR:
+			continue
+		case unix.EOPNOTSUPP:
+			return writeZeros(file, size)
+		default:
+			return err
+		}
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !linux && !darwin
+
+package fsx
+
+import (
+	"os"
+	"runtime"
+)
+
+// preallocate allocates size bytes of disk space for file and sets its size.
+// Extending a file on Windows allocates its clusters; elsewhere files may be sparse,
+// so zeros are written
+func preallocate(file *os.File, size int64) error {
+	if runtime.GOOS == "windows" {
+		return file.Truncate(size)
+	}
+
+	return writeZeros(file, size)
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package fsx
+
+import "golang.org/x/sys/unix"
+
+// processAlive reports whether a process with pid exists
+func processAlive(pid int) bool {
+	err := unix.Kill(pid, 0)
+	return err == nil || err == unix.EPERM
+}
@@ -0,0 +1,24 @@
+//go:build windows
+
+package fsx
+
+import "golang.org/x/sys/windows"
+
+// stillActive is the exit code GetExitCodeProcess reports for running processes
+const stillActive = 259
+
+// processAlive reports whether a process with pid exists
+func processAlive(pid int) bool {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return err == windows.ERROR_ACCESS_DENIED
+	}
+	defer windows.CloseHandle(handle)
+
+	var code uint32
+	if err := windows.GetExitCodeProcess(handle, &code); err != nil {
+		return true
+	}
+
+	return code == stillActive
+}
@@ -0,0 +1,353 @@
+package fsx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	queueSegmentExt = ".seg"
+	queueOffsetFile = "offset"
+	queueLockFile   = "lock"
+)
+
+// queueOffset is the position of the next item to dequeue, kept in the offset file
+type queueOffset struct {
+	Segment  uint64 `json:"segment"`
+	Position int64  `json:"position"`
+}
+
+// Queue is a persistent FIFO queue kept in a directory: items are appended as
+// checksummed records to numbered segment files and the consumer position is
+// replaced atomically in an offset file. Operations hold an exclusive file lock,
+// so several processes can share a queue
+type Queue struct {
+	mu       sync.Mutex
+	dir      string
+	lock     *os.File
+	opts     *queueOptions
+	verified map[uint64]int64 // segment sizes known to end with a valid record
+	closed   bool
+}
+
+// OpenQueue opens or creates the queue in dir
+func OpenQueue(dir string, options ...QueueOption) (*Queue, error) {
+	opts := defaultQueueOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, newQueueError(dir, err)
+	}
+
+	lock, err := os.OpenFile(filepath.Join(dir, queueLockFile), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, newQueueError(dir, err)
+	}
+
+	return &Queue{
+		dir:      dir,
+		lock:     lock,
+		opts:     opts,
+		verified: make(map[uint64]int64),
+	}, nil
+}
+
+// Enqueue appends item to the queue and, unless WithQueueNoSync is set, syncs it to disk
+func (q *Queue) Enqueue(item []byte) error {
+	frame, err := encodeJournalRecord(item)
+	if err == nil {
+		err = q.locked(func() error {
+			return q.append(frame)
+		})
+	}
+
+	if err != nil {
+		return ErrQueueEnqueue.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  q.dir,
+				Error: err,
+			})
+	}
+
+	return nil
+}
+
+// Dequeue removes and returns the oldest item. It fails with ErrQueueEmpty
+// when there is none
+func (q *Queue) Dequeue() ([]byte, error) {
+	return q.next(true)
+}
+
+// Peek returns the oldest item without removing it. It fails with ErrQueueEmpty
+// when there is none
+func (q *Queue) Peek() ([]byte, error) {
+	return q.next(false)
+}
+
+// Close releases the queue. Items stay on disk for the next OpenQueue
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+
+	if err := q.lock.Close(); err != nil {
+		return newQueueError(q.dir, err)
+	}
+
+	return nil
+}
+
+// locked runs fn holding the queue mutex and the lock shared with other processes
+func (q *Queue) locked(fn func() error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return os.ErrClosed
+	}
+
+	if err := lockHandle(q.lock); err != nil {
+		return err
+	}
+	defer unlockHandle(q.lock)
+
+	return fn()
+}
+
+// append writes frame to the last segment, starting a new one when it is full
+func (q *Queue) append(frame []byte) error {
+	segments, err := q.segments()
+	if err != nil {
+		return err
+	}
+
+	var id uint64
+	if len(segments) > 0 {
+		id = segments[len(segments)-1]
+	} else {
+		offset, err := q.readOffset()
+		if err != nil {
+			return err
+		}
+		id = offset.Segment + 1
+	}
+
+	file, size, err := q.openSegment(id)
+	if err != nil {
+		return err
+	}
+	if size > 0 && size+int64(len(frame)) > q.opts.segmentSize {
+		file.Close()
+		id++
+		if file, size, err = q.openSegment(id); err != nil {
+			return err
+		}
+	}
+	defer file.Close()
+
+	if _, err := file.Write(frame); err != nil {
+		file.Truncate(size)
+		return err
+	}
+	if !q.opts.noSync {
+		if err := file.Sync(); err != nil {
+			return err
+		}
+	}
+
+	q.verified[id] = size + int64(len(frame))
+	return nil
+}
+
+// openSegment opens segment id for appending. A record torn by a crashed writer is
+// cut off; only data appended since this queue last wrote the segment is checked
+func (q *Queue) openSegment(id uint64) (*os.File, int64, error) {
+	file, err := os.OpenFile(q.segmentPath(id), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	start := q.verified[id]
+	if info, err := file.Stat(); err != nil || info.Size() < start {
+		start = 0
+	}
+
+	size, err := recoverJournal(file, start)
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	q.verified[id] = size
+
+	return file, size, nil
+}
+
+// next returns the oldest item, removing it when advance is set. Fully consumed
+// segments are deleted when advancing past them
+func (q *Queue) next(advance bool) ([]byte, error) {
+	var item []byte
+	err := q.locked(func() error {
+		segments, err := q.segments()
+		if err != nil {
+			return err
+		}
+		offset, err := q.readOffset()
+		if err != nil {
+			return err
+		}
+
+		moved := false
+		for i, id := range segments {
+			if id < offset.Segment {
+				continue
+			}
+			if id > offset.Segment {
+				offset = queueOffset{Segment: id}
+				moved = true
+			}
+
+			record, err := readQueueRecord(q.segmentPath(id), offset.Position)
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				if i == len(segments)-1 {
+					break // the end, or a record torn by a crashed writer
+				}
+				if advance {
+					if err := os.Remove(q.segmentPath(id)); err != nil {
+						return err
+					}
+					delete(q.verified, id)
+				}
+				continue
+			}
+			if err != nil {
+				return ErrQueueCorrupt.
+					SetError(err).
+					SetData(pathErrorContext{
+						Path:  q.segmentPath(id),
+						Error: err,
+					})
+			}
+
+			item = record
+			if !advance {
+				return nil
+			}
+			offset.Position += journalHeaderSize + int64(len(record))
+			return q.writeOffset(offset)
+		}
+
+		if advance && moved {
+			if err := q.writeOffset(offset); err != nil {
+				return err
+			}
+		}
+
+		return ErrQueueEmpty.
+			SetData(pathErrorContext{
+				Path:  q.dir,
+				Error: nil,
+			})
+	})
+
+	if errors.Is(err, ErrQueueEmpty) || errors.Is(err, ErrQueueCorrupt) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, ErrQueueDequeue.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  q.dir,
+				Error: err,
+			})
+	}
+
+	return item, nil
+}
+
+// segments returns the ids of the segment files in ascending order
+func (q *Queue) segments() ([]uint64, error) {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uint64
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), queueSegmentExt)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if id, err := strconv.ParseUint(name, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	// Names are zero padded, so directory order is numeric order
+	return ids, nil
+}
+
+func (q *Queue) segmentPath(id uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", id, queueSegmentExt))
+}
+
+func (q *Queue) readOffset() (queueOffset, error) {
+	var offset queueOffset
+
+	data, err := os.ReadFile(filepath.Join(q.dir, queueOffsetFile))
+	if os.IsNotExist(err) {
+		return offset, nil
+	}
+	if err != nil {
+		return offset, err
+	}
+
+	err = json.Unmarshal(data, &offset)
+	return offset, err
+}
+
+func (q *Queue) writeOffset(offset queueOffset) error {
+	data, err := json.Marshal(offset)
+	if err != nil {
+		return err
+	}
+
+	return AtomicWriteFile(filepath.Join(q.dir, queueOffsetFile), data, 0644)
+}
+
+// readQueueRecord reads the record at position in the segment at path
+func readQueueRecord(path string, position int64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(position, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	return readJournalRecord(file)
+}
+
+func newQueueError(dir string, err error) error {
+	return ErrQueue.
+		SetError(err).
+		SetData(pathErrorContext{
+			Path:  dir,
+			Error: err,
+		})
+}
@@ -0,0 +1,160 @@
+package fsx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestQueue(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "fsx_queue_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	t.Run("EnqueueDequeuePeek", func(t *testing.T) {
+		dir := filepath.Join(tmpDir, "fifo")
+		queue, err := OpenQueue(dir, WithQueueSegmentSize(64))
+		if err != nil {
+			t.Fatalf("Failed to open queue: %v", err)
+		}
+
+		if _, err := queue.Dequeue(); !errors.Is(err, ErrQueueEmpty) {
+			t.Errorf("Expected ErrQueueEmpty, got %v", err)
+		}
+
+		// Small segments make the items span several segment files
+		for i := 0; i < 10; i++ {
+			if err := queue.Enqueue([]byte(fmt.Sprintf("item-%02d", i))); err != nil {
+				t.Fatalf("Failed to enqueue: %v", err)
+			}
+		}
+
+		item, err := queue.Peek()
+		if err != nil || string(item) != "item-00" {
+			t.Fatalf("Expected to peek item-00, got %q (%v)", item, err)
+		}
+		for i := 0; i < 4; i++ {
+			item, err := queue.Dequeue()
+			if err != nil || string(item) != fmt.Sprintf("item-%02d", i) {
+				t.Fatalf("Expected item-%02d, got %q (%v)", i, item, err)
+			}
+		}
+		queue.Close()
+
+		// The consumer position survives reopening
+		queue, err = OpenQueue(dir, WithQueueSegmentSize(64))
+		if err != nil {
+			t.Fatalf("Failed to reopen queue: %v", err)
+		}
+		defer queue.Close()
+
+		for i := 4; i < 10; i++ {
+			item, err := queue.Dequeue()
+			if err != nil || string(item) != fmt.Sprintf("item-%02d", i) {
+				t.Fatalf("Expected item-%02d, got %q (%v)", i, item, err)
+			}
+		}
+		if _, err := queue.Peek(); !errors.Is(err, ErrQueueEmpty) {
+			t.Errorf("Expected ErrQueueEmpty, got %v", err)
+		}
+
+		segments, _ := filepath.Glob(filepath.Join(dir, "*"+queueSegmentExt))
+		if len(segments) != 1 {
+			t.Errorf("Expected consumed segments to be removed, got %v", segments)
+		}
+	})
+
+	t.Run("SharedQueue", func(t *testing.T) {
+		dir := filepath.Join(tmpDir, "shared")
+
+		// Two queues on one directory lock like two processes would
+		producer, err := OpenQueue(dir, WithQueueNoSync(), WithQueueSegmentSize(256))
+		if err != nil {
+			t.Fatalf("Failed to open queue: %v", err)
+		}
+		defer producer.Close()
+		consumer, err := OpenQueue(dir, WithQueueNoSync(), WithQueueSegmentSize(256))
+		if err != nil {
+			t.Fatalf("Failed to open queue: %v", err)
+		}
+		defer consumer.Close()
+
+		var wg sync.WaitGroup
+		for _, queue := range []*Queue{producer, consumer} {
+			wg.Add(1)
+			go func(queue *Queue) {
+				defer wg.Done()
+				for i := 0; i < 50; i++ {
+					queue.Enqueue([]byte(fmt.Sprintf("%p-%02d", queue, i)))
+				}
+			}(queue)
+		}
+		wg.Wait()
+
+		var items []string
+		for {
+			item, err := consumer.Dequeue()
+			if errors.Is(err, ErrQueueEmpty) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Failed to dequeue: %v", err)
+			}
+			items = append(items, string(item))
+		}
+		if len(items) != 100 {
+			t.Fatalf("Expected 100 items, got %d", len(items))
+		}
+		var produced []string
+		for _, item := range items {
+			if strings.HasPrefix(item, fmt.Sprintf("%p-", producer)) {
+				produced = append(produced, item)
+			}
+		}
+		if len(produced) != 50 || !sort.StringsAreSorted(produced) {
+			t.Errorf("Expected the 50 items of one producer in FIFO order, got %v", produced)
+		}
+	})
+
+	t.Run("TornItem", func(t *testing.T) {
+		dir := filepath.Join(tmpDir, "torn")
+		queue, err := OpenQueue(dir)
+		if err != nil {
+			t.Fatalf("Failed to open queue: %v", err)
+		}
+		defer queue.Close()
+
+		queue.Enqueue([]byte("whole"))
+		queue.Enqueue([]byte("torn item"))
+
+		// Cut the last item in half, as a crashed writer would leave it
+		segments, _ := filepath.Glob(filepath.Join(dir, "*"+queueSegmentExt))
+		info, _ := os.Stat(segments[0])
+		os.Truncate(segments[0], info.Size()-3)
+
+		if item, err := queue.Dequeue(); err != nil || string(item) != "whole" {
+			t.Fatalf("Expected whole, got %q (%v)", item, err)
+		}
+		if _, err := queue.Dequeue(); !errors.Is(err, ErrQueueEmpty) {
+			t.Errorf("Expected the torn item to be ignored, got %v", err)
+		}
+
+		// The next writer cuts the torn item off before appending
+		fresh, err := OpenQueue(dir)
+		if err != nil {
+			t.Fatalf("Failed to open queue: %v", err)
+		}
+		defer fresh.Close()
+		fresh.Enqueue([]byte("next"))
+		if item, err := queue.Dequeue(); err != nil || string(item) != "next" {
+			t.Errorf("Expected next, got %q (%v)", item, err)
+		}
+	})
+}
@@ -0,0 +1,317 @@
+package fsx
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// renameStep is a rename done by RenameAll, undone on rollback
+type renameStep struct {
+	from string
+	to   string
+}
+
+// plannedRename is a validated pair of RenameAll
+type plannedRename struct {
+	RenamePair
+	replace bool // the destination exists and is replaced
+	staged  string
+	backup  string
+}
+
+// RenameAll renames every pair as one batch. The whole batch is validated first:
+// missing sources, duplicate sources, destinations colliding with each other or with
+// existing entries (ErrRenameConflict) and sources nested in other sources fail it
+// without renaming anything. Sources are then moved to staging names next to them and
+// from there to their destinations, so swaps and cycles work; if any rename fails, the
+// done renames are rolled back
+func RenameAll(pairs []RenamePair, options ...RenameOption) error {
+	opts := defaultRenameOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	plan, err := planRenames(pairs, opts)
+	if err != nil || opts.dryRun {
+		return err
+	}
+
+	return applyRenames(plan, opts)
+}
+
+// renameKeys identifies paths for collision checks, ignoring case where the filesystem
+// of their directory does
+type renameKeys struct {
+	folded map[string]bool
+}
+
+func newRenameKeys() *renameKeys {
+	return &renameKeys{
+		folded: make(map[string]bool),
+	}
+}
+
+// key returns the absolute path, lower cased on case-insensitive filesystems
+func (k *renameKeys) key(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	dir := filepath.Dir(path)
+	folded, ok := k.folded[dir]
+	if !ok {
+		folded = caseInsensitiveDir(dir)
+		k.folded[dir] = folded
+	}
+	if folded {
+		path = strings.ToLower(path)
+	}
+
+	return path
+}
+
+// planRenames validates pairs and returns the renames to apply
+func planRenames(pairs []RenamePair, opts *renameOptions) ([]*plannedRename, error) {
+	keys := newRenameKeys()
+	sources := make(map[string]int, len(pairs))
+	destinations := make(map[string]int, len(pairs))
+	for i, pair := range pairs {
+		sources[keys.key(pair.From)] = i
+		destinations[keys.key(pair.To)] = i
+	}
+
+	var (
+		plan     []*plannedRename
+		problems []error
+		seenFrom = make(map[string]bool, len(pairs))
+		seenTo   = make(map[string]bool, len(pairs))
+	)
+	for _, pair := range pairs {
+		if pair.From == "" || pair.To == "" {
+			problems = append(problems, newInvalidRenameError(pair, errors.New("empty path")))
+			continue
+		}
+
+		pair.From, pair.To = filepath.Clean(pair.From), filepath.Clean(pair.To)
+		fromKey, toKey := keys.key(pair.From), keys.key(pair.To)
+
+		if _, err := os.Lstat(pair.From); err != nil {
+			problems = append(problems, newInvalidRenameError(pair, err))
+			continue
+		}
+
+		if seenFrom[fromKey] {
+			problems = append(problems, newInvalidRenameError(pair, errors.New("duplicate source")))
+			continue
+		}
+		seenFrom[fromKey] = true
+
+		if seenTo[toKey] {
+			problems = append(problems, newRenameConflictError(pair, errors.New("duplicate destination")))
+			continue
+		}
+		seenTo[toKey] = true
+
+		if pair.From == pair.To {
+			continue
+		}
+
+		planned := &plannedRename{RenamePair: pair}
+
+		// A case-only rename finds its own source on case-insensitive filesystems
+		if info, err := os.Lstat(pair.To); err == nil && toKey != fromKey {
+			if _, moved := sources[toKey]; !moved {
+				if !opts.overwrite {
+					problems = append(problems, newRenameConflictError(pair, os.ErrExist))
+					continue
+				}
+				// Replacing would remove the whole tree
+				if info.IsDir() {
+					problems = append(problems, newRenameConflictError(pair, errors.New("destination is a directory")))
+					continue
+				}
+				planned.replace = true
+			}
+		}
+
+		if problem := checkRenameNesting(pair, fromKey, toKey, sources); problem != nil {
+			problems = append(problems, problem)
+			continue
+		}
+
+		if parent := filepath.Dir(pair.To); !opts.createDirs && !DirectoryExist(parent) {
+			if _, planned := destinations[keys.key(parent)]; !planned {
+				problems = append(problems, newInvalidRenameError(pair, errors.New("destination directory does not exist")))
+				continue
+			}
+		}
+
+		plan = append(plan, planned)
+	}
+
+	if len(problems) > 0 {
+		messages := make([]string, len(problems))
+		for i, problem := range problems {
+			messages[i] = problem.Error()
+		}
+
+		return nil, ErrInvalidRename.
+			SetError(errors.Join(problems...)).
+			SetData(struct {
+				Pairs    int      `json:"pairs"`
+				Problems []string `json:"problems"`
+			}{
+				Pairs:    len(pairs),
+				Problems: messages,
+			})
+	}
+
+	return plan, nil
+}
+
+// checkRenameNesting rejects sources and destinations inside renamed sources,
+// whose paths change during the batch
+func checkRenameNesting(pair RenamePair, fromKey, toKey string, sources map[string]int) error {
+	for dir := filepath.Dir(fromKey); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if _, nested := sources[dir]; nested {
+			return newInvalidRenameError(pair, errors.New("source inside a renamed source"))
+		}
+	}
+
+	for dir := filepath.Dir(toKey); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if _, nested := sources[dir]; nested {
+			return newInvalidRenameError(pair, errors.New("destination inside a renamed source"))
+		}
+	}
+
+	return nil
+}
+
+// applyRenames stages every source, moves it to its destination and rolls back on failure
+func applyRenames(plan []*plannedRename, opts *renameOptions) error {
+	var (
+		done    []renameStep
+		created []string
+	)
+
+	fail := func(pair RenamePair, err error) error {
+		if rollbackErr := rollbackRenames(done, created); rollbackErr != nil {
+			err = errors.Join(err, rollbackErr)
+		}
+
+		return ErrRename.
+			SetError(err).
+			SetData(moveErrorContext{
+				Source:      pair.From,
+				Destination: pair.To,
+				Error:       err,
+			})
+	}
+
+	rename := func(from, to string) error {
+		if err := os.Rename(from, to); err != nil {
+			return err
+		}
+		done = append(done, renameStep{from: from, to: to})
+		return nil
+	}
+
+	for _, planned := range plan {
+		if planned.replace {
+			planned.backup = stagingName(planned.To)
+			if err := rename(planned.To, planned.backup); err != nil {
+				return fail(planned.RenamePair, err)
+			}
+		}
+
+		planned.staged = stagingName(planned.From)
+		if err := rename(planned.From, planned.staged); err != nil {
+			return fail(planned.RenamePair, err)
+		}
+	}
+
+	// Parents first, so destinations inside other destinations find their directory
+	sort.SliceStable(plan, func(i, j int) bool {
+		return strings.Count(plan[i].To, string(filepath.Separator)) < strings.Count(plan[j].To, string(filepath.Separator))
+	})
+
+	for _, planned := range plan {
+		if opts.createDirs {
+			missing := missingDirectories(filepath.Dir(planned.To))
+			if err := os.MkdirAll(filepath.Dir(planned.To), 0755); err != nil {
+				return fail(planned.RenamePair, err)
+			}
+			created = append(created, missing...)
+		}
+
+		if err := rename(planned.staged, planned.To); err != nil {
+			return fail(planned.RenamePair, err)
+		}
+	}
+
+	// The batch is done; replaced entries are dropped
+	for _, planned := range plan {
+		if planned.backup != "" {
+			os.RemoveAll(planned.backup)
+		}
+	}
+
+	return nil
+}
+
+// rollbackRenames undoes done renames, newest first, and removes created directories
+func rollbackRenames(done []renameStep, created []string) error {
+	var errs []error
+	for i := len(done) - 1; i >= 0; i-- {
+		if err := os.Rename(done[i].to, done[i].from); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Deepest first, so parents are empty when they are removed
+	sort.Slice(created, func(i, j int) bool {
+		return len(created[i]) > len(created[j])
+	})
+	for _, dir := range created {
+		os.Remove(dir)
+	}
+
+	return errors.Join(errs...)
+}
+
+// stagingName returns an unused name next to path
+func stagingName(path string) string {
+	random := make([]byte, 8)
+	for {
+		rand.Read(random)
+		name := filepath.Join(filepath.Dir(path), ".fsx-rename-"+hex.EncodeToString(random))
+		if _, err := os.Lstat(name); os.IsNotExist(err) {
+			return name
+		}
+	}
+}
+
+func newInvalidRenameError(pair RenamePair, err error) error {
+	return ErrInvalidRename.
+		SetError(err).
+		SetData(moveErrorContext{
+			Source:      pair.From,
+			Destination: pair.To,
+			Error:       err,
+		})
+}
+
+func newRenameConflictError(pair RenamePair, err error) error {
+	return ErrRenameConflict.
+		SetError(err).
+		SetData(moveErrorContext{
+			Source:      pair.From,
+			Destination: pair.To,
+			Error:       err,
+		})
+}
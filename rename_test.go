@@ -0,0 +1,372 @@
+package fsx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRenameAll(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "fsx_rename_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := func(name string) string {
+		return filepath.Join(tmpDir, name)
+	}
+	write := func(t *testing.T, files map[string]string) {
+		t.Helper()
+		for name, content := range files {
+			if err := WriteFileString(path(name), content, WithCreateDirs()); err != nil {
+				t.Fatalf("Failed to write %s: %v", name, err)
+			}
+		}
+	}
+	expect := func(t *testing.T, files map[string]string) {
+		t.Helper()
+		for name, content := range files {
+			if got, err := ReadFileString(path(name)); err != nil || got != content {
+				t.Errorf("%s: expected %q, got %q (%v)", name, content, got, err)
+			}
+		}
+		if leftovers, _ := filepath.Glob(path(".fsx-rename-*")); len(leftovers) != 0 {
+			t.Errorf("Staging names left: %v", leftovers)
+		}
+	}
+
+	t.Run("SwapAndCycle", func(t *testing.T) {
+		write(t, map[string]string{"swap/a": "a", "swap/b": "b", "swap/c": "c"})
+
+		err := RenameAll([]RenamePair{
+			{From: path("swap/a"), To: path("swap/b")},
+			{From: path("swap/b"), To: path("swap/c")},
+			{From: path("swap/c"), To: path("swap/a")},
+		})
+		if err != nil {
+			t.Fatalf("Failed to rename: %v", err)
+		}
+		expect(t, map[string]string{"swap/b": "a", "swap/c": "b", "swap/a": "c"})
+	})
+
+	t.Run("DirectoriesAndNestedDestinations", func(t *testing.T) {
+		write(t, map[string]string{"tree/old/inner.txt": "inner", "tree/loose.txt": "loose"})
+
+		err := RenameAll([]RenamePair{
+			{From: path("tree/loose.txt"), To: path("tree/new/loose.txt")},
+			{From: path("tree/old"), To: path("tree/new")},
+		})
+		if err != nil {
+			t.Fatalf("Failed to rename: %v", err)
+		}
+		expect(t, map[string]string{"tree/new/inner.txt": "inner", "tree/new/loose.txt": "loose"})
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		write(t, map[string]string{"check/a": "a", "check/b": "b", "check/taken": "taken", "check/dir/x": "x"})
+
+		err := RenameAll([]RenamePair{
+			{From: path("check/a"), To: path("check/taken")},     // existing destination
+			{From: path("check/missing"), To: path("check/m")},   // missing source
+			{From: path("check/dir"), To: path("check/d")},       // fine on its own
+			{From: path("check/dir/x"), To: path("check/x")},     // inside a renamed source
+			{From: path("check/b"), To: path("check/nowhere/b")}, // missing destination directory
+			{From: path("check/b"), To: path("check/d")},         // duplicate source
+		})
+		if !errors.Is(err, ErrInvalidRename) || !errors.Is(err, ErrRenameConflict) {
+			t.Fatalf("Expected ErrInvalidRename with conflicts, got %v", err)
+		}
+		expect(t, map[string]string{"check/a": "a", "check/b": "b", "check/taken": "taken", "check/dir/x": "x"})
+
+		// Dry run validates only
+		err = RenameAll([]RenamePair{{From: path("check/a"), To: path("check/nowhere/a")}},
+			WithRenameCreateDirs(), WithRenameDryRun())
+		if err != nil || FileExist(path("check/nowhere/a")) {
+			t.Errorf("Dry run should validate without renaming: %v", err)
+		}
+	})
+
+	t.Run("OverwriteAndCreateDirs", func(t *testing.T) {
+		write(t, map[string]string{"over/a": "new", "over/b": "old", "over/c": "c"})
+
+		err := RenameAll([]RenamePair{
+			{From: path("over/a"), To: path("over/b")},
+			{From: path("over/c"), To: path("over/sub/dir/c")},
+		}, WithRenameOverwrite(), WithRenameCreateDirs())
+		if err != nil {
+			t.Fatalf("Failed to rename: %v", err)
+		}
+		expect(t, map[string]string{"over/b": "new", "over/sub/dir/c": "c"})
+
+		// Directories are never replaced
+		write(t, map[string]string{"over/keep/deep/important.db": "data"})
+		err = RenameAll([]RenamePair{{From: path("over/b"), To: path("over/keep")}}, WithRenameOverwrite())
+		if !errors.Is(err, ErrRenameConflict) {
+			t.Errorf("Expected ErrRenameConflict for a directory destination, got %v", err)
+		}
+		expect(t, map[string]string{"over/b": "new", "over/keep/deep/important.db": "data"})
+	})
+
+	t.Run("CaseProbe", func(t *testing.T) {
+		write(t, map[string]string{"probe/Name.txt": "probe"})
+
+		_, err := os.Lstat(path("probe/NAME.TXT"))
+		if folded := caseInsensitiveDir(path("probe")); folded != (err == nil) {
+			t.Errorf("Probe reported case-insensitive %v, lookup of another case gave %v", folded, err)
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		write(t, map[string]string{"roll/a": "a", "roll/b": "b", "roll/c": "c", "roll/file": "blocks directories"})
+
+		// The last rename can't create its directory below a file
+		err := RenameAll([]RenamePair{
+			{From: path("roll/b"), To: path("roll/c")},
+			{From: path("roll/c"), To: path("roll/new/b")},
+			{From: path("roll/a"), To: path("roll/file/deeper/a")},
+		}, WithRenameCreateDirs())
+		if !errors.Is(err, ErrRename) {
+			t.Fatalf("Expected ErrRename, got %v", err)
+		}
+		expect(t, map[string]string{"roll/a": "a", "roll/b": "b", "roll/c": "c"})
+		if DirectoryExist(path("roll/new")) {
+			t.Error("Created directories should be removed on rollback")
+		}
+	})
+}
+
+func TestBatchRename(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "fsx_batch_rename_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	setup := func(t *testing.T, dir string, names ...string) string {
+		t.Helper()
+		root := filepath.Join(tmpDir, dir)
+		for _, name := range names {
+			if err := WriteFileString(filepath.Join(root, name), name, WithCreateDirs()); err != nil {
+				t.Fatalf("Failed to write %s: %v", name, err)
+			}
+		}
+		return root
+	}
+	expect := func(t *testing.T, root string, files map[string]string) {
+		t.Helper()
+		for name, content := range files {
+			if got, err := ReadFileString(filepath.Join(root, name)); err != nil || got != content {
+				t.Errorf("%s: expected %q, got %q (%v)", name, content, got, err)
+			}
+		}
+	}
+
+	t.Run("SequenceAndDate", func(t *testing.T) {
+		root := setup(t, "seq", "IMG_10.jpg", "IMG_9.jpg", "IMG_100.jpg", "notes.txt", "sub/IMG_1.jpg")
+		mtime := time.Date(2024, 3, 15, 12, 0, 0, 0, time.Local)
+		os.Chtimes(filepath.Join(root, "IMG_9.jpg"), mtime, mtime)
+
+		pairs, err := BatchRename(root, "IMG_*.jpg", "holiday_{seq:3}_{mtime:20060102}{ext}")
+		if err != nil {
+			t.Fatalf("Failed to rename: %v", err)
+		}
+		if len(pairs) != 3 {
+			t.Errorf("Expected 3 renames, got %v", pairs)
+		}
+		expect(t, root, map[string]string{
+			"holiday_001_20240315.jpg": "IMG_9.jpg",
+			"notes.txt":                "notes.txt",
+			"sub/IMG_1.jpg":            "sub/IMG_1.jpg",
+		})
+
+		entries, _ := os.ReadDir(root)
+		if len(entries) != 5 { // 3 renamed, notes.txt, sub
+			t.Errorf("Unexpected entries: %v", entries)
+		}
+	})
+
+	t.Run("RegexAndDryRun", func(t *testing.T) {
+		root := setup(t, "regex", "report-2023.TXT", "report-2024.TXT", "sub/report-2022.TXT")
+
+		pairs, err := BatchRename(root, `^report-(\d+)\.TXT$`, "$1-{name}.txt", WithBatchRenameRegex(), WithBatchRenameRecursive(), WithBatchRenameDryRun())
+		if err != nil {
+			t.Fatalf("Failed to plan renames: %v", err)
+		}
+		if len(pairs) != 3 || filepath.Base(pairs[0].To) != "2023-report-2023.txt" {
+			t.Errorf("Unexpected plan: %v", pairs)
+		}
+		expect(t, root, map[string]string{"report-2023.TXT": "report-2023.TXT"})
+
+		if _, err := BatchRename(root, `^report-(\d+)\.TXT$`, "$1.txt", WithBatchRenameRegex(), WithBatchRenameRecursive()); err != nil {
+			t.Fatalf("Failed to rename: %v", err)
+		}
+		expect(t, root, map[string]string{"2023.txt": "report-2023.TXT", "sub/2022.txt": "sub/report-2022.TXT"})
+	})
+
+	t.Run("Collisions", func(t *testing.T) {
+		root := setup(t, "collide", "a.log", "b.log", "c.log", "taken.log")
+
+		if _, err := BatchRename(root, "[ab].log", "taken.log"); !errors.Is(err, ErrRenameConflict) {
+			t.Errorf("Expected ErrRenameConflict by default, got %v", err)
+		}
+
+		pairs, err := BatchRename(root, "[ab].log", "taken.log", WithCollisionStrategy(CollisionSkip))
+		if err != nil || len(pairs) != 0 {
+			t.Errorf("Expected every rename to be skipped: %v, %v", pairs, err)
+		}
+
+		if _, err := BatchRename(root, "[ab].log", "taken.log", WithCollisionStrategy(CollisionUniquify)); err != nil {
+			t.Fatalf("Failed to rename: %v", err)
+		}
+		expect(t, root, map[string]string{"taken.log": "taken.log", "taken.2.log": "a.log", "taken.3.log": "b.log"})
+
+		if _, err := BatchRename(root, "c.log", "taken.log", WithCollisionStrategy(CollisionOverwrite)); err != nil {
+			t.Fatalf("Failed to rename: %v", err)
+		}
+		expect(t, root, map[string]string{"taken.log": "c.log"})
+
+		setup(t, "collide", "keep/deep/important.db")
+		if _, err := BatchRename(root, "taken.log", "keep", WithCollisionStrategy(CollisionOverwrite)); !errors.Is(err, ErrRenameConflict) {
+			t.Errorf("Expected ErrRenameConflict for a directory, got %v", err)
+		}
+		expect(t, root, map[string]string{"taken.log": "c.log", "keep/deep/important.db": "keep/deep/important.db"})
+
+		// A swap is not a collision
+		if _, err := BatchRename(root, "taken.[23].log", "{name}.log", WithBatchRenameDryRun()); err != nil {
+			t.Errorf("Unchanged names should not collide: %v", err)
+		}
+	})
+}
+
+func TestNormalizeFilenameCase(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "fsx_filename_case_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	setup := func(t *testing.T, dir string, names ...string) string {
+		t.Helper()
+		root := filepath.Join(tmpDir, dir)
+		for _, name := range names {
+			if err := WriteFileString(filepath.Join(root, name), name, WithCreateDirs()); err != nil {
+				t.Fatalf("Failed to write %s: %v", name, err)
+			}
+		}
+		return root
+	}
+
+	t.Run("Conversions", func(t *testing.T) {
+		cases := map[FilenameCase]map[string]string{
+			CaseLower: {"Logo Big.PNG": "logo big.png"},
+			CaseUpper: {"readme.md": "README.MD"},
+			CaseSnake: {"LogoBig-v2.PNG": "logo_big_v2.png", "HTMLParser.go": "html_parser.go", ".EnvLocal": ".env_local", "___": "___"},
+			CaseKebab: {"LogoBig_v2.PNG": "logo-big-v2.png", "my file (1).txt": "my-file-1.txt"},
+		}
+		for style, names := range cases {
+			for name, expected := range names {
+				if got := convertFilenameCase(name, style, false); got != expected {
+					t.Errorf("Case %d of %q: expected %q, got %q", style, name, expected, got)
+				}
+			}
+		}
+		if got := convertFilenameCase("Release.V2", CaseSnake, true); got != "release_v2" {
+			t.Errorf("Directories have no extension, got %q", got)
+		}
+	})
+
+	t.Run("Recursive", func(t *testing.T) {
+		root := setup(t, "tree", "Assets/IconSet/BigIcon.PNG", "Assets/IconSet/small-icon.png", "Assets/ReadMe.md", "Top File.txt")
+
+		pairs, err := NormalizeFilenameCase(root, CaseSnake, WithFilenameCaseDryRun())
+		if err != nil {
+			t.Fatalf("Failed to plan renames: %v", err)
+		}
+		if len(pairs) != 6 || !FileExist(filepath.Join(root, "Assets", "ReadMe.md")) {
+			t.Errorf("Unexpected plan: %v", pairs)
+		}
+
+		pairs, err = NormalizeFilenameCase(root, CaseSnake)
+		if err != nil {
+			t.Fatalf("Failed to rename: %v", err)
+		}
+
+		for _, name := range []string{"assets/icon_set/big_icon.png", "assets/icon_set/small_icon.png", "assets/read_me.md", "top_file.txt"} {
+			if !FileExist(filepath.Join(root, name)) {
+				t.Errorf("Expected %s to exist", name)
+			}
+		}
+		for _, pair := range pairs {
+			if _, err := os.Lstat(pair.To); err != nil {
+				t.Errorf("Returned destination %s does not exist", pair.To)
+			}
+		}
+	})
+
+	t.Run("FilesOnly", func(t *testing.T) {
+		root := setup(t, "files", "SubDir/MyFile.txt")
+
+		if _, err := NormalizeFilenameCase(root, CaseKebab, WithFilenameCaseFilesOnly()); err != nil {
+			t.Fatalf("Failed to rename: %v", err)
+		}
+		if !FileExist(filepath.Join(root, "SubDir", "my-file.txt")) {
+			t.Error("Expected only the file to be renamed")
+		}
+	})
+
+	t.Run("Collisions", func(t *testing.T) {
+		root := setup(t, "collide", "Logo_Big.png", "logo-big.png", "Other.png")
+
+		if _, err := NormalizeFilenameCase(root, CaseKebab); !errors.Is(err, ErrRenameConflict) {
+			t.Errorf("Expected ErrRenameConflict, got %v", err)
+		}
+		if !FileExist(filepath.Join(root, "Other.png")) {
+			t.Error("Nothing should be renamed on a conflict")
+		}
+
+		if _, err := NormalizeFilenameCase(root, CaseKebab, WithFilenameCaseCollision(CollisionSkip)); err != nil {
+			t.Fatalf("Failed to rename: %v", err)
+		}
+		if !FileExist(filepath.Join(root, "Logo_Big.png")) || !FileExist(filepath.Join(root, "other.png")) {
+			t.Error("Expected the colliding file to keep its name")
+		}
+
+		if _, err := NormalizeFilenameCase(root, CaseKebab, WithFilenameCaseCollision(CollisionUniquify)); err != nil {
+			t.Fatalf("Failed to rename: %v", err)
+		}
+		if content, _ := ReadFileString(filepath.Join(root, "logo-big.2.png")); content != "Logo_Big.png" {
+			t.Errorf("Expected a uniquified name, got %q", content)
+		}
+
+		if _, err := NormalizeFilenameCase(root, CaseKebab, WithFilenameCaseCollision(CollisionOverwrite)); !errors.Is(err, ErrInvalidRename) {
+			t.Errorf("Expected ErrInvalidRename, got %v", err)
+		}
+		if _, err := NormalizeFilenameCase(root, FilenameCase(42)); !errors.Is(err, ErrUnsupportedFilenameCase) {
+			t.Errorf("Expected ErrUnsupportedFilenameCase, got %v", err)
+		}
+	})
+
+	t.Run("CaseOnly", func(t *testing.T) {
+		root := setup(t, "case", "ReadMe.md")
+
+		if caseInsensitiveDir(root) {
+			// The only entry is renamed in place
+			if _, err := NormalizeFilenameCase(root, CaseLower); err != nil {
+				t.Fatalf("Failed to rename: %v", err)
+			}
+			if names, _ := os.ReadDir(root); len(names) != 1 || names[0].Name() != "readme.md" {
+				t.Errorf("Expected readme.md, got %v", names)
+			}
+			return
+		}
+
+		// Both names exist on case-sensitive filesystems and collide in lower case
+		setup(t, "case", "readme.md")
+		if _, err := NormalizeFilenameCase(root, CaseLower); !errors.Is(err, ErrRenameConflict) {
+			t.Errorf("Expected ErrRenameConflict, got %v", err)
+		}
+	})
+}
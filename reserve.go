@@ -0,0 +1,113 @@
+package fsx
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ReserveFile atomically creates a placeholder of size bytes at path with its disk space
+// allocated, failing with ErrFileExists when path exists. The placeholder is prepared
+// under a temp name and linked into place, so it never appears partially allocated and
+// concurrent callers can use it to claim output slots. The placeholder gets mode 0644,
+// the umask doesn't apply
+func ReserveFile(path string, size int64) error {
+	if size < 0 {
+		return newReserveFileError(path, errors.New("negative size"))
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), ".reserve-*")
+	if err != nil {
+		return newReserveFileError(path, err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	// CreateTemp uses 0600, give the placeholder the mode reserveInPlace uses
+	if err := tmpFile.Chmod(0644); err != nil {
+		tmpFile.Close()
+		return newReserveFileError(path, err)
+	}
+
+	if err := preallocate(tmpFile, size); err != nil {
+		tmpFile.Close()
+		return newReserveFileError(path, err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return newReserveFileError(path, err)
+	}
+
+	err = os.Link(tmpPath, path)
+	if errors.Is(err, os.ErrExist) {
+		return newFileExistsError(path, err)
+	}
+	if err == nil {
+		return nil
+	}
+
+	// Filesystems without hardlinks: claim the name first, then allocate
+	return reserveInPlace(path, size)
+}
+
+// reserveInPlace creates path exclusively and allocates its space, removing it on failure
+func reserveInPlace(path string, size int64) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return newFileExistsError(path, err)
+	}
+	if err != nil {
+		return newReserveFileError(path, err)
+	}
+
+	err = file.Chmod(0644)
+	if err == nil {
+		err = preallocate(file, size)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return newReserveFileError(path, err)
+	}
+
+	return nil
+}
+
+// writeZeros allocates space by writing size zero bytes from the start of file
+func writeZeros(file *os.File, size int64) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	zeros := make([]byte, min(size, 1024*1024))
+	for size > 0 {
+		n, err := file.Write(zeros[:min(size, int64(len(zeros)))])
+		if err != nil {
+			return err
+		}
+		size -= int64(n)
+	}
+
+	return nil
+}
+
+func newReserveFileError(path string, err error) error {
+	return ErrReserveFile.
+		SetError(err).
+		SetData(pathErrorContext{
+			Path:  path,
+			Error: err,
+		})
+}
+
+func newFileExistsError(path string, err error) error {
+	return ErrFileExists.
+		SetError(err).
+		SetData(pathErrorContext{
+			Path:  path,
+			Error: err,
+		})
+}
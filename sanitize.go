@@ -0,0 +1,88 @@
+package fsx
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// windowsReservedNames are device names Windows rejects as file names, with any extension
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// SanitizeFilename turns user input into a safe file name: path separators, control
+// characters and, for Windows, <>:"\|?* are replaced, the name is truncated to the maximum
+// length keeping its extension, for Windows trailing dots and spaces are removed and reserved
+// device names like CON (also as CON.tar.gz) get the replacement appended, "." and ".." are replaced
+func SanitizeFilename(name string, options ...SanitizeOption) string {
+	opts := defaultSanitizeOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	fallback := opts.replacement
+	if fallback == "" {
+		fallback = "_"
+	}
+
+	windows := opts.target == "windows"
+
+	var builder strings.Builder
+	for _, r := range strings.ToValidUTF8(name, opts.replacement) {
+		if r == '/' || r == 0 || (windows && (r < 0x20 || strings.ContainsRune(`<>:"\|?*`, r))) || r == 0x7f {
+			builder.WriteString(opts.replacement)
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	sanitized := builder.String()
+
+	if opts.maxLength > 0 && len(sanitized) > opts.maxLength {
+		sanitized = truncateFilename(sanitized, opts.maxLength)
+	}
+
+	if windows {
+		sanitized = strings.TrimRight(sanitized, ". ")
+
+		// Windows ignores everything from the first dot on: CON.tar.gz is CON too
+		stem, rest, _ := strings.Cut(sanitized, ".")
+		if windowsReservedNames[strings.ToUpper(strings.TrimRight(stem, " "))] {
+			sanitized = stem + fallback
+			if rest != "" {
+				sanitized += "." + rest
+			}
+		}
+	}
+
+	if sanitized == "" || sanitized == "." || sanitized == ".." {
+		sanitized = fallback
+	}
+
+	return sanitized
+}
+
+// truncateFilename shortens a name to at most maxLength bytes on a character boundary,
+// keeping the extension unless it alone is too long
+func truncateFilename(name string, maxLength int) string {
+	ext := filepath.Ext(name)
+	if len(ext) >= maxLength {
+		ext = ""
+	}
+
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+	if ext == "" {
+		stem = name
+	}
+
+	limit := maxLength - len(ext)
+	for limit > 0 && !utf8.RuneStart(stem[limit]) {
+		limit--
+	}
+
+	return stem[:limit] + ext
+}
@@ -1,12 +1,18 @@
 package fsx
 
 import (
+	"bufio"
+	"errors"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 )
 
 // FindFiles finds files by name pattern (supports wildcards)
@@ -16,88 +22,19 @@ func FindFiles(root string, pattern string, options ...SearchOption) ([]SearchRe
 		opt(opts)
 	}
 
-	var results []SearchResult
-	currentDepth := 0
-	resultsFound := 0
-
-	err := walkWithDepth(root, currentDepth, func(path string, info os.FileInfo, depth int, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Check depth limits
-		if opts.maxDepth >= 0 && depth > opts.maxDepth {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-		if depth < opts.minDepth {
-			return nil
-		}
-
-		// Check result limit
-		if opts.limitResults > 0 && resultsFound >= opts.limitResults {
-			return io.EOF // Stop walking
-		}
-
-		// Handle hidden files
-		if opts.ignoreHidden && isHidden(info.Name()) {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		// Apply exclude patterns first
-		for _, excludePattern := range opts.excludePatterns {
-			matched, err := matchPattern(info.Name(), excludePattern, opts.caseSensitive)
-			if err != nil {
-				return err
-			}
-			if matched {
-				if info.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
-			}
-		}
-
-		// Apply include patterns
-		if len(opts.includePatterns) > 0 {
-			included := false
-			for _, includePattern := range opts.includePatterns {
-				matched, err := matchPattern(info.Name(), includePattern, opts.caseSensitive)
-				if err != nil {
-					return err
-				}
-				if matched {
-					included = true
-					break
-				}
-			}
-			if !included {
-				return nil
-			}
-		}
-
+	results, err := searchFiles(root, opts, func(path string, info os.FileInfo, remaining int) ([]SearchResult, error) {
 		// Match main pattern
 		matched, err := matchPattern(info.Name(), pattern, opts.caseSensitive)
-		if err != nil {
-			return err
-		}
-
-		if matched && !info.IsDir() {
-			results = append(results, SearchResult{
-				Path:      path,
-				Info:      info,
-				MatchedBy: "name",
-			})
-			resultsFound++
+		if err != nil || !matched {
+			return nil, err
 		}
 
-		return nil
-	}, opts.followSymlinks)
+		return []SearchResult{{
+			Path:      path,
+			Info:      info,
+			MatchedBy: "name",
+		}}, nil
+	})
 
 	if err != nil && err != io.EOF {
 		return nil, ErrSearchFiles.
@@ -108,7 +45,7 @@ func FindFiles(root string, pattern string, options ...SearchOption) ([]SearchRe
 			})
 	}
 
-	return results, nil
+	return finishSearch(results, opts), nil
 }
 
 // FindFilesByRegex finds files by regex pattern
@@ -138,49 +75,17 @@ func FindFilesByRegex(root string, pattern string, options ...SearchOption) ([]S
 			})
 	}
 
-	var results []SearchResult
-	resultsFound := 0
-
-	err = walkWithDepth(root, 0, func(path string, info os.FileInfo, depth int, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Check depth limits
-		if opts.maxDepth >= 0 && depth > opts.maxDepth {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-		if depth < opts.minDepth {
-			return nil
-		}
-
-		// Check result limit
-		if opts.limitResults > 0 && resultsFound >= opts.limitResults {
-			return io.EOF
-		}
-
-		// Handle hidden files
-		if opts.ignoreHidden && isHidden(info.Name()) {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		if re.MatchString(info.Name()) && !info.IsDir() {
-			results = append(results, SearchResult{
-				Path:      path,
-				Info:      info,
-				MatchedBy: "regex",
-			})
-			resultsFound++
+	results, err := searchFiles(root, opts, func(path string, info os.FileInfo, remaining int) ([]SearchResult, error) {
+		if !re.MatchString(info.Name()) {
+			return nil, nil
 		}
 
-		return nil
-	}, opts.followSymlinks)
+		return []SearchResult{{
+			Path:      path,
+			Info:      info,
+			MatchedBy: "regex",
+		}}, nil
+	})
 
 	if err != nil && err != io.EOF {
 		return nil, ErrSearchFiles.
@@ -191,7 +96,7 @@ func FindFilesByRegex(root string, pattern string, options ...SearchOption) ([]S
 			})
 	}
 
-	return results, nil
+	return finishSearch(results, opts), nil
 }
 
 // FindFilesByContent finds files containing specific content
@@ -207,50 +112,15 @@ func FindFilesByContent(root string, content string, options ...SearchOption) ([
 		searchPattern = strings.ToLower(searchPattern)
 	}
 
-	var results []SearchResult
-	resultsFound := 0
-
-	err := walkWithDepth(root, 0, func(path string, info os.FileInfo, depth int, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Check depth limits
-		if opts.maxDepth >= 0 && depth > opts.maxDepth {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-		if depth < opts.minDepth {
-			return nil
-		}
-
-		// Check result limit
-		if opts.limitResults > 0 && resultsFound >= opts.limitResults {
-			return io.EOF
-		}
-
-		// Handle hidden files
-		if opts.ignoreHidden && isHidden(info.Name()) {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		// Skip directories and binary files
-		if info.IsDir() || !isTextFile(path) {
-			return nil
+	results, err := searchFiles(root, opts, func(path string, info os.FileInfo, remaining int) ([]SearchResult, error) {
+		// Skip binary and oversized files
+		if !IsTextFile(path) || (opts.maxFileSize >= 0 && info.Size() > opts.maxFileSize) {
+			return nil, nil
 		}
 
-		// Search in file content
-		lines, err := ReadFileLines(path)
-		if err != nil {
-			return nil // Skip files we can't read
-		}
-
-		for lineNum, line := range lines {
+		// Search in file content, line by line
+		var matches []SearchResult
+		scanned, _ := scanFileLines(path, info.Size(), opts.maxLineLength, func(lineNum int, line string) bool {
 			searchLine := line
 			if !opts.caseSensitive {
 				searchLine = strings.ToLower(searchLine)
@@ -272,26 +142,24 @@ func FindFilesByContent(root string, content string, options ...SearchOption) ([
 			}
 
 			if found {
-				results = append(results, SearchResult{
+				matches = append(matches, SearchResult{
 					Path:       path,
 					Info:       info,
 					MatchedBy:  "content",
-					LineNumber: lineNum + 1,
+					LineNumber: lineNum,
 					Line:       line,
 				})
-				resultsFound++
+			}
 
-				// If limit reached, stop
-				if opts.limitResults > 0 && resultsFound >= opts.limitResults {
-					return io.EOF
-				}
+			// Move to next file after first match
+			return !found
+		})
 
-				break // Move to next file after first match
-			}
-		}
+		opts.addScanned(scanned)
 
-		return nil
-	}, opts.followSymlinks)
+		// Unreadable files have no matches
+		return matches, nil
+	})
 
 	if err != nil && err != io.EOF {
 		return nil, ErrSearchContent.
@@ -302,62 +170,79 @@ func FindFilesByContent(root string, content string, options ...SearchOption) ([
 			})
 	}
 
-	return results, nil
+	return finishSearch(results, opts), nil
 }
 
-// FindFilesBySize finds files by size criteria
-func FindFilesBySize(root string, minSize, maxSize int64, options ...SearchOption) ([]SearchResult, error) {
+// FindContentRegex finds every line matching a regular expression in text files, like grep.
+// Each match is a result; WithContextLines adds surrounding lines and
+// WithLimitResults limits the number of matches
+func FindContentRegex(root string, pattern string, options ...SearchOption) ([]SearchResult, error) {
 	opts := defaultSearchOptions()
 	for _, opt := range options {
 		opt(opts)
 	}
 
-	var results []SearchResult
-	resultsFound := 0
+	expr := pattern
+	if opts.wholeWord {
+		expr = `\b(?:` + expr + `)\b`
+	}
+	if !opts.caseSensitive {
+		expr = "(?i)" + expr
+	}
 
-	err := walkWithDepth(root, 0, func(path string, info os.FileInfo, depth int, err error) error {
-		if err != nil {
-			return err
-		}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, ErrInvalidRegex.
+			SetError(err).
+			SetData(struct {
+				Pattern string `json:"pattern"`
+				Error   error  `json:"error"`
+			}{
+				Pattern: pattern,
+				Error:   err,
+			})
+	}
 
-		// Check depth limits
-		if opts.maxDepth >= 0 && depth > opts.maxDepth {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-		if depth < opts.minDepth {
-			return nil
+	results, err := searchFiles(root, opts, func(path string, info os.FileInfo, remaining int) ([]SearchResult, error) {
+		// Skip binary and oversized files
+		if !IsTextFile(path) || (opts.maxFileSize >= 0 && info.Size() > opts.maxFileSize) {
+			return nil, nil
 		}
 
-		// Check result limit
-		if opts.limitResults > 0 && resultsFound >= opts.limitResults {
-			return io.EOF
-		}
+		return grepFile(path, info, re, opts, remaining), nil
+	})
 
-		// Handle hidden files
-		if opts.ignoreHidden && isHidden(info.Name()) {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
+	if err != nil && err != io.EOF {
+		return nil, ErrSearchContent.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
 
-		if !info.IsDir() {
-			size := info.Size()
-			if (minSize < 0 || size >= minSize) && (maxSize < 0 || size <= maxSize) {
-				results = append(results, SearchResult{
-					Path:      path,
-					Info:      info,
-					MatchedBy: "size",
-				})
-				resultsFound++
-			}
+	return finishSearch(results, opts), nil
+}
+
+// FindFilesBy finds files accepted by match, applying the usual depth, hidden,
+// ignore, pattern, limit and symlink options of searches
+func FindFilesBy(root string, match FilterFunc, options ...SearchOption) ([]SearchResult, error) {
+	opts := defaultSearchOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	results, err := searchFiles(root, opts, func(path string, info os.FileInfo, remaining int) ([]SearchResult, error) {
+		if !match(path, info) {
+			return nil, nil
 		}
 
-		return nil
-	}, opts.followSymlinks)
+		return []SearchResult{{
+			Path:      path,
+			Info:      info,
+			MatchedBy: "predicate",
+		}}, nil
+	})
 
 	if err != nil && err != io.EOF {
 		return nil, ErrSearchFiles.
@@ -368,62 +253,75 @@ func FindFilesBySize(root string, minSize, maxSize int64, options ...SearchOptio
 			})
 	}
 
-	return results, nil
+	return finishSearch(results, opts), nil
 }
 
-// FindFilesByTime finds files by modification time
-func FindFilesByTime(root string, after, before time.Time, options ...SearchOption) ([]SearchResult, error) {
+// FindFilesByMIME finds files whose content is of a MIME type regardless of their extension,
+// e.g. "image", "image/*" or "application/pdf", see DetectMIME and MatchMIME
+func FindFilesByMIME(root string, mimeType string, options ...SearchOption) ([]SearchResult, error) {
 	opts := defaultSearchOptions()
 	for _, opt := range options {
 		opt(opts)
 	}
+	opts.fileTypes = append(opts.fileTypes, mimeType)
 
-	var results []SearchResult
-	resultsFound := 0
+	results, err := searchFiles(root, opts, func(path string, info os.FileInfo, remaining int) ([]SearchResult, error) {
+		return []SearchResult{{
+			Path:      path,
+			Info:      info,
+			MatchedBy: "mime",
+		}}, nil
+	})
 
-	err := walkWithDepth(root, 0, func(path string, info os.FileInfo, depth int, err error) error {
-		if err != nil {
-			return err
-		}
+	if err != nil && err != io.EOF {
+		return nil, ErrSearchFiles.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
 
-		// Check depth limits
-		if opts.maxDepth >= 0 && depth > opts.maxDepth {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-		if depth < opts.minDepth {
-			return nil
-		}
+	return finishSearch(results, opts), nil
+}
 
-		// Check result limit
-		if opts.limitResults > 0 && resultsFound >= opts.limitResults {
-			return io.EOF
-		}
+// FindFilesFuzzy finds files whose names fuzzily match query, e.g. "usrctrl" matches
+// "user_controller.go". Names containing query as a subsequence score from 0.5 to 1,
+// favoring consecutive characters and word starts; names within a small edit distance
+// score below 0.5. Results are ranked by score unless WithSortResults is given,
+// WithLimitResults keeps the best ones
+func FindFilesFuzzy(root string, query string, options ...SearchOption) ([]SearchResult, error) {
+	opts := defaultSearchOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
 
-		// Handle hidden files
-		if opts.ignoreHidden && isHidden(info.Name()) {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
+	if query == "" {
+		return nil, ErrInvalidPattern.
+			SetData(struct {
+				Pattern string `json:"pattern"`
+			}{
+				Pattern: query,
+			})
+	}
 
-		if !info.IsDir() {
-			modTime := info.ModTime()
-			if (after.IsZero() || modTime.After(after)) && (before.IsZero() || modTime.Before(before)) {
-				results = append(results, SearchResult{
-					Path:      path,
-					Info:      info,
-					MatchedBy: "time",
-				})
-				resultsFound++
-			}
+	// Ranking needs every match, the limit applies afterwards
+	limit := opts.limitResults
+	opts.limitResults = -1
+
+	results, err := searchFiles(root, opts, func(path string, info os.FileInfo, remaining int) ([]SearchResult, error) {
+		score, ok := fuzzyScore(query, info.Name())
+		if !ok {
+			return nil, nil
 		}
 
-		return nil
-	}, opts.followSymlinks)
+		return []SearchResult{{
+			Path:      path,
+			Info:      info,
+			MatchedBy: "fuzzy",
+			Score:     score,
+		}}, nil
+	})
 
 	if err != nil && err != io.EOF {
 		return nil, ErrSearchFiles.
@@ -434,28 +332,226 @@ func FindFilesByTime(root string, after, before time.Time, options ...SearchOpti
 			})
 	}
 
-	return results, nil
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Path < results[j].Path
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return finishSearch(results, opts), nil
 }
 
-// FindFilesByPermissions finds files by permission bits
-func FindFilesByPermissions(root string, mode os.FileMode, exact bool, options ...SearchOption) ([]SearchResult, error) {
+// FindFilesByBytes finds files of any type containing a byte sequence,
+// e.g. a magic number or an embedded secret. Files are streamed, not loaded
+func FindFilesByBytes(root string, needle []byte, options ...SearchOption) ([]SearchResult, error) {
 	opts := defaultSearchOptions()
 	for _, opt := range options {
 		opt(opts)
 	}
 
-	var results []SearchResult
-	resultsFound := 0
+	if len(needle) == 0 {
+		return nil, ErrInvalidPattern.
+			SetData(struct {
+				Pattern string `json:"pattern"`
+				Error   error  `json:"error"`
+			}{
+				Pattern: "",
+				Error:   nil,
+			})
+	}
+
+	searcher := newByteSearcher(needle)
 
-	err := walkWithDepth(root, 0, func(path string, info os.FileInfo, depth int, err error) error {
+	results, err := searchFiles(root, opts, func(path string, info os.FileInfo, remaining int) ([]SearchResult, error) {
+		if !info.Mode().IsRegular() || info.Size() < int64(len(needle)) {
+			return nil, nil
+		}
+		if opts.maxFileSize >= 0 && info.Size() > opts.maxFileSize {
+			return nil, nil
+		}
+
+		file, err := os.Open(path)
 		if err != nil {
-			return err
+			return nil, nil // Skip files we can't read
 		}
+		defer file.Close()
 
-		// Check depth limits
-		if opts.maxDepth >= 0 && depth > opts.maxDepth {
-			if info.IsDir() {
-				return filepath.SkipDir
+		offset, scanned, err := searcher.find(file)
+		opts.addScanned(scanned)
+		if err != nil || offset < 0 {
+			return nil, nil
+		}
+
+		return []SearchResult{{
+			Path:      path,
+			Info:      info,
+			MatchedBy: "bytes",
+			Offset:    offset,
+		}}, nil
+	})
+
+	if err != nil && err != io.EOF {
+		return nil, ErrSearchContent.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+
+	return finishSearch(results, opts), nil
+}
+
+// grepFile returns up to limit matching lines of a file (all when limit is negative)
+// with context lines. Unreadable files have no matches
+func grepFile(path string, info os.FileInfo, re *regexp.Regexp, opts *searchOptions, limit int) []SearchResult {
+	var (
+		matches []SearchResult
+		before  []string
+		pending []int // matches still collecting lines after them
+	)
+
+	scanned, _ := scanFileLines(path, info.Size(), opts.maxLineLength, func(lineNum int, line string) bool {
+		// Lines after earlier matches
+		waiting := pending[:0]
+		for _, i := range pending {
+			matches[i].ContextAfter = append(matches[i].ContextAfter, line)
+			if len(matches[i].ContextAfter) < opts.contextLines {
+				waiting = append(waiting, i)
+			}
+		}
+		pending = waiting
+
+		limitReached := limit >= 0 && len(matches) >= limit
+		if !limitReached && re.MatchString(line) {
+			matches = append(matches, SearchResult{
+				Path:          path,
+				Info:          info,
+				MatchedBy:     "content",
+				LineNumber:    lineNum,
+				Line:          line,
+				ContextBefore: append([]string(nil), before...),
+			})
+			if opts.contextLines > 0 {
+				pending = append(pending, len(matches)-1)
+			}
+			limitReached = limit >= 0 && len(matches) >= limit
+		}
+
+		if opts.contextLines > 0 {
+			before = append(before, line)
+			if len(before) > opts.contextLines {
+				before = before[1:]
+			}
+		}
+
+		// Stop once the limit is reached and its context is complete
+		return !limitReached || len(pending) > 0
+	})
+	opts.addScanned(scanned)
+
+	return matches
+}
+
+// searchVisitFunc returns the results for a file that passed the search options.
+// remaining is the number of results still allowed, negative for no limit. An error
+// stops the search and is returned by searchFiles
+type searchVisitFunc func(path string, info os.FileInfo, remaining int) ([]SearchResult, error)
+
+// searchWalker applies the depth, result limit, hidden, ignore and include/exclude
+// options of a search while walking and collects the results of visit
+type searchWalker struct {
+	opts  *searchOptions
+	visit searchVisitFunc
+	mu    sync.Mutex
+	found int
+	err   error // first error of visit
+}
+
+// searchFiles walks root, in parallel with WithSearchWorkers, and returns results in
+// walk order. It returns io.EOF with the results once the result limit is reached
+func searchFiles(root string, opts *searchOptions, visit searchVisitFunc) ([]SearchResult, error) {
+	walker := &searchWalker{
+		opts:  opts,
+		visit: visit,
+	}
+
+	var (
+		results []SearchResult
+		err     error
+	)
+	if opts.workers > 1 {
+		results, err = walker.walkParallel(root)
+	} else {
+		results, err = walker.walk(root, 0)
+	}
+
+	if walker.err != nil {
+		return nil, walker.err
+	}
+	return results, err
+}
+
+// remaining returns the number of results still allowed, negative for no limit,
+// and 0 once visit failed
+func (w *searchWalker) remaining() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return 0
+	}
+	if w.opts.limitResults <= 0 {
+		return -1
+	}
+	return max(w.opts.limitResults-w.found, 0)
+}
+
+// fail records the first error of visit
+func (w *searchWalker) fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// add counts found results and reports whether the limit is reached
+func (w *searchWalker) add(found int) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.found += found
+	return w.opts.limitResults > 0 && w.found >= w.opts.limitResults
+}
+
+// walk walks the tree at root, which is depth levels below the search root
+func (w *searchWalker) walk(root string, depth int) ([]SearchResult, error) {
+	var results []SearchResult
+	err := walkWithDepth(root, depth, w.walkFunc(&results), w.opts.followSymlinks, w.opts.walked)
+
+	return results, err
+}
+
+// walkFunc returns the function applying the search options to every walked
+// entry, collecting the results of visit into results
+func (w *searchWalker) walkFunc(results *[]SearchResult) func(path string, info os.FileInfo, depth int, err error) error {
+	opts := w.opts
+
+	return opts.walkFunc(func(path string, info os.FileInfo, depth int, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Check depth limits
+		if opts.maxDepth >= 0 && depth > opts.maxDepth {
+			if info.IsDir() {
+				return filepath.SkipDir
 			}
 			return nil
 		}
@@ -464,41 +560,207 @@ func FindFilesByPermissions(root string, mode os.FileMode, exact bool, options .
 		}
 
 		// Check result limit
-		if opts.limitResults > 0 && resultsFound >= opts.limitResults {
+		remaining := w.remaining()
+		if remaining == 0 {
 			return io.EOF
 		}
 
 		// Handle hidden files
-		if opts.ignoreHidden && isHidden(info.Name()) {
+		if opts.ignoreHidden && isHidden(info) {
 			if info.IsDir() {
 				return filepath.SkipDir
 			}
 			return nil
 		}
 
-		if !info.IsDir() {
-			fileMode := info.Mode().Perm()
-			matched := false
-
-			if exact {
-				matched = fileMode == mode
-			} else {
-				// Check if file has at least the specified permissions
-				matched = fileMode&mode == mode
+		// Handle ignore rules
+		if opts.ignored(path, info, depth) {
+			if info.IsDir() {
+				return filepath.SkipDir
 			}
+			return nil
+		}
 
+		// Apply exclude patterns first
+		for _, excludePattern := range opts.excludePatterns {
+			matched, err := matchPattern(info.Name(), excludePattern, opts.caseSensitive)
+			if err != nil {
+				return err
+			}
 			if matched {
-				results = append(results, SearchResult{
-					Path:      path,
-					Info:      info,
-					MatchedBy: "permissions",
-				})
-				resultsFound++
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
 			}
 		}
 
+		if info.IsDir() {
+			return nil
+		}
+
+		// Apply include patterns
+		if len(opts.includePatterns) > 0 {
+			included := false
+			for _, includePattern := range opts.includePatterns {
+				matched, err := matchPattern(info.Name(), includePattern, opts.caseSensitive)
+				if err != nil {
+					return err
+				}
+				if matched {
+					included = true
+					break
+				}
+			}
+			if !included {
+				return nil
+			}
+		}
+
+		// Apply file type filter, unreadable files have no type
+		if len(opts.fileTypes) > 0 {
+			mimeType, err := DetectMIME(path)
+			if err != nil || !MatchMIME(mimeType, opts.fileTypes...) {
+				return nil
+			}
+		}
+
+		found, err := w.visit(path, info, remaining)
+		if err != nil {
+			// Stop like the limit does, walks continue past other errors
+			w.fail(err)
+			return io.EOF
+		}
+		*results = append(*results, found...)
+
+		if w.add(len(found)) {
+			return io.EOF
+		}
 		return nil
-	}, opts.followSymlinks)
+	})
+}
+
+// walkParallel walks the entries of root concurrently with a bounded pool and merges
+// their results in walk order. root is handled like walkWithDepth does: symlinks are
+// only followed with WithSearchFollowSymlinks and errors at root fail the search,
+// while errors below it only skip the entry
+func (w *searchWalker) walkParallel(root string) ([]SearchResult, error) {
+	info, err := os.Lstat(root)
+	if err == nil && info.Mode()&os.ModeSymlink != 0 && w.opts.followSymlinks {
+		info, err = os.Stat(root)
+	}
+	if err != nil || !info.IsDir() || (!w.opts.followSymlinks && isJunction(info)) {
+		return w.walk(root, 0)
+	}
+
+	if w.opts.followSymlinks {
+		w.opts.walked.enter(root, info)
+	}
+
+	var results []SearchResult
+	fn := w.walkFunc(&results)
+	if err := fn(root, info, 0, nil); err != nil {
+		if err == filepath.SkipDir {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fn(root, info, 0, err)
+	}
+
+	type subtree struct {
+		results []SearchResult
+		err     error
+	}
+
+	subtrees := make([]subtree, len(entries))
+	sem := make(chan struct{}, w.opts.workers)
+	var wg sync.WaitGroup
+
+	for i, entry := range entries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, path string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			subtrees[i].results, subtrees[i].err = w.walk(path, 1)
+		}(i, filepath.Join(root, entry.Name()))
+	}
+	wg.Wait()
+
+	var walkErr error
+	for _, tree := range subtrees {
+		results = append(results, tree.results...)
+
+		// Like a serial walk, only the limit or a cancellation stops the search
+		if walkErr == nil && (tree.err == io.EOF || errors.Is(tree.err, ErrSearchCanceled)) {
+			walkErr = tree.err
+		}
+	}
+
+	// Subtrees running concurrently may overshoot the limit
+	if w.opts.limitResults > 0 && len(results) > w.opts.limitResults {
+		results = results[:w.opts.limitResults]
+	}
+
+	return results, walkErr
+}
+
+// FindFilesBySize finds files by size criteria
+func FindFilesBySize(root string, minSize, maxSize int64, options ...SearchOption) ([]SearchResult, error) {
+	opts := defaultSearchOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	results, err := searchFiles(root, opts, func(path string, info os.FileInfo, remaining int) ([]SearchResult, error) {
+		size := info.Size()
+		if (minSize >= 0 && size < minSize) || (maxSize >= 0 && size > maxSize) {
+			return nil, nil
+		}
+
+		return []SearchResult{{
+			Path:      path,
+			Info:      info,
+			MatchedBy: "size",
+		}}, nil
+	})
+
+	if err != nil && err != io.EOF {
+		return nil, ErrSearchFiles.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+
+	return finishSearch(results, opts), nil
+}
+
+// FindFilesByTime finds files by modification time
+func FindFilesByTime(root string, after, before time.Time, options ...SearchOption) ([]SearchResult, error) {
+	opts := defaultSearchOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	results, err := searchFiles(root, opts, func(path string, info os.FileInfo, remaining int) ([]SearchResult, error) {
+		modTime := info.ModTime()
+		if (!after.IsZero() && !modTime.After(after)) || (!before.IsZero() && !modTime.Before(before)) {
+			return nil, nil
+		}
+
+		return []SearchResult{{
+			Path:      path,
+			Info:      info,
+			MatchedBy: "time",
+		}}, nil
+	})
 
 	if err != nil && err != io.EOF {
 		return nil, ErrSearchFiles.
@@ -509,13 +771,313 @@ func FindFilesByPermissions(root string, mode os.FileMode, exact bool, options .
 			})
 	}
 
-	return results, nil
+	return finishSearch(results, opts), nil
+}
+
+// FindFilesNewerThan finds files modified after a reference file, like find -newer
+func FindFilesNewerThan(root string, refPath string, options ...SearchOption) ([]SearchResult, error) {
+	refInfo, err := os.Stat(refPath)
+	if err != nil {
+		return nil, ErrStatFile.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  refPath,
+				Error: err,
+			})
+	}
+
+	opts := defaultSearchOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	results, err := searchFiles(root, opts, func(path string, info os.FileInfo, remaining int) ([]SearchResult, error) {
+		if !info.ModTime().After(refInfo.ModTime()) {
+			return nil, nil
+		}
+
+		return []SearchResult{{
+			Path:      path,
+			Info:      info,
+			MatchedBy: "newer",
+		}}, nil
+	})
+
+	if err != nil && err != io.EOF {
+		return nil, ErrSearchFiles.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+
+	return finishSearch(results, opts), nil
+}
+
+// FindFilesByLinkCount finds files with at least minLinks hard links, minLinks 2 finds
+// hardlinked files. Nothing matches on platforms without link counts
+func FindFilesByLinkCount(root string, minLinks uint64, options ...SearchOption) ([]SearchResult, error) {
+	return findFilesByIdentity(root, "link_count", func(info os.FileInfo) bool {
+		links, ok := fileLinkCount(info)
+		return ok && links >= minLinks
+	}, options)
+}
+
+// FindFilesByInode finds names of the file with an inode number on the device of root.
+// Nothing matches on platforms without inode numbers
+func FindFilesByInode(root string, inode uint64, options ...SearchOption) ([]SearchResult, error) {
+	rootInfo, err := os.Stat(root)
+	if err != nil {
+		return nil, ErrSearchFiles.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+	rootID, ok := fileIdentity(rootInfo)
+
+	return findFilesByIdentity(root, "inode", func(info os.FileInfo) bool {
+		id, idOk := fileIdentity(info)
+		return ok && idOk && id == fileID{dev: rootID.dev, ino: inode}
+	}, options)
+}
+
+// FindSameFile finds all names under root pointing to the same file as path,
+// i.e. its hard links including path itself when it is under root
+func FindSameFile(root string, path string, options ...SearchOption) ([]SearchResult, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, ErrStatFile.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  path,
+				Error: err,
+			})
+	}
+
+	return findFilesByIdentity(root, "inode", func(candidate os.FileInfo) bool {
+		return os.SameFile(info, candidate)
+	}, options)
+}
+
+// findFilesByIdentity finds files whose stat information satisfies match
+func findFilesByIdentity(root string, matchedBy string, match func(info os.FileInfo) bool, options []SearchOption) ([]SearchResult, error) {
+	opts := defaultSearchOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	results, err := searchFiles(root, opts, func(path string, info os.FileInfo, remaining int) ([]SearchResult, error) {
+		if !match(info) {
+			return nil, nil
+		}
+
+		return []SearchResult{{
+			Path:      path,
+			Info:      info,
+			MatchedBy: matchedBy,
+		}}, nil
+	})
+
+	if err != nil && err != io.EOF {
+		return nil, ErrSearchFiles.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+
+	return finishSearch(results, opts), nil
+}
+
+// FindFilesByPermissions finds files by permission bits
+func FindFilesByPermissions(root string, mode os.FileMode, exact bool, options ...SearchOption) ([]SearchResult, error) {
+	opts := defaultSearchOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	results, err := searchFiles(root, opts, func(path string, info os.FileInfo, remaining int) ([]SearchResult, error) {
+		fileMode := info.Mode().Perm()
+
+		// Exact permissions, or at least the specified ones
+		matched := fileMode&mode == mode
+		if exact {
+			matched = fileMode == mode
+		}
+		if !matched {
+			return nil, nil
+		}
+
+		return []SearchResult{{
+			Path:      path,
+			Info:      info,
+			MatchedBy: "permissions",
+		}}, nil
+	})
+
+	if err != nil && err != io.EOF {
+		return nil, ErrSearchFiles.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+
+	return finishSearch(results, opts), nil
+}
+
+// FindBrokenSymlinks finds symbolic links whose targets don't exist, including links
+// in a loop and links that go through a regular file (e.g. "file.txt/sub"). With WithDeleteBrokenSymlinks the links are removed as they are found.
+// Links that can't be resolved for other reasons (e.g. permissions) fail the search,
+// so they are never deleted. Symlinks are not followed
+func FindBrokenSymlinks(root string, options ...SearchOption) ([]SearchResult, error) {
+	opts := defaultSearchOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+	opts.followSymlinks = false
+
+	results, err := searchFiles(root, opts, func(path string, info os.FileInfo, remaining int) ([]SearchResult, error) {
+		if info.Mode()&os.ModeSymlink == 0 {
+			return nil, nil
+		}
+
+		// A link is broken when its target (or a link in the chain) is missing
+		_, err := os.Stat(path)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) && !isSymlinkLoop(err) && !isNotDirectory(err) {
+			return nil, ErrStatFile.
+				SetError(err).
+				SetData(pathErrorContext{
+					Path:  path,
+					Error: err,
+				})
+		}
+
+		if opts.deleteBrokenSymlinks {
+			if err := os.Remove(path); err != nil {
+				return nil, ErrDeleteFile.
+					SetError(err).
+					SetData(pathErrorContext{
+						Path:  path,
+						Error: err,
+					})
+			}
+		}
+
+		return []SearchResult{{
+			Path:      path,
+			Info:      info,
+			MatchedBy: "broken_symlink",
+		}}, nil
+	})
+
+	if err != nil && err != io.EOF {
+		return nil, ErrSearchFiles.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  root,
+				Error: err,
+			})
+	}
+
+	return finishSearch(results, opts), nil
 }
 
 // Helper functions
 
-// walkWithDepth is a helper that walks directory tree tracking depth
-func walkWithDepth(root string, currentDepth int, fn func(path string, info os.FileInfo, depth int, err error) error, followSymlinks bool) error {
+// finishSearch adds symlink loops met while following symlinks as "symlink_loop" results,
+// sorts results as requested by WithSortResults, results of the same file keep their order,
+// and fills the statistics requested by WithSearchStats
+func finishSearch(results []SearchResult, opts *searchOptions) []SearchResult {
+	matches := len(results)
+	for _, loop := range opts.walked.loops {
+		info, err := os.Lstat(loop)
+		if err != nil {
+			continue
+		}
+
+		results = append(results, SearchResult{
+			Path:      loop,
+			Info:      info,
+			MatchedBy: "symlink_loop",
+		})
+	}
+
+	if opts.sortBy != SortNone {
+		sortSearchResults(results, opts)
+	}
+
+	if opts.stats != nil {
+		opts.stats.finish(matches)
+	}
+
+	return results
+}
+
+// sortSearchResults orders results by opts.sortBy
+func sortSearchResults(results []SearchResult, opts *searchOptions) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if !opts.sortAscending {
+			a, b = b, a
+		}
+
+		switch opts.sortBy {
+		case SortBySize:
+			return a.Info.Size() < b.Info.Size()
+		case SortByModTime:
+			return a.Info.ModTime().Before(b.Info.ModTime())
+		case SortByName:
+			return a.Info.Name() < b.Info.Name()
+		default:
+			return a.Path < b.Path
+		}
+	})
+}
+
+// walkState tracks directories walked while following symlinks, shared by parallel walkers
+type walkState struct {
+	mu      sync.Mutex
+	visited map[string]string // directory identity to the path it was walked at
+	loops   []string
+}
+
+func newWalkState() *walkState {
+	return &walkState{
+		visited: make(map[string]string),
+	}
+}
+
+// enter records a directory and reports whether it was not walked yet.
+// Reaching a directory again below the path it was walked at is a symlink loop
+func (s *walkState) enter(path string, info os.FileInfo) bool {
+	key := dirKey(path, info)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if walked, ok := s.visited[key]; ok {
+		if strings.HasPrefix(path, walked+string(filepath.Separator)) {
+			s.loops = append(s.loops, path)
+		}
+		return false
+	}
+
+	s.visited[key] = path
+	return true
+}
+
+// walkWithDepth is a helper that walks directory tree tracking depth. When following
+// symlinks, directories already walked are skipped and loops are recorded in state
+func walkWithDepth(root string, currentDepth int, fn func(path string, info os.FileInfo, depth int, err error) error, followSymlinks bool, state *walkState) error {
 	info, err := os.Lstat(root)
 	if err != nil {
 		return fn(root, nil, currentDepth, err)
@@ -529,6 +1091,10 @@ func walkWithDepth(root string, currentDepth int, fn func(path string, info os.F
 		}
 	}
 
+	if followSymlinks && info.IsDir() && state != nil && !state.enter(root, info) {
+		return nil
+	}
+
 	err = fn(root, info, currentDepth, nil)
 	if err != nil {
 		if info.IsDir() && err == filepath.SkipDir {
@@ -537,7 +1103,8 @@ func walkWithDepth(root string, currentDepth int, fn func(path string, info os.F
 		return err
 	}
 
-	if !info.IsDir() {
+	// Like symlinks, junctions are only descended into when following links
+	if !info.IsDir() || (!followSymlinks && isJunction(info)) {
 		return nil
 	}
 
@@ -548,9 +1115,9 @@ func walkWithDepth(root string, currentDepth int, fn func(path string, info os.F
 
 	for _, entry := range entries {
 		path := filepath.Join(root, entry.Name())
-		err = walkWithDepth(path, currentDepth+1, fn, followSymlinks)
+		err = walkWithDepth(path, currentDepth+1, fn, followSymlinks, state)
 		if err != nil {
-			if err == io.EOF {
+			if err == io.EOF || errors.Is(err, ErrSearchCanceled) {
 				return err
 			}
 			// Continue on error unless it's a stop signal
@@ -561,6 +1128,122 @@ func walkWithDepth(root string, currentDepth int, fn func(path string, info os.F
 	return nil
 }
 
+// byteSearcher finds a byte sequence in streams with the Boyer-Moore-Horspool algorithm
+type byteSearcher struct {
+	needle []byte
+	shift  [256]int
+}
+
+// newByteSearcher prepares the bad character shifts of a non-empty needle
+func newByteSearcher(needle []byte) *byteSearcher {
+	s := &byteSearcher{
+		needle: needle,
+	}
+
+	for i := range s.shift {
+		s.shift[i] = len(needle)
+	}
+	for i := 0; i < len(needle)-1; i++ {
+		s.shift[needle[i]] = len(needle) - 1 - i
+	}
+
+	return s
+}
+
+// index returns the position of the first match in data or -1
+func (s *byteSearcher) index(data []byte) int {
+	last := len(s.needle) - 1
+	for pos := 0; pos+last < len(data); pos += s.shift[data[pos+last]] {
+		i := last
+		for i >= 0 && data[pos+i] == s.needle[i] {
+			i--
+		}
+		if i < 0 {
+			return pos
+		}
+	}
+
+	return -1
+}
+
+// find returns the offset of the first match in r or -1 and the number of bytes read.
+// Chunks overlap by len(needle)-1 bytes so matches crossing chunk boundaries are found
+func (s *byteSearcher) find(r io.Reader) (int64, int64, error) {
+	overlap := len(s.needle) - 1
+	buf := make([]byte, max(64*1024, 2*len(s.needle)))
+
+	var base int64 // offset of buf[0] in the stream
+	var scanned int64
+	kept := 0
+	for {
+		n, err := r.Read(buf[kept:])
+		data := buf[:kept+n]
+		scanned += int64(n)
+
+		if n > 0 {
+			if i := s.index(data); i >= 0 {
+				return base + int64(i), scanned, nil
+			}
+
+			// Keep the tail that may start a match
+			kept = min(overlap, len(data))
+			base += int64(len(data) - kept)
+			copy(buf, data[len(data)-kept:])
+		}
+
+		if err == io.EOF {
+			return -1, scanned, nil
+		}
+		if err != nil {
+			return -1, scanned, err
+		}
+	}
+}
+
+// scanFileLines calls fn with each line of a file and its 1-based number until fn
+// returns false. Lines longer than maxLength bytes are truncated, so memory use is
+// bounded by maxLength regardless of the file size. It returns the number of bytes read
+func scanFileLines(path string, size int64, maxLength int, fn func(lineNum int, line string) bool) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	// Small files don't need a buffer of the full line cap
+	bufferSize := maxLength
+	if size >= 0 && size < int64(bufferSize) {
+		bufferSize = int(size) + 1
+	}
+
+	var scanned int64
+	reader := bufio.NewReaderSize(file, bufferSize)
+	for lineNum := 1; ; lineNum++ {
+		chunk, err := reader.ReadSlice('\n')
+		line := string(chunk)
+		scanned += int64(len(chunk))
+
+		// Drop the rest of a truncated line
+		for err == bufio.ErrBufferFull {
+			chunk, err = reader.ReadSlice('\n')
+			scanned += int64(len(chunk))
+		}
+		if err != nil && err != io.EOF {
+			return scanned, err
+		}
+
+		if err == io.EOF && line == "" {
+			return scanned, nil
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		line = strings.TrimSuffix(line, "\r")
+		if !fn(lineNum, line) || err == io.EOF {
+			return scanned, nil
+		}
+	}
+}
+
 // matchPattern matches a pattern against a name (supports * and ? wildcards)
 func matchPattern(name, pattern string, caseSensitive bool) (bool, error) {
 	if !caseSensitive {
@@ -584,30 +1267,100 @@ func matchPattern(name, pattern string, caseSensitive bool) (bool, error) {
 	return matched, nil
 }
 
-// isHidden checks if a file/directory is hidden
-func isHidden(name string) bool {
-	return strings.HasPrefix(name, ".")
+// fuzzyScore scores a file name against a fuzzy query, see FindFilesFuzzy
+func fuzzyScore(query, name string) (float64, bool) {
+	q := []rune(strings.ToLower(query))
+	original := []rune(name)
+	lower := []rune(strings.ToLower(name))
+
+	// Subsequence match: every matched character earns a point, plus
+	// a point when it follows the previous match and more at word starts
+	points := 0.0
+	qi, prev := 0, -2
+	for i := 0; i < len(lower) && qi < len(q); i++ {
+		if lower[i] != q[qi] {
+			continue
+		}
+
+		points++
+		if i == prev+1 {
+			points++
+		}
+		if isWordStart(original, i) {
+			points += 1.5
+		}
+		prev = i
+		qi++
+	}
+
+	if qi == len(q) {
+		quality := points / (3.5 * float64(len(q)))
+		coverage := float64(len(q)) / float64(len(lower))
+		return 0.5 + 0.5*quality*(0.75+0.25*coverage), true
+	}
+
+	// Typo tolerance on the name with and without its extension
+	stem := strings.TrimSuffix(strings.ToLower(name), strings.ToLower(filepath.Ext(name)))
+	distance := min(levenshtein(q, lower), levenshtein(q, []rune(stem)))
+	if distance > max(1, len(q)/3) {
+		return 0, false
+	}
+
+	return 0.5 * (1 - float64(distance)/float64(len(q)+1)), true
+}
+
+// isWordStart reports whether name[i] starts a word: the first character, one after
+// a separator or an upper case letter following a lower case one
+func isWordStart(name []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+
+	switch name[i-1] {
+	case '_', '-', '.', ' ':
+		return true
+	}
+
+	return unicode.IsUpper(name[i]) && unicode.IsLower(name[i-1])
 }
 
-// isTextFile checks if a file is likely a text file (simple heuristic)
-func isTextFile(path string) bool {
-	// Check by extension first
-	ext := strings.ToLower(filepath.Ext(path))
-	textExtensions := []string{
-		".txt", ".log", ".md", ".json", ".xml", ".yaml", ".yml",
-		".go", ".js", ".py", ".java", ".c", ".cpp", ".h", ".hpp",
-		".html", ".css", ".scss", ".less", ".vue", ".jsx", ".tsx",
-		".sh", ".bash", ".zsh", ".fish", ".conf", ".cfg", ".ini",
-		".csv", ".sql", ".rs", ".rb", ".php", ".swift", ".kt",
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b []rune) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
 	}
 
-	for _, textExt := range textExtensions {
-		if ext == textExt {
-			return true
+	for i := 1; i <= len(a); i++ {
+		diagonal := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			next := min(row[j]+1, row[j-1]+1, diagonal+cost)
+			diagonal = row[j]
+			row[j] = next
 		}
 	}
 
-	// Could implement more sophisticated detection by reading first few bytes
-	// and checking for binary content, but this is good enough for most cases
-	return false
+	return row[len(b)]
+}
+
+// IsHidden reports whether a file or directory is hidden: its name starts with a dot
+// or, on Windows, it has the hidden or system attribute
+func IsHidden(path string) bool {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false
+	}
+
+	return isHidden(info)
+}
+
+// isHidden checks if a file/directory is hidden
+func isHidden(info os.FileInfo) bool {
+	return strings.HasPrefix(info.Name(), ".") || hiddenAttribute(info)
 }
@@ -1,8 +1,12 @@
 package fsx
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -390,6 +394,602 @@ func TestSearchOperations(t *testing.T) {
 			t.Error("Should respect result limit")
 		}
 	})
+
+	t.Run("FindBrokenSymlinks", func(t *testing.T) {
+		linkDir := filepath.Join(tmpDir, "links")
+		if err := CreateFile(filepath.Join(linkDir, "target.txt"), []byte("target"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create target: %v", err)
+		}
+		if err := os.Symlink("target.txt", filepath.Join(linkDir, "good")); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+		if err := os.Symlink("missing.txt", filepath.Join(linkDir, "broken")); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+
+		results, err := FindBrokenSymlinks(linkDir)
+		if err != nil {
+			t.Fatalf("Failed to find broken symlinks: %v", err)
+		}
+		if len(results) != 1 || filepath.Base(results[0].Path) != "broken" {
+			t.Fatalf("Expected only the broken link, got %+v", results)
+		}
+
+		if _, err := FindBrokenSymlinks(linkDir, WithDeleteBrokenSymlinks()); err != nil {
+			t.Fatalf("Failed to delete broken symlinks: %v", err)
+		}
+		if _, err := os.Lstat(filepath.Join(linkDir, "broken")); !os.IsNotExist(err) {
+			t.Error("Broken link should be deleted")
+		}
+		if _, err := os.Lstat(filepath.Join(linkDir, "good")); err != nil {
+			t.Error("Valid link must be kept")
+		}
+
+		if runtime.GOOS == "windows" {
+			return
+		}
+
+		// A link to itself never resolves
+		if err := os.Symlink("loop", filepath.Join(linkDir, "loop")); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+		results, err = FindBrokenSymlinks(linkDir, WithSearchWorkers(2))
+		if err != nil || len(results) != 1 || filepath.Base(results[0].Path) != "loop" {
+			t.Errorf("Expected the looping link, got %+v (%v)", results, err)
+		}
+		os.Remove(filepath.Join(linkDir, "loop"))
+
+		// A link through a regular file can't resolve either
+		if err := os.Symlink(filepath.Join("target.txt", "sub"), filepath.Join(linkDir, "notdir")); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+		results, err = FindBrokenSymlinks(linkDir)
+		if err != nil || len(results) != 1 || filepath.Base(results[0].Path) != "notdir" {
+			t.Errorf("Expected the link through a file, got %+v (%v)", results, err)
+		}
+		os.Remove(filepath.Join(linkDir, "notdir"))
+
+		// Unreadable targets are not broken and must never be deleted
+		if os.Geteuid() == 0 {
+			return
+		}
+		locked := filepath.Join(linkDir, "locked")
+		if err := CreateFile(filepath.Join(locked, "secret.txt"), []byte("secret"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create target: %v", err)
+		}
+		if err := os.Symlink(filepath.Join("locked", "secret.txt"), filepath.Join(linkDir, "unreadable")); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+		os.Chmod(locked, 0)
+		defer os.Chmod(locked, 0755)
+
+		if _, err := FindBrokenSymlinks(linkDir, WithDeleteBrokenSymlinks(), WithExcludePatterns("locked")); !errors.Is(err, ErrSearchFiles) {
+			t.Errorf("Expected ErrSearchFiles for an unreadable target, got %v", err)
+		}
+		if _, err := os.Lstat(filepath.Join(linkDir, "unreadable")); err != nil {
+			t.Error("Link with an unreadable target must be kept")
+		}
+	})
+
+	t.Run("FindContentRegex", func(t *testing.T) {
+		grepDir := filepath.Join(tmpDir, "grep")
+		content := "one\ntwo ERROR 42\nthree\nfour\nfive error 7\nsix\n"
+		if err := CreateFile(filepath.Join(grepDir, "app.log"), []byte(content), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		results, err := FindContentRegex(grepDir, `error \d+`, WithCaseSensitive(false), WithContextLines(1))
+		if err != nil {
+			t.Fatalf("Failed to search content: %v", err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("Expected 2 matches, got %+v", results)
+		}
+
+		first := results[0]
+		if first.LineNumber != 2 || !reflect.DeepEqual(first.ContextBefore, []string{"one"}) || !reflect.DeepEqual(first.ContextAfter, []string{"three"}) {
+			t.Errorf("Unexpected first match: %+v", first)
+		}
+		second := results[1]
+		if second.LineNumber != 5 || !reflect.DeepEqual(second.ContextBefore, []string{"four"}) || !reflect.DeepEqual(second.ContextAfter, []string{"six"}) {
+			t.Errorf("Unexpected second match: %+v", second)
+		}
+
+		limited, err := FindContentRegex(grepDir, `error`, WithCaseSensitive(false), WithLimitResults(1))
+		if err != nil {
+			t.Fatalf("Failed to search content: %v", err)
+		}
+		if len(limited) != 1 || limited[0].ContextAfter != nil {
+			t.Errorf("Expected a single match without context, got %+v", limited)
+		}
+
+		if _, err := FindContentRegex(grepDir, `(`); !errors.Is(err, ErrInvalidRegex) {
+			t.Errorf("Expected ErrInvalidRegex, got %v", err)
+		}
+	})
+
+	t.Run("SearchWorkers", func(t *testing.T) {
+		serial, err := FindFiles(tmpDir, "*.txt")
+		if err != nil {
+			t.Fatalf("Failed to find files: %v", err)
+		}
+		parallel, err := FindFiles(tmpDir, "*.txt", WithSearchWorkers(4))
+		if err != nil {
+			t.Fatalf("Failed to find files in parallel: %v", err)
+		}
+
+		if len(parallel) != len(serial) {
+			t.Fatalf("Expected %d results, got %d", len(serial), len(parallel))
+		}
+		for i := range serial {
+			if parallel[i].Path != serial[i].Path {
+				t.Errorf("Result %d: expected %s, got %s", i, serial[i].Path, parallel[i].Path)
+			}
+		}
+
+		limited, err := FindFilesByContent(tmpDir, "Content", WithSearchWorkers(4), WithLimitResults(1))
+		if err != nil {
+			t.Fatalf("Failed to search content in parallel: %v", err)
+		}
+		if len(limited) != 1 {
+			t.Errorf("Expected 1 result, got %d", len(limited))
+		}
+
+		// Every finder honours workers and patterns like the serial walk
+		for name, find := range map[string]func(options ...SearchOption) ([]SearchResult, error){
+			"Regex": func(options ...SearchOption) ([]SearchResult, error) {
+				return FindFilesByRegex(tmpDir, `\.txt$`, options...)
+			},
+			"Size": func(options ...SearchOption) ([]SearchResult, error) {
+				return FindFilesBySize(tmpDir, 0, -1, options...)
+			},
+			"Time": func(options ...SearchOption) ([]SearchResult, error) {
+				return FindFilesByTime(tmpDir, time.Time{}, time.Time{}, options...)
+			},
+			"Permissions": func(options ...SearchOption) ([]SearchResult, error) {
+				return FindFilesByPermissions(tmpDir, 0, false, options...)
+			},
+		} {
+			serial, err := find(WithIncludePatterns("*.txt"))
+			if err != nil {
+				t.Fatalf("%s: failed to search: %v", name, err)
+			}
+			parallel, err := find(WithIncludePatterns("*.txt"), WithSearchWorkers(4))
+			if err != nil {
+				t.Fatalf("%s: failed to search in parallel: %v", name, err)
+			}
+			if len(serial) == 0 || len(parallel) != len(serial) {
+				t.Errorf("%s: expected the same results, got %d serial and %d parallel", name, len(serial), len(parallel))
+			}
+			for _, result := range serial {
+				if filepath.Ext(result.Path) != ".txt" {
+					t.Errorf("%s: include patterns not applied to %s", name, result.Path)
+				}
+			}
+		}
+
+		// A symlinked root is only followed when asked to, serial or not
+		link := filepath.Join(t.TempDir(), "root")
+		if err := os.Symlink(tmpDir, link); err == nil {
+			for _, workers := range []int{1, 4} {
+				results, err := FindFiles(link, "*.txt", WithSearchWorkers(workers))
+				if err != nil || len(results) != 0 {
+					t.Errorf("Workers %d: expected no results through the root link, got %d (%v)", workers, len(results), err)
+				}
+			}
+		}
+
+		// Errors at the root fail the search in parallel too
+		if _, err := FindFiles(tmpDir, "*.txt", WithExcludePatterns("["), WithSearchWorkers(4)); !errors.Is(err, ErrSearchFiles) {
+			t.Errorf("Expected ErrSearchFiles for a bad pattern, got %v", err)
+		}
+	})
+
+	t.Run("SortResults", func(t *testing.T) {
+		results, err := FindFiles(tmpDir, "*.txt", WithSortResults(SortBySize, false))
+		if err != nil {
+			t.Fatalf("Failed to find files: %v", err)
+		}
+		for i := 1; i < len(results); i++ {
+			if results[i-1].Info.Size() < results[i].Info.Size() {
+				t.Fatalf("Results not sorted by size descending at %d", i)
+			}
+		}
+
+		results, err = FindFiles(tmpDir, "*.txt", WithSortResults(SortByPath, true))
+		if err != nil {
+			t.Fatalf("Failed to find files: %v", err)
+		}
+		for i := 1; i < len(results); i++ {
+			if results[i-1].Path > results[i].Path {
+				t.Fatalf("Results not sorted by path at %d", i)
+			}
+		}
+	})
+
+	t.Run("SearchStats", func(t *testing.T) {
+		var stats SearchStats
+		results, err := FindFilesByContent(tmpDir, "content", WithSearchStats(&stats))
+		if err != nil {
+			t.Fatalf("Failed to search content: %v", err)
+		}
+
+		if stats.Matches != len(results) {
+			t.Errorf("Expected %d matches, got %d", len(results), stats.Matches)
+		}
+		if stats.DirsVisited == 0 || stats.FilesExamined == 0 {
+			t.Errorf("Expected walked entries to be counted, got %+v", stats)
+		}
+		if stats.BytesScanned == 0 {
+			t.Error("Expected scanned bytes to be counted")
+		}
+
+		var workerStats SearchStats
+		_, err = FindFilesByContent(tmpDir, "content", WithSearchWorkers(4), WithSearchStats(&workerStats))
+		if err != nil {
+			t.Fatalf("Failed to search content: %v", err)
+		}
+		if workerStats.FilesExamined != stats.FilesExamined || workerStats.BytesScanned != stats.BytesScanned {
+			t.Errorf("Expected parallel stats %+v to match %+v", workerStats, stats)
+		}
+	})
+
+	t.Run("FindFilesByMIME", func(t *testing.T) {
+		mimeDir := filepath.Join(tmpDir, "mime")
+		if err := os.MkdirAll(mimeDir, 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		files := map[string][]byte{
+			"photo.dat":  []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"),
+			"notes":      []byte("plain text notes\n"),
+			"report.pdf": []byte("%PDF-1.7\n"),
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(mimeDir, name), content, 0644); err != nil {
+				t.Fatalf("Failed to create file: %v", err)
+			}
+		}
+
+		mimeType, err := DetectMIME(filepath.Join(mimeDir, "photo.dat"))
+		if err != nil {
+			t.Fatalf("Failed to detect MIME type: %v", err)
+		}
+		if mimeType != "image/png" {
+			t.Errorf("Expected image/png, got %s", mimeType)
+		}
+
+		results, err := FindFilesByMIME(mimeDir, "image")
+		if err != nil {
+			t.Fatalf("Failed to find files by MIME type: %v", err)
+		}
+		if len(results) != 1 || filepath.Base(results[0].Path) != "photo.dat" || results[0].MatchedBy != "mime" {
+			t.Errorf("Expected only photo.dat, got %+v", results)
+		}
+
+		results, err = FindFiles(mimeDir, "*", WithFileType("text/*", "application/pdf"))
+		if err != nil {
+			t.Fatalf("Failed to find files: %v", err)
+		}
+		if len(results) != 2 {
+			t.Errorf("Expected notes and report.pdf, got %+v", results)
+		}
+	})
+
+	t.Run("IsTextFile", func(t *testing.T) {
+		textDir := filepath.Join(tmpDir, "text")
+		if err := os.MkdirAll(textDir, 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		files := map[string][]byte{
+			"Makefile":   []byte("build:\n\tgo build ./... # needle\n"),
+			"run":        []byte("#!/bin/sh\necho needle ünïcode\n"),
+			"binary.txt": []byte("needle\x00\x01\x02"),
+			"latin1.cfg": []byte("needle \xe9\n"),
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(textDir, name), content, 0644); err != nil {
+				t.Fatalf("Failed to create file: %v", err)
+			}
+		}
+
+		expected := map[string]bool{
+			"Makefile":   true,
+			"run":        true,
+			"binary.txt": false,
+			"latin1.cfg": false,
+		}
+		for name, text := range expected {
+			if IsTextFile(filepath.Join(textDir, name)) != text {
+				t.Errorf("%s: expected text=%v", name, text)
+			}
+		}
+
+		results, err := FindFilesByContent(textDir, "needle")
+		if err != nil {
+			t.Fatalf("Failed to search content: %v", err)
+		}
+		if len(results) != 2 {
+			t.Errorf("Expected matches in extensionless text files only, got %+v", results)
+		}
+	})
+
+	t.Run("FindFilesNewerThan", func(t *testing.T) {
+		newerDir := filepath.Join(tmpDir, "newer")
+		if err := os.MkdirAll(newerDir, 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		now := time.Now()
+		times := map[string]time.Time{
+			"old.txt":  now.Add(-2 * time.Hour),
+			"marker":   now.Add(-time.Hour),
+			"new1.txt": now.Add(-time.Minute),
+			"new2.txt": now,
+		}
+		for name, modTime := range times {
+			path := filepath.Join(newerDir, name)
+			if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+				t.Fatalf("Failed to create file: %v", err)
+			}
+			if err := os.Chtimes(path, modTime, modTime); err != nil {
+				t.Fatalf("Failed to set file times: %v", err)
+			}
+		}
+
+		results, err := FindFilesNewerThan(newerDir, filepath.Join(newerDir, "marker"), WithSortResults(SortByName, true))
+		if err != nil {
+			t.Fatalf("Failed to find newer files: %v", err)
+		}
+		if len(results) != 2 || results[0].Info.Name() != "new1.txt" || results[1].Info.Name() != "new2.txt" {
+			t.Errorf("Expected new1.txt and new2.txt, got %+v", results)
+		}
+
+		if _, err := FindFilesNewerThan(newerDir, filepath.Join(newerDir, "missing")); !errors.Is(err, ErrStatFile) {
+			t.Errorf("Expected ErrStatFile, got %v", err)
+		}
+	})
+
+	t.Run("FindByLinks", func(t *testing.T) {
+		linkDir := filepath.Join(tmpDir, "links")
+		original := filepath.Join(linkDir, "original.txt")
+		if err := CreateFile(original, []byte("data"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := CreateFile(filepath.Join(linkDir, "single.txt"), []byte("data")); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := os.MkdirAll(filepath.Join(linkDir, "sub"), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.Link(original, filepath.Join(linkDir, "sub", "linked.txt")); err != nil {
+			t.Skipf("Hard links not supported: %v", err)
+		}
+
+		results, err := FindSameFile(linkDir, original)
+		if err != nil {
+			t.Fatalf("Failed to find links: %v", err)
+		}
+		if len(results) != 2 {
+			t.Errorf("Expected 2 names of the same file, got %+v", results)
+		}
+
+		info, err := os.Stat(original)
+		if err != nil {
+			t.Fatalf("Failed to stat file: %v", err)
+		}
+		id, ok := fileIdentity(info)
+		if !ok {
+			t.Skip("Inode numbers not supported")
+		}
+
+		results, err = FindFilesByLinkCount(linkDir, 2)
+		if err != nil {
+			t.Fatalf("Failed to find hardlinked files: %v", err)
+		}
+		if len(results) != 2 || results[0].MatchedBy != "link_count" {
+			t.Errorf("Expected 2 hardlinked names, got %+v", results)
+		}
+
+		results, err = FindFilesByInode(linkDir, id.ino)
+		if err != nil {
+			t.Fatalf("Failed to find files by inode: %v", err)
+		}
+		if len(results) != 2 {
+			t.Errorf("Expected 2 names of inode %d, got %+v", id.ino, results)
+		}
+	})
+
+	t.Run("IsHidden", func(t *testing.T) {
+		hiddenDir := filepath.Join(tmpDir, "hidden")
+		for _, name := range []string{".secret", "visible"} {
+			if err := CreateFile(filepath.Join(hiddenDir, name), []byte(name), WithCreateDirs()); err != nil {
+				t.Fatalf("Failed to create file: %v", err)
+			}
+		}
+
+		if !IsHidden(filepath.Join(hiddenDir, ".secret")) {
+			t.Error("Expected dot file to be hidden")
+		}
+		if IsHidden(filepath.Join(hiddenDir, "visible")) {
+			t.Error("Expected visible file not to be hidden")
+		}
+		if IsHidden(filepath.Join(hiddenDir, "missing")) {
+			t.Error("Expected missing file not to be hidden")
+		}
+	})
+
+	t.Run("SearchCancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		for _, workers := range []int{0, 4} {
+			_, err := FindFiles(tmpDir, "*", WithSearchContext(ctx), WithSearchWorkers(workers))
+			if !errors.Is(err, ErrSearchCanceled) || !errors.Is(err, context.Canceled) {
+				t.Errorf("Workers %d: expected ErrSearchCanceled, got %v", workers, err)
+			}
+		}
+
+		if _, err := FindFilesBySize(tmpDir, 0, -1, WithSearchTimeout(-time.Second)); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected an expired timeout to stop the search, got %v", err)
+		}
+
+		if _, err := FindFiles(tmpDir, "*", WithSearchTimeout(time.Minute)); err != nil {
+			t.Errorf("Expected search within timeout to succeed, got %v", err)
+		}
+	})
+
+	t.Run("FindFilesFuzzy", func(t *testing.T) {
+		fuzzyDir := filepath.Join(tmpDir, "fuzzy")
+		names := []string{"user_controller.go", "UserControl.go", "src_utils.go", "controller.go", "readme.md"}
+		for _, name := range names {
+			if err := CreateFile(filepath.Join(fuzzyDir, name), []byte(name), WithCreateDirs()); err != nil {
+				t.Fatalf("Failed to create file: %v", err)
+			}
+		}
+
+		results, err := FindFilesFuzzy(fuzzyDir, "usrctrl")
+		if err != nil {
+			t.Fatalf("Failed to run fuzzy search: %v", err)
+		}
+
+		var found []string
+		for i, result := range results {
+			found = append(found, result.Info.Name())
+			if result.MatchedBy != "fuzzy" || result.Score <= 0 || result.Score > 1 {
+				t.Errorf("Unexpected result %+v", result)
+			}
+			if i > 0 && results[i-1].Score < result.Score {
+				t.Errorf("Results not ranked by score at %d", i)
+			}
+		}
+		if len(found) != 2 || found[0] != "UserControl.go" || found[1] != "user_controller.go" {
+			t.Errorf("Expected both user controllers ranked by closeness, got %v", found)
+		}
+
+		// Typos are matched by edit distance below subsequence matches
+		results, err = FindFilesFuzzy(fuzzyDir, "contorller", WithLimitResults(1))
+		if err != nil {
+			t.Fatalf("Failed to run fuzzy search: %v", err)
+		}
+		if len(results) != 1 || results[0].Info.Name() != "controller.go" || results[0].Score >= 0.5 {
+			t.Errorf("Expected controller.go by edit distance, got %+v", results)
+		}
+
+		if _, err := FindFilesFuzzy(fuzzyDir, ""); !errors.Is(err, ErrInvalidPattern) {
+			t.Errorf("Expected ErrInvalidPattern, got %v", err)
+		}
+	})
+
+	t.Run("FindFilesBy", func(t *testing.T) {
+		results, err := FindFilesBy(tmpDir, func(path string, info os.FileInfo) bool {
+			return strings.HasPrefix(info.Name(), "test")
+		}, WithMaxDepth(1), WithLimitResults(2))
+		if err != nil {
+			t.Fatalf("Failed to find files: %v", err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("Expected 2 results, got %d", len(results))
+		}
+		for _, result := range results {
+			if result.MatchedBy != "predicate" || !strings.HasPrefix(filepath.Base(result.Path), "test") {
+				t.Errorf("Unexpected result: %+v", result)
+			}
+		}
+	})
+
+	t.Run("FindFilesByBytes", func(t *testing.T) {
+		bytesDir := filepath.Join(tmpDir, "bytes")
+		needle := []byte{0xde, 0xad, 0xbe, 0xef}
+
+		// Match across the 64 KiB chunk boundary
+		data := make([]byte, 64*1024+10)
+		copy(data[64*1024-2:], needle)
+		if err := CreateFile(filepath.Join(bytesDir, "blob.bin"), data, WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := CreateFile(filepath.Join(bytesDir, "other.bin"), []byte{0xde, 0xad, 0xbe, 0x00}); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		results, err := FindFilesByBytes(bytesDir, needle)
+		if err != nil {
+			t.Fatalf("Failed to search bytes: %v", err)
+		}
+		if len(results) != 1 || filepath.Base(results[0].Path) != "blob.bin" || results[0].Offset != 64*1024-2 {
+			t.Errorf("Expected blob.bin at offset %d, got %+v", 64*1024-2, results)
+		}
+
+		if _, err := FindFilesByBytes(bytesDir, nil); !errors.Is(err, ErrInvalidPattern) {
+			t.Errorf("Expected ErrInvalidPattern for an empty needle, got %v", err)
+		}
+	})
+
+	t.Run("ContentSearchLimits", func(t *testing.T) {
+		limitDir := filepath.Join(tmpDir, "limits")
+		long := strings.Repeat("x", 200) + " needle\nsecond needle\r\n"
+		if err := CreateFile(filepath.Join(limitDir, "long.txt"), []byte(long), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		big := strings.Repeat("needle\n", 100)
+		if err := CreateFile(filepath.Join(limitDir, "big.log"), []byte(big)); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		results, err := FindFilesByContent(limitDir, "needle", WithMaxLineLength(64), WithMaxFileSize(500))
+		if err != nil {
+			t.Fatalf("Failed to search content: %v", err)
+		}
+		if len(results) != 1 {
+			t.Fatalf("Expected only long.txt, got %+v", results)
+		}
+		if results[0].LineNumber != 2 || results[0].Line != "second needle" {
+			t.Errorf("Match past the line cap must be skipped, got line %d %q", results[0].LineNumber, results[0].Line)
+		}
+	})
+
+	t.Run("SymlinkLoop", func(t *testing.T) {
+		loopDir := filepath.Join(tmpDir, "loop")
+		if err := CreateFile(filepath.Join(loopDir, "sub", "file.txt"), []byte("data"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := os.Symlink("..", filepath.Join(loopDir, "sub", "back")); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+
+		if err := os.Symlink("sub", filepath.Join(loopDir, "alias")); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+
+		for _, workers := range []int{0, 4} {
+			results, err := FindFiles(loopDir, "*", WithSearchFollowSymlinks(), WithSearchWorkers(workers))
+			if err != nil {
+				t.Fatalf("Failed to find files: %v", err)
+			}
+
+			var files, loops []string
+			for _, result := range results {
+				switch result.MatchedBy {
+				case "name":
+					files = append(files, result.Path)
+				case "symlink_loop":
+					loops = append(loops, result.Path)
+				}
+			}
+			if len(files) != 1 {
+				t.Errorf("Workers %d: expected the file once, got %v", workers, files)
+			}
+			if len(loops) != 1 || filepath.Base(loops[0]) != "back" {
+				t.Errorf("Workers %d: expected the back link reported as a loop, got %v", workers, loops)
+			}
+		}
+
+		results, err := FindFiles(loopDir, "*.txt")
+		if err != nil || len(results) != 1 {
+			t.Errorf("Expected 1 file without following symlinks, got %d: %v", len(results), err)
+		}
+	})
 }
 
 // setupSearchTestStructure creates a test directory structure
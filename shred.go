@@ -0,0 +1,77 @@
+package fsx
+
+import (
+	"crypto/rand"
+	"os"
+)
+
+// shredBufferSize is the size of the random blocks ShredFile writes
+const shredBufferSize = 64 * 1024
+
+// ShredFile overwrites the content of a regular file with random data passes times
+// (at least once), syncing each pass to disk, and then removes it.
+//
+// Overwriting in place only destroys the data where the storage writes to the same
+// blocks: SSDs (wear levelling), copy-on-write filesystems (Btrfs, ZFS, APFS),
+// snapshots, journals of data and backups may keep copies. Other hardlinks to the
+// file see the random data. Use full-disk encryption where that matters
+func ShredFile(path string, passes int) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return newStatFile(path, err)
+	}
+	if !info.Mode().IsRegular() {
+		return newShredFileError(path, os.ErrInvalid)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return newOpenFileError(path, err)
+	}
+
+	err = overwriteRandom(file, info.Size(), max(passes, 1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Remove(path)
+	}
+	if err != nil {
+		return newShredFileError(path, err)
+	}
+
+	return nil
+}
+
+// overwriteRandom writes size random bytes from the start of file passes times
+func overwriteRandom(file *os.File, size int64, passes int) error {
+	buf := make([]byte, shredBufferSize)
+	for pass := 0; pass < passes; pass++ {
+		for offset := int64(0); offset < size; {
+			chunk := buf[:min(int64(len(buf)), size-offset)]
+			if _, err := rand.Read(chunk); err != nil {
+				return err
+			}
+			n, err := file.WriteAt(chunk, offset)
+			if err != nil {
+				return err
+			}
+			offset += int64(n)
+		}
+
+		if err := file.Sync(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newShredFileError(path string, err error) error {
+	return ErrShredFile.
+		SetError(err).
+		SetData(pathErrorContext{
+			Path:  path,
+			Error: err,
+		})
+}
@@ -0,0 +1,433 @@
+package fsx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Snapshot directories are named by their UTC creation time, so names sort chronologically.
+// A snapshot is built under a ".partial" name and renamed once complete
+const (
+	snapshotIDLayout      = "20060102T150405.000000000Z"
+	snapshotPartialSuffix = ".partial"
+)
+
+// Snapshot is a point-in-time copy of a directory created by MirrorSnapshot
+type Snapshot struct {
+	ID        string
+	Path      string
+	CreatedAt time.Time
+}
+
+// SnapshotReport describes a snapshot created by MirrorSnapshot
+type SnapshotReport struct {
+	Snapshot    Snapshot
+	Previous    string // ID of the snapshot unchanged files are linked to, empty for the first one
+	Files       int
+	Linked      int
+	Copied      int
+	CopiedBytes int64
+}
+
+// snapshotDir is a directory whose attributes are restored after its content is written
+type snapshotDir struct {
+	path string
+	info os.FileInfo
+}
+
+// MirrorSnapshot copies src into a new timestamped snapshot directory under snapshotsRoot.
+// Like rsync --link-dest, files unchanged since the latest snapshot (same size, mode and
+// modification time) are hardlinked to it, so every snapshot is complete but only changed files take space
+func MirrorSnapshot(src, snapshotsRoot string, options ...SnapshotOption) (*SnapshotReport, error) {
+	opts := defaultSnapshotOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return nil, newMirrorSnapshotError(src, snapshotsRoot, err)
+	}
+
+	if !srcInfo.IsDir() {
+		return nil, ErrSourceNotDirectory.
+			SetData(moveErrorContext{
+				Source:      src,
+				Destination: snapshotsRoot,
+				Error:       nil,
+			})
+	}
+
+	snapshots, err := listSnapshots(snapshotsRoot)
+	if err != nil {
+		return nil, newMirrorSnapshotError(src, snapshotsRoot, err)
+	}
+
+	if err := os.MkdirAll(snapshotsRoot, 0755); err != nil {
+		return nil, newMirrorSnapshotError(src, snapshotsRoot, err)
+	}
+
+	report := &SnapshotReport{
+		Snapshot: newSnapshot(snapshotsRoot),
+	}
+
+	previous := ""
+	if len(snapshots) > 0 {
+		latest := snapshots[len(snapshots)-1]
+		report.Previous = latest.ID
+		previous = latest.Path
+	}
+
+	// An interrupted run must never become the base of the next snapshot
+	partial := report.Snapshot.Path + snapshotPartialSuffix
+	if err := mirrorSnapshotTree(src, partial, previous, opts, report); err != nil {
+		os.RemoveAll(partial)
+		return nil, newMirrorSnapshotError(src, snapshotsRoot, err)
+	}
+
+	if err := os.Rename(partial, report.Snapshot.Path); err != nil {
+		os.RemoveAll(partial)
+		return nil, newMirrorSnapshotError(src, snapshotsRoot, err)
+	}
+
+	return report, nil
+}
+
+// mirrorSnapshotTree copies src into dst, linking files unchanged since the previous snapshot
+func mirrorSnapshotTree(src, dst, previous string, opts *snapshotOptions, report *SnapshotReport) error {
+	var dirs []snapshotDir
+
+	err := walkTree(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		if relPath != "." && matchAnyPattern(filepath.ToSlash(relPath), opts.excludePatterns) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		target := filepath.Join(dst, relPath)
+
+		switch {
+		case info.IsDir():
+			// Read-only directories get their mode once their content is written
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			dirs = append(dirs, snapshotDir{path: target, info: info})
+			return nil
+		case info.Mode()&os.ModeSymlink != 0:
+			return copySymlink(path, target, false)
+		case !info.Mode().IsRegular():
+			// Devices, sockets and pipes are not part of snapshots
+			return nil
+		}
+
+		report.Files++
+
+		if previous != "" {
+			previousPath := filepath.Join(previous, relPath)
+			if previousInfo, err := os.Lstat(previousPath); err == nil && unchangedSnapshotFile(info, previousInfo) {
+				if err := os.Link(previousPath, target); err == nil {
+					report.Linked++
+					return nil
+				}
+			}
+		}
+
+		copyOpts := &copyOptions{
+			overwrite:     true,
+			preservePerms: true,
+			preserveTimes: true,
+		}
+		if err := copyFileWithOptions(path, target, info, copyOpts); err != nil {
+			return err
+		}
+		report.Copied++
+		report.CopiedBytes += info.Size()
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Writing content changes directory times, so restore them deepest first
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Chmod(dirs[i].path, dirs[i].info.Mode().Perm())
+		os.Chtimes(dirs[i].path, dirs[i].info.ModTime(), dirs[i].info.ModTime())
+	}
+
+	return nil
+}
+
+// unchangedSnapshotFile reports whether a file matches its copy in the previous snapshot
+func unchangedSnapshotFile(info, previous os.FileInfo) bool {
+	return previous.Mode().IsRegular() &&
+		previous.Size() == info.Size() &&
+		previous.Mode() == info.Mode() &&
+		previous.ModTime().Equal(info.ModTime())
+}
+
+// listSnapshots returns complete snapshots under root, oldest first.
+// A missing root has no snapshots
+func listSnapshots(root string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasSuffix(entry.Name(), snapshotPartialSuffix) {
+			continue
+		}
+
+		createdAt, err := time.Parse(snapshotIDLayout, entry.Name())
+		if err != nil {
+			continue
+		}
+
+		snapshots = append(snapshots, Snapshot{
+			ID:        entry.Name(),
+			Path:      filepath.Join(root, entry.Name()),
+			CreatedAt: createdAt,
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+	})
+
+	return snapshots, nil
+}
+
+// newSnapshot returns a time based snapshot not used in root yet
+func newSnapshot(root string) Snapshot {
+	for {
+		createdAt := time.Now().UTC()
+		id := createdAt.Format(snapshotIDLayout)
+		path := filepath.Join(root, id)
+		if !FileExist(path) && !FileExist(path+snapshotPartialSuffix) {
+			return Snapshot{
+				ID:        id,
+				Path:      path,
+				CreatedAt: createdAt,
+			}
+		}
+		time.Sleep(time.Microsecond)
+	}
+}
+
+func newMirrorSnapshotError(src, root string, err error) error {
+	return ErrMirrorSnapshot.
+		SetError(err).
+		SetData(moveErrorContext{
+			Source:      src,
+			Destination: root,
+			Error:       err,
+		})
+}
+
+// Snapshots manages the snapshots created by MirrorSnapshot under a root directory
+type Snapshots struct {
+	root string
+}
+
+// RetentionPolicy selects snapshots kept by Snapshots.Prune. KeepLast keeps the newest
+// snapshots; the other fields keep the newest snapshot of that many recent days, weeks,
+// months and years (grandfather-father-son rotation). Periods use the UTC creation time
+type RetentionPolicy struct {
+	KeepLast    int
+	KeepDaily   int
+	KeepWeekly  int
+	KeepMonthly int
+	KeepYearly  int
+}
+
+// OpenSnapshots returns a manager of the snapshots under root
+func OpenSnapshots(root string) *Snapshots {
+	return &Snapshots{
+		root: root,
+	}
+}
+
+// Root returns the directory holding the snapshots
+func (s *Snapshots) Root() string {
+	return s.root
+}
+
+// Create creates a new snapshot of src, see MirrorSnapshot
+func (s *Snapshots) Create(src string, options ...SnapshotOption) (*SnapshotReport, error) {
+	return MirrorSnapshot(src, s.root, options...)
+}
+
+// List returns complete snapshots, oldest first
+func (s *Snapshots) List() ([]Snapshot, error) {
+	snapshots, err := listSnapshots(s.root)
+	if err != nil {
+		return nil, ErrListSnapshots.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  s.root,
+				Error: err,
+			})
+	}
+
+	return snapshots, nil
+}
+
+// Get returns a snapshot by ID; an empty ID returns the latest snapshot
+func (s *Snapshots) Get(id string) (*Snapshot, error) {
+	snapshots, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	if id == "" && len(snapshots) > 0 {
+		return &snapshots[len(snapshots)-1], nil
+	}
+
+	for i := range snapshots {
+		if snapshots[i].ID == id {
+			return &snapshots[i], nil
+		}
+	}
+
+	return nil, ErrSnapshotNotFound.
+		SetData(struct {
+			Root     string `json:"root"`
+			Snapshot string `json:"snapshot"`
+		}{
+			Root:     s.root,
+			Snapshot: id,
+		})
+}
+
+// Diff compares two snapshots. Added and removed entries are relative to fromID
+func (s *Snapshots) Diff(fromID, toID string, options ...CompareOption) ([]Difference, error) {
+	from, err := s.Get(fromID)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := s.Get(toID)
+	if err != nil {
+		return nil, err
+	}
+
+	return CompareDirectories(from.Path, to.Path, options...)
+}
+
+// Restore copies a snapshot into dst; an empty ID restores the latest snapshot.
+// Use WithOverwrite to restore over an existing directory
+func (s *Snapshots) Restore(id, dst string, options ...CopyOption) error {
+	snapshot, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+
+	if err := CopyDirectory(snapshot.Path, dst, options...); err != nil {
+		return ErrRestoreSnapshot.
+			SetError(err).
+			SetData(moveErrorContext{
+				Source:      snapshot.Path,
+				Destination: dst,
+				Error:       err,
+			})
+	}
+
+	return nil
+}
+
+// Prune removes snapshots not kept by the policy and returns them, oldest first.
+// A policy keeping nothing is rejected
+func (s *Snapshots) Prune(policy RetentionPolicy) ([]Snapshot, error) {
+	if policy.KeepLast <= 0 && policy.KeepDaily <= 0 && policy.KeepWeekly <= 0 &&
+		policy.KeepMonthly <= 0 && policy.KeepYearly <= 0 {
+		return nil, ErrPruneSnapshots.
+			SetData(pathErrorContext{
+				Path:  s.root,
+				Error: nil,
+			})
+	}
+
+	snapshots, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	keep := policy.keep(snapshots)
+
+	var removed []Snapshot
+	for i, snapshot := range snapshots {
+		if keep[i] {
+			continue
+		}
+
+		if err := os.RemoveAll(snapshot.Path); err != nil {
+			return removed, ErrPruneSnapshots.
+				SetError(err).
+				SetData(pathErrorContext{
+					Path:  snapshot.Path,
+					Error: err,
+				})
+		}
+		removed = append(removed, snapshot)
+	}
+
+	return removed, nil
+}
+
+// keep marks snapshots kept by the policy; snapshots are sorted oldest first
+func (p RetentionPolicy) keep(snapshots []Snapshot) []bool {
+	keep := make([]bool, len(snapshots))
+
+	// Newest snapshots first
+	for i := len(snapshots) - 1; i >= 0 && len(snapshots)-i <= p.KeepLast; i-- {
+		keep[i] = true
+	}
+
+	buckets := []struct {
+		count  int
+		period func(t time.Time) string
+	}{
+		{p.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{p.KeepWeekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{p.KeepMonthly, func(t time.Time) string { return t.Format("2006-01") }},
+		{p.KeepYearly, func(t time.Time) string { return t.Format("2006") }},
+	}
+
+	// Keep the newest snapshot of each of the most recent periods
+	for _, bucket := range buckets {
+		seen := make(map[string]bool)
+		for i := len(snapshots) - 1; i >= 0 && len(seen) < bucket.count; i-- {
+			period := bucket.period(snapshots[i].CreatedAt.UTC())
+			if seen[period] {
+				continue
+			}
+			seen[period] = true
+			keep[i] = true
+		}
+	}
+
+	return keep
+}
@@ -0,0 +1,161 @@
+package fsx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshots(t *testing.T) {
+	// Create a temporary directory for tests
+	tmpDir, err := os.MkdirTemp("", "fsx_snapshot_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "data")
+	root := filepath.Join(tmpDir, "snapshots")
+
+	files := map[string]string{
+		"a.txt":     "alpha",
+		"b.txt":     "beta",
+		"sub/c.txt": "gamma",
+		"cache.tmp": "scratch",
+	}
+	for name, content := range files {
+		if err := CreateFile(filepath.Join(src, name), []byte(content), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	var first *SnapshotReport
+
+	t.Run("MirrorSnapshot", func(t *testing.T) {
+		report, err := MirrorSnapshot(src, root, WithSnapshotExclude("*.tmp"))
+		if err != nil {
+			t.Fatalf("Failed to create snapshot: %v", err)
+		}
+		if report.Previous != "" || report.Files != 3 || report.Copied != 3 || report.Linked != 0 {
+			t.Errorf("Unexpected first snapshot: %+v", report)
+		}
+		if FileExist(filepath.Join(report.Snapshot.Path, "cache.tmp")) {
+			t.Error("Excluded file must not be in the snapshot")
+		}
+		first = report
+	})
+
+	t.Run("LinkUnchangedFiles", func(t *testing.T) {
+		future := time.Now().Add(time.Hour)
+		if err := WriteFileString(filepath.Join(src, "b.txt"), "beta v2"); err != nil {
+			t.Fatalf("Failed to modify file: %v", err)
+		}
+		os.Chtimes(filepath.Join(src, "b.txt"), future, future)
+
+		report, err := MirrorSnapshot(src, root, WithSnapshotExclude("*.tmp"))
+		if err != nil {
+			t.Fatalf("Failed to create snapshot: %v", err)
+		}
+		if report.Previous != first.Snapshot.ID || report.Copied != 1 || report.Linked != 2 {
+			t.Errorf("Unexpected second snapshot: %+v", report)
+		}
+
+		oldInfo, _ := os.Stat(filepath.Join(first.Snapshot.Path, "a.txt"))
+		newInfo, _ := os.Stat(filepath.Join(report.Snapshot.Path, "a.txt"))
+		if !os.SameFile(oldInfo, newInfo) {
+			t.Error("Unchanged file should be hardlinked to the previous snapshot")
+		}
+
+		if content, _ := ReadFileString(filepath.Join(first.Snapshot.Path, "b.txt")); content != "beta" {
+			t.Errorf("Previous snapshot must keep the old content, got %q", content)
+		}
+		if content, _ := ReadFileString(filepath.Join(report.Snapshot.Path, "b.txt")); content != "beta v2" {
+			t.Errorf("New snapshot should have the new content, got %q", content)
+		}
+	})
+
+	snapshots := OpenSnapshots(root)
+
+	t.Run("ListDiffRestore", func(t *testing.T) {
+		list, err := snapshots.List()
+		if err != nil {
+			t.Fatalf("Failed to list snapshots: %v", err)
+		}
+		if len(list) != 2 || list[0].ID != first.Snapshot.ID {
+			t.Fatalf("Expected 2 snapshots starting with %s, got %+v", first.Snapshot.ID, list)
+		}
+
+		diffs, err := snapshots.Diff(list[0].ID, list[1].ID)
+		if err != nil {
+			t.Fatalf("Failed to diff snapshots: %v", err)
+		}
+		counts := DiffReport(diffs).Counts()
+		if counts[DiffModified] != 1 || counts[DiffSame] != 2 {
+			t.Errorf("Expected only b.txt to be modified, got %+v", diffs)
+		}
+
+		dst := filepath.Join(tmpDir, "restore")
+		if err := snapshots.Restore(first.Snapshot.ID, dst); err != nil {
+			t.Fatalf("Failed to restore snapshot: %v", err)
+		}
+		if content, _ := ReadFileString(filepath.Join(dst, "b.txt")); content != "beta" {
+			t.Errorf("Expected restored content %q, got %q", "beta", content)
+		}
+
+		if _, err := snapshots.Get("missing"); !errors.Is(err, ErrSnapshotNotFound) {
+			t.Errorf("Expected ErrSnapshotNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Prune", func(t *testing.T) {
+		if _, err := snapshots.Prune(RetentionPolicy{}); !errors.Is(err, ErrPruneSnapshots) {
+			t.Errorf("Empty policy should be rejected, got %v", err)
+		}
+
+		removed, err := snapshots.Prune(RetentionPolicy{KeepLast: 1})
+		if err != nil {
+			t.Fatalf("Failed to prune snapshots: %v", err)
+		}
+		if len(removed) != 1 || removed[0].ID != first.Snapshot.ID {
+			t.Errorf("Expected the first snapshot to be removed, got %+v", removed)
+		}
+
+		latest, err := snapshots.Get("")
+		if err != nil {
+			t.Fatalf("Failed to get latest snapshot: %v", err)
+		}
+		if content, _ := ReadFileString(filepath.Join(latest.Path, "a.txt")); content != "alpha" {
+			t.Errorf("Linked files must survive pruning, got %q", content)
+		}
+	})
+
+	t.Run("RetentionPolicy", func(t *testing.T) {
+		base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		var list []Snapshot
+		// Two snapshots a day for 60 days
+		for day := 0; day < 60; day++ {
+			for _, hour := range []int{0, 6} {
+				list = append(list, Snapshot{CreatedAt: base.AddDate(0, 0, day).Add(time.Duration(hour) * time.Hour)})
+			}
+		}
+
+		keep := RetentionPolicy{KeepLast: 2, KeepDaily: 3, KeepMonthly: 2}.keep(list)
+
+		var kept []time.Time
+		for i, ok := range keep {
+			if ok {
+				kept = append(kept, list[i].CreatedAt)
+			}
+		}
+
+		// Last two, newest of the last 3 days and newest of January
+		if len(kept) != 5 {
+			t.Fatalf("Expected 5 kept snapshots, got %d: %v", len(kept), kept)
+		}
+		if !kept[0].Equal(time.Date(2024, 1, 31, 18, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected the newest January snapshot to be kept, got %v", kept[0])
+		}
+	})
+}
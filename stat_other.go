@@ -0,0 +1,30 @@
+//go:build !unix
+
+package fsx
+
+import "os"
+
+// isSymlinkLoop can't tell symlink loops apart on this platform
+func isSymlinkLoop(err error) bool {
+	return false
+}
+
+// isNotDirectory is reported as a missing path on this platform
+func isNotDirectory(err error) bool {
+	return false
+}
+
+// fileIdentity is not available on this platform
+func fileIdentity(info os.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
+
+// fileLinkCount is not available on this platform
+func fileLinkCount(info os.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
+// fileOwner is not available on this platform
+func fileOwner(info os.FileInfo) (int, int, bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,52 @@
+//go:build unix
+
+package fsx
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// isSymlinkLoop reports whether err comes from resolving symlinks in a loop
+func isSymlinkLoop(err error) bool {
+	return errors.Is(err, syscall.ELOOP)
+}
+
+// isNotDirectory reports whether err comes from resolving a path through a non-directory
+func isNotDirectory(err error) bool {
+	return errors.Is(err, syscall.ENOTDIR)
+}
+
+// fileIdentity returns the device and inode of a file
+func fileIdentity(info os.FileInfo) (fileID, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+
+	return fileID{
+		dev: uint64(stat.Dev),
+		ino: uint64(stat.Ino),
+	}, true
+}
+
+// fileLinkCount returns the number of hard links to a file
+func fileLinkCount(info os.FileInfo) (uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+
+	return uint64(stat.Nlink), true
+}
+
+// fileOwner returns the uid and gid of a file
+func fileOwner(info os.FileInfo) (int, int, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+
+	return int(stat.Uid), int(stat.Gid), true
+}
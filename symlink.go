@@ -0,0 +1,167 @@
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// CreateSymlink creates a symbolic link at link pointing to target. The target is stored
+// as given, relative targets are resolved from the link's directory. WithCreateDirs creates
+// missing parent directories
+func CreateSymlink(target, link string, options ...FileOption) error {
+	opts := defaultFileOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if opts.createDirs {
+		if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+			return newCreateDirectories(link, err)
+		}
+	}
+
+	if err := os.Symlink(target, link); err != nil {
+		return newCreateSymlinkError(target, link, err)
+	}
+
+	return nil
+}
+
+// IsSymlink reports whether path is a symbolic link, or a junction on Windows,
+// whether or not its target exists
+func IsSymlink(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && isLink(info)
+}
+
+// isLink reports whether info describes a symbolic link or a junction
+func isLink(info os.FileInfo) bool {
+	return info.Mode()&os.ModeSymlink != 0 || isJunction(info)
+}
+
+// ReadSymlink returns the target stored in a symbolic link or junction,
+// fails with ErrNotSymlink for other files
+func ReadSymlink(path string) (string, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return "", newReadSymlinkError(path, err)
+	}
+	if !isLink(info) {
+		return "", ErrNotSymlink.
+			SetData(pathErrorContext{
+				Path:  path,
+				Error: nil,
+			})
+	}
+
+	target, err := os.Readlink(path)
+	if err != nil {
+		return "", newReadSymlinkError(path, err)
+	}
+
+	return target, nil
+}
+
+// ResolveSymlink returns the absolute path path finally points to, following every link
+// in the chain and in its parent directories. Broken links and loops fail with ErrResolveSymlink
+func ResolveSymlink(path string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		resolved, err = filepath.Abs(resolved)
+	}
+	if err != nil {
+		return "", ErrResolveSymlink.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  path,
+				Error: err,
+			})
+	}
+
+	return resolved, nil
+}
+
+// CopySymlink creates at dst a link with the same target as the link src, without copying
+// what it points to. A junction is copied as a symbolic link. An existing file or link
+// at dst is replaced. WithCreateDirs creates missing parent directories
+func CopySymlink(src, dst string, options ...FileOption) error {
+	opts := defaultFileOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if opts.createDirs {
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return newCreateDirectories(dst, err)
+		}
+	}
+
+	return copySymlink(src, dst, true)
+}
+
+// copySymlink recreates the link src at dst, junctions become symbolic links. An existing
+// dst is replaced when replace is set and kept otherwise; directories are never replaced
+func copySymlink(src, dst string, replace bool) error {
+	target, err := ReadSymlink(src)
+	if err != nil {
+		return err
+	}
+
+	if info, err := os.Lstat(dst); err == nil {
+		if !replace {
+			return nil
+		}
+
+		if info.IsDir() {
+			return newCreateSymlinkError(target, dst, os.ErrExist)
+		}
+
+		// Create next to dst and rename over it so dst is never missing. Symlink
+		// fails on an existing name, so a name taken in the meantime is retried
+		tmp := stagingName(dst)
+		for {
+			err := os.Symlink(target, tmp)
+			if err == nil {
+				break
+			}
+			if !os.IsExist(err) {
+				return newCreateSymlinkError(target, dst, err)
+			}
+			tmp = stagingName(dst)
+		}
+		if err := os.Rename(tmp, dst); err != nil {
+			_ = os.Remove(tmp)
+			return newCreateSymlinkError(target, dst, err)
+		}
+		return nil
+	}
+
+	if err := os.Symlink(target, dst); err != nil {
+		return newCreateSymlinkError(target, dst, err)
+	}
+
+	return nil
+}
+
+func newCreateSymlinkError(target, link string, err error) error {
+	return ErrCreateSymlink.
+		SetError(err).
+		SetData(struct {
+			Target string `json:"target"`
+			Link   string `json:"link"`
+			Error  error  `json:"error"`
+		}{
+			Target: target,
+			Link:   link,
+			Error:  err,
+		})
+}
+
+func newReadSymlinkError(path string, err error) error {
+	return ErrReadSymlink.
+		SetError(err).
+		SetData(pathErrorContext{
+			Path:  path,
+			Error: err,
+		})
+}
@@ -0,0 +1,115 @@
+package fsx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSymlinks(t *testing.T) {
+	// Create a temporary directory for tests
+	tmpDir, err := os.MkdirTemp("", "fsx_symlink_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	target := filepath.Join(tmpDir, "data", "file.txt")
+	if err := CreateFile(target, []byte("data"), WithCreateDirs()); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	link := filepath.Join(tmpDir, "links", "file.link")
+	if err := CreateSymlink("../data/file.txt", link, WithCreateDirs()); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	t.Run("CreateAndRead", func(t *testing.T) {
+		if !IsSymlink(link) {
+			t.Error("Expected link to be a symlink")
+		}
+		if IsSymlink(target) {
+			t.Error("Expected regular file not to be a symlink")
+		}
+
+		stored, err := ReadSymlink(link)
+		if err != nil {
+			t.Fatalf("Failed to read symlink: %v", err)
+		}
+		if stored != "../data/file.txt" {
+			t.Errorf("Expected relative target, got %s", stored)
+		}
+
+		if _, err := ReadSymlink(target); !errors.Is(err, ErrNotSymlink) {
+			t.Errorf("Expected ErrNotSymlink, got %v", err)
+		}
+		if err := CreateSymlink("../data/file.txt", link); !errors.Is(err, ErrCreateSymlink) {
+			t.Errorf("Expected ErrCreateSymlink for existing link, got %v", err)
+		}
+	})
+
+	t.Run("ResolveSymlink", func(t *testing.T) {
+		chained := filepath.Join(tmpDir, "links", "chained.link")
+		if err := CreateSymlink("file.link", chained); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+
+		resolved, err := ResolveSymlink(chained)
+		if err != nil {
+			t.Fatalf("Failed to resolve symlink: %v", err)
+		}
+		expected, _ := filepath.EvalSymlinks(target)
+		if resolved != expected {
+			t.Errorf("Expected %s, got %s", expected, resolved)
+		}
+
+		broken := filepath.Join(tmpDir, "links", "broken.link")
+		if err := CreateSymlink("missing", broken); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+		if _, err := ResolveSymlink(broken); !errors.Is(err, ErrResolveSymlink) {
+			t.Errorf("Expected ErrResolveSymlink, got %v", err)
+		}
+	})
+
+	t.Run("CopySymlink", func(t *testing.T) {
+		copied := filepath.Join(tmpDir, "links", "copy.link")
+		if err := CreateFile(copied, []byte("in the way")); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		// A file with a predictable staging name must survive the copy
+		bystander := copied + ".fsx-link.tmp"
+		if err := CreateFile(bystander, []byte("keep")); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		if err := CopySymlink(link, copied); err != nil {
+			t.Fatalf("Failed to copy symlink: %v", err)
+		}
+
+		stored, err := ReadSymlink(copied)
+		if err != nil {
+			t.Fatalf("Failed to read copied symlink: %v", err)
+		}
+		if stored != "../data/file.txt" {
+			t.Errorf("Expected the same target, got %s", stored)
+		}
+
+		content, err := os.ReadFile(copied)
+		if err != nil || string(content) != "data" {
+			t.Errorf("Expected copied link to reach the target, got %q: %v", content, err)
+		}
+
+		if content, err := os.ReadFile(bystander); err != nil || string(content) != "keep" {
+			t.Errorf("Unrelated file next to dst must be kept, got %q: %v", content, err)
+		}
+		entries, _ := os.ReadDir(filepath.Dir(copied))
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), ".fsx-") {
+				t.Errorf("Staging link left behind: %s", entry.Name())
+			}
+		}
+	})
+}
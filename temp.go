@@ -0,0 +1,330 @@
+package fsx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// tempRegistry tracks temporary paths to remove later
+type tempRegistry struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+// registeredTemps holds paths created with WithTempCleanup
+var registeredTemps = &tempRegistry{}
+
+func (r *tempRegistry) add(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.paths = append(r.paths, path)
+}
+
+// remove forgets path without removing it
+func (r *tempRegistry) remove(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, registered := range r.paths {
+		if registered == path {
+			r.paths = append(r.paths[:i], r.paths[i+1:]...)
+			return
+		}
+	}
+}
+
+// removeAll removes the tracked paths, newest first, and forgets them
+func (r *tempRegistry) removeAll() error {
+	r.mu.Lock()
+	paths := r.paths
+	r.paths = nil
+	r.mu.Unlock()
+
+	var errs []error
+	for i := len(paths) - 1; i >= 0; i-- {
+		if err := os.RemoveAll(paths[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// CleanupTempFiles removes every temporary file and directory created with
+// WithTempCleanup that still exists, typically deferred in main
+func CleanupTempFiles() error {
+	if err := registeredTemps.removeAll(); err != nil {
+		return ErrTempFile.SetError(err)
+	}
+
+	return nil
+}
+
+// checkPrivateDir fails unless dir is owned by the current user and closed to group and others
+func checkPrivateDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return newStatFile(dir, err)
+	}
+
+	uid, _, ok := fileOwner(info)
+	if !ok {
+		return nil // no ownership on this platform
+	}
+
+	if uid != os.Getuid() || info.Mode().Perm()&0077 != 0 {
+		return ErrInsecureTempDir.
+			SetData(struct {
+				Dir   string `json:"dir"`
+				Mode  string `json:"mode"`
+				Owner int    `json:"owner"`
+			}{
+				Dir:   dir,
+				Mode:  info.Mode().Perm().String(),
+				Owner: uid,
+			})
+	}
+
+	return nil
+}
+
+// prepareTemp applies the parent checks of opts before creating a temporary path in dir
+func prepareTemp(dir string, opts *tempOptions) error {
+	if opts.privateParent {
+		return checkPrivateDir(dir)
+	}
+
+	return nil
+}
+
+// finishTemp applies the mode and registration of opts to a created temporary path
+func finishTemp(path string, opts *tempOptions) error {
+	if opts.perm != 0 {
+		if err := os.Chmod(path, opts.perm); err != nil {
+			os.RemoveAll(path)
+			return ErrTempFile.
+				SetError(err).
+				SetData(pathErrorContext{
+					Path:  path,
+					Error: err,
+				})
+		}
+	}
+
+	if opts.cleanup {
+		registeredTemps.add(path)
+	}
+
+	return nil
+}
+
+// CreateTempFileWithCleanup creates a temporary file like CreateTempFile and also
+// returns a function removing it, to be deferred
+func CreateTempFileWithCleanup(dir, pattern string, content []byte, options ...TempOption) (string, func() error, error) {
+	path, err := CreateTempFile(dir, pattern, content, options...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return path, removeTempFunc(path, nil), nil
+}
+
+// tempManagerMarker precedes the process ID in names created by a TempManager, so that
+// managers with overlapping prefixes and unrelated files never look like each other
+const tempManagerMarker = "fsx"
+
+// TempManager creates temporary files and directories named after a pattern and the
+// process ID, tracks them until Cleanup, and sweeps leftovers of crashed processes
+type TempManager struct {
+	dir      string
+	prefix   string
+	suffix   string
+	options  []TempOption
+	registry tempRegistry
+}
+
+// NewTempManager returns a manager creating temporary paths in dir (the system temp
+// directory when empty) named after pattern, as in os.CreateTemp, with "fsx" and the
+// process ID inserted before the random part. The pattern needs a prefix before its "*".
+// Call Sweep to remove leftovers of processes that no longer run
+func NewTempManager(dir, pattern string, options ...TempOption) (*TempManager, error) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	prefix, suffix := pattern, ""
+	if i := strings.LastIndex(pattern, "*"); i >= 0 {
+		prefix, suffix = pattern[:i], pattern[i+1:]
+	}
+
+	if prefix == "" || strings.ContainsRune(pattern, os.PathSeparator) || strings.ContainsRune(pattern, '/') {
+		return nil, ErrTempFile.
+			SetData(struct {
+				Dir     string `json:"dir"`
+				Pattern string `json:"pattern"`
+			}{
+				Dir:     dir,
+				Pattern: pattern,
+			})
+	}
+
+	return &TempManager{
+		dir:     dir,
+		prefix:  prefix,
+		suffix:  suffix,
+		options: options,
+	}, nil
+}
+
+// pattern returns the os.CreateTemp pattern of the current process
+func (m *TempManager) pattern() string {
+	return m.prefix + tempManagerMarker + strconv.Itoa(os.Getpid()) + "-*" + m.suffix
+}
+
+// CreateFile creates a temporary file with content and returns it with a function removing it
+func (m *TempManager) CreateFile(content []byte) (string, func() error, error) {
+	path, err := CreateTempFile(m.dir, m.pattern(), content, m.options...)
+	if err != nil {
+		return "", nil, err
+	}
+	m.registry.add(path)
+
+	return path, removeTempFunc(path, &m.registry), nil
+}
+
+// CreateDirectory creates a temporary directory and returns it with a function removing it
+func (m *TempManager) CreateDirectory() (string, func() error, error) {
+	path, err := CreateTempDirectory(m.dir, m.pattern(), m.options...)
+	if err != nil {
+		return "", nil, err
+	}
+	m.registry.add(path)
+
+	return path, removeTempFunc(path, &m.registry), nil
+}
+
+// Paths returns the temporary paths created by the manager and not removed yet
+func (m *TempManager) Paths() []string {
+	m.registry.mu.Lock()
+	defer m.registry.mu.Unlock()
+
+	return append([]string(nil), m.registry.paths...)
+}
+
+// Cleanup removes every temporary path created by the manager
+func (m *TempManager) Cleanup() error {
+	if err := m.registry.removeAll(); err != nil {
+		return ErrTempFile.SetError(err)
+	}
+
+	return nil
+}
+
+// Sweep removes paths matching the manager pattern that were created by processes
+// of the current user that no longer run, and returns them
+func (m *TempManager) Sweep() ([]string, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return nil, ErrReadDirectory.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  m.dir,
+				Error: err,
+			})
+	}
+
+	var (
+		swept []string
+		errs  []error
+	)
+	for _, entry := range entries {
+		pid, ok := m.ownerPID(entry.Name())
+		if !ok || pid == os.Getpid() || processAlive(pid) {
+			continue
+		}
+
+		// Process IDs are only meaningful for our own files
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if uid, _, ok := fileOwner(info); ok && uid != os.Getuid() {
+			continue
+		}
+
+		path := filepath.Join(m.dir, entry.Name())
+		if err := os.RemoveAll(path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		swept = append(swept, path)
+	}
+
+	if len(errs) > 0 {
+		return swept, ErrTempFile.SetError(errors.Join(errs...))
+	}
+
+	return swept, nil
+}
+
+// ownerPID returns the process ID in a name created by a manager with the same pattern
+func (m *TempManager) ownerPID(name string) (int, bool) {
+	if len(name) < len(m.prefix)+len(m.suffix) ||
+		!strings.HasPrefix(name, m.prefix) || !strings.HasSuffix(name, m.suffix) {
+		return 0, false
+	}
+
+	rest, ok := strings.CutPrefix(name[len(m.prefix):len(name)-len(m.suffix)], tempManagerMarker)
+	if !ok {
+		return 0, false
+	}
+	digits, random, found := strings.Cut(rest, "-")
+	if !found || !isDigits(random) {
+		return 0, false
+	}
+
+	pid, err := strconv.Atoi(digits)
+	if err != nil || pid <= 0 || !isDigits(digits) {
+		return 0, false
+	}
+
+	return pid, true
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// removeTempFunc returns a function removing path and forgetting it in registry
+func removeTempFunc(path string, registry *tempRegistry) func() error {
+	return func() error {
+		if registry != nil {
+			registry.remove(path)
+		}
+
+		if err := os.RemoveAll(path); err != nil {
+			return ErrTempFile.
+				SetError(err).
+				SetData(pathErrorContext{
+					Path:  path,
+					Error: err,
+				})
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,64 @@
+package fsx
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// RenderTemplateFile executes the text/template at tmplPath with data and writes the
+// result to outPath atomically, so readers never see a partial file and a failed render
+// leaves the previous output in place. A string data is the path of a JSON or YAML data
+// file, loaded with LoadConfig (including ${VAR} expansion); any other value is used as is
+func RenderTemplateFile(tmplPath string, data any, outPath string, options ...TemplateOption) error {
+	opts := defaultTemplateOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if dataPath, ok := data.(string); ok {
+		var values map[string]any
+		if err := LoadConfig(dataPath, &values); err != nil {
+			return err
+		}
+		data = values
+	}
+
+	tmpl := template.New(filepath.Base(tmplPath)).
+		Funcs(opts.funcs).
+		Delims(opts.leftDelim, opts.rightDelim)
+	if opts.strict {
+		tmpl = tmpl.Option("missingkey=error")
+	}
+
+	tmpl, err := tmpl.ParseFiles(tmplPath)
+	if err != nil {
+		return newRenderTemplateError(tmplPath, outPath, err)
+	}
+
+	var output bytes.Buffer
+	if err := tmpl.Execute(&output, data); err != nil {
+		return newRenderTemplateError(tmplPath, outPath, err)
+	}
+
+	perm := opts.perm
+	if perm == 0 {
+		perm = 0644
+		if info, err := os.Stat(outPath); err == nil {
+			perm = info.Mode().Perm()
+		}
+	}
+
+	return AtomicWriteFile(outPath, output.Bytes(), perm, opts.fileOptions...)
+}
+
+func newRenderTemplateError(tmplPath, outPath string, err error) error {
+	return ErrRenderTemplate.
+		SetError(err).
+		SetData(moveErrorContext{
+			Source:      tmplPath,
+			Destination: outPath,
+			Error:       err,
+		})
+}
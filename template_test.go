@@ -0,0 +1,97 @@
+package fsx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestRenderTemplateFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "fsx_template_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tmplPath := filepath.Join(tmpDir, "nginx.conf.tmpl")
+	if err := WriteFileString(tmplPath, "server {{ .Host }}:{{ .Port }};{{ range .Upstreams }} {{ upper . }}{{ end }}\n"); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	funcs := WithTemplateFuncs(template.FuncMap{"upper": strings.ToUpper})
+	outPath := filepath.Join(tmpDir, "nginx.conf")
+
+	t.Run("StructData", func(t *testing.T) {
+		data := struct {
+			Host      string
+			Port      int
+			Upstreams []string
+		}{"localhost", 8080, []string{"a", "b"}}
+
+		if err := RenderTemplateFile(tmplPath, data, outPath, funcs, WithTemplatePermissions(0600)); err != nil {
+			t.Fatalf("Failed to render: %v", err)
+		}
+		if got, _ := ReadFileString(outPath); got != "server localhost:8080; A B\n" {
+			t.Errorf("Unexpected output: %q", got)
+		}
+		if info, _ := os.Stat(outPath); runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+			t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+		}
+	})
+
+	t.Run("DataFileWithBackup", func(t *testing.T) {
+		t.Setenv("FSX_TEST_PORT", "9090")
+		dataPath := filepath.Join(tmpDir, "values.yaml")
+		if err := WriteFileString(dataPath, "Host: example.com\nPort: ${FSX_TEST_PORT}\nUpstreams: [x]\n"); err != nil {
+			t.Fatalf("Failed to write data: %v", err)
+		}
+
+		if err := RenderTemplateFile(tmplPath, dataPath, outPath, funcs, WithTemplateFileOptions(WithBackup())); err != nil {
+			t.Fatalf("Failed to render: %v", err)
+		}
+		if got, _ := ReadFileString(outPath); got != "server example.com:9090; X\n" {
+			t.Errorf("Unexpected output: %q", got)
+		}
+		if got, _ := ReadFileString(outPath + ".backup"); got != "server localhost:8080; A B\n" {
+			t.Errorf("Unexpected backup: %q", got)
+		}
+		if info, _ := os.Stat(outPath); runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+			t.Errorf("Existing mode should be kept, got %v", info.Mode().Perm())
+		}
+	})
+
+	t.Run("FailedRenderKeepsOutput", func(t *testing.T) {
+		before, _ := ReadFileString(outPath)
+
+		err := RenderTemplateFile(tmplPath, map[string]any{"Host": "h"}, outPath, funcs, WithTemplateStrict())
+		if !errors.Is(err, ErrRenderTemplate) {
+			t.Errorf("Expected ErrRenderTemplate for missing key, got %v", err)
+		}
+
+		err = RenderTemplateFile(tmplPath, map[string]any{}, outPath)
+		if !errors.Is(err, ErrRenderTemplate) {
+			t.Errorf("Expected ErrRenderTemplate for unknown function, got %v", err)
+		}
+
+		if after, _ := ReadFileString(outPath); after != before {
+			t.Errorf("Output changed after failed render: %q", after)
+		}
+	})
+
+	t.Run("Delims", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "delims.tmpl")
+		if err := WriteFileString(path, "{{ keep }} <% .Name %>"); err != nil {
+			t.Fatalf("Failed to write template: %v", err)
+		}
+		out := filepath.Join(tmpDir, "delims.txt")
+		if err := RenderTemplateFile(path, map[string]string{"Name": "fsx"}, out, WithTemplateDelims("<%", "%>")); err != nil {
+			t.Fatalf("Failed to render: %v", err)
+		}
+		if got, _ := ReadFileString(out); got != "{{ keep }} fsx" {
+			t.Errorf("Unexpected output: %q", got)
+		}
+	})
+}
@@ -0,0 +1,324 @@
+package fsx
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// trashCan keeps deleted entries together with their original path
+type trashCan interface {
+	put(path string, info os.FileInfo) error
+	list() ([]TrashItem, error)
+	forget(item TrashItem) error
+	empty() error
+}
+
+// openTrash returns the trash selected by opts
+func openTrash(opts *trashOptions) (trashCan, error) {
+	if opts.dir != "" {
+		return newXDGTrash(opts.dir), nil
+	}
+
+	return defaultTrash()
+}
+
+// SafeDelete moves a file or directory into the trash instead of deleting it: the
+// freedesktop.org trash on Linux, ~/.Trash on macOS and the Recycle Bin on Windows,
+// or the directory of WithTrashDir. Entries on other filesystems are copied to the trash
+func SafeDelete(path string, options ...TrashOption) error {
+	opts := defaultTrashOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	info, err := os.Lstat(path)
+	if err == nil {
+		var trash trashCan
+		if trash, err = openTrash(opts); err == nil {
+			err = trash.put(path, info)
+		}
+	}
+
+	if err != nil {
+		return ErrMoveToTrash.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  path,
+				Error: err,
+			})
+	}
+
+	return nil
+}
+
+// ListTrash lists the entries in the trash that can be restored. On macOS these
+// are the entries moved there by SafeDelete
+func ListTrash(options ...TrashOption) ([]TrashItem, error) {
+	opts := defaultTrashOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	trash, err := openTrash(opts)
+	if err != nil {
+		return nil, ErrListTrash.SetError(err)
+	}
+
+	items, err := trash.list()
+	if err != nil {
+		return nil, ErrListTrash.SetError(err)
+	}
+
+	return items, nil
+}
+
+// RestoreFromTrash moves the trashed entry name (see TrashItem.Name) back to its original
+// path, recreating missing parent directories. It fails with ErrDestinationExists when
+// something else took that path in the meantime
+func RestoreFromTrash(name string, options ...TrashOption) error {
+	opts := defaultTrashOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	trash, err := openTrash(opts)
+	if err != nil {
+		return ErrRestoreFromTrash.SetError(err)
+	}
+
+	items, err := trash.list()
+	if err != nil {
+		return ErrRestoreFromTrash.SetError(err)
+	}
+
+	for _, item := range items {
+		if item.Name != name {
+			continue
+		}
+
+		if _, err := os.Lstat(item.OriginalPath); err == nil {
+			return ErrDestinationExists.
+				SetData(moveErrorContext{
+					Source:      item.Path,
+					Destination: item.OriginalPath,
+					Error:       nil,
+				})
+		}
+
+		info, err := os.Lstat(item.Path)
+		if err == nil {
+			err = moveEntry(item.Path, item.OriginalPath, info)
+		}
+		if err == nil {
+			err = trash.forget(item)
+		}
+		if err != nil {
+			return ErrRestoreFromTrash.
+				SetError(err).
+				SetData(moveErrorContext{
+					Source:      item.Path,
+					Destination: item.OriginalPath,
+					Error:       err,
+				})
+		}
+
+		return nil
+	}
+
+	return ErrTrashItemNotFound.
+		SetData(struct {
+			Name string `json:"name"`
+		}{
+			Name: name,
+		})
+}
+
+// EmptyTrash permanently deletes everything in the trash
+func EmptyTrash(options ...TrashOption) error {
+	opts := defaultTrashOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	trash, err := openTrash(opts)
+	if err == nil {
+		err = trash.empty()
+	}
+	if err != nil {
+		return ErrEmptyTrash.SetError(err)
+	}
+
+	return nil
+}
+
+const (
+	trashInfoExt    = ".trashinfo"
+	trashDateLayout = "2006-01-02T15:04:05"
+)
+
+// xdgTrash is a trash laid out as in the freedesktop.org specification:
+// entries in files and a .trashinfo file per entry with its origin in info
+type xdgTrash struct {
+	files string
+	info  string
+}
+
+func newXDGTrash(root string) *xdgTrash {
+	return &xdgTrash{
+		files: filepath.Join(root, "files"),
+		info:  filepath.Join(root, "info"),
+	}
+}
+
+func (t *xdgTrash) put(path string, info os.FileInfo) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range []string{t.files, t.info} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+
+	base := filepath.Base(absPath)
+	ext := filepath.Ext(base)
+	for i := 1; ; i++ {
+		name := base
+		if i > 1 {
+			name = fmt.Sprintf("%s.%d%s", strings.TrimSuffix(base, ext), i, ext)
+		}
+
+		// The info file is created first and exclusively to reserve the name
+		infoPath := filepath.Join(t.info, name+trashInfoExt)
+		file, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := os.Lstat(filepath.Join(t.files, name)); err == nil {
+			file.Close()
+			os.Remove(infoPath)
+			continue
+		}
+
+		_, err = fmt.Fprintf(file, "[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+			(&url.URL{Path: absPath}).EscapedPath(), time.Now().Format(trashDateLayout))
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = moveEntry(absPath, filepath.Join(t.files, name), info)
+		}
+		if err != nil {
+			os.Remove(infoPath)
+			return err
+		}
+
+		return nil
+	}
+}
+
+func (t *xdgTrash) list() ([]TrashItem, error) {
+	entries, err := os.ReadDir(t.info)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var items []TrashItem
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), trashInfoExt)
+		if !ok || entry.IsDir() {
+			continue
+		}
+
+		item, err := readTrashInfo(filepath.Join(t.info, entry.Name()))
+		if err != nil {
+			continue // not written by a spec compliant trash
+		}
+
+		item.Name = name
+		item.Path = filepath.Join(t.files, name)
+		info, err := os.Lstat(item.Path)
+		if err != nil {
+			continue
+		}
+		item.IsDir = info.IsDir()
+		item.Size = info.Size()
+		if item.IsDir {
+			item.Size, _ = CalculateDirectorySize(item.Path)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (t *xdgTrash) forget(item TrashItem) error {
+	return os.Remove(filepath.Join(t.info, item.Name+trashInfoExt))
+}
+
+func (t *xdgTrash) empty() error {
+	for _, dir := range []string{t.files, t.info} {
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// readTrashInfo parses the original path and deletion date of a .trashinfo file
+func readTrashInfo(path string) (TrashItem, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return TrashItem{}, err
+	}
+	defer file.Close()
+
+	var item TrashItem
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), "=")
+		switch key {
+		case "Path":
+			if item.OriginalPath, err = url.PathUnescape(value); err != nil {
+				return TrashItem{}, err
+			}
+		case "DeletionDate":
+			item.DeletedAt, _ = time.ParseInLocation(trashDateLayout, value, time.Local)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return TrashItem{}, err
+	}
+
+	if item.OriginalPath == "" {
+		return TrashItem{}, os.ErrInvalid
+	}
+
+	return item, nil
+}
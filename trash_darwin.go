@@ -0,0 +1,23 @@
+//go:build darwin
+
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// defaultTrash returns ~/.Trash. Finder keeps no origin of trashed entries that fsx
+// could read, so SafeDelete records it in .fsx-info inside the trash
+func defaultTrash() (trashCan, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	trash := filepath.Join(home, ".Trash")
+	return &xdgTrash{
+		files: trash,
+		info:  filepath.Join(trash, ".fsx-info"),
+	}, nil
+}
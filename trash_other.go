@@ -0,0 +1,22 @@
+//go:build !darwin && !windows
+
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// defaultTrash returns the freedesktop.org home trash in $XDG_DATA_HOME/Trash
+func defaultTrash() (trashCan, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+
+	return newXDGTrash(filepath.Join(dataHome, "Trash")), nil
+}
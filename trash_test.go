@@ -0,0 +1,102 @@
+package fsx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTrash(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "fsx_trash_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	trashDir := filepath.Join(tmpDir, "trash")
+	workDir := filepath.Join(tmpDir, "work")
+
+	t.Run("SafeDeleteAndRestore", func(t *testing.T) {
+		file := filepath.Join(workDir, "notes.txt")
+		CreateFile(file, []byte("first"), WithCreateDirs())
+		if err := SafeDelete(file, WithTrashDir(trashDir)); err != nil {
+			t.Fatalf("Failed to move to trash: %v", err)
+		}
+
+		// A second entry with the same name gets a unique name in the trash
+		CreateFile(file, []byte("second"))
+		if err := SafeDelete(file, WithTrashDir(trashDir)); err != nil {
+			t.Fatalf("Failed to move to trash: %v", err)
+		}
+		dir := filepath.Join(workDir, "reports")
+		CreateFile(filepath.Join(dir, "q1.txt"), []byte("q1"), WithCreateDirs())
+		if err := SafeDelete(dir, WithTrashDir(trashDir)); err != nil {
+			t.Fatalf("Failed to move directory to trash: %v", err)
+		}
+
+		if FileExist(file) || DirectoryExist(dir) {
+			t.Fatal("Expected trashed entries to be gone")
+		}
+
+		items, err := ListTrash(WithTrashDir(trashDir))
+		if err != nil {
+			t.Fatalf("Failed to list trash: %v", err)
+		}
+		if len(items) != 3 {
+			t.Fatalf("Expected 3 trashed entries, got %+v", items)
+		}
+
+		byName := make(map[string]TrashItem)
+		for _, item := range items {
+			byName[item.Name] = item
+			if time.Since(item.DeletedAt) > time.Minute {
+				t.Errorf("Unexpected deletion time %v", item.DeletedAt)
+			}
+		}
+		if byName["notes.txt"].OriginalPath != file || byName["notes.2.txt"].OriginalPath != file {
+			t.Errorf("Expected both notes.txt entries from %s, got %+v", file, items)
+		}
+		if !byName["reports"].IsDir || byName["reports"].Size != 2 {
+			t.Errorf("Expected reports directory of 2 bytes, got %+v", byName["reports"])
+		}
+
+		if err := RestoreFromTrash("notes.2.txt", WithTrashDir(trashDir)); err != nil {
+			t.Fatalf("Failed to restore: %v", err)
+		}
+		if content, _ := ReadFile(file); string(content) != "second" {
+			t.Errorf("Expected restored content second, got %q", content)
+		}
+		if err := RestoreFromTrash("notes.txt", WithTrashDir(trashDir)); !errors.Is(err, ErrDestinationExists) {
+			t.Errorf("Expected ErrDestinationExists, got %v", err)
+		}
+		if err := RestoreFromTrash("reports", WithTrashDir(trashDir)); err != nil {
+			t.Fatalf("Failed to restore directory: %v", err)
+		}
+		if !FileExist(filepath.Join(dir, "q1.txt")) {
+			t.Error("Expected restored directory contents")
+		}
+		if err := RestoreFromTrash("missing", WithTrashDir(trashDir)); !errors.Is(err, ErrTrashItemNotFound) {
+			t.Errorf("Expected ErrTrashItemNotFound, got %v", err)
+		}
+	})
+
+	t.Run("EmptyTrash", func(t *testing.T) {
+		if err := EmptyTrash(WithTrashDir(trashDir)); err != nil {
+			t.Fatalf("Failed to empty trash: %v", err)
+		}
+
+		items, err := ListTrash(WithTrashDir(trashDir))
+		if err != nil {
+			t.Fatalf("Failed to list trash: %v", err)
+		}
+		if len(items) != 0 {
+			t.Errorf("Expected an empty trash, got %+v", items)
+		}
+
+		if err := SafeDelete(filepath.Join(workDir, "missing.txt"), WithTrashDir(trashDir)); !errors.Is(err, ErrMoveToTrash) {
+			t.Errorf("Expected ErrMoveToTrash, got %v", err)
+		}
+	})
+}
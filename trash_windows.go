@@ -0,0 +1,242 @@
+//go:build windows
+
+package fsx
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"golang.org/x/sys/windows"
+)
+
+// recycleBin is the Recycle Bin of the current user, a $Recycle.Bin\<SID> directory
+// per drive with the entry renamed to $R<id> and its origin in a $I<id> file
+type recycleBin struct{}
+
+// defaultTrash returns the Recycle Bin
+func defaultTrash() (trashCan, error) {
+	return recycleBin{}, nil
+}
+
+// recycleBinDir returns the Recycle Bin directory of the current user on volume
+func recycleBinDir(volume string) (string, error) {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return "", err
+	}
+
+	return volume + `\$Recycle.Bin\` + user.User.Sid.String(), nil
+}
+
+func (recycleBin) put(path string, info os.FileInfo) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	volume := filepath.VolumeName(absPath)
+	if len(volume) != 2 {
+		return errors.ErrUnsupported // network shares have no Recycle Bin
+	}
+
+	dir, err := recycleBinDir(volume)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	size := info.Size()
+	if info.IsDir() {
+		size, _ = CalculateDirectorySize(absPath)
+	}
+
+	ext := filepath.Ext(absPath)
+	for {
+		id, err := recycleID()
+		if err != nil {
+			return err
+		}
+
+		infoPath := filepath.Join(dir, "$I"+id+ext)
+		file, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = file.Write(encodeRecycleInfo(absPath, size, time.Now()))
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(absPath, filepath.Join(dir, "$R"+id+ext))
+		}
+		if err != nil {
+			os.Remove(infoPath)
+			return err
+		}
+
+		return nil
+	}
+}
+
+func (recycleBin) list() ([]TrashItem, error) {
+	drives, err := windows.GetLogicalDrives()
+	if err != nil {
+		return nil, err
+	}
+
+	var items []TrashItem
+	for i := 0; i < 26; i++ {
+		if drives&(1<<i) == 0 {
+			continue
+		}
+
+		volume := string(rune('A'+i)) + ":"
+		dir, err := recycleBinDir(volume)
+		if err != nil {
+			return nil, err
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue // no Recycle Bin on this drive
+		}
+
+		for _, entry := range entries {
+			id, ok := strings.CutPrefix(entry.Name(), "$I")
+			if !ok {
+				continue
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				continue
+			}
+			item, ok := decodeRecycleInfo(data)
+			if !ok {
+				continue
+			}
+
+			item.Name = volume + "$R" + id
+			item.Path = filepath.Join(dir, "$R"+id)
+			info, err := os.Lstat(item.Path)
+			if err != nil {
+				continue
+			}
+			item.IsDir = info.IsDir()
+
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+func (recycleBin) forget(item TrashItem) error {
+	dir, name := filepath.Split(item.Path)
+	return os.Remove(filepath.Join(dir, "$I"+strings.TrimPrefix(name, "$R")))
+}
+
+func (bin recycleBin) empty() error {
+	items, err := bin.list()
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err := os.RemoveAll(item.Path); err != nil {
+			return err
+		}
+		if err := bin.forget(item); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recycleID returns a random id like those Explorer gives recycled entries
+func recycleID() (string, error) {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = alphabet[int(b)%len(alphabet)]
+	}
+
+	return string(buf), nil
+}
+
+// encodeRecycleInfo builds a version 2 $I file: version, size, deletion time
+// as FILETIME, path length in UTF-16 units and the NUL terminated path
+func encodeRecycleInfo(path string, size int64, deletedAt time.Time) []byte {
+	name := utf16.Encode([]rune(path + "\x00"))
+	filetime := windows.NsecToFiletime(deletedAt.UnixNano())
+
+	data := make([]byte, 28, 28+2*len(name))
+	binary.LittleEndian.PutUint64(data[0:], 2)
+	binary.LittleEndian.PutUint64(data[8:], uint64(size))
+	binary.LittleEndian.PutUint32(data[16:], filetime.LowDateTime)
+	binary.LittleEndian.PutUint32(data[20:], filetime.HighDateTime)
+	binary.LittleEndian.PutUint32(data[24:], uint32(len(name)))
+	for _, unit := range name {
+		data = binary.LittleEndian.AppendUint16(data, unit)
+	}
+
+	return data
+}
+
+// decodeRecycleInfo parses a version 1 (fixed 260 unit path) or version 2 $I file
+func decodeRecycleInfo(data []byte) (TrashItem, bool) {
+	if len(data) < 24 {
+		return TrashItem{}, false
+	}
+
+	var raw []byte
+	switch binary.LittleEndian.Uint64(data[0:]) {
+	case 1:
+		raw = data[24:]
+	case 2:
+		if len(data) < 28 {
+			return TrashItem{}, false
+		}
+		length := int(binary.LittleEndian.Uint32(data[24:]))
+		raw = data[28:min(len(data), 28+2*length)]
+	default:
+		return TrashItem{}, false
+	}
+
+	units := make([]uint16, 0, len(raw)/2)
+	for i := 0; i+1 < len(raw); i += 2 {
+		unit := binary.LittleEndian.Uint16(raw[i:])
+		if unit == 0 {
+			break
+		}
+		units = append(units, unit)
+	}
+
+	filetime := windows.Filetime{
+		LowDateTime:  binary.LittleEndian.Uint32(data[16:]),
+		HighDateTime: binary.LittleEndian.Uint32(data[20:]),
+	}
+
+	return TrashItem{
+		OriginalPath: string(utf16.Decode(units)),
+		DeletedAt:    time.Unix(0, filetime.Nanoseconds()),
+		Size:         int64(binary.LittleEndian.Uint64(data[8:])),
+	}, true
+}
@@ -0,0 +1,202 @@
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// versionTimeLayout names timestamped versions kept by WithTimestampedVersions
+const versionTimeLayout = "20060102T150405.000000000"
+
+// fileVersion is a previous version of a file found next to it
+type fileVersion struct {
+	path   string
+	number int
+	time   time.Time
+}
+
+// backupBeforeOverwrite keeps a backup and/or rotated versions of path before it is replaced
+func backupBeforeOverwrite(path string, opts *fileOptions) error {
+	if !FileExist(path) {
+		return nil
+	}
+
+	if opts.backup {
+		if err := CopyFile(path, path+".backup"); err != nil {
+			return newCreateBackupFileError(path, err)
+		}
+	}
+
+	if opts.versions > 0 {
+		if err := rotateVersions(path, opts.versions, opts.timestampVersions); err != nil {
+			return newCreateBackupFileError(path, err)
+		}
+	}
+
+	return nil
+}
+
+// rotateVersions copies the current content of path into a new version and
+// drops versions beyond keep
+func rotateVersions(path string, keep int, timestamped bool) error {
+	versions, err := listFileVersions(path)
+	if err != nil {
+		return err
+	}
+
+	if timestamped {
+		versionPath := path + "." + time.Now().Format(versionTimeLayout)
+		if err := CopyFile(path, versionPath); err != nil {
+			return err
+		}
+
+		var stamped []fileVersion
+		for _, version := range versions {
+			if version.number == 0 {
+				stamped = append(stamped, version)
+			}
+		}
+		// The new version is the newest, keep-1 older ones stay
+		for i := keep - 1; i < len(stamped); i++ {
+			os.Remove(stamped[i].path)
+		}
+
+		return nil
+	}
+
+	// Shift name.1 -> name.2 ... starting from the oldest
+	for i := len(versions) - 1; i >= 0; i-- {
+		version := versions[i]
+		if version.number == 0 {
+			continue
+		}
+
+		if version.number >= keep {
+			if err := os.Remove(version.path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := os.Rename(version.path, path+"."+strconv.Itoa(version.number+1)); err != nil {
+			return err
+		}
+	}
+
+	return CopyFile(path, path+".1")
+}
+
+// listFileVersions returns versions of path: numbered ones in ascending order,
+// then timestamped ones from newest to oldest. Numbered versions are only those
+// numbered 1, 2, 3... without gaps, as rotation creates them, so that unrelated
+// files like report.2024 next to report are never rotated or removed
+func listFileVersions(path string) ([]fileVersion, error) {
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := filepath.Base(path) + "."
+	var versions []fileVersion
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		suffix := strings.TrimPrefix(name, prefix)
+		version := fileVersion{
+			path: filepath.Join(filepath.Dir(path), name),
+		}
+
+		if number, err := strconv.Atoi(suffix); err == nil && number > 0 && suffix[0] != '0' {
+			version.number = number
+		} else if stamp, err := time.ParseInLocation(versionTimeLayout, suffix, time.Local); err == nil {
+			version.time = stamp
+		} else {
+			continue
+		}
+
+		versions = append(versions, version)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		a, b := versions[i], versions[j]
+		if (a.number == 0) != (b.number == 0) {
+			return a.number != 0
+		}
+		if a.number != b.number {
+			return a.number < b.number
+		}
+		return a.time.After(b.time)
+	})
+
+	kept := versions[:0]
+	for _, version := range versions {
+		if version.number == 0 || version.number == len(kept)+1 {
+			kept = append(kept, version)
+		}
+	}
+
+	return kept, nil
+}
+
+// FileVersions returns paths of versions kept by WithVersioning, most recent first
+func FileVersions(path string) ([]string, error) {
+	versions, err := listFileVersions(path)
+	if err != nil {
+		return nil, ErrFileVersion.
+			SetError(err).
+			SetData(pathErrorContext{
+				Path:  path,
+				Error: err,
+			})
+	}
+
+	paths := make([]string, 0, len(versions))
+	for _, version := range versions {
+		paths = append(paths, version.path)
+	}
+
+	return paths, nil
+}
+
+// RestoreVersion atomically replaces path with one of its versions.
+// Version 1 is the most recent one, as returned first by FileVersions
+func RestoreVersion(path string, version int) error {
+	versions, err := FileVersions(path)
+	if err != nil {
+		return err
+	}
+
+	if version < 1 || version > len(versions) {
+		return ErrFileVersion.
+			SetData(struct {
+				Path     string `json:"path"`
+				Version  int    `json:"version"`
+				Versions int    `json:"versions"`
+			}{
+				Path:     path,
+				Version:  version,
+				Versions: len(versions),
+			})
+	}
+
+	versionPath := versions[version-1]
+	info, err := os.Stat(versionPath)
+	if err != nil {
+		return newStatFile(versionPath, err)
+	}
+
+	data, err := ReadFile(versionPath)
+	if err != nil {
+		return err
+	}
+
+	return AtomicWriteFile(path, data, info.Mode().Perm())
+}
@@ -0,0 +1,114 @@
+package fsx
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// WatchSearch keeps the set of files under root accepted by match up to date. It emits
+// SearchEventAdded for every current match, then repeats the search every watch interval
+// (see WithWatchInterval) and emits added, removed and updated (size or modification time)
+// events. Failed rounds are retried on the next tick. The channel is closed when ctx is done.
+// An interval that isn't positive fails with ErrSearchFiles
+func WatchSearch(ctx context.Context, root string, match FilterFunc, options ...SearchOption) (<-chan SearchEvent, error) {
+	opts := defaultSearchOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if opts.watchInterval <= 0 {
+		return nil, ErrSearchFiles.
+			SetData(struct {
+				Root     string        `json:"root"`
+				Interval time.Duration `json:"interval"`
+			}{
+				Root:     root,
+				Interval: opts.watchInterval,
+			})
+	}
+
+	search := func() ([]SearchResult, error) {
+		return FindFilesBy(root, match, append(options, WithSearchContext(ctx))...)
+	}
+
+	initial, err := search()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan SearchEvent)
+	go func() {
+		defer close(events)
+
+		known := make(map[string]SearchResult)
+		if !emitSearchChanges(ctx, events, known, initial) {
+			return
+		}
+
+		ticker := time.NewTicker(opts.watchInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			results, err := search()
+			if err != nil {
+				continue
+			}
+
+			if !emitSearchChanges(ctx, events, known, results) {
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// emitSearchChanges sends the differences between known and results and updates known.
+// It reports false when ctx is done before all events are delivered
+func emitSearchChanges(ctx context.Context, events chan<- SearchEvent, known map[string]SearchResult, results []SearchResult) bool {
+	var changes []SearchEvent
+	current := make(map[string]bool, len(results))
+
+	for _, result := range results {
+		current[result.Path] = true
+
+		previous, ok := known[result.Path]
+		switch {
+		case !ok:
+			changes = append(changes, SearchEvent{Type: SearchEventAdded, Result: result})
+		case previous.Info.Size() != result.Info.Size() || !previous.Info.ModTime().Equal(result.Info.ModTime()):
+			changes = append(changes, SearchEvent{Type: SearchEventUpdated, Result: result})
+		}
+		known[result.Path] = result
+	}
+
+	var removed []string
+	for path := range known {
+		if !current[path] {
+			removed = append(removed, path)
+		}
+	}
+	sort.Strings(removed)
+
+	for _, path := range removed {
+		changes = append(changes, SearchEvent{Type: SearchEventRemoved, Result: known[path]})
+		delete(known, path)
+	}
+
+	for _, event := range changes {
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,82 @@
+package fsx
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchSearch(t *testing.T) {
+	// Create a temporary directory for tests
+	tmpDir, err := os.MkdirTemp("", "fsx_watch_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	t.Run("Events", func(t *testing.T) {
+		existing := filepath.Join(tmpDir, "existing.log")
+		if err := CreateFile(existing, []byte("start")); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := CreateFile(filepath.Join(tmpDir, "notes.txt"), []byte("notes")); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		events, err := WatchSearch(ctx, tmpDir, func(path string, info os.FileInfo) bool {
+			return strings.HasSuffix(path, ".log")
+		}, WithWatchInterval(10*time.Millisecond))
+		if err != nil {
+			t.Fatalf("Failed to watch search: %v", err)
+		}
+
+		next := func(expected SearchEventType, path string) {
+			t.Helper()
+			select {
+			case event := <-events:
+				if event.Type != expected || event.Result.Path != path {
+					t.Fatalf("Expected %s %s, got %s %s", expected, path, event.Type, event.Result.Path)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("Timed out waiting for %s %s", expected, path)
+			}
+		}
+
+		next(SearchEventAdded, existing)
+
+		created := filepath.Join(tmpDir, "created.log")
+		if err := CreateFile(created, []byte("new")); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		next(SearchEventAdded, created)
+
+		if err := os.WriteFile(existing, []byte("start and more"), 0644); err != nil {
+			t.Fatalf("Failed to update file: %v", err)
+		}
+		next(SearchEventUpdated, existing)
+
+		if err := os.Remove(created); err != nil {
+			t.Fatalf("Failed to remove file: %v", err)
+		}
+		next(SearchEventRemoved, created)
+
+		cancel()
+		for range events {
+		}
+	})
+
+	t.Run("InvalidInterval", func(t *testing.T) {
+		_, err := WatchSearch(context.Background(), tmpDir, func(string, os.FileInfo) bool { return true },
+			WithWatchInterval(0))
+		if !errors.Is(err, ErrSearchFiles) {
+			t.Errorf("Expected ErrSearchFiles for a zero interval, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,267 @@
+package fsx
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Workspace is a scratch directory whose content is removed on Close. Nothing removes it
+// on exit by itself: the root is registered with CleanupTempFiles, so callers that may
+// skip Close must defer CleanupTempFiles in main, which still doesn't run after os.Exit
+// or a panic in another goroutine. Roots left behind that way are removed by
+// StartTempJanitor. The root is not removed when the Workspace is garbage collected,
+// as its path may still be in use
+type Workspace struct {
+	root  string
+	quota int64
+
+	mu     sync.Mutex
+	used   int64
+	paths  []string
+	closed bool
+}
+
+// NewWorkspace creates a workspace with a new temp root
+func NewWorkspace(options ...WorkspaceOption) (*Workspace, error) {
+	opts := defaultWorkspaceOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	root, err := CreateTempDirectory(opts.dir, opts.pattern, WithTempCleanup())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Workspace{
+		root:  root,
+		quota: opts.quota,
+	}, nil
+}
+
+// Root returns the root directory of the workspace
+func (w *Workspace) Root() string {
+	return w.root
+}
+
+// Path returns name joined to the root, rejecting names escaping the workspace
+func (w *Workspace) Path(name string) (string, error) {
+	return SafeJoin(w.root, name)
+}
+
+// Paths returns the files and directories created through the workspace, in creation order
+func (w *Workspace) Paths() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]string(nil), w.paths...)
+}
+
+// Usage returns the bytes currently stored under the root, including files
+// written without the workspace
+func (w *Workspace) Usage() (int64, error) {
+	var size int64
+	err := filepath.WalkDir(w.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type().IsRegular() {
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, newWorkspaceError(w.root, err)
+	}
+
+	return size, nil
+}
+
+// Mkdir creates the directory name and its parents inside the workspace
+func (w *Workspace) Mkdir(name string) (string, error) {
+	path, err := w.begin(name)
+	if err != nil {
+		return "", err
+	}
+	defer w.mu.Unlock()
+
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return "", newCreateDirectories(path, err)
+	}
+	w.paths = append(w.paths, path)
+
+	return path, nil
+}
+
+// WriteFile writes data to the file name inside the workspace, creating parent directories
+func (w *Workspace) WriteFile(name string, data []byte) (string, error) {
+	file, err := w.Create(name)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+// Create creates or truncates the file name inside the workspace, creating parent
+// directories. Writes to it count against the quota
+func (w *Workspace) Create(name string) (*WorkspaceFile, error) {
+	path, err := w.begin(name)
+	if err != nil {
+		return nil, err
+	}
+	defer w.mu.Unlock()
+
+	// Recount from disk, the file is replaced and the root may have changed behind us
+	if w.quota > 0 {
+		used, err := w.Usage()
+		if err != nil {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			used -= info.Size()
+		}
+		w.used = used
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, newCreateDirectories(path, err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, newOpenFileError(path, err)
+	}
+	w.paths = append(w.paths, path)
+
+	return &WorkspaceFile{File: file, workspace: w}, nil
+}
+
+// begin resolves name and locks the workspace, failing when it is closed
+func (w *Workspace) begin(name string) (string, error) {
+	path, err := w.Path(name)
+	if err != nil {
+		return "", err
+	}
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return "", ErrWorkspaceClosed.
+			SetData(pathErrorContext{
+				Path: w.root,
+			})
+	}
+
+	return path, nil
+}
+
+// reserve counts n more bytes against the quota
+func (w *Workspace) reserve(path string, n int64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.quota > 0 && w.used+n > w.quota {
+		return ErrWorkspaceQuota.
+			SetData(struct {
+				Path  string `json:"path"`
+				Used  int64  `json:"used"`
+				Quota int64  `json:"quota"`
+			}{
+				Path:  path,
+				Used:  w.used,
+				Quota: w.quota,
+			})
+	}
+	w.used += n
+
+	return nil
+}
+
+// Close removes the workspace root with everything in it. It is safe to call more than once
+func (w *Workspace) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	registeredTemps.remove(w.root)
+
+	if err := os.RemoveAll(w.root); err != nil {
+		return newWorkspaceError(w.root, err)
+	}
+
+	return nil
+}
+
+// WorkspaceFile is a file created in a Workspace. Writes fail with ErrWorkspaceQuota
+// instead of exceeding the workspace quota
+type WorkspaceFile struct {
+	*os.File
+	workspace *Workspace
+}
+
+// Write writes p when it fits in the workspace quota
+func (f *WorkspaceFile) Write(p []byte) (int, error) {
+	if err := f.workspace.reserve(f.Name(), int64(len(p))); err != nil {
+		return 0, err
+	}
+
+	n, err := f.File.Write(p)
+	if n < len(p) {
+		f.workspace.reserve(f.Name(), int64(n-len(p)))
+	}
+
+	return n, err
+}
+
+// WriteString writes s when it fits in the workspace quota
+func (f *WorkspaceFile) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+// WriteAt writes p at off when the growth of the file fits in the workspace quota
+func (f *WorkspaceFile) WriteAt(p []byte, off int64) (int, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	growth := max(0, off+int64(len(p))-info.Size())
+	if err := f.workspace.reserve(f.Name(), growth); err != nil {
+		return 0, err
+	}
+
+	return f.File.WriteAt(p, off)
+}
+
+// ReadFrom copies r into the file through Write, so the quota applies
+func (f *WorkspaceFile) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(struct{ io.Writer }{f}, r)
+}
+
+func newWorkspaceError(root string, err error) error {
+	return ErrWorkspace.
+		SetError(err).
+		SetData(pathErrorContext{
+			Path:  root,
+			Error: err,
+		})
+}
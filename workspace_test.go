@@ -0,0 +1,137 @@
+package fsx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestWorkspace(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "fsx_workspace_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	t.Run("CreateAndClose", func(t *testing.T) {
+		workspace, err := NewWorkspace(WithWorkspaceDir(tmpDir), WithWorkspacePattern("job-*"))
+		if err != nil {
+			t.Fatalf("Failed to create workspace: %v", err)
+		}
+		if !strings.HasPrefix(filepath.Base(workspace.Root()), "job-") {
+			t.Errorf("Unexpected root name: %s", workspace.Root())
+		}
+
+		dir, err := workspace.Mkdir("out/parts")
+		if err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		file, err := workspace.WriteFile("out/report.txt", []byte("report"))
+		if err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		if got, _ := ReadFileString(file); got != "report" {
+			t.Errorf("Unexpected content: %q", got)
+		}
+
+		paths := workspace.Paths()
+		if len(paths) != 2 || paths[0] != dir || paths[1] != file {
+			t.Errorf("Unexpected tracked paths: %v", paths)
+		}
+
+		if _, err := workspace.Path("../escape"); !errors.Is(err, ErrUnsafeArchivePath) {
+			t.Errorf("Expected ErrUnsafeArchivePath, got %v", err)
+		}
+
+		if err := workspace.Close(); err != nil {
+			t.Fatalf("Failed to close workspace: %v", err)
+		}
+		if DirectoryExist(workspace.Root()) {
+			t.Error("Workspace root should be removed on Close")
+		}
+		if err := workspace.Close(); err != nil {
+			t.Errorf("Second Close should be a no-op: %v", err)
+		}
+		if _, err := workspace.WriteFile("late.txt", nil); !errors.Is(err, ErrWorkspaceClosed) {
+			t.Errorf("Expected ErrWorkspaceClosed, got %v", err)
+		}
+	})
+
+	t.Run("Quota", func(t *testing.T) {
+		workspace, err := NewWorkspace(WithWorkspaceDir(tmpDir), WithWorkspaceQuota(100))
+		if err != nil {
+			t.Fatalf("Failed to create workspace: %v", err)
+		}
+		defer workspace.Close()
+
+		if _, err := workspace.WriteFile("a.bin", make([]byte, 60)); err != nil {
+			t.Fatalf("Failed to write within quota: %v", err)
+		}
+
+		file, err := workspace.Create("b.bin")
+		if err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if _, err := file.Write(make([]byte, 30)); err != nil {
+			t.Errorf("Failed to write within quota: %v", err)
+		}
+		if _, err := file.Write(make([]byte, 20)); !errors.Is(err, ErrWorkspaceQuota) {
+			t.Errorf("Expected ErrWorkspaceQuota, got %v", err)
+		}
+		file.Close()
+
+		// Replacing a file frees its previous size
+		if _, err := workspace.WriteFile("a.bin", make([]byte, 70)); err != nil {
+			t.Errorf("Failed to replace file within quota: %v", err)
+		}
+
+		// Files written directly under the root are counted when files are created
+		if err := os.WriteFile(filepath.Join(workspace.Root(), "direct.bin"), make([]byte, 10), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		if usage, _ := workspace.Usage(); usage != 110 {
+			t.Errorf("Expected usage 110, got %d", usage)
+		}
+		if _, err := workspace.WriteFile("c.bin", []byte("x")); !errors.Is(err, ErrWorkspaceQuota) {
+			t.Errorf("Expected ErrWorkspaceQuota, got %v", err)
+		}
+	})
+
+	t.Run("CleanupTempFiles", func(t *testing.T) {
+		workspace, err := NewWorkspace(WithWorkspaceDir(tmpDir))
+		if err != nil {
+			t.Fatalf("Failed to create workspace: %v", err)
+		}
+
+		if err := CleanupTempFiles(); err != nil {
+			t.Fatalf("Failed to clean up: %v", err)
+		}
+		if DirectoryExist(workspace.Root()) {
+			t.Error("Workspace root should be removed by CleanupTempFiles")
+		}
+	})
+
+	t.Run("SurvivesCollection", func(t *testing.T) {
+		scratch := func() string {
+			workspace, err := NewWorkspace(WithWorkspaceDir(tmpDir))
+			if err != nil {
+				t.Fatalf("Failed to create workspace: %v", err)
+			}
+			return workspace.Root()
+		}
+
+		root := scratch()
+		if err := WriteFileString(filepath.Join(root, "work.txt"), "in use"); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		runtime.GC()
+		runtime.GC()
+
+		if !FileExist(filepath.Join(root, "work.txt")) {
+			t.Error("The root should survive collection of its Workspace")
+		}
+	})
+}
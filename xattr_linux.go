@@ -0,0 +1,39 @@
+//go:build linux
+
+package fsx
+
+import (
+	"os"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// aclXattrPrefix marks extended attributes that store POSIX ACLs
+const aclXattrPrefix = "system.posix_acl_"
+
+// copyXattrs copies extended attributes and/or POSIX ACLs from src to dst
+func copyXattrs(src, dst string, xattrs, acls bool) error {
+	names, err := listXattrs(src)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		isACL := strings.HasPrefix(name, aclXattrPrefix)
+		if (isACL && !acls) || (!isACL && !xattrs) {
+			continue
+		}
+
+		value, err := getXattr(src, name)
+		if err != nil {
+			return err
+		}
+
+		if err := unix.Lsetxattr(dst, name, value, 0); err != nil {
+			return &os.PathError{Op: "setxattr " + name, Path: dst, Err: err}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+//go:build linux
+
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestCopyXattrs(t *testing.T) {
+	// Create a temporary directory for tests
+	tmpDir, err := os.MkdirTemp("", "fsx_xattr_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	srcDir := filepath.Join(tmpDir, "src")
+	srcFile := filepath.Join(srcDir, "file.txt")
+	if err := CreateFile(srcFile, []byte("attributes"), WithCreateDirs()); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := unix.Lsetxattr(srcFile, "user.fsx.label", []byte("blue"), 0); err != nil {
+		t.Skipf("Extended attributes not supported: %v", err)
+	}
+	if err := unix.Lsetxattr(srcDir, "user.fsx.dir", []byte("root"), 0); err != nil {
+		t.Fatalf("Failed to set directory xattr: %v", err)
+	}
+
+	t.Run("DroppedByDefault", func(t *testing.T) {
+		dstDir := filepath.Join(tmpDir, "plain")
+		if err := CopyDirectory(srcDir, dstDir); err != nil {
+			t.Fatalf("Failed to copy directory: %v", err)
+		}
+
+		names, err := listXattrs(filepath.Join(dstDir, "file.txt"))
+		if err != nil {
+			t.Fatalf("Failed to list xattrs: %v", err)
+		}
+		for _, name := range names {
+			if name == "user.fsx.label" {
+				t.Error("Xattrs should only be copied on request")
+			}
+		}
+	})
+
+	t.Run("WithPreserveXattrs", func(t *testing.T) {
+		dstDir := filepath.Join(tmpDir, "xattrs")
+		if err := CopyDirectory(srcDir, dstDir, WithPreserveXattrs(), WithPreserveACLs()); err != nil {
+			t.Fatalf("Failed to copy directory: %v", err)
+		}
+
+		value, err := getXattr(filepath.Join(dstDir, "file.txt"), "user.fsx.label")
+		if err != nil || string(value) != "blue" {
+			t.Errorf("Expected file xattr 'blue', got %q (%v)", value, err)
+		}
+		value, err = getXattr(dstDir, "user.fsx.dir")
+		if err != nil || string(value) != "root" {
+			t.Errorf("Expected directory xattr 'root', got %q (%v)", value, err)
+		}
+	})
+
+	t.Run("ReadOnlyFile", func(t *testing.T) {
+		// Xattrs are copied before the mode makes the copy read-only; only
+		// meaningful without root, which may write read-only files anyway
+		readOnlyDir := filepath.Join(tmpDir, "read_only_src")
+		readOnly := filepath.Join(readOnlyDir, "locked.txt")
+		if err := CreateFile(readOnly, []byte("locked"), WithCreateDirs()); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := unix.Lsetxattr(readOnly, "user.fsx.label", []byte("red"), 0); err != nil {
+			t.Fatalf("Failed to set xattr: %v", err)
+		}
+		if err := os.Chmod(readOnly, 0444); err != nil {
+			t.Fatalf("Failed to chmod: %v", err)
+		}
+
+		dstDir := filepath.Join(tmpDir, "read_only_dst")
+		if err := CopyDirectory(readOnlyDir, dstDir, WithPreserveXattrs()); err != nil {
+			t.Fatalf("Failed to copy directory: %v", err)
+		}
+
+		copied := filepath.Join(dstDir, "locked.txt")
+		value, err := getXattr(copied, "user.fsx.label")
+		if err != nil || string(value) != "red" {
+			t.Errorf("Expected file xattr 'red', got %q (%v)", value, err)
+		}
+		if info, err := os.Stat(copied); err != nil || info.Mode().Perm() != 0444 {
+			t.Errorf("Expected mode 0444 after the xattrs, got %v (%v)", info.Mode(), err)
+		}
+	})
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package fsx
+
+// copyXattrs is a no-op on platforms without Linux extended attributes
+func copyXattrs(src, dst string, xattrs, acls bool) error {
+	return nil
+}
@@ -0,0 +1,66 @@
+//go:build linux || darwin
+
+package fsx
+
+import (
+	"bytes"
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// listXattrs returns names of extended attributes of path
+func listXattrs(path string) ([]string, error) {
+	for {
+		size, err := unix.Llistxattr(path, nil)
+		if errors.Is(err, unix.ENOTSUP) {
+			return nil, nil // Filesystem without xattrs
+		}
+		if err != nil {
+			return nil, &os.PathError{Op: "listxattr", Path: path, Err: err}
+		}
+		if size == 0 {
+			return nil, nil
+		}
+
+		buf := make([]byte, size)
+		size, err = unix.Llistxattr(path, buf)
+		if errors.Is(err, unix.ERANGE) {
+			continue // Attributes added meanwhile
+		}
+		if err != nil {
+			return nil, &os.PathError{Op: "listxattr", Path: path, Err: err}
+		}
+
+		var names []string
+		for _, name := range bytes.Split(buf[:size], []byte{0}) {
+			if len(name) > 0 {
+				names = append(names, string(name))
+			}
+		}
+
+		return names, nil
+	}
+}
+
+// getXattr returns the value of an extended attribute of path
+func getXattr(path, name string) ([]byte, error) {
+	for {
+		size, err := unix.Lgetxattr(path, name, nil)
+		if err != nil {
+			return nil, &os.PathError{Op: "getxattr " + name, Path: path, Err: err}
+		}
+
+		buf := make([]byte, size)
+		size, err = unix.Lgetxattr(path, name, buf)
+		if errors.Is(err, unix.ERANGE) {
+			continue // Value grown meanwhile
+		}
+		if err != nil {
+			return nil, &os.PathError{Op: "getxattr " + name, Path: path, Err: err}
+		}
+
+		return buf[:size], nil
+	}
+}